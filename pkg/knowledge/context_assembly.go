@@ -0,0 +1,251 @@
+package knowledge
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+)
+
+const (
+	// defaultContextTokens is the context budget when the caller sets none
+	defaultContextTokens = 2000
+
+	// contextSeparator separates passages in assembled context
+	contextSeparator = "\n\n"
+
+	// minStitchOverlap is the least shared text treated as chunk overlap
+	// when stitching chunks whose source text is unavailable
+	minStitchOverlap = 8
+)
+
+// rerankChunks reorders candidates with the document reranker, keeping
+// the fused order if reranking fails
+func (km *DefaultKnowledgeManager) rerankChunks(ctx context.Context, query string, candidates []*scoredChunk) []*scoredChunk {
+	if len(candidates) <= 1 {
+		return candidates
+	}
+
+	byID := make(map[string]*scoredChunk, len(candidates))
+	documents := make([]*Document, len(candidates))
+	for i, scored := range candidates {
+		byID[scored.chunk.ID] = scored
+		documents[i] = &Document{
+			ID:        scored.chunk.ID,
+			Title:     scored.document.Title,
+			Content:   scored.chunk.Content,
+			Embedding: scored.chunk.Embedding,
+		}
+	}
+
+	reranked, err := km.reranker.Rerank(ctx, query, documents)
+	if err != nil {
+		km.logger.WithError(err).Warn("Failed to rerank context chunks")
+		return candidates
+	}
+
+	result := make([]*scoredChunk, 0, len(reranked))
+	for _, doc := range reranked {
+		if scored, exists := byID[doc.ID]; exists {
+			result = append(result, scored)
+		}
+	}
+	return result
+}
+
+// dedupeChunks drops chunks whose text a better ranked chunk already
+// holds: a span of the same document it covers, or identical content
+// from another document
+func dedupeChunks(candidates []*scoredChunk) []*scoredChunk {
+	var kept []*scoredChunk
+	contents := make(map[string]bool)
+	for _, scored := range candidates {
+		content := normalizeChunkContent(scored.chunk.Content)
+		if contents[content] {
+			continue
+		}
+
+		covered := false
+		for _, better := range kept {
+			if chunkCovers(better.chunk, scored.chunk) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+
+		contents[content] = true
+		kept = append(kept, scored)
+	}
+	return kept
+}
+
+// chunkCovers reports whether chunk a holds all the text of chunk b
+func chunkCovers(a, b *DocumentChunk) bool {
+	if a.DocumentID != b.DocumentID {
+		return false
+	}
+	if a.StartOffset <= b.StartOffset && b.EndOffset <= a.EndOffset {
+		return true
+	}
+	return strings.Contains(a.Content, b.Content)
+}
+
+func normalizeChunkContent(content string) string {
+	return strings.Join(strings.Fields(strings.ToLower(content)), " ")
+}
+
+// packContext adds candidates best first while the assembled context fits
+// in maxTokens. A candidate that would overflow the budget is skipped, so
+// smaller ones ranked below it can still fill the space.
+func packContext(candidates []*scoredChunk, maxTokens int, tokenizer llm.Tokenizer) ([]*ContextChunk, string, int) {
+	var selected []*scoredChunk
+	var passages []*ContextChunk
+	var assembled string
+	var tokens int
+
+	for _, scored := range candidates {
+		trial := append(selected[:len(selected):len(selected)], scored)
+		trialPassages, trialContext := assembleContext(trial)
+		trialTokens := tokenizer.CountTokens(trialContext)
+		if trialTokens > maxTokens {
+			continue
+		}
+		selected, passages, assembled, tokens = trial, trialPassages, trialContext, trialTokens
+	}
+
+	return passages, assembled, tokens
+}
+
+// assembleContext merges adjacent and overlapping chunks of each document
+// into passages and joins them, most relevant passage first. The selected
+// chunks must be ordered best first.
+func assembleContext(selected []*scoredChunk) ([]*ContextChunk, string) {
+	rank := make(map[*scoredChunk]int, len(selected))
+	groups := make(map[string][]*scoredChunk)
+	var documentIDs []string
+	for i, scored := range selected {
+		rank[scored] = i
+		id := scored.chunk.DocumentID
+		if _, exists := groups[id]; !exists {
+			documentIDs = append(documentIDs, id)
+		}
+		groups[id] = append(groups[id], scored)
+	}
+
+	type rankedPassage struct {
+		passage  *ContextChunk
+		document *Document
+		chunks   []*DocumentChunk
+		rank     int
+	}
+	var ranked []*rankedPassage
+
+	for _, id := range documentIDs {
+		group := groups[id]
+		sort.SliceStable(group, func(i, j int) bool {
+			if group[i].chunk.StartOffset != group[j].chunk.StartOffset {
+				return group[i].chunk.StartOffset < group[j].chunk.StartOffset
+			}
+			return group[i].chunk.ChunkIndex < group[j].chunk.ChunkIndex
+		})
+
+		var current *rankedPassage
+		for _, scored := range group {
+			chunk := scored.chunk
+			if current != nil && chunksAdjacent(current.passage, current.chunks[len(current.chunks)-1], chunk) {
+				current.passage.ChunkIDs = append(current.passage.ChunkIDs, chunk.ID)
+				current.passage.EndOffset = max(current.passage.EndOffset, chunk.EndOffset)
+				current.passage.Score = max(current.passage.Score, scored.score)
+				current.chunks = append(current.chunks, chunk)
+				current.rank = min(current.rank, rank[scored])
+				continue
+			}
+
+			current = &rankedPassage{
+				passage: &ContextChunk{
+					DocumentID:  id,
+					Title:       scored.document.Title,
+					ChunkIDs:    []string{chunk.ID},
+					StartOffset: chunk.StartOffset,
+					EndOffset:   chunk.EndOffset,
+					Score:       scored.score,
+				},
+				document: scored.document,
+				chunks:   []*DocumentChunk{chunk},
+				rank:     rank[scored],
+			}
+			ranked = append(ranked, current)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].rank < ranked[j].rank
+	})
+
+	var sb strings.Builder
+	passages := make([]*ContextChunk, len(ranked))
+	for i, r := range ranked {
+		if i > 0 {
+			sb.WriteString(contextSeparator)
+		}
+		r.passage.Content = passageContent(r.document, r.chunks, r.passage.StartOffset, r.passage.EndOffset)
+		r.passage.ContextOffset = sb.Len()
+		sb.WriteString(r.passage.Content)
+		passages[i] = r.passage
+	}
+
+	return passages, sb.String()
+}
+
+// chunksAdjacent reports whether chunk continues a passage whose last
+// chunk is last
+func chunksAdjacent(passage *ContextChunk, last, chunk *DocumentChunk) bool {
+	return chunk.ChunkIndex == last.ChunkIndex+1 || chunk.StartOffset <= passage.EndOffset
+}
+
+// passageContent returns the text of merged chunks. It is cut from the
+// source document when the chunk offsets match it, and otherwise stitched
+// from the chunks with their overlaps removed.
+func passageContent(doc *Document, chunks []*DocumentChunk, start, end int) string {
+	if sourceMatches(doc, chunks) {
+		return doc.Content[start:end]
+	}
+
+	content := chunks[0].Content
+	for _, chunk := range chunks[1:] {
+		if strings.Contains(content, chunk.Content) {
+			continue
+		}
+		if overlap := textOverlap(content, chunk.Content); overlap > 0 {
+			content += chunk.Content[overlap:]
+		} else {
+			content += " " + chunk.Content
+		}
+	}
+	return content
+}
+
+func sourceMatches(doc *Document, chunks []*DocumentChunk) bool {
+	for _, chunk := range chunks {
+		if chunk.StartOffset < 0 || chunk.EndOffset > len(doc.Content) || chunk.StartOffset > chunk.EndOffset ||
+			doc.Content[chunk.StartOffset:chunk.EndOffset] != chunk.Content {
+			return false
+		}
+	}
+	return true
+}
+
+// textOverlap returns the length of the longest suffix of a that is a
+// prefix of b, or 0 when it is shorter than minStitchOverlap
+func textOverlap(a, b string) int {
+	for n := min(len(a), len(b)); n >= minStitchOverlap; n-- {
+		if strings.HasSuffix(a, b[:n]) {
+			return n
+		}
+	}
+	return 0
+}
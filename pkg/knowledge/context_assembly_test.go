@@ -0,0 +1,134 @@
+package knowledge
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkDocument splits doc into fixed chunks with overlap, ranked in the
+// given chunk order
+func chunkDocument(t *testing.T, doc *Document, size, overlap int, order ...int) []*scoredChunk {
+	chunks, err := (&FixedSizeChunker{}).Chunk(doc.Content, size, overlap)
+	require.NoError(t, err)
+
+	var ranked []*scoredChunk
+	for i, index := range order {
+		chunk := chunks[index]
+		chunk.ID = doc.ID + "-" + string(rune('a'+index))
+		chunk.DocumentID = doc.ID
+		ranked = append(ranked, &scoredChunk{chunk: chunk, document: doc, score: 1 / float64(i+1)})
+	}
+	return ranked
+}
+
+func TestContextAssembly(t *testing.T) {
+	guide := &Document{ID: "guide", Title: "Guide", Content: "aaaaaaaaaabbbbbbbbbbccccccccccddddddddddeeeeeeeeee"}
+	notes := &Document{ID: "notes", Title: "Notes", Content: "0123456789"}
+
+	t.Run("MergeAdjacent", func(t *testing.T) {
+		// Chunks 2 and 0 are separated by chunk 1 until it is added
+		ranked := append(chunkDocument(t, guide, 10, 0, 2, 0), chunkDocument(t, notes, 10, 0, 0)...)
+		passages, assembled := assembleContext(ranked)
+		require.Len(t, passages, 3)
+		assert.Equal(t, "cccccccccc\n\naaaaaaaaaa\n\n0123456789", assembled)
+
+		ranked = append(ranked, chunkDocument(t, guide, 10, 0, 1)...)
+		passages, assembled = assembleContext(ranked)
+		require.Len(t, passages, 2)
+		assert.Equal(t, "aaaaaaaaaabbbbbbbbbbcccccccccc\n\n0123456789", assembled)
+		assert.Equal(t, []string{"guide-a", "guide-b", "guide-c"}, passages[0].ChunkIDs)
+		assert.Equal(t, 0, passages[0].StartOffset)
+		assert.Equal(t, 30, passages[0].EndOffset)
+		assert.Equal(t, float64(1), passages[0].Score)
+
+		// Offsets locate each passage in the context and in its source
+		for _, passage := range passages {
+			source := map[string]*Document{"guide": guide, "notes": notes}[passage.DocumentID]
+			assert.Equal(t, passage.Content, assembled[passage.ContextOffset:passage.ContextOffset+len(passage.Content)])
+			assert.Equal(t, passage.Content, source.Content[passage.StartOffset:passage.EndOffset])
+		}
+	})
+
+	t.Run("StitchOverlap", func(t *testing.T) {
+		// Overlapping chunks whose offsets do not match the source text
+		doc := &Document{ID: "doc", Content: "rewritten"}
+		first := &scoredChunk{document: doc, chunk: &DocumentChunk{ID: "1", DocumentID: "doc", ChunkIndex: 0, EndOffset: 30,
+			Content: "The pool size limits connections"}}
+		second := &scoredChunk{document: doc, chunk: &DocumentChunk{ID: "2", DocumentID: "doc", ChunkIndex: 1, StartOffset: 20, EndOffset: 50,
+			Content: "limits connections to the database"}}
+		passages, assembled := assembleContext([]*scoredChunk{second, first})
+		require.Len(t, passages, 1)
+		assert.Equal(t, "The pool size limits connections to the database", assembled)
+	})
+
+	t.Run("Dedupe", func(t *testing.T) {
+		copied := &Document{ID: "copy", Content: notes.Content}
+		ranked := chunkDocument(t, guide, 20, 10, 0, 1, 2, 3)
+		ranked = append(ranked, &scoredChunk{document: guide, chunk: &DocumentChunk{ID: "inner", DocumentID: "guide", StartOffset: 12, EndOffset: 18,
+			Content: guide.Content[12:18]}})
+		ranked = append(ranked, chunkDocument(t, notes, 10, 0, 0)...)
+		ranked = append(ranked, chunkDocument(t, copied, 10, 0, 0)...)
+
+		var ids []string
+		for _, scored := range dedupeChunks(ranked) {
+			ids = append(ids, scored.chunk.ID)
+		}
+		assert.Equal(t, []string{"guide-a", "guide-b", "guide-c", "guide-d", "notes-a"}, ids)
+	})
+
+	t.Run("Budget", func(t *testing.T) {
+		// One token per character: the 30 token chunk is skipped for the smaller one below it
+		tokenizer := &llm.HeuristicTokenizer{CharsPerToken: 1}
+		large := &Document{ID: "large", Content: strings.Repeat("x", 30)}
+		ranked := append(chunkDocument(t, guide, 10, 0, 0), chunkDocument(t, large, 30, 0, 0)...)
+		ranked = append(ranked, chunkDocument(t, notes, 10, 0, 0)...)
+
+		passages, assembled, tokens := packContext(ranked, 25, tokenizer)
+		require.Len(t, passages, 2)
+		assert.Equal(t, "aaaaaaaaaa\n\n0123456789", assembled)
+		assert.Equal(t, 20, tokens)
+
+		passages, _, tokens = packContext(ranked, 5, tokenizer)
+		assert.Empty(t, passages)
+		assert.Zero(t, tokens)
+	})
+}
+
+func TestRetrieveContext(t *testing.T) {
+	ctx := context.Background()
+	manager := newOfflineKnowledgeManager(t, hybridCorpus)
+
+	result, err := manager.RetrieveContext(ctx, "ERR_CONN_RESET connection reset", &RetrievalOptions{
+		TopK:             3,
+		HybridSearch:     true,
+		RerankingEnabled: true,
+		MaxTokens:        1000,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Chunks)
+	assert.Equal(t, "network", result.Chunks[0].DocumentID)
+	assert.LessOrEqual(t, len(result.Chunks), 3)
+	assert.Equal(t, llm.NewHeuristicTokenizer().CountTokens(result.Context), result.TokenCount)
+	require.Len(t, result.Documents, len(result.Chunks))
+	assert.Nil(t, result.Documents[0].Embedding)
+	for _, chunk := range result.Chunks {
+		assert.Equal(t, chunk.Content, result.Context[chunk.ContextOffset:chunk.ContextOffset+len(chunk.Content)])
+	}
+
+	// Nothing fits a tiny budget
+	small, err := manager.RetrieveContext(ctx, "ERR_CONN_RESET", &RetrievalOptions{HybridSearch: true, MaxTokens: 5})
+	require.NoError(t, err)
+	assert.Empty(t, small.Context)
+	assert.Empty(t, small.Documents)
+
+	// Defaults apply without options
+	result, err = manager.RetrieveContext(ctx, "TLS certificates", nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Chunks)
+	assert.Equal(t, "certs", result.Chunks[0].DocumentID)
+}
@@ -60,6 +60,34 @@ func NewDefaultEmbeddingManager(model string, logger *logrus.Logger) (EmbeddingM
 	return manager, nil
 }
 
+// NewEmbeddingManagerWithConfig creates an embedding manager backed by any
+// vectordb embedding provider, for example "local" to embed offline
+func NewEmbeddingManagerWithConfig(config *vectordb.EmbeddingConfig, logger *logrus.Logger) (EmbeddingManager, error) {
+	if config == nil {
+		return nil, fmt.Errorf("embedding config cannot be nil")
+	}
+
+	provider, err := vectordb.NewEmbeddingManager(logger).CreateProvider(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding provider: %w", err)
+	}
+
+	return &DefaultEmbeddingManager{
+		provider: provider,
+		logger:   logger,
+		tracer:   otel.Tracer("knowledge.embedding_manager"),
+		config: &EmbeddingManagerConfig{
+			Provider:    config.Provider,
+			Model:       provider.GetModel(),
+			Dimensions:  provider.GetDimensions(),
+			BatchSize:   config.BatchSize,
+			CacheSize:   10000,
+			EnableCache: true,
+		},
+		cache: make(map[string][]float32),
+	}, nil
+}
+
 // initializeProvider initializes the embedding provider
 func (em *DefaultEmbeddingManager) initializeProvider() error {
 	embeddingManager := vectordb.NewEmbeddingManager(em.logger)
@@ -0,0 +1,537 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aios/aios/pkg/vectordb"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// rrfK dampens the weight of top ranks in reciprocal rank fusion; 60
+	// is the value from the original paper and common practice
+	rrfK = 60
+
+	// minFusionCandidates is the least number of candidates each ranking
+	// contributes to fusion
+	minFusionCandidates = 50
+
+	// defaultChunkCollection is the vector collection of document chunks
+	defaultChunkCollection = "knowledge_chunks"
+)
+
+// chunkFields are the keys chunkVector adds to the document fields
+var chunkFields = []string{"document_id", "title", "created_at", "chunk_index", "start_offset", "end_offset", "content"}
+
+// scoredChunk is a chunk ranked by hybrid search
+type scoredChunk struct {
+	chunk        *DocumentChunk
+	document     *Document
+	score        float64
+	vectorScore  float64
+	keywordScore float64
+}
+
+// openVectorDB connects the vector database holding the chunk embeddings,
+// creating the chunk collection on first use, and loads the chunks stored
+// in it
+func (km *DefaultKnowledgeManager) openVectorDB(ctx context.Context, config *vectordb.VectorDBConfig) error {
+	db, err := vectordb.NewVectorDBManager(km.logger).CreateVectorDB(config)
+	if err != nil {
+		return err
+	}
+	if err := db.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	collection := km.chunkCollection()
+	exists, err := db.CollectionExists(ctx, collection)
+	if err == nil && !exists {
+		err = db.CreateCollection(ctx, &vectordb.CollectionConfig{
+			Name:      collection,
+			Dimension: km.embeddingManager.GetEmbeddingDimensions(),
+			Metric:    "cosine",
+		})
+	}
+	if err != nil {
+		db.Disconnect(ctx)
+		return fmt.Errorf("failed to prepare collection %s: %w", collection, err)
+	}
+
+	km.vectorDB = db
+	return km.loadChunks(ctx)
+}
+
+// chunkCollection returns the vector collection of document chunks
+func (km *DefaultKnowledgeManager) chunkCollection() string {
+	if km.config.ChunkCollection != "" {
+		return km.config.ChunkCollection
+	}
+	return defaultChunkCollection
+}
+
+// loadChunks rebuilds the chunks, their documents and the keyword index
+// from the chunks stored in the vector database
+func (km *DefaultKnowledgeManager) loadChunks(ctx context.Context) error {
+	scanner, ok := km.vectorDB.(vectordb.VectorScanner)
+	if !ok {
+		km.logger.Warn("Vector database cannot list stored chunks, keyword search only covers documents added from now on")
+		return nil
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	loaded := make(map[string][]*DocumentChunk)
+	err := scanner.ScanVectors(ctx, km.chunkCollection(), func(vector *vectordb.Vector) error {
+		doc, chunk := restoreChunk(vector)
+		if chunk == nil {
+			return nil
+		}
+		if _, exists := km.documents[doc.ID]; !exists {
+			km.documents[doc.ID] = doc
+		}
+		loaded[doc.ID] = append(loaded[doc.ID], chunk)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load chunks: %w", err)
+	}
+
+	count := 0
+	for documentID, chunks := range loaded {
+		sort.Slice(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+		km.storeChunks(documentID, chunks)
+		count += len(chunks)
+	}
+
+	km.logger.WithFields(logrus.Fields{
+		"documents": len(loaded),
+		"chunks":    count,
+	}).Info("Loaded chunks from the vector database")
+	return nil
+}
+
+// indexChunks embeds the chunks of a document and stores them in the
+// vector database, if one is configured
+func (km *DefaultKnowledgeManager) indexChunks(ctx context.Context, doc *Document, chunks []*DocumentChunk) error {
+	if km.vectorDB == nil || len(chunks) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Content
+	}
+	embeddings, err := km.embeddingManager.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	if len(embeddings) != len(chunks) {
+		return fmt.Errorf("expected %d embeddings, got %d", len(chunks), len(embeddings))
+	}
+
+	vectors := make([]*vectordb.Vector, len(chunks))
+	for i, chunk := range chunks {
+		vectors[i] = chunkVector(doc, chunk, embeddings[i])
+	}
+	if err := km.vectorDB.Insert(ctx, km.chunkCollection(), vectors); err != nil {
+		return fmt.Errorf("failed to insert chunks: %w", err)
+	}
+	return nil
+}
+
+// removeChunks deletes chunks from the vector database. Failures are only
+// logged: the chunks are already gone from memory, so searches skip them.
+func (km *DefaultKnowledgeManager) removeChunks(ctx context.Context, ids []string) {
+	if km.vectorDB == nil || len(ids) == 0 {
+		return
+	}
+	if err := km.vectorDB.Delete(ctx, km.chunkCollection(), ids); err != nil {
+		km.logger.WithError(err).Warn("Failed to delete chunks from the vector database")
+	}
+}
+
+// storeChunks replaces the chunks of a document in memory and in the
+// keyword index, returning the IDs of the replaced chunks. The caller must
+// hold km.mu.
+func (km *DefaultKnowledgeManager) storeChunks(documentID string, chunks []*DocumentChunk) []string {
+	var replaced []string
+	for _, chunk := range km.chunks[documentID] {
+		km.keywordIndex.Remove(chunk.ID)
+		delete(km.chunksByID, chunk.ID)
+		replaced = append(replaced, chunk.ID)
+	}
+	delete(km.chunks, documentID)
+	if len(chunks) == 0 {
+		return replaced
+	}
+
+	km.chunks[documentID] = chunks
+	for _, chunk := range chunks {
+		km.chunksByID[chunk.ID] = chunk
+		km.keywordIndex.Add(chunk.ID, chunk.Content)
+	}
+	return replaced
+}
+
+// searchChunks ranks the chunks matching options by fused vector and
+// keyword relevance, best first. Each ranking contributes a candidate pool
+// proportional to topK.
+func (km *DefaultKnowledgeManager) searchChunks(ctx context.Context, query string, options *SearchOptions, topK int) ([]*scoredChunk, error) {
+	alpha := km.config.HybridAlpha
+	if alpha == 0 {
+		alpha = 0.5
+	}
+	if options.Alpha != nil {
+		alpha = *options.Alpha
+	}
+	if alpha < 0 || alpha > 1 {
+		return nil, fmt.Errorf("alpha must be between 0 and 1, got %v", alpha)
+	}
+
+	fusion := options.Fusion
+	if fusion == "" {
+		fusion = km.config.HybridFusion
+	}
+	if fusion == "" {
+		fusion = FusionRRF
+	}
+	if fusion != FusionRRF && fusion != FusionWeighted {
+		return nil, fmt.Errorf("unknown fusion method: %s", fusion)
+	}
+
+	filter, err := vectordb.FilterFromMap(options.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filters: %w", err)
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Float64("search.alpha", float64(alpha)),
+		attribute.String("search.fusion", string(fusion)),
+	)
+
+	poolSize := topK * 5
+	if poolSize < minFusionCandidates {
+		poolSize = minFusionCandidates
+	}
+
+	// Vector search first, outside the lock
+	var vectorMatches []vectordb.Match
+	if alpha > 0 && km.vectorDB != nil {
+		vectorMatches, err = km.searchVectors(ctx, query, filter, options.KnowledgeBaseIDs, poolSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	accepted := make(map[string]bool)
+	accept := func(chunk *DocumentChunk) bool {
+		if ok, checked := accepted[chunk.DocumentID]; checked {
+			return ok
+		}
+		doc := km.documents[chunk.DocumentID]
+		ok := doc != nil && doc.Status != DocumentStatusDeleted && inKnowledgeBases(doc, options.KnowledgeBaseIDs) &&
+			(filter == nil || filter.Match(documentFields(doc)))
+		accepted[chunk.DocumentID] = ok
+		return ok
+	}
+
+	candidates := make(map[string]*scoredChunk)
+	candidate := func(chunk *DocumentChunk) *scoredChunk {
+		scored, exists := candidates[chunk.ID]
+		if !exists {
+			scored = &scoredChunk{chunk: chunk, document: km.documents[chunk.DocumentID]}
+			candidates[chunk.ID] = scored
+		}
+		return scored
+	}
+
+	// Vector ranking; the database filtered and ranked the matches, the
+	// check against memory skips chunks replaced since
+	var vectorRanking []*scoredChunk
+	for _, match := range vectorMatches {
+		chunk := km.chunksByID[match.ID]
+		if chunk == nil || !accept(chunk) {
+			continue
+		}
+		scored := candidate(chunk)
+		scored.vectorScore = float64(match.Score)
+		vectorRanking = append(vectorRanking, scored)
+	}
+	sort.SliceStable(vectorRanking, func(i, j int) bool {
+		if vectorRanking[i].vectorScore != vectorRanking[j].vectorScore {
+			return vectorRanking[i].vectorScore > vectorRanking[j].vectorScore
+		}
+		return chunkLess(vectorRanking[i].chunk, vectorRanking[j].chunk)
+	})
+
+	// Keyword ranking
+	var keywordRanking []*scoredChunk
+	if alpha < 1 {
+		for _, match := range km.keywordIndex.Search(query, 0) {
+			if len(keywordRanking) == poolSize {
+				break
+			}
+			chunk := km.chunksByID[match.ID]
+			if chunk == nil || !accept(chunk) {
+				continue
+			}
+			scored := candidate(chunk)
+			scored.keywordScore = match.Score
+			keywordRanking = append(keywordRanking, scored)
+		}
+	}
+
+	// Fusion
+	switch fusion {
+	case FusionRRF:
+		// Scaled so a chunk ranked first by both rankings scores 1
+		for rank, scored := range vectorRanking {
+			scored.score += float64(alpha) * (rrfK + 1) / float64(rrfK+rank+1)
+		}
+		for rank, scored := range keywordRanking {
+			scored.score += float64(1-alpha) * (rrfK + 1) / float64(rrfK+rank+1)
+		}
+	case FusionWeighted:
+		vectorScores := normalizeScores(vectorRanking, func(s *scoredChunk) float64 { return s.vectorScore })
+		keywordScores := normalizeScores(keywordRanking, func(s *scoredChunk) float64 { return s.keywordScore })
+		for scored, normalized := range vectorScores {
+			scored.score += float64(alpha) * normalized
+		}
+		for scored, normalized := range keywordScores {
+			scored.score += float64(1-alpha) * normalized
+		}
+	}
+
+	results := make([]*scoredChunk, 0, len(candidates))
+	for _, scored := range candidates {
+		if scored.score > 0 && scored.score >= float64(options.Threshold) {
+			results = append(results, scored)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return chunkLess(results[i].chunk, results[j].chunk)
+	})
+
+	return results, nil
+}
+
+// searchVectors returns the chunks nearest to query in the vector
+// database, which applies the filter and knowledge bases
+func (km *DefaultKnowledgeManager) searchVectors(ctx context.Context, query string, filter *vectordb.Filter, knowledgeBaseIDs []string, topK int) ([]vectordb.Match, error) {
+	embedding, err := km.embeddingManager.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+
+	var conditions []*vectordb.Filter
+	if filter != nil {
+		conditions = append(conditions, filter)
+	}
+	if len(knowledgeBaseIDs) > 0 {
+		ids := make([]interface{}, len(knowledgeBaseIDs))
+		for i, id := range knowledgeBaseIDs {
+			ids[i] = id
+		}
+		conditions = append(conditions, vectordb.Field("knowledge_base_id").In(ids...))
+	}
+
+	request := &vectordb.SearchRequest{
+		Collection: km.chunkCollection(),
+		Vector:     embedding,
+		TopK:       topK,
+	}
+	switch len(conditions) {
+	case 0:
+	case 1:
+		request.Where = conditions[0]
+	default:
+		request.Where = vectordb.And(conditions...)
+	}
+
+	result, err := km.vectorDB.Search(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("vector search failed: %w", err)
+	}
+	return result.Matches, nil
+}
+
+// normalizeScores min-max normalizes the scores of a ranking to [0, 1].
+// When all scores are equal they normalize to 1.
+func normalizeScores(ranking []*scoredChunk, score func(*scoredChunk) float64) map[*scoredChunk]float64 {
+	normalized := make(map[*scoredChunk]float64, len(ranking))
+	if len(ranking) == 0 {
+		return normalized
+	}
+
+	low, high := score(ranking[0]), score(ranking[0])
+	for _, scored := range ranking {
+		low = math.Min(low, score(scored))
+		high = math.Max(high, score(scored))
+	}
+	for _, scored := range ranking {
+		if high == low {
+			normalized[scored] = 1
+		} else {
+			normalized[scored] = (score(scored) - low) / (high - low)
+		}
+	}
+	return normalized
+}
+
+// chunkLess orders chunks by document and position for stable rankings
+func chunkLess(a, b *DocumentChunk) bool {
+	if a.DocumentID != b.DocumentID {
+		return a.DocumentID < b.DocumentID
+	}
+	return a.ChunkIndex < b.ChunkIndex
+}
+
+func inKnowledgeBases(doc *Document, ids []string) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, id := range ids {
+		if doc.KnowledgeBaseID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// documentFields returns the fields search filters apply to: the document
+// metadata plus its descriptive fields
+func documentFields(doc *Document) map[string]interface{} {
+	fields := make(map[string]interface{}, len(doc.Metadata)+7)
+	for key, value := range doc.Metadata {
+		fields[key] = value
+	}
+	fields["source"] = doc.Source
+	fields["language"] = doc.Language
+	fields["content_type"] = doc.ContentType
+	fields["author"] = doc.Author
+	fields["tags"] = doc.Tags
+	fields["categories"] = doc.Categories
+	fields["knowledge_base_id"] = doc.KnowledgeBaseID
+	return fields
+}
+
+// chunkVector returns the vector database entry of a chunk. Its metadata
+// holds the document fields, for filtering in the database, and what
+// restoreChunk needs to rebuild the chunk and its document.
+func chunkVector(doc *Document, chunk *DocumentChunk, values []float32) *vectordb.Vector {
+	metadata := documentFields(doc)
+	metadata["document_id"] = doc.ID
+	metadata["title"] = doc.Title
+	metadata["created_at"] = doc.CreatedAt.Format(time.RFC3339Nano)
+	metadata["chunk_index"] = chunk.ChunkIndex
+	metadata["start_offset"] = chunk.StartOffset
+	metadata["end_offset"] = chunk.EndOffset
+	metadata["content"] = chunk.Content
+	return &vectordb.Vector{ID: chunk.ID, Values: values, Metadata: metadata}
+}
+
+// restoreChunk rebuilds a chunk and its document from a vector written by
+// chunkVector, or returns nil for other vectors. The document has no
+// content beyond its chunks.
+func restoreChunk(vector *vectordb.Vector) (*Document, *DocumentChunk) {
+	fields := vector.Metadata
+	documentID, _ := fields["document_id"].(string)
+	content, _ := fields["content"].(string)
+	if documentID == "" {
+		return nil, nil
+	}
+
+	chunk := &DocumentChunk{
+		ID:          vector.ID,
+		DocumentID:  documentID,
+		Content:     content,
+		ChunkIndex:  intField(fields["chunk_index"]),
+		StartOffset: intField(fields["start_offset"]),
+		EndOffset:   intField(fields["end_offset"]),
+	}
+
+	doc := &Document{
+		ID:              documentID,
+		Title:           stringField(fields["title"]),
+		ContentType:     stringField(fields["content_type"]),
+		Language:        stringField(fields["language"]),
+		Source:          stringField(fields["source"]),
+		Author:          stringField(fields["author"]),
+		Tags:            stringsField(fields["tags"]),
+		Categories:      stringsField(fields["categories"]),
+		Metadata:        make(map[string]interface{}),
+		Version:         1,
+		Status:          DocumentStatusActive,
+		KnowledgeBaseID: stringField(fields["knowledge_base_id"]),
+	}
+	if createdAt, err := time.Parse(time.RFC3339Nano, stringField(fields["created_at"])); err == nil {
+		doc.CreatedAt = createdAt
+		doc.UpdatedAt = createdAt
+		chunk.CreatedAt = createdAt
+	}
+
+	reserved := make(map[string]bool)
+	for key := range documentFields(&Document{}) {
+		reserved[key] = true
+	}
+	for _, key := range chunkFields {
+		reserved[key] = true
+	}
+	for key, value := range fields {
+		if !reserved[key] {
+			doc.Metadata[key] = value
+		}
+	}
+	return doc, chunk
+}
+
+func stringField(value interface{}) string {
+	text, _ := value.(string)
+	return text
+}
+
+// stringsField reads a string list, which comes back as []interface{}
+// from databases storing metadata as JSON
+func stringsField(value interface{}) []string {
+	switch list := value.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		values := make([]string, 0, len(list))
+		for _, item := range list {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// intField reads an integer, which comes back as float64 from databases
+// storing metadata as JSON
+func intField(value interface{}) int {
+	switch number := value.(type) {
+	case int:
+		return number
+	case int64:
+		return int(number)
+	case float64:
+		return int(number)
+	}
+	return 0
+}
@@ -0,0 +1,189 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aios/aios/pkg/vectordb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var hybridCorpus = []*Document{
+	{ID: "network", Title: "Network errors", Content: "The client logs ERR_CONN_RESET when the peer closes the socket during a request.", Source: "runbook"},
+	{ID: "retries", Title: "Retries", Content: "Connections that are reset by the server should be retried with exponential backoff.", Source: "runbook"},
+	{ID: "deploy", Title: "Deployments", Content: "Scale the deployment horizontally by adding more pod replicas to the cluster.", Source: "guide"},
+	{ID: "certs", Title: "Certificates", Content: "Rotate TLS certificates before they expire to avoid outages.", Source: "guide"},
+}
+
+func newOfflineKnowledgeManager(t *testing.T, docs []*Document) KnowledgeManager {
+	return newPersistentKnowledgeManager(t, "", docs)
+}
+
+// newPersistentKnowledgeManager embeds locally into an embedded vector
+// database snapshotted to directory, or kept in memory when it is empty
+func newPersistentKnowledgeManager(t *testing.T, directory string, docs []*Document) KnowledgeManager {
+	config := &KnowledgeManagerConfig{
+		DefaultChunkSize:    500,
+		DefaultChunkOverlap: 50,
+		MaxConcurrentOps:    5,
+		VectorStoreConfig: &vectordb.VectorStoreConfig{
+			VectorDB:  &vectordb.VectorDBConfig{Provider: "embedded", Database: directory},
+			Embedding: &vectordb.EmbeddingConfig{Provider: "local"},
+		},
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	manager, err := NewKnowledgeManager(config, logger)
+	require.NoError(t, err)
+	for _, doc := range docs {
+		copied := *doc
+		require.NoError(t, manager.AddDocument(context.Background(), &copied))
+	}
+	return manager
+}
+
+// failingEmbeddings fails to embed batches, as an unreachable provider does
+type failingEmbeddings struct {
+	EmbeddingManager
+}
+
+func (f failingEmbeddings) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, errors.New("provider unavailable")
+}
+
+func TestBM25Index(t *testing.T) {
+	index := NewBM25Index(0, 0)
+	index.Add("a", "Dial failed with ERR_CONN_RESET from net/http")
+	index.Add("b", "The connection was reset by the peer")
+	index.Add("c", "Unrelated text about certificates")
+
+	// The exact identifier outranks texts sharing only its parts
+	matches := index.Search("ERR_CONN_RESET", 0)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "a", matches[0].ID)
+	assert.Greater(t, matches[0].Score, 4*matches[1].Score)
+
+	matches = index.Search("reset", 0)
+	require.Len(t, matches, 2)
+	assert.ElementsMatch(t, []string{"a", "b"}, []string{matches[0].ID, matches[1].ID})
+	assert.Equal(t, "a", index.Search("http", 1)[0].ID)
+
+	index.Add("a", "replaced")
+	assert.Equal(t, []KeywordMatch{{ID: "b", Score: index.Search("reset", 0)[0].Score}}, index.Search("ERR_CONN_RESET", 0))
+	index.Remove("b")
+	assert.Empty(t, index.Search("peer", 0))
+	assert.Equal(t, 2, index.Len())
+}
+
+func TestHybridSearch(t *testing.T) {
+	ctx := context.Background()
+	manager := newOfflineKnowledgeManager(t, hybridCorpus)
+	alpha := func(value float32) *float32 { return &value }
+
+	t.Run("KeywordOnly", func(t *testing.T) {
+		// The document sharing only the word reset trails far behind
+		results, err := manager.HybridSearch(ctx, "ERR_CONN_RESET", &SearchOptions{Alpha: alpha(0)})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, "network", results[0].ID)
+		assert.Equal(t, "retries", results[1].ID)
+		assert.Greater(t, results[0].Metadata["keyword_score"], 4*results[1].Metadata["keyword_score"].(float64))
+		assert.Equal(t, float64(0), results[0].Metadata["vector_score"])
+		assert.Greater(t, results[0].Metadata["keyword_score"], float64(0))
+		assert.Nil(t, results[0].Embedding)
+	})
+
+	t.Run("Fusion", func(t *testing.T) {
+		for _, fusion := range []FusionMethod{FusionRRF, FusionWeighted} {
+			results, err := manager.HybridSearch(ctx, "ERR_CONN_RESET socket", &SearchOptions{TopK: 2, Fusion: fusion})
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.Equal(t, "network", results[0].ID, fusion)
+			assert.GreaterOrEqual(t, results[0].Metadata["score"], results[1].Metadata["score"])
+		}
+	})
+
+	t.Run("Alpha", func(t *testing.T) {
+		// Pure vector search ranks every chunk, keyword search only matches
+		vector, err := manager.HybridSearch(ctx, "certificates", &SearchOptions{Alpha: alpha(1)})
+		require.NoError(t, err)
+		keyword, err := manager.HybridSearch(ctx, "certificates", &SearchOptions{Alpha: alpha(0)})
+		require.NoError(t, err)
+		assert.Len(t, vector, len(hybridCorpus))
+		require.Len(t, keyword, 1)
+		assert.Equal(t, "certs", vector[0].ID)
+		assert.Equal(t, "certs", keyword[0].ID)
+
+		_, err = manager.HybridSearch(ctx, "certificates", &SearchOptions{Alpha: alpha(1.5)})
+		assert.Error(t, err)
+		_, err = manager.HybridSearch(ctx, "certificates", &SearchOptions{Fusion: "unknown"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		results, err := manager.HybridSearch(ctx, "reset", &SearchOptions{
+			Alpha:   alpha(0),
+			Filters: map[string]interface{}{"source": "guide"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		require.NoError(t, manager.DeleteDocument(ctx, "network"))
+		results, err = manager.HybridSearch(ctx, "ERR_CONN_RESET", &SearchOptions{Alpha: alpha(0)})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "retries", results[0].ID)
+	})
+}
+
+func TestHybridSearchVectorDB(t *testing.T) {
+	ctx := context.Background()
+	alpha := func(value float32) *float32 { return &value }
+
+	t.Run("Restart", func(t *testing.T) {
+		directory := t.TempDir()
+		manager := newPersistentKnowledgeManager(t, directory, hybridCorpus)
+		require.NoError(t, manager.DeleteDocument(ctx, "deploy"))
+		require.NoError(t, manager.(*DefaultKnowledgeManager).vectorDB.Disconnect(ctx))
+
+		// The keyword index and documents are rebuilt from the stored chunks
+		restarted := newPersistentKnowledgeManager(t, directory, nil)
+		results, err := restarted.HybridSearch(ctx, "ERR_CONN_RESET", &SearchOptions{Alpha: alpha(0)})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, "network", results[0].ID)
+		assert.Equal(t, "Network errors", results[0].Title)
+		assert.Equal(t, "runbook", results[0].Source)
+
+		vector, err := restarted.HybridSearch(ctx, "certificates", &SearchOptions{
+			Alpha:   alpha(1),
+			Filters: map[string]interface{}{"source": "guide"},
+		})
+		require.NoError(t, err)
+		require.Len(t, vector, 1)
+		assert.Equal(t, "certs", vector[0].ID)
+
+		_, err = restarted.GetDocument(ctx, "deploy")
+		assert.Error(t, err)
+	})
+
+	t.Run("EmbeddingFailure", func(t *testing.T) {
+		manager := newOfflineKnowledgeManager(t, nil).(*DefaultKnowledgeManager)
+		working := manager.embeddingManager
+		manager.embeddingManager = failingEmbeddings{working}
+		doc := *hybridCorpus[0]
+		require.NoError(t, manager.AddDocument(ctx, &doc))
+
+		// The document is still found by keywords, just not by vector
+		manager.embeddingManager = working
+		results, err := manager.HybridSearch(ctx, "ERR_CONN_RESET", nil)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, float64(0), results[0].Metadata["vector_score"])
+		assert.Greater(t, results[0].Metadata["keyword_score"], float64(0))
+	})
+}
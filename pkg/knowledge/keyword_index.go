@@ -0,0 +1,195 @@
+package knowledge
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// BM25Index is an in-memory inverted index that ranks texts with Okapi
+// BM25. The knowledge manager keeps one over document chunks so keyword
+// queries, such as error codes and identifiers, find exact matches that
+// embeddings miss.
+type BM25Index struct {
+	k1 float64
+	b  float64
+
+	postings    map[string]map[string]int // term -> id -> term frequency
+	terms       map[string][]string       // id -> distinct terms
+	lengths     map[string]int            // id -> length in terms
+	totalLength int
+	mu          sync.RWMutex
+}
+
+// KeywordMatch is a BM25 search result
+type KeywordMatch struct {
+	ID    string
+	Score float64
+}
+
+// NewBM25Index creates a BM25 index. Non-positive parameters select the
+// usual defaults, k1 = 1.2 and b = 0.75.
+func NewBM25Index(k1, b float64) *BM25Index {
+	if k1 <= 0 {
+		k1 = 1.2
+	}
+	if b <= 0 || b > 1 {
+		b = 0.75
+	}
+	return &BM25Index{
+		k1:       k1,
+		b:        b,
+		postings: make(map[string]map[string]int),
+		terms:    make(map[string][]string),
+		lengths:  make(map[string]int),
+	}
+}
+
+// Add indexes text under id, replacing any text indexed under it before
+func (idx *BM25Index) Add(id string, text string) {
+	terms := keywordTerms(text)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+	for _, term := range terms {
+		postings, exists := idx.postings[term]
+		if !exists {
+			postings = make(map[string]int)
+			idx.postings[term] = postings
+		}
+		if postings[id] == 0 {
+			idx.terms[id] = append(idx.terms[id], term)
+		}
+		postings[id]++
+	}
+	idx.lengths[id] = len(terms)
+	idx.totalLength += len(terms)
+}
+
+// Remove removes the text indexed under id
+func (idx *BM25Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+}
+
+func (idx *BM25Index) remove(id string) {
+	length, exists := idx.lengths[id]
+	if !exists {
+		return
+	}
+	for _, term := range idx.terms[id] {
+		postings := idx.postings[term]
+		delete(postings, id)
+		if len(postings) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.terms, id)
+	delete(idx.lengths, id)
+	idx.totalLength -= length
+}
+
+// Len returns the number of indexed texts
+func (idx *BM25Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.lengths)
+}
+
+// Search returns up to limit texts matching any query term, best first.
+// A non-positive limit returns every match.
+func (idx *BM25Index) Search(query string, limit int) []KeywordMatch {
+	terms := keywordTerms(query)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.lengths) == 0 {
+		return nil
+	}
+
+	count := float64(len(idx.lengths))
+	averageLength := float64(idx.totalLength) / count
+	if averageLength == 0 {
+		averageLength = 1
+	}
+
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		// Repeated query terms count once
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (count-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			frequency := float64(tf)
+			norm := idx.k1 * (1 - idx.b + idx.b*float64(idx.lengths[id])/averageLength)
+			scores[id] += idf * frequency * (idx.k1 + 1) / (frequency + norm)
+		}
+	}
+
+	matches := make([]KeywordMatch, 0, len(scores))
+	for id, score := range scores {
+		matches = append(matches, KeywordMatch{ID: id, Score: score})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// keywordTerms splits text into lowercase index terms. Compound tokens
+// such as ERR_CONN_RESET, net/http or v1.2.3 are kept whole and also split
+// into their parts, so both exact identifiers and their words match.
+func keywordTerms(text string) []string {
+	var terms []string
+	for _, token := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isTermRune(r) && !isJoinerRune(r)
+	}) {
+		token = strings.TrimFunc(token, isJoinerRune)
+		if token == "" {
+			continue
+		}
+		terms = append(terms, token)
+
+		parts := strings.FieldsFunc(token, isJoinerRune)
+		if len(parts) > 1 {
+			terms = append(terms, parts...)
+		}
+	}
+	return terms
+}
+
+func isTermRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isJoinerRune reports whether r joins the parts of a compound token
+func isJoinerRune(r rune) bool {
+	switch r {
+	case '_', '-', '.', '/', ':', '#', '@':
+		return true
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aios/aios/pkg/langchain/llm"
 	"github.com/aios/aios/pkg/vectordb"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -17,11 +18,12 @@ import (
 // DefaultKnowledgeManager implements the KnowledgeManager interface
 type DefaultKnowledgeManager struct {
 	// Core components
-	vectorStore      vectordb.VectorStore
+	vectorDB         vectordb.VectorDB // chunk embeddings, nil without a configured database
 	embeddingManager EmbeddingManager
 	processor        DocumentProcessor
 	indexer          KnowledgeIndexer
 	ragPipeline      RAGPipeline
+	reranker         DocumentReranker
 	queryProcessor   QueryProcessor
 	knowledgeGraph   KnowledgeGraph
 	cache            SemanticCache
@@ -29,6 +31,9 @@ type DefaultKnowledgeManager struct {
 	// Storage
 	knowledgeBases map[string]*KnowledgeBase
 	documents      map[string]*Document
+	chunks         map[string][]*DocumentChunk // document ID -> chunks
+	chunksByID     map[string]*DocumentChunk
+	keywordIndex   *BM25Index
 
 	// Configuration
 	config *KnowledgeManagerConfig
@@ -53,6 +58,9 @@ type KnowledgeManagerConfig struct {
 	MultiModalEnabled     bool                        `json:"multimodal_enabled"`
 	MaxConcurrentOps      int                         `json:"max_concurrent_ops"`
 	MetricsInterval       time.Duration               `json:"metrics_interval"`
+	HybridAlpha           float32                     `json:"hybrid_alpha"`     // vector weight in hybrid search, default 0.5
+	HybridFusion          FusionMethod                `json:"hybrid_fusion"`    // default rrf
+	ChunkCollection       string                      `json:"chunk_collection"` // vector collection of chunks, default knowledge_chunks
 }
 
 // NewKnowledgeManager creates a new knowledge manager
@@ -68,12 +76,17 @@ func NewKnowledgeManager(config *KnowledgeManagerConfig, logger *logrus.Logger)
 			MultiModalEnabled:     false,
 			MaxConcurrentOps:      10,
 			MetricsInterval:       5 * time.Minute,
+			HybridAlpha:           0.5,
+			HybridFusion:          FusionRRF,
 		}
 	}
 
 	manager := &DefaultKnowledgeManager{
 		knowledgeBases:    make(map[string]*KnowledgeBase),
 		documents:         make(map[string]*Document),
+		chunks:            make(map[string][]*DocumentChunk),
+		chunksByID:        make(map[string]*DocumentChunk),
+		keywordIndex:      NewBM25Index(0, 0),
 		config:            config,
 		logger:            logger,
 		tracer:            otel.Tracer("knowledge.manager"),
@@ -91,13 +104,30 @@ func NewKnowledgeManager(config *KnowledgeManagerConfig, logger *logrus.Logger)
 
 // initializeComponents initializes all knowledge manager components
 func (km *DefaultKnowledgeManager) initializeComponents() error {
-	// Initialize embedding manager
-	embeddingManager, err := NewDefaultEmbeddingManager(km.config.DefaultEmbeddingModel, km.logger)
+	// Initialize embedding manager, from the vector store's embedding
+	// provider when one is configured
+	var embeddingManager EmbeddingManager
+	var err error
+	if km.config.VectorStoreConfig != nil && km.config.VectorStoreConfig.Embedding != nil {
+		embeddingManager, err = NewEmbeddingManagerWithConfig(km.config.VectorStoreConfig.Embedding, km.logger)
+	} else {
+		embeddingManager, err = NewDefaultEmbeddingManager(km.config.DefaultEmbeddingModel, km.logger)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to initialize embedding manager: %w", err)
 	}
 	km.embeddingManager = embeddingManager
 
+	// Initialize the vector database holding chunk embeddings; without one,
+	// hybrid search ranks by keywords only
+	if km.config.VectorStoreConfig != nil && km.config.VectorStoreConfig.VectorDB != nil {
+		if err := km.openVectorDB(context.Background(), km.config.VectorStoreConfig.VectorDB); err != nil {
+			return fmt.Errorf("failed to initialize vector database: %w", err)
+		}
+	} else {
+		km.logger.Warn("No vector database configured, hybrid search ranks by keywords only")
+	}
+
 	// Initialize document processor
 	processor, err := NewDefaultDocumentProcessor(km.logger)
 	if err != nil {
@@ -112,6 +142,13 @@ func (km *DefaultKnowledgeManager) initializeComponents() error {
 	}
 	km.ragPipeline = ragPipeline
 
+	// Initialize reranker for context assembly
+	reranker, err := NewDefaultDocumentReranker(km.embeddingManager, km.logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize document reranker: %w", err)
+	}
+	km.reranker = reranker
+
 	// Initialize query processor
 	queryProcessor, err := NewDefaultQueryProcessor(km.embeddingManager, km.logger)
 	if err != nil {
@@ -188,11 +225,20 @@ func (km *DefaultKnowledgeManager) AddDocument(ctx context.Context, doc *Documen
 		doc.Embedding = embedding
 	}
 
-	// Store document
+	// Store document and its chunks; the keyword index lives in memory
 	km.mu.Lock()
 	km.documents[doc.ID] = doc
+	replaced := km.storeChunks(doc.ID, processedDoc.Chunks)
 	km.mu.Unlock()
 
+	// Index chunks for vector search. A failure leaves the document
+	// searchable by keywords rather than rejecting it.
+	km.removeChunks(ctx, replaced)
+	if err := km.indexChunks(ctx, doc, processedDoc.Chunks); err != nil {
+		span.RecordError(err)
+		km.logger.WithError(err).WithField("document_id", doc.ID).Warn("Failed to index chunks for vector search")
+	}
+
 	// Index document
 	if err := km.indexer.IndexDocument(ctx, doc); err != nil {
 		km.logger.WithError(err).Warn("Failed to index document")
@@ -329,8 +375,11 @@ func (km *DefaultKnowledgeManager) DeleteDocument(ctx context.Context, id string
 	// Mark as deleted
 	doc.Status = DocumentStatusDeleted
 	doc.UpdatedAt = time.Now()
+	replaced := km.storeChunks(id, nil)
 	km.mu.Unlock()
 
+	km.removeChunks(ctx, replaced)
+
 	// Remove from index
 	if err := km.indexer.DeleteFromIndex(ctx, id); err != nil {
 		km.logger.WithError(err).Warn("Failed to remove document from index")
@@ -472,18 +521,155 @@ func (km *DefaultKnowledgeManager) SimilaritySearch(ctx context.Context, query s
 	return []*Document{}, nil
 }
 
-// HybridSearch performs hybrid search
+// HybridSearch ranks document chunks by vector similarity and BM25
+// keyword relevance together, fused by reciprocal rank or by normalized
+// score, and returns the documents of the best chunks. Each result is a
+// copy whose metadata holds its fused score and best matching chunk.
 func (km *DefaultKnowledgeManager) HybridSearch(ctx context.Context, query string, options *SearchOptions) ([]*Document, error) {
-	// For now, return empty results
-	return []*Document{}, nil
+	ctx, span := km.tracer.Start(ctx, "knowledge_manager.hybrid_search")
+	defer span.End()
+
+	if options == nil {
+		options = &SearchOptions{}
+	}
+	topK := options.TopK
+	if topK <= 0 {
+		topK = 10
+	}
+
+	chunks, err := km.searchChunks(ctx, query, options, topK)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// Chunks arrive best first, so a document's first chunk is its best
+	var results []*Document
+	seen := make(map[string]bool)
+	for _, scored := range chunks {
+		if len(results) == topK {
+			break
+		}
+		if seen[scored.document.ID] {
+			continue
+		}
+		seen[scored.document.ID] = true
+
+		result := *scored.document
+		result.Metadata = make(map[string]interface{}, len(scored.document.Metadata)+4)
+		for key, value := range scored.document.Metadata {
+			result.Metadata[key] = value
+		}
+		result.Metadata["score"] = scored.score
+		result.Metadata["vector_score"] = scored.vectorScore
+		result.Metadata["keyword_score"] = scored.keywordScore
+		result.Metadata["chunk_id"] = scored.chunk.ID
+		if !options.IncludeEmbedding {
+			result.Embedding = nil
+		}
+		results = append(results, &result)
+	}
+
+	span.SetAttributes(
+		attribute.Int("search.candidates", len(chunks)),
+		attribute.Int("search.results", len(results)),
+	)
+
+	return results, nil
 }
 
-// RetrieveContext retrieves context for a query
+// RetrieveContext assembles prompt context for a query. Candidate chunks
+// are reranked, chunks a better ranked one already covers are dropped, and
+// the rest are packed best first into the token budget, with adjacent
+// chunks of a document merged into one passage.
 func (km *DefaultKnowledgeManager) RetrieveContext(ctx context.Context, query string, options *RetrievalOptions) (*RetrievalResult, error) {
+	ctx, span := km.tracer.Start(ctx, "knowledge_manager.retrieve_context")
+	defer span.End()
+
+	startTime := time.Now()
+
+	if options == nil {
+		options = &RetrievalOptions{RerankingEnabled: true, HybridSearch: true}
+	}
+	topK := options.TopK
+	if topK <= 0 {
+		topK = 10
+	}
+	maxTokens := options.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultContextTokens
+	}
+	tokenizer := options.Tokenizer
+	if tokenizer == nil {
+		tokenizer = llm.NewHeuristicTokenizer()
+	}
+
+	searchOptions := &SearchOptions{
+		TopK:             topK,
+		Threshold:        options.Threshold,
+		Filters:          options.Filters,
+		KnowledgeBaseIDs: options.KnowledgeBaseIDs,
+	}
+	if !options.HybridSearch {
+		vectorOnly := float32(1)
+		searchOptions.Alpha = &vectorOnly
+	}
+
+	candidates, err := km.searchChunks(ctx, query, searchOptions, topK)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// Rerank a wider pool than is kept, so reranking can promote chunks
+	if len(candidates) > topK*2 {
+		candidates = candidates[:topK*2]
+	}
+	if options.RerankingEnabled {
+		candidates = km.rerankChunks(ctx, query, candidates)
+	}
+	candidates = dedupeChunks(candidates)
+	if len(candidates) > topK {
+		candidates = candidates[:topK]
+	}
+
+	passages, assembled, tokens := packContext(candidates, maxTokens, tokenizer)
+
+	sources := make(map[string]*Document)
+	for _, scored := range candidates {
+		sources[scored.document.ID] = scored.document
+	}
+	var documents []*Document
+	for _, passage := range passages {
+		source, exists := sources[passage.DocumentID]
+		if !exists {
+			continue
+		}
+		delete(sources, passage.DocumentID)
+
+		doc := *source
+		doc.Embedding = nil
+		documents = append(documents, &doc)
+	}
+
+	span.SetAttributes(
+		attribute.Int("retrieval.candidates", len(candidates)),
+		attribute.Int("retrieval.passages", len(passages)),
+		attribute.Int("retrieval.tokens", tokens),
+	)
+
 	return &RetrievalResult{
-		Documents: []*Document{},
-		Context:   "",
-		Query:     query,
+		Documents:      documents,
+		Context:        assembled,
+		Chunks:         passages,
+		TokenCount:     tokens,
+		Query:          query,
+		ProcessingTime: time.Since(startTime),
+		Metadata: map[string]interface{}{
+			"candidates": len(candidates),
+			"max_tokens": maxTokens,
+			"tokenizer":  tokenizer.Name(),
+		},
 	}, nil
 }
 
@@ -2,6 +2,8 @@ package knowledge
 
 import (
 	"time"
+
+	"github.com/aios/aios/pkg/langchain/llm"
 )
 
 // Document represents a knowledge document
@@ -128,6 +130,11 @@ type SearchOptions struct {
 	RerankingEnabled bool                   `json:"reranking_enabled"`
 	Filters          map[string]interface{} `json:"filters,omitempty"`
 	KnowledgeBaseIDs []string               `json:"knowledge_base_ids,omitempty"`
+
+	// Hybrid search: Alpha weighs vector similarity against keywords, from
+	// 0 (keywords only) to 1 (vectors only), overriding the manager default
+	Alpha  *float32     `json:"alpha,omitempty"`
+	Fusion FusionMethod `json:"fusion,omitempty"`
 }
 
 // SearchResult represents search results
@@ -158,6 +165,8 @@ type RetrievalOptions struct {
 	GraphSearch      bool                   `json:"graph_search"`
 	Filters          map[string]interface{} `json:"filters,omitempty"`
 	KnowledgeBaseIDs []string               `json:"knowledge_base_ids,omitempty"`
+	MaxTokens        int                    `json:"max_tokens"` // Context token budget, default 2000
+	Tokenizer        llm.Tokenizer          `json:"-"`          // Counts the budget, heuristic by default
 }
 
 // GenerationOptions represents generation options
@@ -188,11 +197,26 @@ type RAGResponse struct {
 type RetrievalResult struct {
 	Documents      []*Document            `json:"documents"`
 	Context        string                 `json:"context"`
+	Chunks         []*ContextChunk        `json:"chunks,omitempty"`
+	TokenCount     int                    `json:"token_count"`
 	Query          string                 `json:"query"`
 	ProcessingTime time.Duration          `json:"processing_time"`
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// ContextChunk is a passage of assembled context: one or more adjacent
+// chunks of a document, merged
+type ContextChunk struct {
+	DocumentID    string   `json:"document_id"`
+	Title         string   `json:"title"`
+	ChunkIDs      []string `json:"chunk_ids"`
+	Content       string   `json:"content"`
+	StartOffset   int      `json:"start_offset"`   // Offset in the source document
+	EndOffset     int      `json:"end_offset"`     // Offset in the source document
+	ContextOffset int      `json:"context_offset"` // Offset in RetrievalResult.Context
+	Score         float64  `json:"score"`
+}
+
 // GenerationResult represents generation results
 type GenerationResult struct {
 	Response       string                 `json:"response"`
@@ -307,6 +331,16 @@ const (
 	SearchTypeGraph    SearchType = "graph"
 )
 
+// FusionMethod combines vector and keyword rankings in hybrid search
+type FusionMethod string
+
+const (
+	// FusionRRF sums reciprocal ranks, ignoring raw score scales
+	FusionRRF FusionMethod = "rrf"
+	// FusionWeighted sums min-max normalized scores
+	FusionWeighted FusionMethod = "weighted"
+)
+
 type SecurityLevel string
 
 const (
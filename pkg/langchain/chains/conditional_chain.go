@@ -0,0 +1,370 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultRouteKey is the output key holding the route taken by a conditional chain
+	DefaultRouteKey = "route"
+
+	// DefaultRoute names the route of the default chain
+	DefaultRoute = "default"
+)
+
+// DefaultConditionalChain implements the ConditionalChain interface. Conditions
+// are evaluated in order and the chain of the first one that holds runs; the
+// default chain runs when none does.
+type DefaultConditionalChain struct {
+	conditions   []ConditionChainPair
+	defaultChain Chain
+	inputKeys    []string
+	outputKeys   []string
+	routeKey     string
+	callbacks    []ChainCallback
+	logger       *logrus.Logger
+	tracer       trace.Tracer
+	metadata     map[string]interface{}
+	mu           sync.RWMutex
+}
+
+// ConditionalChainConfig represents configuration for a conditional chain
+type ConditionalChainConfig struct {
+	Conditions   []ConditionChainPair   `json:"-"`
+	DefaultChain Chain                  `json:"-"`
+	InputKeys    []string               `json:"input_keys,omitempty"`
+	OutputKeys   []string               `json:"output_keys,omitempty"`
+	RouteKey     string                 `json:"route_key,omitempty"` // Output key of the route taken; defaults to "route"
+	Callbacks    []ChainCallback        `json:"-"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ConditionalRoute describes the branch selected by a conditional chain
+type ConditionalRoute struct {
+	Route string `json:"route"` // Condition description, or "default"
+	Index int    `json:"index"` // Condition index, or -1 for the default chain
+	Chain Chain  `json:"-"`
+}
+
+// RouteError is returned when the chain of the selected route fails
+type RouteError struct {
+	Route string
+	Err   error
+}
+
+func (e *RouteError) Error() string {
+	return fmt.Sprintf("route %s failed: %v", e.Route, e.Err)
+}
+
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// NewConditionalChain creates a new conditional chain
+func NewConditionalChain(config *ConditionalChainConfig, logger *logrus.Logger) (ConditionalChain, error) {
+	routeKey := config.RouteKey
+	if routeKey == "" {
+		routeKey = DefaultRouteKey
+	}
+
+	chain := &DefaultConditionalChain{
+		conditions:   append([]ConditionChainPair(nil), config.Conditions...),
+		defaultChain: config.DefaultChain,
+		inputKeys:    config.InputKeys,
+		outputKeys:   config.OutputKeys,
+		routeKey:     routeKey,
+		callbacks:    config.Callbacks,
+		logger:       logger,
+		tracer:       otel.Tracer("langchain.chains.conditional"),
+		metadata:     config.Metadata,
+	}
+
+	if err := chain.Validate(); err != nil {
+		return nil, fmt.Errorf("chain validation failed: %w", err)
+	}
+
+	return chain, nil
+}
+
+// NewExpressionChain creates a conditional chain from expressions keyed by
+// route name. Routes are tried in the order given by routes.
+func NewExpressionChain(routes []string, expressions map[string]string, branches map[string]Chain, defaultChain Chain, logger *logrus.Logger) (ConditionalChain, error) {
+	conditions := make([]ConditionChainPair, 0, len(routes))
+	for _, route := range routes {
+		branch, exists := branches[route]
+		if !exists {
+			return nil, fmt.Errorf("no chain for route %s", route)
+		}
+		condition, err := NewExpressionCondition(route, expressions[route])
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", route, err)
+		}
+		conditions = append(conditions, ConditionChainPair{Condition: condition, Chain: branch})
+	}
+
+	return NewConditionalChain(&ConditionalChainConfig{
+		Conditions:   conditions,
+		DefaultChain: defaultChain,
+	}, logger)
+}
+
+// Run evaluates the conditions and runs the chain of the selected route. The
+// output holds the route under the route key.
+func (c *DefaultConditionalChain) Run(ctx context.Context, input ChainInput) (ChainOutput, error) {
+	ctx, span := c.tracer.Start(ctx, "conditional_chain.run")
+	defer span.End()
+
+	executionID := uuid.New().String()
+	span.SetAttributes(
+		attribute.String("chain.type", c.GetChainType()),
+		attribute.String("chain.execution_id", executionID),
+	)
+
+	c.notify(func(cb ChainCallback) error { return cb.OnStart(ctx, c, input) })
+
+	route, err := c.SelectRoute(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		c.notify(func(cb ChainCallback) error { return cb.OnError(ctx, c, input, err) })
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("chain.route", route.Route),
+		attribute.Int("chain.route_index", route.Index),
+	)
+
+	c.logger.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"route":        route.Route,
+		"step_type":    route.Chain.GetChainType(),
+	}).Debug("Conditional chain route selected")
+
+	start := time.Now()
+	output, err := route.Chain.Run(ctx, input)
+	duration := time.Since(start)
+
+	if err != nil {
+		routeErr := &RouteError{Route: route.Route, Err: err}
+		span.RecordError(routeErr)
+		c.logger.WithError(err).WithFields(logrus.Fields{
+			"execution_id": executionID,
+			"route":        route.Route,
+			"duration":     duration,
+		}).Error("Conditional chain route failed")
+		c.notify(func(cb ChainCallback) error { return cb.OnError(ctx, c, input, routeErr) })
+		return nil, routeErr
+	}
+
+	result := make(ChainOutput, len(output)+1)
+	for key, value := range output {
+		result[key] = value
+	}
+	result[c.routeKey] = route.Route
+
+	c.logger.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"route":        route.Route,
+		"duration":     duration,
+	}).Info("Conditional chain execution completed")
+
+	c.notify(func(cb ChainCallback) error { return cb.OnEnd(ctx, c, input, result) })
+
+	return result, nil
+}
+
+// SelectRoute evaluates the conditions without running any chain
+func (c *DefaultConditionalChain) SelectRoute(ctx context.Context, input ChainInput) (*ConditionalRoute, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for i, pair := range c.conditions {
+		matched, err := pair.Condition.Evaluate(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("condition %d (%s) failed: %w", i, pair.Condition.GetDescription(), err)
+		}
+		if matched {
+			return &ConditionalRoute{Route: pair.Condition.GetDescription(), Index: i, Chain: pair.Chain}, nil
+		}
+	}
+
+	if c.defaultChain == nil {
+		return nil, fmt.Errorf("no condition matched and no default chain is set")
+	}
+
+	return &ConditionalRoute{Route: DefaultRoute, Index: -1, Chain: c.defaultChain}, nil
+}
+
+// GetInputKeys returns the expected input keys. Unless configured, these are
+// the keys read by the conditions and the branch chains.
+func (c *DefaultConditionalChain) GetInputKeys() []string {
+	if len(c.inputKeys) > 0 {
+		return c.inputKeys
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keySet := make(map[string]bool)
+	for _, pair := range c.conditions {
+		if condition, ok := pair.Condition.(*ExpressionCondition); ok {
+			for _, key := range condition.GetExpression().Variables() {
+				keySet[key] = true
+			}
+		}
+		for _, key := range pair.Chain.GetInputKeys() {
+			keySet[key] = true
+		}
+	}
+	if c.defaultChain != nil {
+		for _, key := range c.defaultChain.GetInputKeys() {
+			keySet[key] = true
+		}
+	}
+
+	return sortedKeys(keySet)
+}
+
+// GetOutputKeys returns the output keys this chain produces. Unless
+// configured, these are the keys of all branch chains and the route key.
+func (c *DefaultConditionalChain) GetOutputKeys() []string {
+	if len(c.outputKeys) > 0 {
+		return c.outputKeys
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keySet := map[string]bool{c.routeKey: true}
+	for _, pair := range c.conditions {
+		for _, key := range pair.Chain.GetOutputKeys() {
+			keySet[key] = true
+		}
+	}
+	if c.defaultChain != nil {
+		for _, key := range c.defaultChain.GetOutputKeys() {
+			keySet[key] = true
+		}
+	}
+
+	return sortedKeys(keySet)
+}
+
+// GetChainType returns the type of this chain
+func (c *DefaultConditionalChain) GetChainType() string {
+	return "conditional"
+}
+
+// Validate validates the chain configuration
+func (c *DefaultConditionalChain) Validate() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.conditions) == 0 && c.defaultChain == nil {
+		return fmt.Errorf("at least one condition or a default chain is required")
+	}
+
+	for i, pair := range c.conditions {
+		if pair.Condition == nil {
+			return fmt.Errorf("condition %d is nil", i)
+		}
+		if pair.Chain == nil {
+			return fmt.Errorf("condition %d has no chain", i)
+		}
+		if err := pair.Chain.Validate(); err != nil {
+			return fmt.Errorf("chain of condition %d validation failed: %w", i, err)
+		}
+	}
+
+	if c.defaultChain != nil {
+		if err := c.defaultChain.Validate(); err != nil {
+			return fmt.Errorf("default chain validation failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// AddCondition adds a condition and associated chain
+func (c *DefaultConditionalChain) AddCondition(condition ChainCondition, chain Chain) error {
+	if condition == nil {
+		return fmt.Errorf("condition cannot be nil")
+	}
+	if chain == nil {
+		return fmt.Errorf("chain cannot be nil")
+	}
+	if err := chain.Validate(); err != nil {
+		return fmt.Errorf("chain validation failed: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conditions = append(c.conditions, ConditionChainPair{Condition: condition, Chain: chain})
+
+	return nil
+}
+
+// SetDefaultChain sets the default chain to run if no conditions match
+func (c *DefaultConditionalChain) SetDefaultChain(chain Chain) error {
+	if chain != nil {
+		if err := chain.Validate(); err != nil {
+			return fmt.Errorf("chain validation failed: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.defaultChain = chain
+
+	return nil
+}
+
+// GetConditions returns all conditions and their chains
+func (c *DefaultConditionalChain) GetConditions() []ConditionChainPair {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return append([]ConditionChainPair(nil), c.conditions...)
+}
+
+// AddCallback adds a callback notified of executions
+func (c *DefaultConditionalChain) AddCallback(callback ChainCallback) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.callbacks = append(c.callbacks, callback)
+}
+
+// notify invokes the callbacks; their errors are logged, not propagated
+func (c *DefaultConditionalChain) notify(event func(ChainCallback) error) {
+	c.mu.RLock()
+	callbacks := c.callbacks
+	c.mu.RUnlock()
+
+	for _, callback := range callbacks {
+		if err := event(callback); err != nil {
+			c.logger.WithError(err).Warn("Chain callback failed")
+		}
+	}
+}
+
+func sortedKeys(keySet map[string]bool) []string {
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,153 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticChain returns a fixed answer, or err when set
+type staticChain struct {
+	answer string
+	err    error
+}
+
+func (c *staticChain) Run(ctx context.Context, input ChainInput) (ChainOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return ChainOutput{"answer": c.answer}, nil
+}
+
+func (c *staticChain) GetInputKeys() []string {
+	return []string{"question"}
+}
+
+func (c *staticChain) GetOutputKeys() []string {
+	return []string{"answer"}
+}
+
+func (c *staticChain) GetChainType() string {
+	return "static"
+}
+
+func (c *staticChain) Validate() error {
+	return nil
+}
+
+// recordingCallback records the output and errors of executions
+type recordingCallback struct {
+	outputs []ChainOutput
+	errors  []error
+}
+
+func (r *recordingCallback) OnStart(ctx context.Context, chain Chain, input ChainInput) error {
+	return nil
+}
+
+func (r *recordingCallback) OnEnd(ctx context.Context, chain Chain, input ChainInput, output ChainOutput) error {
+	r.outputs = append(r.outputs, output)
+	return nil
+}
+
+func (r *recordingCallback) OnError(ctx context.Context, chain Chain, input ChainInput, err error) error {
+	r.errors = append(r.errors, err)
+	return nil
+}
+
+func (r *recordingCallback) OnRetry(ctx context.Context, chain Chain, input ChainInput, attempt int, err error) error {
+	return nil
+}
+
+func TestExpressionEvaluation(t *testing.T) {
+	input := ChainInput{
+		"question": "How do I reset my password?",
+		"score":    0.82,
+		"attempts": 3,
+		"user":     map[string]interface{}{"tier": "gold", "tags": []string{"beta"}},
+	}
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{`score >= 0.8 && attempts < 5`, true},
+		{`score > 0.9 or user.tier == "gold"`, true},
+		{`not (question contains "password")`, false},
+		{`lower(question) matches "^how (do|can) i"`, true},
+		{`question startswith 'How' and question endswith "?"`, true},
+		{`user.tags contains "beta" && len(user.tags) == 1`, true},
+		{`missing > 10`, false},
+		{`missing == null && !missing`, true},
+		{`attempts != 3 || -1 < 0`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := ParseExpression(tt.expression)
+			require.NoError(t, err)
+			result, err := expr.EvaluateBool(input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	for _, invalid := range []string{`score >`, `(score > 1`, `question matches "("`, `exec(question)`, `score = 1`} {
+		_, err := ParseExpression(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// Ordering mismatched types fails at evaluation time
+	expr, err := ParseExpression(`question < 1`)
+	require.NoError(t, err)
+	_, err = expr.EvaluateBool(input)
+	assert.Error(t, err)
+}
+
+func TestConditionalChain(t *testing.T) {
+	ctx := context.Background()
+	callback := &recordingCallback{}
+
+	chain, err := NewExpressionChain(
+		[]string{"billing", "urgent"},
+		map[string]string{
+			"billing": `question contains "invoice" || question matches "(?i)refund"`,
+			"urgent":  `priority >= 8`,
+		},
+		map[string]Chain{
+			"billing": &staticChain{answer: "billing team"},
+			"urgent":  &staticChain{err: errors.New("pager offline")},
+		},
+		&staticChain{answer: "general support"},
+		logrus.New(),
+	)
+	require.NoError(t, err)
+	chain.(*DefaultConditionalChain).AddCallback(callback)
+
+	assert.Equal(t, []string{"priority", "question"}, chain.GetInputKeys())
+	assert.Equal(t, []string{"answer", "route"}, chain.GetOutputKeys())
+
+	output, err := chain.Run(ctx, ChainInput{"question": "Where is my Refund?", "priority": 9})
+	require.NoError(t, err)
+	assert.Equal(t, ChainOutput{"answer": "billing team", "route": "billing"}, output)
+
+	output, err = chain.Run(ctx, ChainInput{"question": "Hello", "priority": 2})
+	require.NoError(t, err)
+	assert.Equal(t, "general support", output["answer"])
+	assert.Equal(t, DefaultRoute, output["route"])
+
+	_, err = chain.Run(ctx, ChainInput{"question": "Site is down", "priority": 10})
+	var routeErr *RouteError
+	require.ErrorAs(t, err, &routeErr)
+	assert.Equal(t, "urgent", routeErr.Route)
+
+	// The route is visible to callbacks
+	require.Len(t, callback.outputs, 2)
+	assert.Equal(t, "billing", callback.outputs[0]["route"])
+	require.Len(t, callback.errors, 1)
+	assert.ErrorAs(t, callback.errors[0], &routeErr)
+}
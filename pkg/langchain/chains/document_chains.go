@@ -0,0 +1,782 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aios/aios/pkg/knowledge"
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Document chains run LLM chains over inputs larger than the context window.
+// The documents are read from the input key (a string, a []string or
+// []*knowledge.DocumentChunk), split with a pkg/knowledge chunker and passed
+// to the prompts under the document variable. Other input keys are passed
+// through to every prompt.
+
+const (
+	defaultDocumentInputKey    = "documents"
+	defaultDocumentVariable    = "text"
+	defaultDocumentChunkSize   = 4000
+	defaultDocumentOverlap     = 200
+	defaultDocumentConcurrency = 4
+	defaultDocumentTokenLimit  = 3000
+	defaultCollapseRounds      = 5
+	defaultDocumentSeparator   = "\n\n"
+)
+
+// DocumentSplitConfig configures how document chains read and split their input
+type DocumentSplitConfig struct {
+	InputKey         string                `json:"input_key,omitempty"`         // Input key holding the documents; defaults to "documents"
+	DocumentVariable string                `json:"document_variable,omitempty"` // Prompt variable receiving each chunk; defaults to "text"
+	Chunker          knowledge.TextChunker `json:"-"`                           // Defaults to knowledge.RecursiveChunker
+	ChunkSize        int                   `json:"chunk_size,omitempty"`        // In characters, as for the knowledge chunkers; defaults to 4000
+	ChunkOverlap     int                   `json:"chunk_overlap,omitempty"`     // In characters; defaults to 200, negative disables overlap
+}
+
+func (c *DocumentSplitConfig) withDefaults() DocumentSplitConfig {
+	config := *c
+	if config.InputKey == "" {
+		config.InputKey = defaultDocumentInputKey
+	}
+	if config.DocumentVariable == "" {
+		config.DocumentVariable = defaultDocumentVariable
+	}
+	if config.Chunker == nil {
+		config.Chunker = &knowledge.RecursiveChunker{}
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = defaultDocumentChunkSize
+	}
+	if config.ChunkOverlap < 0 || config.ChunkOverlap >= config.ChunkSize {
+		config.ChunkOverlap = 0
+	} else if config.ChunkOverlap == 0 {
+		config.ChunkOverlap = min(defaultDocumentOverlap, config.ChunkSize/4)
+	}
+	return config
+}
+
+// split reads the documents from the input and splits them into chunks
+func (c *DocumentSplitConfig) split(input ChainInput) ([]string, error) {
+	value, exists := input[c.InputKey]
+	if !exists {
+		return nil, fmt.Errorf("missing required input key: %s", c.InputKey)
+	}
+
+	var documents []string
+	switch docs := value.(type) {
+	case string:
+		documents = []string{docs}
+	case []string:
+		documents = docs
+	case []*knowledge.DocumentChunk:
+		for _, doc := range docs {
+			documents = append(documents, doc.Content)
+		}
+	case []interface{}:
+		for _, doc := range docs {
+			documents = append(documents, toString(doc))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported documents type %T for key %s", value, c.InputKey)
+	}
+
+	var chunks []string
+	for i, doc := range documents {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		docChunks, err := c.Chunker.Chunk(doc, c.ChunkSize, c.ChunkOverlap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to chunk document %d: %w", i, err)
+		}
+		for _, chunk := range docChunks {
+			if strings.TrimSpace(chunk.Content) != "" {
+				chunks = append(chunks, chunk.Content)
+			}
+		}
+	}
+
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no document content in input key %s", c.InputKey)
+	}
+
+	return chunks, nil
+}
+
+// chainInputFor builds the input of a per-chunk chain run
+func (c *DocumentSplitConfig) chainInputFor(input ChainInput, content string, extra ChainInput) ChainInput {
+	chainInput := make(ChainInput, len(input)+len(extra)+1)
+	for key, value := range input {
+		if key != c.InputKey {
+			chainInput[key] = value
+		}
+	}
+	for key, value := range extra {
+		chainInput[key] = value
+	}
+	chainInput[c.DocumentVariable] = content
+	return chainInput
+}
+
+// passthroughKeys returns the input keys of chains other than the document
+// variable and the variables filled in by the document chain itself
+func passthroughKeys(split *DocumentSplitConfig, internal []string, chains ...Chain) []string {
+	keySet := map[string]bool{split.InputKey: true}
+	for _, chain := range chains {
+		if chain == nil {
+			continue
+		}
+		for _, key := range chain.GetInputKeys() {
+			if key != split.DocumentVariable && !containsString(internal, key) {
+				keySet[key] = true
+			}
+		}
+	}
+	return sortedKeys(keySet)
+}
+
+// runBounded runs fn for every index with at most concurrency calls in
+// flight. It stops starting new calls after the first failure.
+func runBounded(ctx context.Context, count, concurrency int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	semaphore := make(chan struct{}, concurrency)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	for i := 0; i < count; i++ {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if err := fn(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// textOutput returns the "text" output of an LLM chain run
+func textOutput(output ChainOutput) (string, error) {
+	text, ok := output["text"].(string)
+	if !ok {
+		return "", fmt.Errorf("chain did not produce a text output")
+	}
+	return text, nil
+}
+
+// MapReduceDocumentsChain maps an LLM chain over every chunk concurrently,
+// collapses the results until they fit the token limit and combines them
+// with a reduce chain.
+type MapReduceDocumentsChain struct {
+	split             DocumentSplitConfig
+	mapChain          LLMChain
+	reduceChain       LLMChain
+	collapseChain     LLMChain
+	tokenizer         llm.Tokenizer
+	tokenLimit        int
+	maxConcurrency    int
+	maxCollapseRounds int
+	returnSteps       bool
+	logger            *logrus.Logger
+	tracer            trace.Tracer
+}
+
+// MapReduceDocumentsChainConfig represents configuration for a map-reduce chain
+type MapReduceDocumentsChainConfig struct {
+	DocumentSplitConfig
+
+	MapChain                LLMChain      `json:"-"` // Run on every chunk
+	ReduceChain             LLMChain      `json:"-"` // Combines the mapped results
+	CollapseChain           LLMChain      `json:"-"` // Shrinks groups of results; defaults to ReduceChain
+	Tokenizer               llm.Tokenizer `json:"-"` // Defaults to llm.NewHeuristicTokenizer()
+	TokenLimit              int           `json:"token_limit,omitempty"`
+	MaxConcurrency          int           `json:"max_concurrency,omitempty"`
+	MaxCollapseRounds       int           `json:"max_collapse_rounds,omitempty"`
+	ReturnIntermediateSteps bool          `json:"return_intermediate_steps,omitempty"`
+}
+
+// NewMapReduceDocumentsChain creates a new map-reduce documents chain
+func NewMapReduceDocumentsChain(config *MapReduceDocumentsChainConfig, logger *logrus.Logger) (*MapReduceDocumentsChain, error) {
+	tokenizer := config.Tokenizer
+	if tokenizer == nil {
+		tokenizer = llm.NewHeuristicTokenizer()
+	}
+	tokenLimit := config.TokenLimit
+	if tokenLimit <= 0 {
+		tokenLimit = defaultDocumentTokenLimit
+	}
+	maxConcurrency := config.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultDocumentConcurrency
+	}
+	maxCollapseRounds := config.MaxCollapseRounds
+	if maxCollapseRounds <= 0 {
+		maxCollapseRounds = defaultCollapseRounds
+	}
+	collapseChain := config.CollapseChain
+	if collapseChain == nil {
+		collapseChain = config.ReduceChain
+	}
+
+	chain := &MapReduceDocumentsChain{
+		split:             config.DocumentSplitConfig.withDefaults(),
+		mapChain:          config.MapChain,
+		reduceChain:       config.ReduceChain,
+		collapseChain:     collapseChain,
+		tokenizer:         tokenizer,
+		tokenLimit:        tokenLimit,
+		maxConcurrency:    maxConcurrency,
+		maxCollapseRounds: maxCollapseRounds,
+		returnSteps:       config.ReturnIntermediateSteps,
+		logger:            logger,
+		tracer:            otel.Tracer("langchain.chains.map_reduce"),
+	}
+
+	if err := chain.Validate(); err != nil {
+		return nil, fmt.Errorf("chain validation failed: %w", err)
+	}
+
+	return chain, nil
+}
+
+// Run executes the chain with the given input
+func (c *MapReduceDocumentsChain) Run(ctx context.Context, input ChainInput) (ChainOutput, error) {
+	ctx, span := c.tracer.Start(ctx, "map_reduce_chain.run")
+	defer span.End()
+
+	executionID := uuid.New().String()
+	start := time.Now()
+
+	chunks, err := c.split.split(input)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("chain.type", c.GetChainType()),
+		attribute.String("chain.execution_id", executionID),
+		attribute.Int("chain.chunks", len(chunks)),
+	)
+
+	c.logger.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"chunks":       len(chunks),
+	}).Info("Starting map-reduce chain execution")
+
+	// Map
+	mapped, err := c.runOnTexts(ctx, c.mapChain, input, chunks)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("map step failed: %w", err)
+	}
+
+	// Collapse until the results fit the token limit
+	results := mapped
+	rounds := 0
+	for c.countTokens(results) > c.tokenLimit && len(results) > 1 {
+		if rounds >= c.maxCollapseRounds {
+			err := fmt.Errorf("results still exceed %d tokens after %d collapse rounds", c.tokenLimit, rounds)
+			span.RecordError(err)
+			return nil, err
+		}
+		rounds++
+
+		groups := c.groupByTokens(results)
+		if len(groups) == len(results) {
+			// Every result fills the limit on its own; collapse them in pairs
+			groups = pairTexts(results)
+		}
+
+		results, err = c.runOnTexts(ctx, c.collapseChain, input, groups)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("collapse round %d failed: %w", rounds, err)
+		}
+
+		c.logger.WithFields(logrus.Fields{
+			"execution_id": executionID,
+			"round":        rounds,
+			"results":      len(results),
+		}).Debug("Collapsed map results")
+	}
+
+	// Reduce
+	reduced, err := c.reduceChain.Run(ctx, c.split.chainInputFor(input, strings.Join(results, defaultDocumentSeparator), nil))
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("reduce step failed: %w", err)
+	}
+	text, err := textOutput(reduced)
+	if err != nil {
+		return nil, fmt.Errorf("reduce step failed: %w", err)
+	}
+
+	output := ChainOutput{"text": text, "collapse_rounds": rounds}
+	if c.returnSteps {
+		output["intermediate_steps"] = mapped
+	}
+
+	duration := time.Since(start)
+	span.SetAttributes(
+		attribute.Int("chain.collapse_rounds", rounds),
+		attribute.Int64("chain.duration_ms", duration.Milliseconds()),
+	)
+
+	c.logger.WithFields(logrus.Fields{
+		"execution_id":    executionID,
+		"duration":        duration,
+		"collapse_rounds": rounds,
+	}).Info("Map-reduce chain execution completed")
+
+	return output, nil
+}
+
+// runOnTexts runs chain on every text concurrently, keeping the order
+func (c *MapReduceDocumentsChain) runOnTexts(ctx context.Context, chain LLMChain, input ChainInput, texts []string) ([]string, error) {
+	results := make([]string, len(texts))
+	err := runBounded(ctx, len(texts), c.maxConcurrency, func(ctx context.Context, i int) error {
+		output, err := chain.Run(ctx, c.split.chainInputFor(input, texts[i], nil))
+		if err != nil {
+			return fmt.Errorf("chunk %d: %w", i, err)
+		}
+		if results[i], err = textOutput(output); err != nil {
+			return fmt.Errorf("chunk %d: %w", i, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (c *MapReduceDocumentsChain) countTokens(texts []string) int {
+	return c.tokenizer.CountTokens(strings.Join(texts, defaultDocumentSeparator))
+}
+
+// groupByTokens joins consecutive results into groups that fit the token limit
+func (c *MapReduceDocumentsChain) groupByTokens(texts []string) []string {
+	var groups []string
+	var current []string
+	for _, text := range texts {
+		if len(current) > 0 && c.countTokens(append(current, text)) > c.tokenLimit {
+			groups = append(groups, strings.Join(current, defaultDocumentSeparator))
+			current = nil
+		}
+		current = append(current, text)
+	}
+	if len(current) > 0 {
+		groups = append(groups, strings.Join(current, defaultDocumentSeparator))
+	}
+	return groups
+}
+
+func pairTexts(texts []string) []string {
+	pairs := make([]string, 0, (len(texts)+1)/2)
+	for i := 0; i < len(texts); i += 2 {
+		if i+1 < len(texts) {
+			pairs = append(pairs, texts[i]+defaultDocumentSeparator+texts[i+1])
+		} else {
+			pairs = append(pairs, texts[i])
+		}
+	}
+	return pairs
+}
+
+// GetInputKeys returns the expected input keys
+func (c *MapReduceDocumentsChain) GetInputKeys() []string {
+	return passthroughKeys(&c.split, nil, c.mapChain, c.collapseChain, c.reduceChain)
+}
+
+// GetOutputKeys returns the output keys this chain produces
+func (c *MapReduceDocumentsChain) GetOutputKeys() []string {
+	if c.returnSteps {
+		return []string{"text", "collapse_rounds", "intermediate_steps"}
+	}
+	return []string{"text", "collapse_rounds"}
+}
+
+// GetChainType returns the type of this chain
+func (c *MapReduceDocumentsChain) GetChainType() string {
+	return "map_reduce_documents"
+}
+
+// Validate validates the chain configuration
+func (c *MapReduceDocumentsChain) Validate() error {
+	if c.mapChain == nil {
+		return fmt.Errorf("map chain is required")
+	}
+	if c.reduceChain == nil {
+		return fmt.Errorf("reduce chain is required")
+	}
+	for name, chain := range map[string]LLMChain{"map": c.mapChain, "reduce": c.reduceChain, "collapse": c.collapseChain} {
+		if !containsString(chain.GetInputKeys(), c.split.DocumentVariable) {
+			return fmt.Errorf("%s chain does not take the document variable %s", name, c.split.DocumentVariable)
+		}
+	}
+	return nil
+}
+
+// RefineDocumentsChain answers from the first chunk and then refines the
+// answer with each following chunk in order.
+type RefineDocumentsChain struct {
+	split          DocumentSplitConfig
+	initialChain   LLMChain
+	refineChain    LLMChain
+	answerVariable string
+	returnSteps    bool
+	logger         *logrus.Logger
+	tracer         trace.Tracer
+}
+
+// RefineDocumentsChainConfig represents configuration for a refine chain
+type RefineDocumentsChainConfig struct {
+	DocumentSplitConfig
+
+	InitialChain            LLMChain `json:"-"`                                  // Run on the first chunk
+	RefineChain             LLMChain `json:"-"`                                  // Run on each following chunk with the current answer
+	ExistingAnswerVariable  string   `json:"existing_answer_variable,omitempty"` // Defaults to "existing_answer"
+	ReturnIntermediateSteps bool     `json:"return_intermediate_steps,omitempty"`
+}
+
+// NewRefineDocumentsChain creates a new refine documents chain
+func NewRefineDocumentsChain(config *RefineDocumentsChainConfig, logger *logrus.Logger) (*RefineDocumentsChain, error) {
+	answerVariable := config.ExistingAnswerVariable
+	if answerVariable == "" {
+		answerVariable = "existing_answer"
+	}
+
+	chain := &RefineDocumentsChain{
+		split:          config.DocumentSplitConfig.withDefaults(),
+		initialChain:   config.InitialChain,
+		refineChain:    config.RefineChain,
+		answerVariable: answerVariable,
+		returnSteps:    config.ReturnIntermediateSteps,
+		logger:         logger,
+		tracer:         otel.Tracer("langchain.chains.refine"),
+	}
+
+	if err := chain.Validate(); err != nil {
+		return nil, fmt.Errorf("chain validation failed: %w", err)
+	}
+
+	return chain, nil
+}
+
+// Run executes the chain with the given input
+func (c *RefineDocumentsChain) Run(ctx context.Context, input ChainInput) (ChainOutput, error) {
+	ctx, span := c.tracer.Start(ctx, "refine_chain.run")
+	defer span.End()
+
+	executionID := uuid.New().String()
+	start := time.Now()
+
+	chunks, err := c.split.split(input)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("chain.type", c.GetChainType()),
+		attribute.String("chain.execution_id", executionID),
+		attribute.Int("chain.chunks", len(chunks)),
+	)
+
+	steps := make([]string, 0, len(chunks))
+	var answer string
+	for i, chunk := range chunks {
+		chain := c.initialChain
+		var extra ChainInput
+		if i > 0 {
+			chain = c.refineChain
+			extra = ChainInput{c.answerVariable: answer}
+		}
+
+		output, err := chain.Run(ctx, c.split.chainInputFor(input, chunk, extra))
+		if err == nil {
+			answer, err = textOutput(output)
+		}
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("refine step %d failed: %w", i, err)
+		}
+		steps = append(steps, answer)
+
+		c.logger.WithFields(logrus.Fields{
+			"execution_id": executionID,
+			"step":         i,
+		}).Debug("Refine step completed")
+	}
+
+	result := ChainOutput{"text": answer}
+	if c.returnSteps {
+		result["intermediate_steps"] = steps
+	}
+
+	duration := time.Since(start)
+	c.logger.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"duration":     duration,
+		"steps":        len(steps),
+	}).Info("Refine chain execution completed")
+
+	return result, nil
+}
+
+// GetInputKeys returns the expected input keys
+func (c *RefineDocumentsChain) GetInputKeys() []string {
+	return passthroughKeys(&c.split, []string{c.answerVariable}, c.initialChain, c.refineChain)
+}
+
+// GetOutputKeys returns the output keys this chain produces
+func (c *RefineDocumentsChain) GetOutputKeys() []string {
+	if c.returnSteps {
+		return []string{"text", "intermediate_steps"}
+	}
+	return []string{"text"}
+}
+
+// GetChainType returns the type of this chain
+func (c *RefineDocumentsChain) GetChainType() string {
+	return "refine_documents"
+}
+
+// Validate validates the chain configuration
+func (c *RefineDocumentsChain) Validate() error {
+	if c.initialChain == nil {
+		return fmt.Errorf("initial chain is required")
+	}
+	if c.refineChain == nil {
+		return fmt.Errorf("refine chain is required")
+	}
+	if !containsString(c.initialChain.GetInputKeys(), c.split.DocumentVariable) {
+		return fmt.Errorf("initial chain does not take the document variable %s", c.split.DocumentVariable)
+	}
+	refineKeys := c.refineChain.GetInputKeys()
+	if !containsString(refineKeys, c.split.DocumentVariable) || !containsString(refineKeys, c.answerVariable) {
+		return fmt.Errorf("refine chain must take %s and %s", c.split.DocumentVariable, c.answerVariable)
+	}
+	return nil
+}
+
+// ScoredAnswer is an answer produced from one chunk by a map-rerank chain
+type ScoredAnswer struct {
+	Answer string  `json:"answer"`
+	Score  float64 `json:"score"`
+	Chunk  int     `json:"chunk"`
+}
+
+// AnswerScoreParser extracts the answer and its score from a chain output
+type AnswerScoreParser func(output ChainOutput) (string, float64, error)
+
+var scoreLinePattern = regexp.MustCompile(`(?im)^\s*score\s*:\s*(-?[0-9]+(?:\.[0-9]+)?)\s*$`)
+
+// ParseAnswerScore is the default AnswerScoreParser. It reads the "parsed"
+// output of structured chains ({"answer": ..., "score": ...}) or, otherwise,
+// a text reply whose last "Score: N" line holds the score.
+func ParseAnswerScore(output ChainOutput) (string, float64, error) {
+	if parsed, ok := output["parsed"].(map[string]interface{}); ok {
+		score, isNumber := toNumber(parsed["score"])
+		if !isNumber {
+			return "", 0, fmt.Errorf("parsed output has no numeric score")
+		}
+		return toString(parsed["answer"]), score, nil
+	}
+
+	text, err := textOutput(output)
+	if err != nil {
+		return "", 0, err
+	}
+	matches := scoreLinePattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return "", 0, fmt.Errorf("no score line in reply")
+	}
+	last := matches[len(matches)-1]
+	score, err := strconv.ParseFloat(text[last[2]:last[3]], 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid score: %w", err)
+	}
+	return strings.TrimSpace(text[:last[0]]), score, nil
+}
+
+// MapRerankDocumentsChain asks the LLM chain to answer from every chunk and
+// score its answer, and returns the best scoring one.
+type MapRerankDocumentsChain struct {
+	split          DocumentSplitConfig
+	chain          LLMChain
+	parser         AnswerScoreParser
+	maxConcurrency int
+	returnSteps    bool
+	logger         *logrus.Logger
+	tracer         trace.Tracer
+}
+
+// MapRerankDocumentsChainConfig represents configuration for a map-rerank chain
+type MapRerankDocumentsChainConfig struct {
+	DocumentSplitConfig
+
+	Chain                   LLMChain          `json:"-"` // Answers from one chunk and scores the answer
+	Parser                  AnswerScoreParser `json:"-"` // Defaults to ParseAnswerScore
+	MaxConcurrency          int               `json:"max_concurrency,omitempty"`
+	ReturnIntermediateSteps bool              `json:"return_intermediate_steps,omitempty"`
+}
+
+// NewMapRerankDocumentsChain creates a new map-rerank documents chain
+func NewMapRerankDocumentsChain(config *MapRerankDocumentsChainConfig, logger *logrus.Logger) (*MapRerankDocumentsChain, error) {
+	parser := config.Parser
+	if parser == nil {
+		parser = ParseAnswerScore
+	}
+	maxConcurrency := config.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultDocumentConcurrency
+	}
+
+	chain := &MapRerankDocumentsChain{
+		split:          config.DocumentSplitConfig.withDefaults(),
+		chain:          config.Chain,
+		parser:         parser,
+		maxConcurrency: maxConcurrency,
+		returnSteps:    config.ReturnIntermediateSteps,
+		logger:         logger,
+		tracer:         otel.Tracer("langchain.chains.map_rerank"),
+	}
+
+	if err := chain.Validate(); err != nil {
+		return nil, fmt.Errorf("chain validation failed: %w", err)
+	}
+
+	return chain, nil
+}
+
+// Run executes the chain with the given input
+func (c *MapRerankDocumentsChain) Run(ctx context.Context, input ChainInput) (ChainOutput, error) {
+	ctx, span := c.tracer.Start(ctx, "map_rerank_chain.run")
+	defer span.End()
+
+	executionID := uuid.New().String()
+	start := time.Now()
+
+	chunks, err := c.split.split(input)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("chain.type", c.GetChainType()),
+		attribute.String("chain.execution_id", executionID),
+		attribute.Int("chain.chunks", len(chunks)),
+	)
+
+	answers := make([]ScoredAnswer, len(chunks))
+	err = runBounded(ctx, len(chunks), c.maxConcurrency, func(ctx context.Context, i int) error {
+		output, err := c.chain.Run(ctx, c.split.chainInputFor(input, chunks[i], nil))
+		if err != nil {
+			return fmt.Errorf("chunk %d: %w", i, err)
+		}
+		answer, score, err := c.parser(output)
+		if err != nil {
+			return fmt.Errorf("chunk %d: failed to parse answer: %w", i, err)
+		}
+		answers[i] = ScoredAnswer{Answer: answer, Score: score, Chunk: i}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("map step failed: %w", err)
+	}
+
+	// Stable sort keeps the earliest chunk on ties
+	ranked := append([]ScoredAnswer(nil), answers...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	best := ranked[0]
+
+	result := ChainOutput{"text": best.Answer, "score": best.Score}
+	if c.returnSteps {
+		result["intermediate_steps"] = answers
+	}
+
+	duration := time.Since(start)
+	span.SetAttributes(
+		attribute.Float64("chain.best_score", best.Score),
+		attribute.Int64("chain.duration_ms", duration.Milliseconds()),
+	)
+
+	c.logger.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"duration":     duration,
+		"best_chunk":   best.Chunk,
+		"best_score":   best.Score,
+	}).Info("Map-rerank chain execution completed")
+
+	return result, nil
+}
+
+// GetInputKeys returns the expected input keys
+func (c *MapRerankDocumentsChain) GetInputKeys() []string {
+	return passthroughKeys(&c.split, nil, c.chain)
+}
+
+// GetOutputKeys returns the output keys this chain produces
+func (c *MapRerankDocumentsChain) GetOutputKeys() []string {
+	if c.returnSteps {
+		return []string{"text", "score", "intermediate_steps"}
+	}
+	return []string{"text", "score"}
+}
+
+// GetChainType returns the type of this chain
+func (c *MapRerankDocumentsChain) GetChainType() string {
+	return "map_rerank_documents"
+}
+
+// Validate validates the chain configuration
+func (c *MapRerankDocumentsChain) Validate() error {
+	if c.chain == nil {
+		return fmt.Errorf("chain is required")
+	}
+	if !containsString(c.chain.GetInputKeys(), c.split.DocumentVariable) {
+		return fmt.Errorf("chain does not take the document variable %s", c.split.DocumentVariable)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aios/aios/pkg/knowledge"
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/aios/aios/pkg/langchain/prompts"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// funcLLM answers every prompt with reply and tracks concurrent calls
+type funcLLM struct {
+	reply       func(prompt string) string
+	mu          sync.Mutex
+	prompts     []string
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (f *funcLLM) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	current := atomic.AddInt32(&f.inFlight, 1)
+	defer atomic.AddInt32(&f.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&f.maxInFlight)
+		if current <= max || atomic.CompareAndSwapInt32(&f.maxInFlight, max, current) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	prompt := req.Messages[0].Content
+	f.mu.Lock()
+	f.prompts = append(f.prompts, prompt)
+	f.mu.Unlock()
+
+	return &llm.CompletionResponse{Content: f.reply(prompt)}, nil
+}
+
+func (f *funcLLM) Stream(ctx context.Context, req *llm.CompletionRequest) (<-chan llm.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *funcLLM) GetEmbeddings(ctx context.Context, req *llm.EmbeddingRequest) (*llm.EmbeddingResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *funcLLM) GetProvider() llm.LLMProvider {
+	return "func"
+}
+
+func (f *funcLLM) GetModels(ctx context.Context) ([]string, error) {
+	return []string{"func"}, nil
+}
+
+func (f *funcLLM) ValidateModel(ctx context.Context, model string) error {
+	return nil
+}
+
+func (f *funcLLM) Close() error {
+	return nil
+}
+
+func newTestLLMChain(t *testing.T, model llm.LLM, template string, variables ...string) LLMChain {
+	prompt, err := prompts.NewPromptTemplate(&prompts.PromptTemplateConfig{
+		Template:       template,
+		InputVariables: variables,
+	})
+	require.NoError(t, err)
+
+	chain, err := NewLLMChain(&LLMChainConfig{LLM: model, Prompt: prompt}, logrus.New())
+	require.NoError(t, err)
+	return chain
+}
+
+// incidentReport builds a report of n paragraphs, one per incident event
+func incidentReport(n int) string {
+	paragraphs := make([]string, n)
+	for i := range paragraphs {
+		paragraphs[i] = fmt.Sprintf("Event %d: the checkout service returned errors while the database failed over to the replica.", i)
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func TestMapReduceDocumentsChain(t *testing.T) {
+	model := &funcLLM{reply: func(prompt string) string {
+		switch {
+		case strings.HasPrefix(prompt, "Summarize"):
+			return "checkout errors during failover"
+		case strings.HasPrefix(prompt, "Combine"):
+			return "repeated checkout errors during failovers"
+		}
+		return ""
+	}}
+
+	chain, err := NewMapReduceDocumentsChain(&MapReduceDocumentsChainConfig{
+		DocumentSplitConfig: DocumentSplitConfig{
+			Chunker:      &knowledge.ParagraphChunker{},
+			ChunkSize:    120,
+			ChunkOverlap: -1,
+		},
+		MapChain:                newTestLLMChain(t, model, "Summarize for {audience}: {text}", "audience", "text"),
+		ReduceChain:             newTestLLMChain(t, model, "Combine: {text}", "text"),
+		TokenLimit:              30,
+		MaxConcurrency:          3,
+		ReturnIntermediateSteps: true,
+	}, logrus.New())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"audience", "documents"}, chain.GetInputKeys())
+
+	output, err := chain.Run(context.Background(), ChainInput{
+		"documents": incidentReport(12),
+		"audience":  "on-call engineers",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "repeated checkout errors during failovers", output["text"])
+	assert.Len(t, output["intermediate_steps"], 12)
+	assert.Greater(t, output["collapse_rounds"], 0)
+	assert.LessOrEqual(t, atomic.LoadInt32(&model.maxInFlight), int32(3))
+	assert.Contains(t, model.prompts[0], "Summarize for on-call engineers: Event")
+}
+
+func TestRefineDocumentsChain(t *testing.T) {
+	model := &funcLLM{reply: func(prompt string) string {
+		chunk := prompt[strings.LastIndex(prompt, "Event "):]
+		event := chunk[:strings.Index(chunk, ":")]
+		if strings.HasPrefix(prompt, "Refine") {
+			existing := strings.TrimPrefix(strings.SplitN(prompt, "\n", 2)[0], "Refine ")
+			return existing + ", " + event
+		}
+		return event
+	}}
+
+	chain, err := NewRefineDocumentsChain(&RefineDocumentsChainConfig{
+		DocumentSplitConfig: DocumentSplitConfig{
+			Chunker:      &knowledge.ParagraphChunker{},
+			ChunkSize:    120,
+			ChunkOverlap: -1,
+		},
+		InitialChain: newTestLLMChain(t, model, "Start {text}", "text"),
+		RefineChain:  newTestLLMChain(t, model, "Refine {existing_answer}\n{text}", "existing_answer", "text"),
+	}, logrus.New())
+	require.NoError(t, err)
+
+	output, err := chain.Run(context.Background(), ChainInput{"documents": incidentReport(3)})
+	require.NoError(t, err)
+	assert.Equal(t, "Event 0, Event 1, Event 2", output["text"])
+}
+
+func TestMapRerankDocumentsChain(t *testing.T) {
+	model := &funcLLM{reply: func(prompt string) string {
+		if strings.Contains(prompt, "replica lag") {
+			return "The failover was caused by replica lag.\nScore: 90"
+		}
+		return "Not mentioned.\nScore: 10"
+	}}
+
+	chain, err := NewMapRerankDocumentsChain(&MapRerankDocumentsChainConfig{
+		Chain: newTestLLMChain(t, model, "Answer {question} from: {text}", "question", "text"),
+	}, logrus.New())
+	require.NoError(t, err)
+
+	output, err := chain.Run(context.Background(), ChainInput{
+		"question":  "What caused the failover?",
+		"documents": []string{"The cache was warm.", "Monitoring showed replica lag before the failover.", "Nobody was paged."},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "The failover was caused by replica lag.", output["text"])
+	assert.Equal(t, 90.0, output["score"])
+}
@@ -0,0 +1,804 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Expression language used by ExpressionCondition.
+//
+// Expressions are evaluated against the chain input; identifiers name input
+// keys and dots select fields of nested maps ("user.tier"). Missing keys
+// evaluate to null. Supported syntax:
+//
+//	literals      42, 0.5, "text", 'text', true, false, null
+//	comparison    ==  !=  <  <=  >  >=
+//	boolean       &&  ||  !   (or the keywords and, or, not)
+//	strings       contains, startswith, endswith, matches (RE2 regex)
+//	functions     len(x), lower(x), upper(x)
+//	grouping      ( ... )
+//
+// Evaluation has no side effects and runs in time linear in the expression
+// size, so expressions may come from configuration.
+
+const (
+	// maxExpressionLength bounds the size of an expression
+	maxExpressionLength = 4096
+
+	// maxExpressionDepth bounds the nesting of an expression
+	maxExpressionDepth = 64
+)
+
+// Expression is a parsed condition expression
+type Expression struct {
+	source    string
+	root      exprNode
+	variables []string
+}
+
+// ParseExpression parses a condition expression
+func ParseExpression(source string) (*Expression, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("expression cannot be empty")
+	}
+	if len(source) > maxExpressionLength {
+		return nil, fmt.Errorf("expression exceeds %d characters", maxExpressionLength)
+	}
+
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens, variables: make(map[string]bool)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+
+	variables := make([]string, 0, len(p.variables))
+	for _, tok := range tokens {
+		if tok.kind == tokenIdent && p.variables[tok.text] {
+			root := strings.SplitN(tok.text, ".", 2)[0]
+			if !containsString(variables, root) {
+				variables = append(variables, root)
+			}
+		}
+	}
+
+	return &Expression{source: source, root: root, variables: variables}, nil
+}
+
+// Evaluate evaluates the expression and returns its value
+func (e *Expression) Evaluate(input ChainInput) (interface{}, error) {
+	return e.root.eval(input)
+}
+
+// EvaluateBool evaluates the expression and returns its truth value
+func (e *Expression) EvaluateBool(input ChainInput) (bool, error) {
+	value, err := e.root.eval(input)
+	if err != nil {
+		return false, err
+	}
+	return truthy(value), nil
+}
+
+// Variables returns the input keys referenced by the expression
+func (e *Expression) Variables() []string {
+	return e.variables
+}
+
+// String returns the expression source
+func (e *Expression) String() string {
+	return e.source
+}
+
+// ExpressionCondition is a ChainCondition backed by an expression
+type ExpressionCondition struct {
+	name       string
+	expression *Expression
+}
+
+// NewExpressionCondition parses expression into a condition. The name
+// identifies the route in chain output and callbacks; it defaults to the
+// expression itself.
+func NewExpressionCondition(name, expression string) (*ExpressionCondition, error) {
+	parsed, err := ParseExpression(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition expression: %w", err)
+	}
+	if name == "" {
+		name = expression
+	}
+
+	return &ExpressionCondition{name: name, expression: parsed}, nil
+}
+
+// Evaluate evaluates the condition with the given input
+func (c *ExpressionCondition) Evaluate(ctx context.Context, input ChainInput) (bool, error) {
+	return c.expression.EvaluateBool(input)
+}
+
+// GetDescription returns the condition name
+func (c *ExpressionCondition) GetDescription() string {
+	return c.name
+}
+
+// GetExpression returns the parsed expression
+func (c *ExpressionCondition) GetExpression() *Expression {
+	return c.expression
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type exprToken struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+var twoCharOperators = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+func tokenizeExpression(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, start)
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: text, value: value, pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					continue
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, exprToken{kind: tokenString, text: string(runes[start:i]), value: sb.String(), pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if strings.HasSuffix(text, ".") || strings.Contains(text, "..") {
+				return nil, fmt.Errorf("invalid identifier %q at position %d", text, start)
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: text, pos: start})
+
+		default:
+			matched := false
+			if i+1 < len(runes) {
+				pair := string(runes[i : i+2])
+				for _, op := range twoCharOperators {
+					if pair == op {
+						tokens = append(tokens, exprToken{kind: tokenOperator, text: op, pos: i})
+						i += 2
+						matched = true
+						break
+					}
+				}
+			}
+			if matched {
+				continue
+			}
+			if strings.ContainsRune("<>!(),-", r) {
+				tokens = append(tokens, exprToken{kind: tokenOperator, text: string(r), pos: i})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+
+	return append(tokens, exprToken{kind: tokenEOF, text: "end of expression", pos: len(runes)}), nil
+}
+
+// Parser
+
+type exprParser struct {
+	tokens    []exprToken
+	pos       int
+	depth     int
+	variables map[string]bool
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the given operators or
+// keywords
+func (p *exprParser) accept(texts ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOperator && tok.kind != tokenIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if tok.text == text {
+			p.pos++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		tok := p.peek()
+		return fmt.Errorf("expected %q at position %d, got %q", text, tok.pos, tok.text)
+	}
+	return nil
+}
+
+func (p *exprParser) enter() error {
+	p.depth++
+	if p.depth > maxExpressionDepth {
+		return fmt.Errorf("expression nested deeper than %d levels", maxExpressionDepth)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "contains", "startswith", "endswith", "matches")
+	if !ok {
+		return left, nil
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &comparisonNode{op: op, left: left, right: right}
+	if op == "matches" {
+		// Literal patterns are compiled once, at parse time
+		if lit, isLiteral := right.(*literalNode); isLiteral {
+			pattern, isString := lit.value.(string)
+			if !isString {
+				return nil, fmt.Errorf("matches requires a string pattern")
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+			}
+			node.regex = re
+		}
+	}
+
+	return node, nil
+}
+
+func (p *exprParser) parseOperand() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber, tokenString:
+		return &literalNode{value: tok.value}, nil
+
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null", "nil":
+			return &literalNode{value: nil}, nil
+		case "and", "or", "not", "contains", "startswith", "endswith", "matches":
+			return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+		}
+
+		if p.peek().text == "(" && p.peek().kind == tokenOperator {
+			return p.parseCall(tok)
+		}
+
+		p.variables[tok.text] = true
+		return &variableNode{path: strings.Split(tok.text, ".")}, nil
+
+	case tokenOperator:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case "-":
+			number := p.next()
+			if number.kind != tokenNumber {
+				return nil, fmt.Errorf("expected number after '-' at position %d", number.pos)
+			}
+			return &literalNode{value: -number.value.(float64)}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	fn, exists := expressionFunctions[name.text]
+	if !exists {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	arg, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	return &callNode{name: name.text, fn: fn, arg: arg}, nil
+}
+
+// Evaluation
+
+type exprNode interface {
+	eval(input ChainInput) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(input ChainInput) (interface{}, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	path []string
+}
+
+func (n *variableNode) eval(input ChainInput) (interface{}, error) {
+	var current interface{} = map[string]interface{}(input)
+	for _, field := range n.path {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			current = value[field]
+		case ChainInput:
+			current = value[field]
+		case ChainOutput:
+			current = value[field]
+		case map[string]string:
+			if s, exists := value[field]; exists {
+				current = s
+			} else {
+				current = nil
+			}
+		default:
+			return nil, nil
+		}
+	}
+	return current, nil
+}
+
+type notNode struct {
+	operand exprNode
+}
+
+func (n *notNode) eval(input ChainInput) (interface{}, error) {
+	value, err := n.operand.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(value), nil
+}
+
+type logicalNode struct {
+	or    bool
+	left  exprNode
+	right exprNode
+}
+
+func (n *logicalNode) eval(input ChainInput) (interface{}, error) {
+	left, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit
+	if truthy(left) == n.or {
+		return n.or, nil
+	}
+
+	right, err := n.right.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(right), nil
+}
+
+type callNode struct {
+	name string
+	fn   func(interface{}) (interface{}, error)
+	arg  exprNode
+}
+
+func (n *callNode) eval(input ChainInput) (interface{}, error) {
+	arg, err := n.arg.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	value, err := n.fn(arg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return value, nil
+}
+
+var expressionFunctions = map[string]func(interface{}) (interface{}, error){
+	"len": func(value interface{}) (interface{}, error) {
+		if value == nil {
+			return float64(0), nil
+		}
+		if s, ok := value.(string); ok {
+			return float64(len([]rune(s))), nil
+		}
+		v := reflect.ValueOf(value)
+		switch v.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return float64(v.Len()), nil
+		}
+		return nil, fmt.Errorf("cannot take length of %T", value)
+	},
+	"lower": func(value interface{}) (interface{}, error) {
+		return strings.ToLower(toString(value)), nil
+	},
+	"upper": func(value interface{}) (interface{}, error) {
+		return strings.ToUpper(toString(value)), nil
+	},
+}
+
+type comparisonNode struct {
+	op    string
+	left  exprNode
+	right exprNode
+	regex *regexp.Regexp
+}
+
+func (n *comparisonNode) eval(input ChainInput) (interface{}, error) {
+	left, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compareOrdered(n.op, left, right)
+	case "contains":
+		return containsValue(left, right), nil
+	case "startswith":
+		return left != nil && strings.HasPrefix(toString(left), toString(right)), nil
+	case "endswith":
+		return left != nil && strings.HasSuffix(toString(left), toString(right)), nil
+	case "matches":
+		if left == nil {
+			return false, nil
+		}
+		re := n.regex
+		if re == nil {
+			if re, err = compileCachedRegex(toString(right)); err != nil {
+				return nil, err
+			}
+		}
+		return re.MatchString(toString(left)), nil
+	}
+
+	return nil, fmt.Errorf("unknown operator %q", n.op)
+}
+
+var (
+	regexCache   = make(map[string]*regexp.Regexp)
+	regexCacheMu sync.Mutex
+)
+
+// compileCachedRegex compiles patterns computed at evaluation time, keeping
+// a bounded cache of them
+func compileCachedRegex(pattern string) (*regexp.Regexp, error) {
+	regexCacheMu.Lock()
+	defer regexCacheMu.Unlock()
+
+	if re, exists := regexCache[pattern]; exists {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+	}
+	if len(regexCache) >= 256 {
+		regexCache = make(map[string]*regexp.Regexp)
+	}
+	regexCache[pattern] = re
+
+	return re, nil
+}
+
+func truthy(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	if f, ok := toNumber(value); ok {
+		return f != 0
+	}
+	if s, ok := value.(string); ok {
+		return s != ""
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len() > 0
+	}
+	return true
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+func valuesEqual(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if l, ok := toNumber(left); ok {
+		r, ok := toNumber(right)
+		return ok && l == r
+	}
+	if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		return ok && l == r
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// compareOrdered compares numbers numerically and strings lexically. Null
+// operands compare false, so missing keys never pass a threshold.
+func compareOrdered(op string, left, right interface{}) (bool, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var cmp int
+	if l, ok := toNumber(left); ok {
+		r, ok := toNumber(right)
+		if !ok {
+			return false, fmt.Errorf("cannot compare number with %T", right)
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	} else if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot compare string with %T", right)
+		}
+		cmp = strings.Compare(l, r)
+	} else {
+		return false, fmt.Errorf("cannot order values of type %T", left)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// containsValue reports whether a string contains a substring, a list
+// contains an element or a map contains a key
+func containsValue(container, item interface{}) bool {
+	if container == nil {
+		return false
+	}
+	if s, ok := container.(string); ok {
+		return strings.Contains(s, toString(item))
+	}
+
+	v := reflect.ValueOf(container)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if valuesEqual(v.Index(i).Interface(), item) {
+				return true
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return v.MapIndex(reflect.ValueOf(toString(item)).Convert(v.Type().Key())).IsValid()
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -23,6 +23,9 @@ type DefaultLLMChain struct {
 	logger     *logrus.Logger
 	tracer     trace.Tracer
 	metadata   map[string]interface{}
+
+	responseSchema    *llm.ResponseSchema
+	maxRepairAttempts int
 }
 
 // LLMChainConfig represents configuration for an LLM chain
@@ -32,6 +35,13 @@ type LLMChainConfig struct {
 	InputKeys  []string               `json:"input_keys,omitempty"`
 	OutputKeys []string               `json:"output_keys,omitempty"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+
+	// ResponseSchema makes the chain request JSON matching the schema and
+	// return the decoded value under the "parsed" output key
+	ResponseSchema *llm.ResponseSchema `json:"response_schema,omitempty"`
+
+	// MaxRepairAttempts limits re-asks after invalid replies (see llm.CompleteStructured)
+	MaxRepairAttempts int `json:"max_repair_attempts,omitempty"`
 }
 
 // NewLLMChain creates a new LLM chain
@@ -64,6 +74,9 @@ func NewLLMChain(config *LLMChainConfig, logger *logrus.Logger) (LLMChain, error
 		logger:     logger,
 		tracer:     otel.Tracer("langchain.chains.llm"),
 		metadata:   config.Metadata,
+
+		responseSchema:    config.ResponseSchema,
+		maxRepairAttempts: config.MaxRepairAttempts,
 	}
 
 	if err := chain.Validate(); err != nil {
@@ -121,15 +134,29 @@ func (c *DefaultLLMChain) Run(ctx context.Context, input ChainInput) (ChainOutpu
 	}
 
 	// Execute LLM request
-	response, err := c.llm.Complete(ctx, req)
-	if err != nil {
-		span.RecordError(err)
-		return nil, fmt.Errorf("LLM completion failed: %w", err)
-	}
+	var response *llm.CompletionResponse
+	output := ChainOutput{}
+	if c.responseSchema != nil {
+		req.ResponseSchema = c.responseSchema
+		structured, err := llm.CompleteStructured(ctx, c.llm, req, c.maxRepairAttempts)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("LLM completion failed: %w", err)
+		}
 
-	// Create output
-	output := ChainOutput{
-		"text": response.Content,
+		response = structured.Response
+		response.Usage = structured.Usage
+		output["text"] = structured.Raw
+		output["parsed"] = structured.Value
+		output["attempts"] = structured.Attempts
+	} else {
+		var err error
+		response, err = c.llm.Complete(ctx, req)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("LLM completion failed: %w", err)
+		}
+		output["text"] = response.Content
 	}
 
 	// Add additional output keys if specified
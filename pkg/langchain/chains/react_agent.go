@@ -0,0 +1,663 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/aios/aios/pkg/langgraph"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AgentMode selects how a ReAct agent asks the model for tool calls
+type AgentMode string
+
+const (
+	// AgentModeAuto uses native tool calling on providers that support it
+	// and the text protocol otherwise
+	AgentModeAuto AgentMode = "auto"
+
+	// AgentModeNative passes the tools to the provider's tool calling API
+	AgentModeNative AgentMode = "native"
+
+	// AgentModeText describes the tools in the prompt and parses
+	// Thought/Action/Action Input/Final Answer replies
+	AgentModeText AgentMode = "text"
+)
+
+var (
+	// ErrAgentMaxIterations is returned when the agent has not answered
+	// within its iteration limit
+	ErrAgentMaxIterations = errors.New("agent stopped after reaching max iterations")
+
+	// ErrAgentTimeout is returned when the agent has not answered within
+	// its execution time limit
+	ErrAgentTimeout = errors.New("agent stopped after reaching max execution time")
+)
+
+// nativeToolProviders map tools and tool calls through their API. The
+// Anthropic and Gemini providers ignore CompletionRequest.Tools, so they use
+// the text protocol.
+var nativeToolProviders = map[llm.LLMProvider]bool{
+	llm.ProviderOpenAI: true,
+	llm.ProviderOllama: true,
+}
+
+const defaultReActPrompt = `Answer the following question as best you can. You have access to the following tools:
+
+%s
+
+Use the following format:
+
+Thought: think about what to do next
+Action: the tool to use, one of [%s]
+Action Input: the tool arguments as a JSON object
+Observation: the tool result
+... (Thought/Action/Action Input/Observation can repeat)
+Thought: I now know the final answer
+Final Answer: the final answer to the original question
+
+Begin!`
+
+// AgentStep records one tool call made by the agent
+type AgentStep struct {
+	Tool        string                 `json:"tool"`
+	ToolInput   map[string]interface{} `json:"tool_input"`
+	Log         string                 `json:"log,omitempty"` // The model's reasoning before the call
+	Observation string                 `json:"observation"`   // What was fed back to the model
+	Error       string                 `json:"error,omitempty"`
+	Duration    time.Duration          `json:"duration"`
+}
+
+// ReActAgent is a chain that lets an LLM pick and call tools iteratively
+// until it produces a final answer. Every tool call is reported to the
+// callbacks as a run of the tool's ToolChain.
+type ReActAgent struct {
+	llm              llm.LLM
+	tools            map[string]*ToolChain
+	toolNames        []string
+	mode             AgentMode
+	systemPrompt     string
+	model            string
+	temperature      float64
+	inputKey         string
+	outputKey        string
+	maxIterations    int
+	maxExecutionTime time.Duration
+	maxObservation   int
+	callbacks        []ChainCallback
+	logger           *logrus.Logger
+	tracer           trace.Tracer
+	mu               sync.RWMutex
+}
+
+// ReActAgentConfig represents configuration for a ReAct agent
+type ReActAgentConfig struct {
+	LLM   llm.LLM          `json:"-"`
+	Tools []langgraph.Tool `json:"-"`
+
+	Mode AgentMode `json:"mode,omitempty"` // Defaults to AgentModeAuto
+
+	// SystemPrompt is sent before the question. In text mode it must contain
+	// two %s verbs, for the tool descriptions and the tool names.
+	SystemPrompt string `json:"system_prompt,omitempty"`
+
+	Model                string          `json:"model,omitempty"`
+	Temperature          float64         `json:"temperature,omitempty"`
+	InputKey             string          `json:"input_key,omitempty"`              // Defaults to "input"
+	OutputKey            string          `json:"output_key,omitempty"`             // Defaults to "output"
+	MaxIterations        int             `json:"max_iterations,omitempty"`         // Defaults to 10
+	MaxExecutionTime     time.Duration   `json:"max_execution_time,omitempty"`     // Defaults to 2 minutes
+	MaxObservationLength int             `json:"max_observation_length,omitempty"` // Longer tool results are truncated; defaults to 4000 characters
+	Callbacks            []ChainCallback `json:"-"`
+}
+
+// NewReActAgent creates a new ReAct agent
+func NewReActAgent(config *ReActAgentConfig, logger *logrus.Logger) (*ReActAgent, error) {
+	if config.LLM == nil {
+		return nil, fmt.Errorf("LLM is required")
+	}
+
+	mode := config.Mode
+	if mode == "" || mode == AgentModeAuto {
+		mode = AgentModeText
+		if nativeToolProviders[config.LLM.GetProvider()] {
+			mode = AgentModeNative
+		}
+	}
+
+	systemPrompt := config.SystemPrompt
+	if systemPrompt == "" && mode == AgentModeText {
+		systemPrompt = defaultReActPrompt
+	}
+
+	agent := &ReActAgent{
+		llm:              config.LLM,
+		tools:            make(map[string]*ToolChain),
+		mode:             mode,
+		systemPrompt:     systemPrompt,
+		model:            config.Model,
+		temperature:      config.Temperature,
+		inputKey:         config.InputKey,
+		outputKey:        config.OutputKey,
+		maxIterations:    config.MaxIterations,
+		maxExecutionTime: config.MaxExecutionTime,
+		maxObservation:   config.MaxObservationLength,
+		callbacks:        config.Callbacks,
+		logger:           logger,
+		tracer:           otel.Tracer("langchain.chains.react_agent"),
+	}
+
+	if agent.inputKey == "" {
+		agent.inputKey = "input"
+	}
+	if agent.outputKey == "" {
+		agent.outputKey = "output"
+	}
+	if agent.maxIterations <= 0 {
+		agent.maxIterations = 10
+	}
+	if agent.maxExecutionTime <= 0 {
+		agent.maxExecutionTime = 2 * time.Minute
+	}
+	if agent.maxObservation <= 0 {
+		agent.maxObservation = 4000
+	}
+
+	for _, tool := range config.Tools {
+		if err := agent.AddTool(tool); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := agent.Validate(); err != nil {
+		return nil, fmt.Errorf("chain validation failed: %w", err)
+	}
+
+	return agent, nil
+}
+
+// AddTool makes a tool available to the agent
+func (a *ReActAgent) AddTool(tool langgraph.Tool) error {
+	if tool == nil {
+		return fmt.Errorf("tool cannot be nil")
+	}
+	if err := tool.Validate(); err != nil {
+		return fmt.Errorf("tool %s validation failed: %w", tool.GetName(), err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	name := tool.GetName()
+	if _, exists := a.tools[name]; exists {
+		return fmt.Errorf("tool already exists: %s", name)
+	}
+	a.tools[name] = NewToolChain(tool)
+	a.toolNames = append(a.toolNames, name)
+	sort.Strings(a.toolNames)
+
+	return nil
+}
+
+// AddCallback adds a callback notified of the agent run and its tool calls
+func (a *ReActAgent) AddCallback(callback ChainCallback) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.callbacks = append(a.callbacks, callback)
+}
+
+// GetMode returns the tool calling mode in use
+func (a *ReActAgent) GetMode() AgentMode {
+	return a.mode
+}
+
+// Run executes the agent loop. The output holds the final answer under the
+// output key, the tool calls under "intermediate_steps" and the number of
+// model calls under "iterations".
+func (a *ReActAgent) Run(ctx context.Context, input ChainInput) (ChainOutput, error) {
+	ctx, span := a.tracer.Start(ctx, "react_agent.run")
+	defer span.End()
+
+	executionID := uuid.New().String()
+	span.SetAttributes(
+		attribute.String("chain.type", a.GetChainType()),
+		attribute.String("chain.execution_id", executionID),
+		attribute.String("agent.mode", string(a.mode)),
+	)
+
+	question, exists := input[a.inputKey]
+	if !exists {
+		err := fmt.Errorf("missing required input key: %s", a.inputKey)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	a.notify(func(cb ChainCallback) error { return cb.OnStart(ctx, a, input) })
+
+	ctx, cancel := context.WithTimeout(ctx, a.maxExecutionTime)
+	defer cancel()
+
+	start := time.Now()
+	messages := a.initialMessages(toString(question))
+	var steps []AgentStep
+
+	for iteration := 1; iteration <= a.maxIterations; iteration++ {
+		answer, newSteps, newMessages, err := a.iterate(ctx, executionID, messages)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("%w (%s)", ErrAgentTimeout, a.maxExecutionTime)
+			}
+			span.RecordError(err)
+			a.notify(func(cb ChainCallback) error { return cb.OnError(ctx, a, input, err) })
+			return nil, err
+		}
+		steps = append(steps, newSteps...)
+		messages = newMessages
+
+		if answer != nil {
+			output := ChainOutput{
+				a.outputKey:          *answer,
+				"intermediate_steps": steps,
+				"iterations":         iteration,
+			}
+
+			duration := time.Since(start)
+			span.SetAttributes(
+				attribute.Int("agent.iterations", iteration),
+				attribute.Int("agent.tool_calls", len(steps)),
+				attribute.Int64("chain.duration_ms", duration.Milliseconds()),
+			)
+			a.logger.WithFields(logrus.Fields{
+				"execution_id": executionID,
+				"iterations":   iteration,
+				"tool_calls":   len(steps),
+				"duration":     duration,
+			}).Info("ReAct agent execution completed")
+
+			a.notify(func(cb ChainCallback) error { return cb.OnEnd(ctx, a, input, output) })
+			return output, nil
+		}
+	}
+
+	err := fmt.Errorf("%w (%d)", ErrAgentMaxIterations, a.maxIterations)
+	span.RecordError(err)
+	a.notify(func(cb ChainCallback) error { return cb.OnError(ctx, a, input, err) })
+	return nil, err
+}
+
+// iterate makes one model call and runs the tools it asks for. It returns
+// the final answer once the model gives one.
+func (a *ReActAgent) iterate(ctx context.Context, executionID string, messages []llm.Message) (*string, []AgentStep, []llm.Message, error) {
+	req := &llm.CompletionRequest{
+		Messages:    messages,
+		Model:       a.model,
+		Temperature: a.temperature,
+	}
+	if a.mode == AgentModeNative {
+		req.Tools = a.toolDefinitions()
+		req.ToolChoice = llm.ToolChoiceAuto
+	} else {
+		req.Stop = []string{"\nObservation:"}
+	}
+
+	response, err := a.llm.Complete(ctx, req)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("LLM completion failed: %w", err)
+	}
+
+	if a.mode == AgentModeNative {
+		return a.iterateNative(ctx, executionID, messages, response)
+	}
+	return a.iterateText(ctx, executionID, messages, response)
+}
+
+func (a *ReActAgent) iterateNative(ctx context.Context, executionID string, messages []llm.Message, response *llm.CompletionResponse) (*string, []AgentStep, []llm.Message, error) {
+	if len(response.ToolCalls) == 0 {
+		answer := strings.TrimSpace(response.Content)
+		return &answer, nil, messages, nil
+	}
+
+	messages = append(messages, llm.Message{
+		Role:      "assistant",
+		Content:   response.Content,
+		ToolCalls: response.ToolCalls,
+		Timestamp: time.Now(),
+	})
+
+	steps := make([]AgentStep, 0, len(response.ToolCalls))
+	for _, call := range response.ToolCalls {
+		var arguments map[string]interface{}
+		var step AgentStep
+		if err := call.DecodeArguments(&arguments); err != nil {
+			step = AgentStep{Tool: call.Name, Log: response.Content, Error: err.Error()}
+			step.Observation = "Error: " + err.Error()
+		} else {
+			step = a.callTool(ctx, executionID, call.Name, arguments, response.Content)
+		}
+		steps = append(steps, step)
+		messages = append(messages, llm.NewToolResultMessage(call, step.Observation))
+	}
+
+	return nil, steps, messages, nil
+}
+
+var (
+	finalAnswerPattern = regexp.MustCompile(`(?is)final answer\s*:\s*(.*)$`)
+	actionPattern      = regexp.MustCompile(`(?im)^\s*action\s*:\s*(.+?)\s*$`)
+	actionInputPattern = regexp.MustCompile(`(?is)action input\s*:\s*(.*)$`)
+)
+
+func (a *ReActAgent) iterateText(ctx context.Context, executionID string, messages []llm.Message, response *llm.CompletionResponse) (*string, []AgentStep, []llm.Message, error) {
+	reply := response.Content
+	if i := strings.Index(reply, "\nObservation:"); i >= 0 {
+		reply = reply[:i] // Providers without stop sequences
+	}
+	reply = strings.TrimSpace(reply)
+
+	messages = append(messages, llm.Message{Role: "assistant", Content: reply, Timestamp: time.Now()})
+
+	action := actionPattern.FindStringSubmatch(reply)
+	if action == nil {
+		if final := finalAnswerPattern.FindStringSubmatch(reply); final != nil {
+			answer := strings.TrimSpace(final[1])
+			return &answer, nil, messages, nil
+		}
+
+		// Tell the model what went wrong and let it try again
+		observation := "Invalid format: reply with an Action and Action Input, or with a Final Answer."
+		messages = append(messages, llm.Message{Role: "user", Content: "Observation: " + observation, Timestamp: time.Now()})
+		return nil, []AgentStep{{Log: reply, Observation: observation, Error: "invalid format"}}, messages, nil
+	}
+
+	toolName := strings.Trim(strings.TrimSpace(action[1]), "`\"'")
+	thought := strings.TrimSpace(reply[:strings.Index(reply, action[0])])
+
+	var arguments map[string]interface{}
+	var step AgentStep
+	rawInput := ""
+	if match := actionInputPattern.FindStringSubmatch(reply); match != nil {
+		rawInput = stripCodeFence(match[1])
+	}
+	if err := decodeActionInput(rawInput, &arguments); err != nil {
+		step = AgentStep{Tool: toolName, Log: thought, Error: err.Error(), Observation: "Error: " + err.Error()}
+	} else {
+		step = a.callTool(ctx, executionID, toolName, arguments, thought)
+	}
+
+	messages = append(messages, llm.Message{Role: "user", Content: "Observation: " + step.Observation, Timestamp: time.Now()})
+
+	return nil, []AgentStep{step}, messages, nil
+}
+
+// callTool runs a tool and turns its result or error into an observation
+func (a *ReActAgent) callTool(ctx context.Context, executionID, name string, arguments map[string]interface{}, thought string) AgentStep {
+	step := AgentStep{Tool: name, ToolInput: arguments, Log: thought}
+
+	a.mu.RLock()
+	tool, exists := a.tools[name]
+	toolNames := strings.Join(a.toolNames, ", ")
+	a.mu.RUnlock()
+
+	if !exists {
+		step.Error = fmt.Sprintf("unknown tool %s", name)
+		step.Observation = fmt.Sprintf("Error: unknown tool %s. Available tools: %s", name, toolNames)
+		return step
+	}
+
+	input := ChainInput(arguments)
+	if input == nil {
+		input = ChainInput{}
+	}
+	a.notify(func(cb ChainCallback) error { return cb.OnStart(ctx, tool, input) })
+
+	start := time.Now()
+	output, err := tool.Run(ctx, input)
+	step.Duration = time.Since(start)
+
+	if err != nil {
+		step.Error = err.Error()
+		step.Observation = "Error: " + err.Error()
+		a.logger.WithError(err).WithFields(logrus.Fields{
+			"execution_id": executionID,
+			"tool":         name,
+		}).Warn("Agent tool call failed")
+		a.notify(func(cb ChainCallback) error { return cb.OnError(ctx, tool, input, err) })
+		return step
+	}
+
+	step.Observation = a.truncate(formatObservation(output))
+	a.logger.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"tool":         name,
+		"duration":     step.Duration,
+	}).Debug("Agent tool call completed")
+	a.notify(func(cb ChainCallback) error { return cb.OnEnd(ctx, tool, input, output) })
+
+	return step
+}
+
+func (a *ReActAgent) initialMessages(question string) []llm.Message {
+	var messages []llm.Message
+	if a.systemPrompt != "" {
+		systemPrompt := a.systemPrompt
+		if a.mode == AgentModeText {
+			systemPrompt = fmt.Sprintf(systemPrompt, a.describeTools(), strings.Join(a.toolNames, ", "))
+		}
+		messages = append(messages, llm.Message{Role: "system", Content: systemPrompt, Timestamp: time.Now()})
+	}
+	return append(messages, llm.Message{Role: "user", Content: question, Timestamp: time.Now()})
+}
+
+func (a *ReActAgent) toolDefinitions() []llm.ToolDefinition {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	definitions := make([]llm.ToolDefinition, 0, len(a.toolNames))
+	for _, name := range a.toolNames {
+		tool := a.tools[name].tool
+		definitions = append(definitions, llm.ToolDefinition{
+			Name:        name,
+			Description: tool.GetDescription(),
+			Parameters:  toolParameters(tool),
+		})
+	}
+	return definitions
+}
+
+func (a *ReActAgent) describeTools() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var sb strings.Builder
+	for _, name := range a.toolNames {
+		tool := a.tools[name].tool
+		schema, _ := json.Marshal(toolParameters(tool))
+		fmt.Fprintf(&sb, "%s: %s Arguments: %s\n", name, tool.GetDescription(), schema)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func (a *ReActAgent) truncate(observation string) string {
+	runes := []rune(observation)
+	if len(runes) <= a.maxObservation {
+		return observation
+	}
+	return string(runes[:a.maxObservation]) + "... [truncated]"
+}
+
+// notify invokes the callbacks; their errors are logged, not propagated
+func (a *ReActAgent) notify(event func(ChainCallback) error) {
+	a.mu.RLock()
+	callbacks := a.callbacks
+	a.mu.RUnlock()
+
+	for _, callback := range callbacks {
+		if err := event(callback); err != nil {
+			a.logger.WithError(err).Warn("Chain callback failed")
+		}
+	}
+}
+
+// GetInputKeys returns the expected input keys
+func (a *ReActAgent) GetInputKeys() []string {
+	return []string{a.inputKey}
+}
+
+// GetOutputKeys returns the output keys this chain produces
+func (a *ReActAgent) GetOutputKeys() []string {
+	return []string{a.outputKey, "intermediate_steps", "iterations"}
+}
+
+// GetChainType returns the type of this chain
+func (a *ReActAgent) GetChainType() string {
+	return "react_agent"
+}
+
+// Validate validates the chain configuration
+func (a *ReActAgent) Validate() error {
+	if a.llm == nil {
+		return fmt.Errorf("LLM is required")
+	}
+	if a.mode != AgentModeNative && a.mode != AgentModeText {
+		return fmt.Errorf("unsupported agent mode: %s", a.mode)
+	}
+	if a.mode == AgentModeText && strings.Count(a.systemPrompt, "%s") != 2 {
+		return fmt.Errorf("text mode system prompt must contain two %%s verbs")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if len(a.tools) == 0 {
+		return fmt.Errorf("at least one tool is required")
+	}
+	return nil
+}
+
+// ToolChain adapts a langgraph.Tool to the Chain interface: the input holds
+// the tool arguments and the output the tool result.
+type ToolChain struct {
+	tool langgraph.Tool
+}
+
+// NewToolChain creates a chain running tool
+func NewToolChain(tool langgraph.Tool) *ToolChain {
+	return &ToolChain{tool: tool}
+}
+
+// Run executes the tool with the given input
+func (c *ToolChain) Run(ctx context.Context, input ChainInput) (ChainOutput, error) {
+	output, err := c.tool.Execute(ctx, map[string]interface{}(input))
+	if err != nil {
+		return nil, err
+	}
+	return ChainOutput(output), nil
+}
+
+// GetInputKeys returns the required properties of the tool input schema
+func (c *ToolChain) GetInputKeys() []string {
+	var keys []string
+	switch required := c.tool.GetInputSchema()["required"].(type) {
+	case []string:
+		keys = append(keys, required...)
+	case []interface{}:
+		for _, key := range required {
+			keys = append(keys, toString(key))
+		}
+	}
+	return keys
+}
+
+// GetOutputKeys returns the properties of the tool output schema
+func (c *ToolChain) GetOutputKeys() []string {
+	properties, _ := c.tool.GetOutputSchema()["properties"].(map[string]interface{})
+	keySet := make(map[string]bool, len(properties))
+	for key := range properties {
+		keySet[key] = true
+	}
+	return sortedKeys(keySet)
+}
+
+// GetChainType returns the type of this chain
+func (c *ToolChain) GetChainType() string {
+	return "tool"
+}
+
+// Validate validates the tool configuration
+func (c *ToolChain) Validate() error {
+	return c.tool.Validate()
+}
+
+// GetTool returns the wrapped tool
+func (c *ToolChain) GetTool() langgraph.Tool {
+	return c.tool
+}
+
+// toolParameters returns the tool input schema, defaulting to any object
+func toolParameters(tool langgraph.Tool) map[string]interface{} {
+	schema := tool.GetInputSchema()
+	if len(schema) == 0 {
+		return map[string]interface{}{"type": "object"}
+	}
+	return schema
+}
+
+// formatObservation renders a tool result for the model. Results with a
+// single string value are passed as is, others as JSON.
+func formatObservation(output ChainOutput) string {
+	if len(output) == 1 {
+		for _, value := range output {
+			if s, ok := value.(string); ok {
+				return s
+			}
+		}
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Sprint(map[string]interface{}(output))
+	}
+	return string(data)
+}
+
+// decodeActionInput parses a text-protocol action input. Anything other than
+// a JSON object is passed to the tool as {"input": ...}.
+func decodeActionInput(raw string, arguments *map[string]interface{}) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		*arguments = map[string]interface{}{}
+		return nil
+	}
+	if strings.HasPrefix(raw, "{") {
+		if err := json.Unmarshal([]byte(raw), arguments); err != nil {
+			return fmt.Errorf("invalid action input: %w", err)
+		}
+		return nil
+	}
+	*arguments = map[string]interface{}{"input": strings.Trim(raw, `"`)}
+	return nil
+}
+
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	text = strings.TrimPrefix(text, "```")
+	if newline := strings.Index(text, "\n"); newline >= 0 {
+		text = text[newline+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+}
@@ -0,0 +1,195 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/aios/aios/pkg/langgraph"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedAgentLLM replays canned responses and records the requests
+type scriptedAgentLLM struct {
+	provider  llm.LLMProvider
+	responses []*llm.CompletionResponse
+	requests  []*llm.CompletionRequest
+}
+
+func (s *scriptedAgentLLM) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	s.requests = append(s.requests, req)
+	if len(s.responses) == 0 {
+		return nil, errors.New("no scripted response left")
+	}
+	response := s.responses[0]
+	s.responses = s.responses[1:]
+	return response, nil
+}
+
+func (s *scriptedAgentLLM) Stream(ctx context.Context, req *llm.CompletionRequest) (<-chan llm.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *scriptedAgentLLM) GetEmbeddings(ctx context.Context, req *llm.EmbeddingRequest) (*llm.EmbeddingResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *scriptedAgentLLM) GetProvider() llm.LLMProvider {
+	return s.provider
+}
+
+func (s *scriptedAgentLLM) GetModels(ctx context.Context) ([]string, error) {
+	return []string{"scripted"}, nil
+}
+
+func (s *scriptedAgentLLM) ValidateModel(ctx context.Context, model string) error {
+	return nil
+}
+
+func (s *scriptedAgentLLM) Close() error {
+	return nil
+}
+
+// stockTool reports stock levels and fails for unknown items
+type stockTool struct{}
+
+func (t *stockTool) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	switch input["item"] {
+	case "widget":
+		return map[string]interface{}{"in_stock": 42}, nil
+	default:
+		return nil, fmt.Errorf("item %v not found", input["item"])
+	}
+}
+
+func (t *stockTool) GetName() string {
+	return "stock"
+}
+
+func (t *stockTool) GetDescription() string {
+	return "Look up the stock level of an item."
+}
+
+func (t *stockTool) GetInputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"item": map[string]interface{}{"type": "string"}},
+		"required":   []string{"item"},
+	}
+}
+
+func (t *stockTool) GetOutputSchema() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (t *stockTool) Validate() error {
+	return nil
+}
+
+func TestReActAgentTextMode(t *testing.T) {
+	model := &scriptedAgentLLM{provider: "local", responses: []*llm.CompletionResponse{
+		{Content: "Thought: I should check gadgets first.\nAction: stock\nAction Input: {\"item\": \"gadget\"}"},
+		{Content: "Thought: Try widgets.\nAction: stock\nAction Input: ```json\n{\"item\": \"widget\"}\n```\nObservation: made up"},
+		{Content: "Thought: I now know the final answer\nFinal Answer: 42 widgets are in stock."},
+	}}
+	callback := &recordingCallback{}
+
+	agent, err := NewReActAgent(&ReActAgentConfig{
+		LLM:       model,
+		Tools:     []langgraph.Tool{&stockTool{}},
+		Callbacks: []ChainCallback{callback},
+	}, logrus.New())
+	require.NoError(t, err)
+	assert.Equal(t, AgentModeText, agent.GetMode())
+
+	output, err := agent.Run(context.Background(), ChainInput{"input": "How many widgets do we have?"})
+	require.NoError(t, err)
+	assert.Equal(t, "42 widgets are in stock.", output["output"])
+	assert.Equal(t, 3, output["iterations"])
+
+	// The tool error is fed back to the model rather than aborting the run
+	steps := output["intermediate_steps"].([]AgentStep)
+	require.Len(t, steps, 2)
+	assert.Equal(t, "Error: item gadget not found", steps[0].Observation)
+	assert.Equal(t, `{"in_stock":42}`, steps[1].Observation)
+	assert.Equal(t, "Thought: Try widgets.", steps[1].Log)
+
+	assert.Contains(t, model.requests[0].Messages[0].Content, "stock: Look up the stock level of an item.")
+	assert.Equal(t, []string{"\nObservation:"}, model.requests[0].Stop)
+	assert.Equal(t, "Observation: Error: item gadget not found", model.requests[1].Messages[3].Content)
+
+	// Tool calls are reported to callbacks alongside the agent run
+	require.Len(t, callback.errors, 1)
+	require.Len(t, callback.outputs, 2)
+	assert.Equal(t, ChainOutput{"in_stock": 42}, callback.outputs[0])
+	assert.Equal(t, "42 widgets are in stock.", callback.outputs[1]["output"])
+}
+
+func TestReActAgentNativeMode(t *testing.T) {
+	model := &scriptedAgentLLM{provider: llm.ProviderOpenAI, responses: []*llm.CompletionResponse{
+		{ToolCalls: []llm.ToolCall{
+			{ID: "call_1", Name: "stock", Arguments: `{"item": "widget"}`},
+			{ID: "call_2", Name: "warehouse", Arguments: `{}`},
+		}},
+		{Content: "We have 42 widgets."},
+	}}
+
+	agent, err := NewReActAgent(&ReActAgentConfig{
+		LLM:   model,
+		Tools: []langgraph.Tool{&stockTool{}},
+	}, logrus.New())
+	require.NoError(t, err)
+	assert.Equal(t, AgentModeNative, agent.GetMode())
+
+	output, err := agent.Run(context.Background(), ChainInput{"input": "How many widgets do we have?"})
+	require.NoError(t, err)
+	assert.Equal(t, "We have 42 widgets.", output["output"])
+
+	require.Len(t, model.requests[0].Tools, 1)
+	assert.Equal(t, "stock", model.requests[0].Tools[0].Name)
+
+	// Tool results answer the calls by ID, including unknown tools
+	messages := model.requests[1].Messages
+	require.Len(t, messages, 4)
+	assert.Equal(t, "call_1", messages[2].ToolCallID)
+	assert.Equal(t, `{"in_stock":42}`, messages[2].Content)
+	assert.Equal(t, "call_2", messages[3].ToolCallID)
+	assert.Contains(t, messages[3].Content, "unknown tool warehouse")
+}
+
+func TestReActAgentAutoMode(t *testing.T) {
+	modes := map[llm.LLMProvider]AgentMode{
+		llm.ProviderOpenAI:    AgentModeNative,
+		llm.ProviderOllama:    AgentModeNative,
+		llm.ProviderAnthropic: AgentModeText,
+		llm.ProviderGemini:    AgentModeText,
+	}
+	for provider, mode := range modes {
+		agent, err := NewReActAgent(&ReActAgentConfig{
+			LLM:   &scriptedAgentLLM{provider: provider},
+			Tools: []langgraph.Tool{&stockTool{}},
+		}, logrus.New())
+		require.NoError(t, err)
+		assert.Equal(t, mode, agent.GetMode(), provider)
+	}
+}
+
+func TestReActAgentMaxIterations(t *testing.T) {
+	loop := &llm.CompletionResponse{Content: "Action: stock\nAction Input: {\"item\": \"widget\"}"}
+	model := &scriptedAgentLLM{responses: []*llm.CompletionResponse{loop, loop, loop}}
+
+	agent, err := NewReActAgent(&ReActAgentConfig{
+		LLM:           model,
+		Tools:         []langgraph.Tool{&stockTool{}},
+		MaxIterations: 2,
+	}, logrus.New())
+	require.NoError(t, err)
+
+	_, err = agent.Run(context.Background(), ChainInput{"input": "Count widgets forever"})
+	assert.ErrorIs(t, err, ErrAgentMaxIterations)
+	assert.Len(t, model.requests, 2)
+}
@@ -1,19 +1,106 @@
 package llm
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	// anthropicVersion is the Messages API version sent with every request
+	anthropicVersion = "2023-06-01"
+
+	// anthropicDefaultMaxTokens is used when neither the request nor the config set max tokens,
+	// since the Messages API requires it
+	anthropicDefaultMaxTokens = 1024
+)
+
 // AnthropicLLM implements the LLM interface for Anthropic Claude
 type AnthropicLLM struct {
-	config *LLMConfig
-	logger *logrus.Logger
-	tracer trace.Tracer
+	config     *LLMConfig
+	httpClient *http.Client
+	logger     *logrus.Logger
+	tracer     trace.Tracer
+}
+
+// AnthropicMessage represents an Anthropic Messages API message
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// AnthropicMessagesRequest represents an Anthropic Messages API request
+type AnthropicMessagesRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []AnthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   float64            `json:"temperature,omitempty"`
+	TopP          float64            `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+// AnthropicContentBlock represents a content block of an Anthropic response
+type AnthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// AnthropicUsage represents Anthropic token usage
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// AnthropicMessagesResponse represents an Anthropic Messages API response
+type AnthropicMessagesResponse struct {
+	ID           string                  `json:"id"`
+	Type         string                  `json:"type"`
+	Role         string                  `json:"role"`
+	Model        string                  `json:"model"`
+	Content      []AnthropicContentBlock `json:"content"`
+	StopReason   string                  `json:"stop_reason"`
+	StopSequence string                  `json:"stop_sequence,omitempty"`
+	Usage        AnthropicUsage          `json:"usage"`
+}
+
+// AnthropicErrorResponse represents an Anthropic API error body
+type AnthropicErrorResponse struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// AnthropicStreamEvent represents a server-sent event of a streaming response
+type AnthropicStreamEvent struct {
+	Type    string                     `json:"type"`
+	Message *AnthropicMessagesResponse `json:"message,omitempty"`
+	Index   int                        `json:"index"`
+	Delta   struct {
+		Type         string `json:"type"`
+		Text         string `json:"text,omitempty"`
+		StopReason   string `json:"stop_reason,omitempty"`
+		StopSequence string `json:"stop_sequence,omitempty"`
+	} `json:"delta"`
+	Usage *AnthropicUsage `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
 }
 
 // NewAnthropicLLM creates a new Anthropic LLM instance
@@ -30,10 +117,19 @@ func NewAnthropicLLM(config *LLMConfig, logger *logrus.Logger) (LLM, error) {
 		config.Model = "claude-3-sonnet-20240229"
 	}
 
+	if config.Timeout == 0 {
+		config.Timeout = 60 * time.Second
+	}
+
+	httpClient := &http.Client{
+		Timeout: config.Timeout,
+	}
+
 	return &AnthropicLLM{
-		config: config,
-		logger: logger,
-		tracer: otel.Tracer("langchain.llm.anthropic"),
+		config:     config,
+		httpClient: httpClient,
+		logger:     logger,
+		tracer:     otel.Tracer("langchain.llm.anthropic"),
 	}, nil
 }
 
@@ -42,8 +138,41 @@ func (a *AnthropicLLM) Complete(ctx context.Context, req *CompletionRequest) (*C
 	ctx, span := a.tracer.Start(ctx, "anthropic.complete")
 	defer span.End()
 
-	// TODO: Implement Anthropic API integration
-	return nil, fmt.Errorf("Anthropic integration not yet implemented")
+	span.SetAttributes(
+		attribute.String("llm.provider", string(ProviderAnthropic)),
+		attribute.String("llm.model", req.Model),
+		attribute.Int("llm.max_tokens", req.MaxTokens),
+	)
+
+	// Convert to Anthropic format
+	anthropicReq := a.convertToAnthropicRequest(req)
+
+	// Make API request
+	resp, err := a.makeRequest(ctx, "/messages", anthropicReq)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to make Anthropic request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Parse response
+	var anthropicResp AnthropicMessagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&anthropicResp); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to parse Anthropic response: %w", err)
+	}
+
+	// Convert to standard format
+	response := a.convertFromAnthropicResponse(&anthropicResp)
+
+	span.SetAttributes(
+		attribute.Int("llm.usage.prompt_tokens", response.Usage.PromptTokens),
+		attribute.Int("llm.usage.completion_tokens", response.Usage.CompletionTokens),
+		attribute.Int("llm.usage.total_tokens", response.Usage.TotalTokens),
+		attribute.String("llm.stop_reason", anthropicResp.StopReason),
+	)
+
+	return response, nil
 }
 
 // Stream generates a streaming completion for the given request
@@ -51,13 +180,33 @@ func (a *AnthropicLLM) Stream(ctx context.Context, req *CompletionRequest) (<-ch
 	ctx, span := a.tracer.Start(ctx, "anthropic.stream")
 	defer span.End()
 
-	// TODO: Implement Anthropic streaming
-	return nil, fmt.Errorf("Anthropic streaming not yet implemented")
+	// Convert to Anthropic format with streaming enabled
+	anthropicReq := a.convertToAnthropicRequest(req)
+	anthropicReq.Stream = true
+
+	// Make the request up front so API errors are returned directly
+	resp, err := a.makeRequest(ctx, "/messages", anthropicReq)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to make Anthropic streaming request: %w", err)
+	}
+
+	responseCh := make(chan StreamResponse, 10)
+
+	go func() {
+		defer close(responseCh)
+		defer resp.Body.Close()
+
+		// Process streaming response
+		a.processStreamingResponse(ctx, resp.Body, responseCh)
+	}()
+
+	return responseCh, nil
 }
 
 // GetEmbeddings generates embeddings for the given text
 func (a *AnthropicLLM) GetEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
-	ctx, span := a.tracer.Start(ctx, "anthropic.embeddings")
+	_, span := a.tracer.Start(ctx, "anthropic.embeddings")
 	defer span.End()
 
 	// Anthropic doesn't provide embeddings API
@@ -72,6 +221,8 @@ func (a *AnthropicLLM) GetProvider() LLMProvider {
 // GetModels returns available models for this provider
 func (a *AnthropicLLM) GetModels(ctx context.Context) ([]string, error) {
 	return []string{
+		"claude-3-5-sonnet-20241022",
+		"claude-3-5-haiku-20241022",
 		"claude-3-opus-20240229",
 		"claude-3-sonnet-20240229",
 		"claude-3-haiku-20240307",
@@ -99,5 +250,253 @@ func (a *AnthropicLLM) ValidateModel(ctx context.Context, model string) error {
 
 // Close closes the LLM client and cleans up resources
 func (a *AnthropicLLM) Close() error {
+	// Nothing to close for HTTP client
 	return nil
 }
+
+// Helper methods
+
+// convertToAnthropicRequest moves system messages into the system prompt and
+// merges consecutive messages of the same role, which the Messages API rejects
+func (a *AnthropicLLM) convertToAnthropicRequest(req *CompletionRequest) *AnthropicMessagesRequest {
+	systemParts := make([]string, 0)
+	messages := make([]AnthropicMessage, 0, len(req.Messages))
+
+	for _, msg := range req.Messages {
+		if msg.Role == "system" {
+			systemParts = append(systemParts, msg.Content)
+			continue
+		}
+
+		role := msg.Role
+		if role != "assistant" {
+			role = "user"
+		}
+
+		if last := len(messages) - 1; last >= 0 && messages[last].Role == role {
+			messages[last].Content += "\n\n" + msg.Content
+			continue
+		}
+		messages = append(messages, AnthropicMessage{Role: role, Content: msg.Content})
+	}
+
+	model := req.Model
+	if model == "" {
+		model = a.config.Model
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = a.config.MaxTokens
+	}
+	if maxTokens == 0 {
+		maxTokens = anthropicDefaultMaxTokens
+	}
+
+	temperature := req.Temperature
+	if temperature == 0 {
+		temperature = a.config.Temperature
+	}
+
+	topP := req.TopP
+	if topP == 0 {
+		topP = a.config.TopP
+	}
+
+	return &AnthropicMessagesRequest{
+		Model:         model,
+		System:        strings.Join(systemParts, "\n\n"),
+		Messages:      messages,
+		MaxTokens:     maxTokens,
+		Temperature:   temperature,
+		TopP:          topP,
+		StopSequences: req.Stop,
+		Stream:        req.Stream,
+	}
+}
+
+func (a *AnthropicLLM) convertFromAnthropicResponse(resp *AnthropicMessagesResponse) *CompletionResponse {
+	var content strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+
+	metadata := map[string]interface{}{
+		"stop_reason":   resp.StopReason,
+		"finish_reason": anthropicFinishReason(resp.StopReason),
+	}
+	if resp.StopSequence != "" {
+		metadata["stop_sequence"] = resp.StopSequence
+	}
+
+	return &CompletionResponse{
+		ID:      resp.ID,
+		Content: content.String(),
+		Model:   resp.Model,
+		Usage: TokenUsage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+		Metadata: metadata,
+	}
+}
+
+// anthropicFinishReason maps Anthropic stop reasons to OpenAI-style finish reasons
+func anthropicFinishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	default:
+		return stopReason
+	}
+}
+
+// makeRequest sends a request and returns the response when it succeeds.
+// Rate limit and overload errors are retried up to RetryCount times.
+func (a *AnthropicLLM) makeRequest(ctx context.Context, endpoint string, payload interface{}) (*http.Response, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", a.config.BaseURL+endpoint, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-api-key", a.config.APIKey)
+		req.Header.Set("anthropic-version", anthropicVersion)
+
+		return req, nil
+	}
+
+	return sendWithRetry(ctx, a.httpClient, a.logger, a.config.RetryCount, newRequest, a.parseError)
+}
+
+func (a *AnthropicLLM) parseError(resp *http.Response) *ProviderError {
+	body, _ := io.ReadAll(resp.Body)
+
+	apiErr := &ProviderError{
+		Provider:   ProviderAnthropic,
+		StatusCode: resp.StatusCode,
+		Message:    string(body),
+		RetryAfter: parseRetryAfter(resp.Header),
+		Kind:       errorKindForStatus(resp.StatusCode),
+	}
+
+	var errResp AnthropicErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Type != "" {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Message = errResp.Error.Message
+		apiErr.Kind = anthropicErrorKind(errResp.Error.Type, apiErr.Kind)
+	}
+
+	return apiErr
+}
+
+// anthropicErrorKind maps an Anthropic error type to a sentinel error
+func anthropicErrorKind(errorType string, fallback error) error {
+	switch errorType {
+	case "rate_limit_error":
+		return ErrRateLimited
+	case "overloaded_error":
+		return ErrOverloaded
+	case "authentication_error", "permission_error":
+		return ErrAuthentication
+	case "invalid_request_error", "not_found_error", "request_too_large":
+		return ErrInvalidRequest
+	default:
+		return fallback
+	}
+}
+
+// processStreamingResponse reads server-sent events until the message stops
+func (a *AnthropicLLM) processStreamingResponse(ctx context.Context, body io.Reader, responseCh chan<- StreamResponse) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		messageID  string
+		stopReason string
+		usage      TokenUsage
+	)
+
+	send := func(chunk StreamResponse) bool {
+		select {
+		case responseCh <- chunk:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// Event names are repeated in the data payload, so only data lines matter
+			continue
+		}
+
+		var event AnthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			send(StreamResponse{ID: messageID, Error: fmt.Errorf("failed to parse Anthropic stream event: %w", err)})
+			return
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				messageID = event.Message.ID
+				usage.PromptTokens = event.Message.Usage.InputTokens
+				usage.CompletionTokens = event.Message.Usage.OutputTokens
+			}
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				if !send(StreamResponse{ID: messageID, Content: event.Delta.Text}) {
+					return
+				}
+			}
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				stopReason = event.Delta.StopReason
+			}
+			if event.Usage != nil {
+				usage.CompletionTokens = event.Usage.OutputTokens
+			}
+		case "message_stop":
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+			send(StreamResponse{
+				ID:           messageID,
+				Done:         true,
+				FinishReason: anthropicFinishReason(stopReason),
+				Usage:        &usage,
+			})
+			return
+		case "error":
+			apiErr := &ProviderError{Provider: ProviderAnthropic, Kind: ErrProviderFailed}
+			if event.Error != nil {
+				apiErr.Type = event.Error.Type
+				apiErr.Message = event.Error.Message
+				apiErr.Kind = anthropicErrorKind(event.Error.Type, ErrProviderFailed)
+			}
+			send(StreamResponse{ID: messageID, Error: apiErr})
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		send(StreamResponse{ID: messageID, Error: fmt.Errorf("failed to read Anthropic stream: %w", err)})
+		return
+	}
+
+	send(StreamResponse{ID: messageID, Error: fmt.Errorf("Anthropic stream ended before message_stop")})
+}
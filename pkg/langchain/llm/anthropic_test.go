@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return logger
+}
+
+// fakeAnthropicServer serves the Messages API, recording the last request
+func fakeAnthropicServer(t *testing.T, handler func(w http.ResponseWriter, req AnthropicMessagesRequest)) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicVersion, r.Header.Get("anthropic-version"))
+
+		var req AnthropicMessagesRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		handler(w, req)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestAnthropic(t *testing.T, server *httptest.Server, retries int) LLM {
+	llm, err := NewAnthropicLLM(&LLMConfig{
+		APIKey:     "test-key",
+		BaseURL:    server.URL + "/v1",
+		RetryCount: retries,
+	}, newTestLogger())
+	require.NoError(t, err)
+	return llm
+}
+
+func TestAnthropicComplete(t *testing.T) {
+	var received AnthropicMessagesRequest
+	server := fakeAnthropicServer(t, func(w http.ResponseWriter, req AnthropicMessagesRequest) {
+		received = req
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-3-sonnet-20240229",
+			"content": [{"type": "text", "text": "Hello"}, {"type": "text", "text": " there"}],
+			"stop_reason": "max_tokens",
+			"usage": {"input_tokens": 12, "output_tokens": 5}
+		}`)
+	})
+
+	llm := newTestAnthropic(t, server, 0)
+	resp, err := llm.Complete(context.Background(), &CompletionRequest{
+		Messages: []Message{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "Hi"},
+			{Role: "user", Content: "Are you there?"},
+			{Role: "system", Content: "Answer in English."},
+		},
+		Stop: []string{"END"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Be brief.\n\nAnswer in English.", received.System)
+	assert.Equal(t, []AnthropicMessage{{Role: "user", Content: "Hi\n\nAre you there?"}}, received.Messages)
+	assert.Equal(t, anthropicDefaultMaxTokens, received.MaxTokens)
+	assert.Equal(t, []string{"END"}, received.StopSequences)
+
+	assert.Equal(t, "msg_1", resp.ID)
+	assert.Equal(t, "Hello there", resp.Content)
+	assert.Equal(t, TokenUsage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17}, resp.Usage)
+	assert.Equal(t, "max_tokens", resp.Metadata["stop_reason"])
+	assert.Equal(t, "length", resp.Metadata["finish_reason"])
+}
+
+func TestAnthropicErrors(t *testing.T) {
+	t.Run("RateLimitIsRetried", func(t *testing.T) {
+		var calls int32
+		server := fakeAnthropicServer(t, func(w http.ResponseWriter, req AnthropicMessagesRequest) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.Header().Set("Retry-After", "0.01")
+				w.WriteHeader(http.StatusTooManyRequests)
+				fmt.Fprint(w, `{"type": "error", "error": {"type": "rate_limit_error", "message": "slow down"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"id": "msg_2", "content": [{"type": "text", "text": "ok"}], "stop_reason": "end_turn"}`)
+		})
+
+		resp, err := newTestAnthropic(t, server, 1).Complete(context.Background(), &CompletionRequest{
+			Messages: []Message{{Role: "user", Content: "Hi"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.Content)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Overloaded", func(t *testing.T) {
+		server := fakeAnthropicServer(t, func(w http.ResponseWriter, req AnthropicMessagesRequest) {
+			w.WriteHeader(529)
+			fmt.Fprint(w, `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`)
+		})
+
+		_, err := newTestAnthropic(t, server, 0).Complete(context.Background(), &CompletionRequest{
+			Messages: []Message{{Role: "user", Content: "Hi"}},
+		})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrOverloaded))
+		assert.True(t, IsRetryable(err))
+
+		var providerErr *ProviderError
+		require.True(t, errors.As(err, &providerErr))
+		assert.Equal(t, 529, providerErr.StatusCode)
+		assert.Equal(t, "Overloaded", providerErr.Message)
+	})
+
+	t.Run("InvalidRequestIsNotRetried", func(t *testing.T) {
+		var calls int32
+		server := fakeAnthropicServer(t, func(w http.ResponseWriter, req AnthropicMessagesRequest) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"type": "error", "error": {"type": "invalid_request_error", "message": "bad"}}`)
+		})
+
+		_, err := newTestAnthropic(t, server, 3).Complete(context.Background(), &CompletionRequest{
+			Messages: []Message{{Role: "user", Content: "Hi"}},
+		})
+		assert.True(t, errors.Is(err, ErrInvalidRequest))
+		assert.False(t, IsRetryable(err))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestAnthropicStream(t *testing.T) {
+	server := fakeAnthropicServer(t, func(w http.ResponseWriter, req AnthropicMessagesRequest) {
+		assert.True(t, req.Stream)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\n"+
+			`data: {"type": "message_start", "message": {"id": "msg_3", "usage": {"input_tokens": 8, "output_tokens": 1}}}`+"\n\n"+
+			"event: ping\ndata: {\"type\": \"ping\"}\n\n"+
+			"event: content_block_delta\n"+
+			`data: {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "Hel"}}`+"\n\n"+
+			"event: content_block_delta\n"+
+			`data: {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "lo"}}`+"\n\n"+
+			"event: message_delta\n"+
+			`data: {"type": "message_delta", "delta": {"stop_reason": "end_turn"}, "usage": {"output_tokens": 3}}`+"\n\n"+
+			"event: message_stop\ndata: {\"type\": \"message_stop\"}\n\n")
+	})
+
+	stream, err := newTestAnthropic(t, server, 0).Stream(context.Background(), &CompletionRequest{
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	})
+	require.NoError(t, err)
+
+	var content string
+	var last StreamResponse
+	for chunk := range stream {
+		require.NoError(t, chunk.Error)
+		content += chunk.Content
+		last = chunk
+	}
+
+	assert.Equal(t, "Hello", content)
+	assert.True(t, last.Done)
+	assert.Equal(t, "msg_3", last.ID)
+	assert.Equal(t, "stop", last.FinishReason)
+	assert.Equal(t, &TokenUsage{PromptTokens: 8, CompletionTokens: 3, TotalTokens: 11}, last.Usage)
+
+	t.Run("ErrorEvent", func(t *testing.T) {
+		server := fakeAnthropicServer(t, func(w http.ResponseWriter, req AnthropicMessagesRequest) {
+			fmt.Fprint(w, "event: error\n"+
+				`data: {"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`+"\n\n")
+		})
+
+		stream, err := newTestAnthropic(t, server, 0).Stream(context.Background(), &CompletionRequest{
+			Messages: []Message{{Role: "user", Content: "Hi"}},
+		})
+		require.NoError(t, err)
+
+		chunk := <-stream
+		assert.True(t, errors.Is(chunk.Error, ErrOverloaded))
+	})
+}
@@ -0,0 +1,682 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrBudgetExceeded is matched by errors.Is when a request would exceed a spend limit
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// ErrModelNotPriced is returned for models missing from the price table when
+// the budget rejects unknown models
+var ErrModelNotPriced = errors.New("model has no price")
+
+// Metadata keys identifying who a request is charged to when the context
+// carries no BudgetScope
+const (
+	BudgetTenantKey = "tenant_id"
+	BudgetUserKey   = "user_id"
+)
+
+// BudgetPeriod is the window a spend limit applies to
+type BudgetPeriod string
+
+const (
+	BudgetPeriodDaily   BudgetPeriod = "daily"
+	BudgetPeriodMonthly BudgetPeriod = "monthly"
+)
+
+// BudgetAction decides what happens to a request over budget
+type BudgetAction string
+
+const (
+	// BudgetActionReject fails the request with a BudgetExceededError
+	BudgetActionReject BudgetAction = "reject"
+	// BudgetActionDowngrade switches to a cheaper model from BudgetConfig.Downgrades
+	// that fits the remaining budget, rejecting when none does
+	BudgetActionDowngrade BudgetAction = "downgrade"
+)
+
+// UnknownModelPolicy decides how requests to models missing from the price
+// table are charged
+type UnknownModelPolicy string
+
+const (
+	// UnknownModelFree lets the request run without charging it
+	UnknownModelFree UnknownModelPolicy = "free"
+	// UnknownModelReject fails the request with ErrModelNotPriced
+	UnknownModelReject UnknownModelPolicy = "reject"
+	// UnknownModelFallback charges BudgetConfig.FallbackPrice
+	UnknownModelFallback UnknownModelPolicy = "fallback"
+)
+
+// BudgetScope identifies who is charged for LLM usage
+type BudgetScope struct {
+	TenantID string `json:"tenant_id"`
+	UserID   string `json:"user_id,omitempty"`
+}
+
+// BudgetLimit is a spend limit in US dollars; zero means unlimited
+type BudgetLimit struct {
+	Daily   float64 `json:"daily,omitempty" yaml:"daily,omitempty"`
+	Monthly float64 `json:"monthly,omitempty" yaml:"monthly,omitempty"`
+}
+
+func (l BudgetLimit) forPeriod(period BudgetPeriod) float64 {
+	if period == BudgetPeriodDaily {
+		return l.Daily
+	}
+	return l.Monthly
+}
+
+// BudgetConfig configures spend limits per tenant and per user
+type BudgetConfig struct {
+	TenantLimits       map[string]BudgetLimit `json:"tenant_limits,omitempty" yaml:"tenant_limits,omitempty"`
+	UserLimits         map[string]BudgetLimit `json:"user_limits,omitempty" yaml:"user_limits,omitempty"`
+	DefaultTenantLimit BudgetLimit            `json:"default_tenant_limit" yaml:"default_tenant_limit"`
+	DefaultUserLimit   BudgetLimit            `json:"default_user_limit" yaml:"default_user_limit"`
+	Action             BudgetAction           `json:"action" yaml:"action"`
+
+	// Downgrades maps a model to a cheaper one; chains are followed until a
+	// model fits the remaining budget
+	Downgrades map[string]string `json:"downgrades,omitempty" yaml:"downgrades,omitempty"`
+
+	// DefaultOutputTokens estimates the reply length of requests without MaxTokens
+	DefaultOutputTokens int `json:"default_output_tokens,omitempty" yaml:"default_output_tokens,omitempty"`
+
+	// UnknownModels decides how models missing from the price table are
+	// charged; the default is UnknownModelFree. Local models can be given a
+	// zero price instead.
+	UnknownModels UnknownModelPolicy `json:"unknown_models,omitempty" yaml:"unknown_models,omitempty"`
+	FallbackPrice ModelPrice         `json:"fallback_price,omitempty" yaml:"fallback_price,omitempty"`
+}
+
+// BudgetExceededError is returned when a request would exceed a spend limit
+type BudgetExceededError struct {
+	Scope     BudgetScope  `json:"scope"`
+	Subject   string       `json:"subject"` // "tenant" or "user"
+	Period    BudgetPeriod `json:"period"`
+	Limit     float64      `json:"limit"`
+	Spent     float64      `json:"spent"`
+	Estimated float64      `json:"estimated"` // Estimated cost of the rejected request
+}
+
+// Error implements the error interface
+func (e *BudgetExceededError) Error() string {
+	id := e.Scope.TenantID
+	if e.Subject == "user" {
+		id = e.Scope.UserID
+	}
+	return fmt.Sprintf("%s %s %s budget of $%.4f exceeded: spent $%.4f, request estimated at $%.4f",
+		e.Subject, id, e.Period, e.Limit, e.Spent, e.Estimated)
+}
+
+// Unwrap returns ErrBudgetExceeded
+func (e *BudgetExceededError) Unwrap() error { return ErrBudgetExceeded }
+
+// BudgetDecision is the outcome of a budget check. It holds the estimated
+// cost reserved against the budget until it is passed to Record or Release.
+type BudgetDecision struct {
+	Model         string  `json:"model"` // The model to use, which differs from the requested one when downgraded
+	Downgraded    bool    `json:"downgraded"`
+	EstimatedCost float64 `json:"estimated_cost"`
+
+	scope    BudgetScope
+	reserved float64
+	windows  []string // The windows the reservation was charged to
+	settled  bool
+}
+
+type budgetScopeKey struct{}
+
+// WithBudgetScope returns a context charging LLM usage to the scope
+func WithBudgetScope(ctx context.Context, scope BudgetScope) context.Context {
+	return context.WithValue(ctx, budgetScopeKey{}, scope)
+}
+
+// BudgetScopeFromContext returns the scope stored by WithBudgetScope
+func BudgetScopeFromContext(ctx context.Context) (BudgetScope, bool) {
+	scope, ok := ctx.Value(budgetScopeKey{}).(BudgetScope)
+	return scope, ok
+}
+
+// spendKey identifies the spend of a tenant, or of one of its users, in a period
+type spendKey struct {
+	tenant string
+	user   string
+	window string // "2006-01-02" for days, "2006-01" for months
+}
+
+// BudgetEnforcer tracks LLM spend per tenant and user and enforces limits
+type BudgetEnforcer struct {
+	config    *BudgetConfig
+	prices    *PriceTable
+	tokenizer Tokenizer
+	spend     map[spendKey]float64
+	unpriced  map[string]bool // Unknown models already warned about
+	day       string          // Day window the spend was last pruned in
+	now       func() time.Time
+	logger    *logrus.Logger
+	mu        sync.Mutex
+}
+
+// NewBudgetEnforcer creates a budget enforcer. The tokenizer estimates prompt
+// sizes before requests are sent; the heuristic tokenizer is used when nil.
+func NewBudgetEnforcer(config *BudgetConfig, prices *PriceTable, tokenizer Tokenizer, logger *logrus.Logger) (*BudgetEnforcer, error) {
+	if config == nil {
+		return nil, fmt.Errorf("budget config is required")
+	}
+	if prices == nil {
+		return nil, fmt.Errorf("price table is required")
+	}
+
+	switch config.Action {
+	case "":
+		config.Action = BudgetActionReject
+	case BudgetActionReject, BudgetActionDowngrade:
+	default:
+		return nil, fmt.Errorf("unsupported budget action: %s", config.Action)
+	}
+	switch config.UnknownModels {
+	case "":
+		config.UnknownModels = UnknownModelFree
+	case UnknownModelFree, UnknownModelReject:
+	case UnknownModelFallback:
+		if config.FallbackPrice == (ModelPrice{}) {
+			return nil, fmt.Errorf("fallback price is required for the fallback unknown model policy")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported unknown model policy: %s", config.UnknownModels)
+	}
+	if config.DefaultOutputTokens <= 0 {
+		config.DefaultOutputTokens = 512
+	}
+	if tokenizer == nil {
+		tokenizer = NewHeuristicTokenizer()
+	}
+
+	return &BudgetEnforcer{
+		config:    config,
+		prices:    prices,
+		tokenizer: tokenizer,
+		spend:     make(map[spendKey]float64),
+		unpriced:  make(map[string]bool),
+		now:       time.Now,
+		logger:    logger,
+	}, nil
+}
+
+// EstimateCompletion estimates the token usage of a completion request
+func (e *BudgetEnforcer) EstimateCompletion(req *CompletionRequest) TokenUsage {
+	usage := TokenUsage{
+		PromptTokens:     CountMessageTokens(e.tokenizer, req.Messages),
+		CompletionTokens: req.MaxTokens,
+	}
+	if usage.CompletionTokens <= 0 {
+		usage.CompletionTokens = e.config.DefaultOutputTokens
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
+}
+
+// Check decides whether a request with the estimated usage may run on model.
+// Over budget it either rejects or picks a cheaper model, per the configured action.
+// The estimated cost of an accepted request is reserved, so concurrent requests
+// cannot overshoot a limit together; pass the decision to Record once the
+// request finishes, or to Release when it fails.
+func (e *BudgetEnforcer) Check(scope BudgetScope, model string, usage TokenUsage) (*BudgetDecision, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	e.prune(now)
+	cost, err := e.cost(model, usage)
+	if err != nil {
+		return nil, err
+	}
+	exceeded := e.exceeded(scope, cost, now)
+	if exceeded == nil {
+		return e.reserve(&BudgetDecision{Model: model, EstimatedCost: cost, scope: scope}, now), nil
+	}
+
+	if e.config.Action == BudgetActionDowngrade {
+		visited := map[string]bool{model: true}
+		for candidate := e.config.Downgrades[model]; candidate != "" && !visited[candidate]; candidate = e.config.Downgrades[candidate] {
+			visited[candidate] = true
+			candidateCost, err := e.cost(candidate, usage)
+			if err != nil {
+				continue
+			}
+			if e.exceeded(scope, candidateCost, now) == nil {
+				e.logger.WithFields(logrus.Fields{
+					"tenant_id": scope.TenantID,
+					"user_id":   scope.UserID,
+					"model":     model,
+					"downgrade": candidate,
+				}).Info("Downgrading LLM request to stay within budget")
+				return e.reserve(&BudgetDecision{Model: candidate, Downgraded: true, EstimatedCost: candidateCost, scope: scope}, now), nil
+			}
+		}
+	}
+
+	return nil, exceeded
+}
+
+// Record replaces the cost reserved by a decision with the cost of the actual
+// usage on the decision's model, and returns that cost. A decision is settled
+// once; later calls to Record or Release do nothing.
+func (e *BudgetEnforcer) Record(decision *BudgetDecision, usage TokenUsage) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if decision.settled {
+		return 0
+	}
+	e.settle(decision)
+
+	// The decision passed Check, so its model is priced or allowed
+	cost, _ := e.cost(decision.Model, usage)
+
+	now := e.now()
+	e.prune(now)
+	e.charge(decision.scope, []string{dayWindow(now), monthWindow(now)}, cost)
+
+	return cost
+}
+
+// Release returns the cost reserved by a decision whose request failed
+func (e *BudgetEnforcer) Release(decision *BudgetDecision) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !decision.settled {
+		e.settle(decision)
+	}
+}
+
+// prune drops the spend of past days and months once per day. Reservations
+// settled after their window was pruned find nothing left to release. The
+// caller must hold the lock.
+func (e *BudgetEnforcer) prune(now time.Time) {
+	day, month := dayWindow(now), monthWindow(now)
+	if day == e.day {
+		return
+	}
+	e.day = day
+
+	for key := range e.spend {
+		if key.window != day && key.window != month {
+			delete(e.spend, key)
+		}
+	}
+}
+
+// cost prices usage on a model, applying the unknown model policy to models
+// missing from the price table. The caller must hold the lock.
+func (e *BudgetEnforcer) cost(model string, usage TokenUsage) (float64, error) {
+	if cost, known := e.prices.Cost(model, usage); known {
+		return cost, nil
+	}
+
+	if !e.unpriced[model] {
+		e.unpriced[model] = true
+		e.logger.WithFields(logrus.Fields{
+			"model":  model,
+			"policy": e.config.UnknownModels,
+		}).Warn("No price for model, applying the unknown model policy")
+	}
+
+	switch e.config.UnknownModels {
+	case UnknownModelReject:
+		return 0, fmt.Errorf("%w: %s", ErrModelNotPriced, model)
+	case UnknownModelFallback:
+		return e.config.FallbackPrice.Cost(usage), nil
+	}
+	return 0, nil
+}
+
+// reserve charges the decision's estimated cost to the current windows. The
+// caller must hold the lock.
+func (e *BudgetEnforcer) reserve(decision *BudgetDecision, now time.Time) *BudgetDecision {
+	decision.reserved = decision.EstimatedCost
+	decision.windows = []string{dayWindow(now), monthWindow(now)}
+	e.charge(decision.scope, decision.windows, decision.reserved)
+	return decision
+}
+
+// settle removes the decision's reservation. The caller must hold the lock.
+func (e *BudgetEnforcer) settle(decision *BudgetDecision) {
+	e.charge(decision.scope, decision.windows, -decision.reserved)
+	decision.settled = true
+}
+
+// charge adds cost to the spend of the scope's tenant and user in windows,
+// dropping entries that fall back to zero. The caller must hold the lock.
+func (e *BudgetEnforcer) charge(scope BudgetScope, windows []string, cost float64) {
+	if cost == 0 {
+		return
+	}
+
+	for _, window := range windows {
+		keys := []spendKey{{tenant: scope.TenantID, window: window}}
+		if scope.UserID != "" {
+			keys = append(keys, spendKey{tenant: scope.TenantID, user: scope.UserID, window: window})
+		}
+		for _, key := range keys {
+			spent := e.spend[key] + cost
+			if spent <= 1e-12 {
+				// Drop the floating point residue of released reservations
+				delete(e.spend, key)
+				continue
+			}
+			e.spend[key] = spent
+		}
+	}
+}
+
+// GetSpend returns the current period's spend of a scope. A scope without a
+// user returns the spend of the whole tenant.
+func (e *BudgetEnforcer) GetSpend(scope BudgetScope, period BudgetPeriod) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.spend[spendKey{tenant: scope.TenantID, user: scope.UserID, window: windowFor(period, e.now())}]
+}
+
+// SpendByTenant returns the current period's spend of every tenant
+func (e *BudgetEnforcer) SpendByTenant(period BudgetPeriod) map[string]float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	window := windowFor(period, e.now())
+	report := make(map[string]float64)
+	for key, spent := range e.spend {
+		if key.window == window && key.user == "" {
+			report[key.tenant] = spent
+		}
+	}
+	return report
+}
+
+// SpendByUser returns the current period's spend of every user of a tenant
+func (e *BudgetEnforcer) SpendByUser(tenantID string, period BudgetPeriod) map[string]float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	window := windowFor(period, e.now())
+	report := make(map[string]float64)
+	for key, spent := range e.spend {
+		if key.window == window && key.tenant == tenantID && key.user != "" {
+			report[key.user] = spent
+		}
+	}
+	return report
+}
+
+// budgetCheck is a limit applying to a tenant, or to one of its users
+type budgetCheck struct {
+	subject string
+	user    string
+	limit   BudgetLimit
+}
+
+// exceeded returns the first limit that cost would break, checking the tenant
+// before the user and the day before the month. Free requests always pass.
+func (e *BudgetEnforcer) exceeded(scope BudgetScope, cost float64, now time.Time) error {
+	if cost <= 0 {
+		return nil
+	}
+
+	tenantLimit, exists := e.config.TenantLimits[scope.TenantID]
+	if !exists {
+		tenantLimit = e.config.DefaultTenantLimit
+	}
+	checks := []budgetCheck{{subject: "tenant", limit: tenantLimit}}
+
+	if scope.UserID != "" {
+		userLimit, exists := e.config.UserLimits[scope.UserID]
+		if !exists {
+			userLimit = e.config.DefaultUserLimit
+		}
+		checks = append(checks, budgetCheck{subject: "user", user: scope.UserID, limit: userLimit})
+	}
+
+	for _, check := range checks {
+		for _, period := range []BudgetPeriod{BudgetPeriodDaily, BudgetPeriodMonthly} {
+			limit := check.limit.forPeriod(period)
+			if limit <= 0 {
+				continue
+			}
+			spent := e.spend[spendKey{tenant: scope.TenantID, user: check.user, window: windowFor(period, now)}]
+			if spent+cost > limit {
+				return &BudgetExceededError{
+					Scope:     scope,
+					Subject:   check.subject,
+					Period:    period,
+					Limit:     limit,
+					Spent:     spent,
+					Estimated: cost,
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func dayWindow(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func monthWindow(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+func windowFor(period BudgetPeriod, t time.Time) string {
+	if period == BudgetPeriodDaily {
+		return dayWindow(t)
+	}
+	return monthWindow(t)
+}
+
+// BudgetedLLM wraps an LLM, checking every request against a BudgetEnforcer
+// and recording its cost. Requests are charged to the BudgetScope in the
+// context, or to the tenant_id and user_id request metadata.
+type BudgetedLLM struct {
+	llm          LLM
+	enforcer     *BudgetEnforcer
+	defaultModel string
+	logger       *logrus.Logger
+}
+
+// NewBudgetedLLM creates a budget-enforcing LLM. defaultModel prices requests
+// that leave the model to the provider's configuration.
+func NewBudgetedLLM(llm LLM, enforcer *BudgetEnforcer, defaultModel string, logger *logrus.Logger) *BudgetedLLM {
+	return &BudgetedLLM{
+		llm:          llm,
+		enforcer:     enforcer,
+		defaultModel: defaultModel,
+		logger:       logger,
+	}
+}
+
+// Complete checks the budget, runs the completion and records its cost
+func (b *BudgetedLLM) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	scope := b.scopeFor(ctx, req.Metadata)
+	budgetedReq, decision, err := b.check(scope, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.llm.Complete(ctx, budgetedReq)
+	if err != nil {
+		b.enforcer.Release(decision)
+		return nil, err
+	}
+
+	usage := resp.Usage
+	if usage.TotalTokens == 0 {
+		usage = b.enforcer.EstimateCompletion(budgetedReq)
+		usage.CompletionTokens = b.enforcer.tokenizer.CountTokens(resp.Content)
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	cost := b.enforcer.Record(decision, usage)
+
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]interface{})
+	}
+	resp.Metadata["cost_usd"] = cost
+	if decision.Downgraded {
+		resp.Metadata["downgraded_from"] = b.modelFor(req)
+	}
+
+	return resp, nil
+}
+
+// Stream checks the budget and records the cost when the stream finishes.
+// Forwarding stops when ctx is done, charging what was generated so far.
+func (b *BudgetedLLM) Stream(ctx context.Context, req *CompletionRequest) (<-chan StreamResponse, error) {
+	scope := b.scopeFor(ctx, req.Metadata)
+	budgetedReq, decision, err := b.check(scope, req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := b.llm.Stream(ctx, budgetedReq)
+	if err != nil {
+		b.enforcer.Release(decision)
+		return nil, err
+	}
+
+	out := make(chan StreamResponse)
+	go func() {
+		defer close(out)
+
+		var content string
+		var usage *TokenUsage
+	forward:
+		for chunk := range stream {
+			content += chunk.Content
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// Keep reading so the provider can finish; its request is
+				// cancelled with ctx, so the stream ends soon
+				for range stream {
+				}
+				break forward
+			}
+		}
+
+		if usage == nil {
+			estimated := b.enforcer.EstimateCompletion(budgetedReq)
+			estimated.CompletionTokens = b.enforcer.tokenizer.CountTokens(content)
+			estimated.TotalTokens = estimated.PromptTokens + estimated.CompletionTokens
+			usage = &estimated
+		}
+		b.enforcer.Record(decision, *usage)
+	}()
+
+	return out, nil
+}
+
+// GetEmbeddings checks the budget, creates the embeddings and records their cost
+func (b *BudgetedLLM) GetEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	scope := b.scopeFor(ctx, nil)
+	model := req.Model
+	if model == "" {
+		model = b.defaultModel
+	}
+
+	estimated := TokenUsage{}
+	for _, input := range req.Input {
+		estimated.PromptTokens += b.enforcer.tokenizer.CountTokens(input)
+	}
+	estimated.TotalTokens = estimated.PromptTokens
+
+	decision, err := b.enforcer.Check(scope, model, estimated)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.llm.GetEmbeddings(ctx, req)
+	if err != nil {
+		b.enforcer.Release(decision)
+		return nil, err
+	}
+
+	usage := resp.Usage
+	if usage.TotalTokens == 0 {
+		usage = estimated
+	}
+	b.enforcer.Record(decision, usage)
+
+	return resp, nil
+}
+
+// GetProvider returns the provider of the wrapped LLM
+func (b *BudgetedLLM) GetProvider() LLMProvider {
+	return b.llm.GetProvider()
+}
+
+// GetModels returns the models of the wrapped LLM
+func (b *BudgetedLLM) GetModels(ctx context.Context) ([]string, error) {
+	return b.llm.GetModels(ctx)
+}
+
+// ValidateModel validates a model with the wrapped LLM
+func (b *BudgetedLLM) ValidateModel(ctx context.Context, model string) error {
+	return b.llm.ValidateModel(ctx, model)
+}
+
+// Close closes the wrapped LLM
+func (b *BudgetedLLM) Close() error {
+	return b.llm.Close()
+}
+
+// check runs the budget check, returning the request to send
+func (b *BudgetedLLM) check(scope BudgetScope, req *CompletionRequest) (*CompletionRequest, *BudgetDecision, error) {
+	decision, err := b.enforcer.Check(scope, b.modelFor(req), b.enforcer.EstimateCompletion(req))
+	if err != nil {
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"tenant_id": scope.TenantID,
+			"user_id":   scope.UserID,
+		}).Warn("LLM request rejected by budget")
+		return nil, nil, err
+	}
+
+	if !decision.Downgraded {
+		return req, decision, nil
+	}
+
+	downgraded := *req
+	downgraded.Model = decision.Model
+	return &downgraded, decision, nil
+}
+
+func (b *BudgetedLLM) modelFor(req *CompletionRequest) string {
+	if req.Model != "" {
+		return req.Model
+	}
+	return b.defaultModel
+}
+
+func (b *BudgetedLLM) scopeFor(ctx context.Context, metadata map[string]interface{}) BudgetScope {
+	if scope, ok := BudgetScopeFromContext(ctx); ok {
+		return scope
+	}
+
+	var scope BudgetScope
+	scope.TenantID, _ = metadata[BudgetTenantKey].(string)
+	scope.UserID, _ = metadata[BudgetUserKey].(string)
+	return scope
+}
@@ -0,0 +1,318 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVocabulary builds a .tiktoken vocabulary of every byte plus the given merges
+func testVocabulary(merges ...string) string {
+	var builder strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&builder, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, merge := range merges {
+		fmt.Fprintf(&builder, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(merge)), 256+i)
+	}
+	return builder.String()
+}
+
+func TestBPETokenizer(t *testing.T) {
+	tokenizer, err := NewBPETokenizer("test", strings.NewReader(testVocabulary("he", "ll", "llo", " world")))
+	require.NoError(t, err)
+
+	tokens := tokenizer.Encode("hello world")
+	assert.Equal(t, []int{256, 258, 259}, tokens)
+	assert.Equal(t, "hello world", tokenizer.Decode(tokens))
+	assert.Equal(t, 3, tokenizer.CountTokens("hello world"))
+
+	// The last space of a run belongs to the next word, but not to numbers
+	assert.Equal(t, []string{"a", " ", " b", " ", "12"}, splitPieces("a  b 12"))
+
+	heuristic := NewHeuristicTokenizer()
+	assert.Equal(t, 6, heuristic.CountTokens("Hello, world!"))
+}
+
+func TestPriceTable(t *testing.T) {
+	prices := DefaultPriceTable()
+
+	price, ok := prices.GetPrice("gpt-4o-mini-2024-07-18")
+	require.True(t, ok)
+	assert.Equal(t, 0.15, price.InputPerMillion, "longest prefix wins over gpt-4o")
+
+	cost, ok := prices.Cost("gpt-4o", TokenUsage{PromptTokens: 1000000, CompletionTokens: 500000})
+	require.True(t, ok)
+	assert.InDelta(t, 7.5, cost, 1e-9)
+
+	_, ok = prices.Cost("llama3", TokenUsage{PromptTokens: 10})
+	assert.False(t, ok)
+
+	// The default models of the hosted providers are priced
+	for _, model := range []string{
+		"gpt-3.5-turbo", "claude-3-sonnet-20240229", "gemini-pro", "text-embedding-ada-002",
+	} {
+		_, ok := prices.GetPrice(model)
+		assert.True(t, ok, model)
+	}
+}
+
+func TestBudgetEnforcer(t *testing.T) {
+	prices := NewPriceTable(map[string]ModelPrice{
+		"big":   {InputPerMillion: 1000, OutputPerMillion: 1000}, // $0.001 per token
+		"small": {InputPerMillion: 100, OutputPerMillion: 100},
+	})
+	scope := BudgetScope{TenantID: "acme", UserID: "alice"}
+	usage := TokenUsage{PromptTokens: 50, CompletionTokens: 50, TotalTokens: 100} // $0.10 on big
+
+	t.Run("Reject", func(t *testing.T) {
+		enforcer, err := NewBudgetEnforcer(&BudgetConfig{
+			TenantLimits: map[string]BudgetLimit{"acme": {Monthly: 1}},
+			UserLimits:   map[string]BudgetLimit{"alice": {Daily: 0.25}},
+		}, prices, nil, newTestLogger())
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			decision, err := enforcer.Check(scope, "big", usage)
+			require.NoError(t, err)
+			enforcer.Record(decision, usage)
+		}
+
+		_, err = enforcer.Check(scope, "big", usage)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrBudgetExceeded))
+
+		var budgetErr *BudgetExceededError
+		require.True(t, errors.As(err, &budgetErr))
+		assert.Equal(t, "user", budgetErr.Subject)
+		assert.Equal(t, BudgetPeriodDaily, budgetErr.Period)
+
+		// Another user of the tenant still has budget
+		decision, err := enforcer.Check(BudgetScope{TenantID: "acme", UserID: "bob"}, "big", usage)
+		require.NoError(t, err)
+		enforcer.Release(decision)
+
+		assert.InDelta(t, 0.2, enforcer.GetSpend(BudgetScope{TenantID: "acme"}, BudgetPeriodMonthly), 1e-9)
+		assert.InDelta(t, 0.2, enforcer.SpendByUser("acme", BudgetPeriodDaily)["alice"], 1e-9)
+
+		// Spend resets with the day
+		enforcer.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+		_, err = enforcer.Check(scope, "big", usage)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Downgrade", func(t *testing.T) {
+		enforcer, err := NewBudgetEnforcer(&BudgetConfig{
+			DefaultTenantLimit: BudgetLimit{Daily: 0.15},
+			Action:             BudgetActionDowngrade,
+			Downgrades:         map[string]string{"big": "small"},
+		}, prices, nil, newTestLogger())
+		require.NoError(t, err)
+		decision, err := enforcer.Check(scope, "big", usage)
+		require.NoError(t, err)
+		enforcer.Record(decision, usage)
+
+		model := &scriptedLLM{replies: []string{"ok"}}
+		budgeted := NewBudgetedLLM(model, enforcer, "big", newTestLogger())
+
+		resp, err := budgeted.Complete(WithBudgetScope(context.Background(), scope), &CompletionRequest{
+			Messages:  []Message{{Role: "user", Content: "hi"}},
+			MaxTokens: 50,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "small", model.requests[0].Model)
+		assert.Equal(t, "big", resp.Metadata["downgraded_from"])
+		assert.InDelta(t, 0.1+15*0.0001, enforcer.GetSpend(BudgetScope{TenantID: "acme"}, BudgetPeriodDaily), 1e-9)
+	})
+	t.Run("PrunesPastWindows", func(t *testing.T) {
+		enforcer, err := NewBudgetEnforcer(&BudgetConfig{}, prices, nil, newTestLogger())
+		require.NoError(t, err)
+
+		now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+		enforcer.now = func() time.Time { return now }
+		for i := 0; i < 3; i++ {
+			decision, err := enforcer.Check(scope, "big", usage)
+			require.NoError(t, err)
+			enforcer.Record(decision, usage)
+			now = now.Add(24 * time.Hour)
+		}
+
+		// Only February 2 and February remain, for the tenant and the user
+		windows := make(map[string]bool)
+		for key := range enforcer.spend {
+			windows[key.window] = true
+		}
+		assert.Equal(t, map[string]bool{"2024-02-02": true, "2024-02": true}, windows)
+		assert.Len(t, enforcer.spend, 4)
+		assert.InDelta(t, 0.2, enforcer.GetSpend(scope, BudgetPeriodMonthly), 1e-9)
+	})
+
+	t.Run("UnknownModels", func(t *testing.T) {
+		limit := BudgetLimit{Daily: 0.15}
+
+		free, err := NewBudgetEnforcer(&BudgetConfig{DefaultTenantLimit: limit}, prices, nil, newTestLogger())
+		require.NoError(t, err)
+		decision, err := free.Check(scope, "llama3", usage)
+		require.NoError(t, err)
+		assert.Zero(t, free.Record(decision, usage))
+
+		reject, err := NewBudgetEnforcer(&BudgetConfig{DefaultTenantLimit: limit, UnknownModels: UnknownModelReject}, prices, nil, newTestLogger())
+		require.NoError(t, err)
+		_, err = reject.Check(scope, "llama3", usage)
+		assert.ErrorIs(t, err, ErrModelNotPriced)
+
+		fallback, err := NewBudgetEnforcer(&BudgetConfig{
+			DefaultTenantLimit: limit,
+			UnknownModels:      UnknownModelFallback,
+			FallbackPrice:      ModelPrice{InputPerMillion: 1000, OutputPerMillion: 1000},
+		}, prices, nil, newTestLogger())
+		require.NoError(t, err)
+		decision, err = fallback.Check(scope, "llama3", usage)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.1, fallback.Record(decision, usage), 1e-9)
+		_, err = fallback.Check(scope, "llama3", usage)
+		assert.ErrorIs(t, err, ErrBudgetExceeded)
+
+		_, err = NewBudgetEnforcer(&BudgetConfig{UnknownModels: UnknownModelFallback}, prices, nil, newTestLogger())
+		assert.Error(t, err)
+	})
+
+	t.Run("ConcurrentChecksReserve", func(t *testing.T) {
+		enforcer, err := NewBudgetEnforcer(&BudgetConfig{
+			DefaultTenantLimit: BudgetLimit{Daily: 0.25},
+		}, prices, nil, newTestLogger())
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		decisions := make(chan *BudgetDecision, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if decision, err := enforcer.Check(scope, "big", usage); err == nil {
+					decisions <- decision
+				}
+			}()
+		}
+		wg.Wait()
+		close(decisions)
+
+		// Only two $0.10 requests fit in $0.25 while both are in flight
+		var accepted []*BudgetDecision
+		for decision := range decisions {
+			accepted = append(accepted, decision)
+		}
+		require.Len(t, accepted, 2)
+		assert.InDelta(t, 0.2, enforcer.GetSpend(BudgetScope{TenantID: "acme"}, BudgetPeriodDaily), 1e-9)
+
+		// A failed request frees its reservation, a finished one is charged its actual cost
+		enforcer.Release(accepted[0])
+		enforcer.Release(accepted[0])
+		assert.Equal(t, 0.02, enforcer.Record(accepted[1], TokenUsage{PromptTokens: 10, CompletionTokens: 10, TotalTokens: 20}))
+		assert.Zero(t, enforcer.Record(accepted[1], usage))
+		assert.InDelta(t, 0.02, enforcer.GetSpend(BudgetScope{TenantID: "acme"}, BudgetPeriodDaily), 1e-9)
+		assert.InDelta(t, 0.02, enforcer.GetSpend(scope, BudgetPeriodMonthly), 1e-9)
+	})
+
+	t.Run("StreamStopsWhenContextDone", func(t *testing.T) {
+		enforcer, err := NewBudgetEnforcer(&BudgetConfig{}, prices, nil, newTestLogger())
+		require.NoError(t, err)
+		budgeted := NewBudgetedLLM(&endlessStreamLLM{}, enforcer, "big", newTestLogger())
+
+		ctx, cancel := context.WithCancel(WithBudgetScope(context.Background(), scope))
+		stream, err := budgeted.Stream(ctx, &CompletionRequest{Messages: []Message{{Role: "user", Content: "hi"}}})
+		require.NoError(t, err)
+
+		<-stream
+		cancel()
+
+		// The endless stream only ends if forwarding stops on cancellation
+		select {
+		case <-drain(stream):
+		case <-time.After(time.Second):
+			t.Fatal("stream was not closed after the context was cancelled")
+		}
+		assert.Greater(t, enforcer.GetSpend(scope, BudgetPeriodDaily), 0.0)
+	})
+
+	t.Run("StreamDrainsProviderAfterCancel", func(t *testing.T) {
+		enforcer, err := NewBudgetEnforcer(&BudgetConfig{}, prices, nil, newTestLogger())
+		require.NoError(t, err)
+		provider := &bufferedStreamLLM{chunks: 50, finished: make(chan struct{})}
+		budgeted := NewBudgetedLLM(provider, enforcer, "big", newTestLogger())
+
+		ctx, cancel := context.WithCancel(WithBudgetScope(context.Background(), scope))
+		stream, err := budgeted.Stream(ctx, &CompletionRequest{Messages: []Message{{Role: "user", Content: "hi"}}})
+		require.NoError(t, err)
+
+		<-stream
+		cancel()
+
+		// Nobody reads stream any more; the provider must still be able to finish
+		select {
+		case <-provider.finished:
+		case <-time.After(time.Second):
+			t.Fatal("provider blocked after the context was cancelled")
+		}
+	})
+}
+
+// bufferedStreamLLM sends a fixed number of chunks on a small buffered
+// channel without watching the context, like the HTTP providers
+type bufferedStreamLLM struct {
+	scriptedLLM
+	chunks   int
+	finished chan struct{}
+}
+
+func (s *bufferedStreamLLM) Stream(ctx context.Context, req *CompletionRequest) (<-chan StreamResponse, error) {
+	out := make(chan StreamResponse, 10)
+	go func() {
+		defer close(s.finished)
+		defer close(out)
+		for i := 0; i < s.chunks; i++ {
+			out <- StreamResponse{Content: "token "}
+		}
+	}()
+	return out, nil
+}
+
+// endlessStreamLLM streams chunks until the context is done
+type endlessStreamLLM struct {
+	scriptedLLM
+}
+
+func (s *endlessStreamLLM) Stream(ctx context.Context, req *CompletionRequest) (<-chan StreamResponse, error) {
+	out := make(chan StreamResponse)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case out <- StreamResponse{Content: "token "}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// drain discards the chunks of stream and returns a channel closed once the
+// stream is closed
+func drain(stream <-chan StreamResponse) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range stream {
+		}
+	}()
+	return done
+}
@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors for provider failures, matched with errors.Is
+var (
+	ErrRateLimited    = errors.New("rate limited")
+	ErrOverloaded     = errors.New("provider overloaded")
+	ErrAuthentication = errors.New("authentication failed")
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrProviderFailed = errors.New("provider request failed")
+	ErrContentBlocked = errors.New("content blocked by safety filters")
+)
+
+// ProviderError is returned when a provider API rejects a request
+type ProviderError struct {
+	Provider   LLMProvider   `json:"provider"`
+	StatusCode int           `json:"status_code"`
+	Type       string        `json:"type,omitempty"`
+	Message    string        `json:"message"`
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+	Kind       error         `json:"-"`
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	if e.StatusCode == 0 {
+		// Errors reported mid-stream carry no HTTP status
+		return fmt.Sprintf("%s API error (%s): %s", e.Provider, e.Type, e.Message)
+	}
+	if e.Type != "" {
+		return fmt.Sprintf("%s API request failed with status %d (%s): %s", e.Provider, e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("%s API request failed with status %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// Unwrap returns the sentinel error describing the failure
+func (e *ProviderError) Unwrap() error { return e.Kind }
+
+// Retryable reports whether the request may succeed if retried later
+func (e *ProviderError) Retryable() bool {
+	return errors.Is(e.Kind, ErrRateLimited) || errors.Is(e.Kind, ErrOverloaded) || e.StatusCode >= 500
+}
+
+// IsRetryable reports whether err is a provider error worth retrying
+func IsRetryable(err error) bool {
+	var providerErr *ProviderError
+	return errors.As(err, &providerErr) && providerErr.Retryable()
+}
+
+// ContentBlockedError is returned when a provider's safety filters block the
+// prompt or the generated response
+type ContentBlockedError struct {
+	Provider   LLMProvider `json:"provider"`
+	Stage      string      `json:"stage"` // "prompt" or "response"
+	Reason     string      `json:"reason"`
+	Categories []string    `json:"categories,omitempty"` // Categories that triggered the block
+}
+
+// Error implements the error interface
+func (e *ContentBlockedError) Error() string {
+	msg := fmt.Sprintf("%s blocked the %s: %s", e.Provider, e.Stage, e.Reason)
+	if len(e.Categories) > 0 {
+		msg += fmt.Sprintf(" (%s)", strings.Join(e.Categories, ", "))
+	}
+	return msg
+}
+
+// Unwrap returns ErrContentBlocked
+func (e *ContentBlockedError) Unwrap() error { return ErrContentBlocked }
+
+// errorKindForStatus maps an HTTP status code to a sentinel error
+func errorKindForStatus(statusCode int) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusServiceUnavailable || statusCode == 529:
+		return ErrOverloaded
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrAuthentication
+	case statusCode >= 400 && statusCode < 500:
+		return ErrInvalidRequest
+	default:
+		return ErrProviderFailed
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// newStatusError builds a ProviderError from a failed response whose body
+// has no provider-specific error format
+func newStatusError(provider LLMProvider, resp *http.Response, body []byte) *ProviderError {
+	return &ProviderError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		RetryAfter: parseRetryAfter(resp.Header),
+		Kind:       errorKindForStatus(resp.StatusCode),
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -109,8 +111,12 @@ func (f *DefaultLLMFactory) registerDefaultProviders() {
 // DefaultLLMManager is the default implementation of LLMManager
 type DefaultLLMManager struct {
 	llms       map[string]LLM
+	routes     map[string]LLMRouteOptions
 	defaultLLM string
 	factory    LLMFactory
+	routing    *RoutingConfig
+	breakers   map[LLMProvider]*circuitBreaker
+	metrics    LLMMetrics
 	logger     *logrus.Logger
 	tracer     trace.Tracer
 	mu         sync.RWMutex
@@ -119,15 +125,25 @@ type DefaultLLMManager struct {
 // NewLLMManager creates a new LLM manager
 func NewLLMManager(factory LLMFactory, logger *logrus.Logger) LLMManager {
 	return &DefaultLLMManager{
-		llms:    make(map[string]LLM),
-		factory: factory,
-		logger:  logger,
-		tracer:  otel.Tracer("langchain.llm.manager"),
+		llms:     make(map[string]LLM),
+		routes:   make(map[string]LLMRouteOptions),
+		factory:  factory,
+		routing:  DefaultRoutingConfig(),
+		breakers: make(map[LLMProvider]*circuitBreaker),
+		metrics:  NewLLMMetrics(),
+		logger:   logger,
+		tracer:   otel.Tracer("langchain.llm.manager"),
 	}
 }
 
 // AddLLM adds an LLM instance to the manager
 func (m *DefaultLLMManager) AddLLM(name string, llm LLM) error {
+	return m.AddLLMWithOptions(name, llm, LLMRouteOptions{})
+}
+
+// AddLLMWithOptions adds an LLM instance with the capabilities and cost tier
+// used for routing
+func (m *DefaultLLMManager) AddLLMWithOptions(name string, llm LLM, options LLMRouteOptions) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -136,6 +152,7 @@ func (m *DefaultLLMManager) AddLLM(name string, llm LLM) error {
 	}
 
 	m.llms[name] = llm
+	m.routes[name] = options
 
 	// Set as default if it's the first LLM
 	if m.defaultLLM == "" {
@@ -143,8 +160,10 @@ func (m *DefaultLLMManager) AddLLM(name string, llm LLM) error {
 	}
 
 	m.logger.WithFields(logrus.Fields{
-		"name":     name,
-		"provider": llm.GetProvider(),
+		"name":         name,
+		"provider":     llm.GetProvider(),
+		"capabilities": options.Capabilities,
+		"cost_tier":    options.CostTier,
 	}).Info("Added LLM to manager")
 
 	return nil
@@ -180,30 +199,128 @@ func (m *DefaultLLMManager) GetDefaultLLM() (LLM, error) {
 	return llm, nil
 }
 
-// Complete routes completion request to appropriate LLM
+// SetDefaultLLM sets the LLM tried first by the priority strategy
+func (m *DefaultLLMManager) SetDefaultLLM(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.llms[name]; !exists {
+		return fmt.Errorf("LLM not found: %s", name)
+	}
+
+	m.defaultLLM = name
+	return nil
+}
+
+// SetRoutingConfig replaces the routing configuration. Circuit breakers are
+// reset so they pick up the new thresholds.
+func (m *DefaultLLMManager) SetRoutingConfig(config *RoutingConfig) error {
+	if config == nil {
+		return fmt.Errorf("routing config cannot be nil")
+	}
+
+	switch config.Strategy {
+	case "":
+		config.Strategy = RoutingStrategyPriority
+	case RoutingStrategyPriority, RoutingStrategyLatency, RoutingStrategyCost:
+	default:
+		return fmt.Errorf("unsupported routing strategy: %s", config.Strategy)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.routing = config
+	m.breakers = make(map[LLMProvider]*circuitBreaker)
+
+	return nil
+}
+
+// SetMetrics replaces the metrics collector used for latency-based routing
+func (m *DefaultLLMManager) SetMetrics(metrics LLMMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metrics = metrics
+}
+
+// GetMetrics returns the metrics collector recording routed requests
+func (m *DefaultLLMManager) GetMetrics() LLMMetrics {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.metrics
+}
+
+// GetCircuitState returns the circuit breaker state of a provider
+func (m *DefaultLLMManager) GetCircuitState(provider LLMProvider) CircuitState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if breaker, exists := m.breakers[provider]; exists {
+		return breaker.currentState()
+	}
+	return CircuitClosed
+}
+
+// Complete routes completion request to appropriate LLM, falling back to the
+// next candidate on retryable errors and timeouts
 func (m *DefaultLLMManager) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
 	ctx, span := m.tracer.Start(ctx, "llm_manager.complete")
 	defer span.End()
 
-	llm, err := m.GetDefaultLLM()
+	var response *CompletionResponse
+	name, err := m.execute(ctx, routeForCompletion(req), true, func(ctx context.Context, name string, llm LLM) error {
+		start := time.Now()
+		resp, err := llm.Complete(ctx, req)
+		if metrics := m.GetMetrics(); metrics != nil {
+			model := m.modelFor(name)
+			tokens := 0
+			if resp != nil {
+				tokens = resp.Usage.TotalTokens
+				if model == "" {
+					model = resp.Model
+				}
+			}
+			metrics.RecordCompletion(llm.GetProvider(), model, time.Since(start), tokens, err == nil)
+		}
+		response = resp
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get default LLM: %w", err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("llm.name", name))
+	if response.Metadata == nil {
+		response.Metadata = make(map[string]interface{})
 	}
+	response.Metadata["llm"] = name
 
-	return llm.Complete(ctx, req)
+	return response, nil
 }
 
-// Stream routes streaming request to appropriate LLM
+// Stream routes streaming request to appropriate LLM. Fallback only applies
+// to errors returned before the stream starts.
 func (m *DefaultLLMManager) Stream(ctx context.Context, req *CompletionRequest) (<-chan StreamResponse, error) {
 	ctx, span := m.tracer.Start(ctx, "llm_manager.stream")
 	defer span.End()
 
-	llm, err := m.GetDefaultLLM()
+	route := routeForCompletion(req)
+	route.capabilities = append(route.capabilities, CapabilityStreaming)
+
+	var stream <-chan StreamResponse
+	name, err := m.execute(ctx, route, false, func(ctx context.Context, name string, llm LLM) error {
+		ch, err := llm.Stream(ctx, req)
+		stream = ch
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get default LLM: %w", err)
+		span.RecordError(err)
+		return nil, err
 	}
 
-	return llm.Stream(ctx, req)
+	span.SetAttributes(attribute.String("llm.name", name))
+	return stream, nil
 }
 
 // GetEmbeddings routes embedding request to appropriate LLM
@@ -211,12 +328,28 @@ func (m *DefaultLLMManager) GetEmbeddings(ctx context.Context, req *EmbeddingReq
 	ctx, span := m.tracer.Start(ctx, "llm_manager.embeddings")
 	defer span.End()
 
-	llm, err := m.GetDefaultLLM()
+	var response *EmbeddingResponse
+	route := routeRequest{capabilities: []string{CapabilityEmbeddings}}
+	name, err := m.execute(ctx, route, true, func(ctx context.Context, name string, llm LLM) error {
+		start := time.Now()
+		resp, err := llm.GetEmbeddings(ctx, req)
+		if metrics := m.GetMetrics(); metrics != nil {
+			model := m.modelFor(name)
+			if model == "" {
+				model = req.Model
+			}
+			metrics.RecordEmbedding(llm.GetProvider(), model, time.Since(start), len(req.Input), err == nil)
+		}
+		response = resp
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get default LLM: %w", err)
+		span.RecordError(err)
+		return nil, err
 	}
 
-	return llm.GetEmbeddings(ctx, req)
+	span.SetAttributes(attribute.String("llm.name", name))
+	return response, nil
 }
 
 // GetHealthStatus returns health status of all managed LLMs
@@ -248,6 +381,8 @@ func (m *DefaultLLMManager) Close() error {
 	}
 
 	m.llms = make(map[string]LLM)
+	m.routes = make(map[string]LLMRouteOptions)
+	m.breakers = make(map[LLMProvider]*circuitBreaker)
 	m.defaultLLM = ""
 
 	return lastErr
@@ -1,19 +1,124 @@
 package llm
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
 // GeminiLLM implements the LLM interface for Google Gemini
 type GeminiLLM struct {
-	config *LLMConfig
-	logger *logrus.Logger
-	tracer trace.Tracer
+	config     *LLMConfig
+	httpClient *http.Client
+	logger     *logrus.Logger
+	tracer     trace.Tracer
+}
+
+// GeminiPart represents a part of Gemini content
+type GeminiPart struct {
+	Text string `json:"text"`
+}
+
+// GeminiContent represents a Gemini message
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []GeminiPart `json:"parts"`
+}
+
+// GeminiGenerationConfig represents Gemini generation parameters
+type GeminiGenerationConfig struct {
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	Temperature      float64  `json:"temperature,omitempty"`
+	TopP             float64  `json:"topP,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	ResponseMimeType string   `json:"responseMimeType,omitempty"`
+}
+
+// GeminiGenerateRequest represents a generateContent request
+type GeminiGenerateRequest struct {
+	Contents          []GeminiContent         `json:"contents"`
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// GeminiSafetyRating represents the safety rating of a prompt or candidate
+type GeminiSafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// GeminiGenerateResponse represents a generateContent response or stream chunk
+type GeminiGenerateResponse struct {
+	Candidates []struct {
+		Content       GeminiContent        `json:"content"`
+		FinishReason  string               `json:"finishReason"`
+		SafetyRatings []GeminiSafetyRating `json:"safetyRatings"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason   string               `json:"blockReason"`
+		SafetyRatings []GeminiSafetyRating `json:"safetyRatings"`
+	} `json:"promptFeedback,omitempty"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata,omitempty"`
+	ModelVersion string `json:"modelVersion,omitempty"`
+	ResponseID   string `json:"responseId,omitempty"`
+}
+
+// GeminiEmbedRequest represents an embedContent request
+type GeminiEmbedRequest struct {
+	Model   string        `json:"model"`
+	Content GeminiContent `json:"content"`
+}
+
+// GeminiEmbedResponse represents an embedContent response
+type GeminiEmbedResponse struct {
+	Embedding struct {
+		Values []float64 `json:"values"`
+	} `json:"embedding"`
+}
+
+// GeminiModelsResponse represents the response from the models endpoint
+type GeminiModelsResponse struct {
+	Models []struct {
+		Name                       string   `json:"name"`
+		DisplayName                string   `json:"displayName"`
+		SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+	} `json:"models"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// GeminiErrorResponse represents a Gemini API error body
+type GeminiErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// geminiBlockedFinishReasons are finish reasons that mean the response was withheld
+var geminiBlockedFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
 }
 
 // NewGeminiLLM creates a new Gemini LLM instance
@@ -30,10 +135,19 @@ func NewGeminiLLM(config *LLMConfig, logger *logrus.Logger) (LLM, error) {
 		config.Model = "gemini-pro"
 	}
 
+	if config.Timeout == 0 {
+		config.Timeout = 60 * time.Second
+	}
+
+	httpClient := &http.Client{
+		Timeout: config.Timeout,
+	}
+
 	return &GeminiLLM{
-		config: config,
-		logger: logger,
-		tracer: otel.Tracer("langchain.llm.gemini"),
+		config:     config,
+		httpClient: httpClient,
+		logger:     logger,
+		tracer:     otel.Tracer("langchain.llm.gemini"),
 	}, nil
 }
 
@@ -42,8 +156,47 @@ func (g *GeminiLLM) Complete(ctx context.Context, req *CompletionRequest) (*Comp
 	ctx, span := g.tracer.Start(ctx, "gemini.complete")
 	defer span.End()
 
-	// TODO: Implement Gemini API integration
-	return nil, fmt.Errorf("Gemini integration not yet implemented")
+	model := g.modelName(req.Model)
+
+	span.SetAttributes(
+		attribute.String("llm.provider", string(ProviderGemini)),
+		attribute.String("llm.model", model),
+		attribute.Int("llm.max_tokens", req.MaxTokens),
+	)
+
+	// Convert to Gemini format
+	geminiReq := g.convertToGeminiRequest(req)
+
+	// Make API request
+	resp, err := g.makeRequest(ctx, "POST", "/models/"+model+":generateContent", nil, geminiReq)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to make Gemini request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Parse response
+	var geminiResp GeminiGenerateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&geminiResp); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to parse Gemini response: %w", err)
+	}
+
+	if err := geminiBlockError(&geminiResp); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// Convert to standard format
+	response := g.convertFromGeminiResponse(&geminiResp, model)
+
+	span.SetAttributes(
+		attribute.Int("llm.usage.prompt_tokens", response.Usage.PromptTokens),
+		attribute.Int("llm.usage.completion_tokens", response.Usage.CompletionTokens),
+		attribute.Int("llm.usage.total_tokens", response.Usage.TotalTokens),
+	)
+
+	return response, nil
 }
 
 // Stream generates a streaming completion for the given request
@@ -51,8 +204,28 @@ func (g *GeminiLLM) Stream(ctx context.Context, req *CompletionRequest) (<-chan
 	ctx, span := g.tracer.Start(ctx, "gemini.stream")
 	defer span.End()
 
-	// TODO: Implement Gemini streaming
-	return nil, fmt.Errorf("Gemini streaming not yet implemented")
+	model := g.modelName(req.Model)
+	geminiReq := g.convertToGeminiRequest(req)
+
+	// Make the request up front so API errors are returned directly
+	query := url.Values{"alt": {"sse"}}
+	resp, err := g.makeRequest(ctx, "POST", "/models/"+model+":streamGenerateContent", query, geminiReq)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to make Gemini streaming request: %w", err)
+	}
+
+	responseCh := make(chan StreamResponse, 10)
+
+	go func() {
+		defer close(responseCh)
+		defer resp.Body.Close()
+
+		// Process streaming response
+		g.processStreamingResponse(ctx, resp.Body, responseCh)
+	}()
+
+	return responseCh, nil
 }
 
 // GetEmbeddings generates embeddings for the given text
@@ -60,8 +233,45 @@ func (g *GeminiLLM) GetEmbeddings(ctx context.Context, req *EmbeddingRequest) (*
 	ctx, span := g.tracer.Start(ctx, "gemini.embeddings")
 	defer span.End()
 
-	// TODO: Implement Gemini embeddings
-	return nil, fmt.Errorf("Gemini embeddings not yet implemented")
+	model := strings.TrimPrefix(req.Model, "models/")
+	if model == "" {
+		model = "text-embedding-004" // Default embedding model for Gemini
+	}
+
+	embeddings := make([][]float64, len(req.Input))
+
+	for i, text := range req.Input {
+		geminiReq := GeminiEmbedRequest{
+			Model:   "models/" + model,
+			Content: GeminiContent{Parts: []GeminiPart{{Text: text}}},
+		}
+
+		resp, err := g.makeRequest(ctx, "POST", "/models/"+model+":embedContent", nil, geminiReq)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to make Gemini embeddings request: %w", err)
+		}
+
+		var geminiResp GeminiEmbedResponse
+		err = json.NewDecoder(resp.Body).Decode(&geminiResp)
+		resp.Body.Close()
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to parse Gemini embeddings response: %w", err)
+		}
+
+		embeddings[i] = geminiResp.Embedding.Values
+	}
+
+	return &EmbeddingResponse{
+		Embeddings: embeddings,
+		Model:      model,
+		Usage: TokenUsage{
+			// Gemini doesn't report token usage for embeddings
+			PromptTokens: len(req.Input),
+			TotalTokens:  len(req.Input),
+		},
+	}, nil
 }
 
 // GetProvider returns the provider type
@@ -71,12 +281,40 @@ func (g *GeminiLLM) GetProvider() LLMProvider {
 
 // GetModels returns available models for this provider
 func (g *GeminiLLM) GetModels(ctx context.Context) ([]string, error) {
-	return []string{
-		"gemini-pro",
-		"gemini-pro-vision",
-		"gemini-ultra",
-		"text-embedding-004",
-	}, nil
+	ctx, span := g.tracer.Start(ctx, "gemini.get_models")
+	defer span.End()
+
+	models := make([]string, 0)
+	pageToken := ""
+
+	for {
+		query := url.Values{}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		resp, err := g.makeRequest(ctx, "GET", "/models", query, nil)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to list Gemini models: %w", err)
+		}
+
+		var modelsResp GeminiModelsResponse
+		err = json.NewDecoder(resp.Body).Decode(&modelsResp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse models response: %w", err)
+		}
+
+		for _, model := range modelsResp.Models {
+			models = append(models, strings.TrimPrefix(model.Name, "models/"))
+		}
+
+		if modelsResp.NextPageToken == "" {
+			return models, nil
+		}
+		pageToken = modelsResp.NextPageToken
+	}
 }
 
 // ValidateModel checks if a model is available
@@ -86,6 +324,7 @@ func (g *GeminiLLM) ValidateModel(ctx context.Context, model string) error {
 		return err
 	}
 
+	model = strings.TrimPrefix(model, "models/")
 	for _, m := range models {
 		if m == model {
 			return nil
@@ -97,5 +336,317 @@ func (g *GeminiLLM) ValidateModel(ctx context.Context, model string) error {
 
 // Close closes the LLM client and cleans up resources
 func (g *GeminiLLM) Close() error {
+	// Nothing to close for HTTP client
 	return nil
 }
+
+// Helper methods
+
+func (g *GeminiLLM) modelName(model string) string {
+	if model == "" {
+		model = g.config.Model
+	}
+	return strings.TrimPrefix(model, "models/")
+}
+
+// convertToGeminiRequest moves system messages into the system instruction,
+// maps the assistant role to "model" and merges consecutive messages of the
+// same role
+func (g *GeminiLLM) convertToGeminiRequest(req *CompletionRequest) *GeminiGenerateRequest {
+	geminiReq := &GeminiGenerateRequest{
+		Contents: make([]GeminiContent, 0, len(req.Messages)),
+	}
+
+	systemParts := make([]GeminiPart, 0)
+	for _, msg := range req.Messages {
+		if msg.Role == "system" {
+			systemParts = append(systemParts, GeminiPart{Text: msg.Content})
+			continue
+		}
+
+		role := "user"
+		if msg.Role == "assistant" {
+			role = "model"
+		}
+
+		if last := len(geminiReq.Contents) - 1; last >= 0 && geminiReq.Contents[last].Role == role {
+			geminiReq.Contents[last].Parts = append(geminiReq.Contents[last].Parts, GeminiPart{Text: msg.Content})
+			continue
+		}
+		geminiReq.Contents = append(geminiReq.Contents, GeminiContent{
+			Role:  role,
+			Parts: []GeminiPart{{Text: msg.Content}},
+		})
+	}
+
+	if len(systemParts) > 0 {
+		geminiReq.SystemInstruction = &GeminiContent{Parts: systemParts}
+	}
+
+	config := &GeminiGenerationConfig{
+		MaxOutputTokens: req.MaxTokens,
+		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		StopSequences:   req.Stop,
+	}
+	if config.MaxOutputTokens == 0 {
+		config.MaxOutputTokens = g.config.MaxTokens
+	}
+	if config.Temperature == 0 {
+		config.Temperature = g.config.Temperature
+	}
+	if config.TopP == 0 {
+		config.TopP = g.config.TopP
+	}
+	if req.ResponseSchema != nil {
+		// Gemini's responseSchema accepts only an OpenAPI subset, so the schema
+		// itself is enforced by validation rather than sent here
+		config.ResponseMimeType = "application/json"
+	}
+	if config.MaxOutputTokens != 0 || config.Temperature != 0 || config.TopP != 0 ||
+		len(config.StopSequences) > 0 || config.ResponseMimeType != "" {
+		geminiReq.GenerationConfig = config
+	}
+
+	return geminiReq
+}
+
+func (g *GeminiLLM) convertFromGeminiResponse(resp *GeminiGenerateResponse, model string) *CompletionResponse {
+	content := ""
+	finishReason := ""
+	if len(resp.Candidates) > 0 {
+		content = geminiText(resp.Candidates[0].Content)
+		finishReason = resp.Candidates[0].FinishReason
+	}
+
+	response := &CompletionResponse{
+		ID:      resp.ResponseID,
+		Content: content,
+		Model:   model,
+		Metadata: map[string]interface{}{
+			"stop_reason":   finishReason,
+			"finish_reason": geminiFinishReason(finishReason),
+		},
+	}
+	if response.ID == "" {
+		response.ID = fmt.Sprintf("gemini-%d", time.Now().UnixNano())
+	}
+	if resp.ModelVersion != "" {
+		response.Model = resp.ModelVersion
+	}
+	if resp.UsageMetadata != nil {
+		response.Usage = TokenUsage{
+			PromptTokens:     resp.UsageMetadata.PromptTokenCount,
+			CompletionTokens: resp.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:      resp.UsageMetadata.TotalTokenCount,
+		}
+	}
+
+	return response
+}
+
+func geminiText(content GeminiContent) string {
+	var text strings.Builder
+	for _, part := range content.Parts {
+		text.WriteString(part.Text)
+	}
+	return text.String()
+}
+
+// geminiFinishReason maps Gemini finish reasons to OpenAI-style finish reasons
+func geminiFinishReason(finishReason string) string {
+	switch {
+	case finishReason == "STOP":
+		return "stop"
+	case finishReason == "MAX_TOKENS":
+		return "length"
+	case geminiBlockedFinishReasons[finishReason]:
+		return "content_filter"
+	default:
+		return strings.ToLower(finishReason)
+	}
+}
+
+// geminiBlockError returns a ContentBlockedError when the prompt was blocked or
+// the first candidate was stopped by safety filters
+func geminiBlockError(resp *GeminiGenerateResponse) error {
+	if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+		return &ContentBlockedError{
+			Provider:   ProviderGemini,
+			Stage:      "prompt",
+			Reason:     resp.PromptFeedback.BlockReason,
+			Categories: geminiBlockedCategories(resp.PromptFeedback.SafetyRatings),
+		}
+	}
+
+	if len(resp.Candidates) > 0 && geminiBlockedFinishReasons[resp.Candidates[0].FinishReason] {
+		return &ContentBlockedError{
+			Provider:   ProviderGemini,
+			Stage:      "response",
+			Reason:     resp.Candidates[0].FinishReason,
+			Categories: geminiBlockedCategories(resp.Candidates[0].SafetyRatings),
+		}
+	}
+
+	return nil
+}
+
+func geminiBlockedCategories(ratings []GeminiSafetyRating) []string {
+	categories := make([]string, 0)
+	for _, rating := range ratings {
+		if rating.Blocked || rating.Probability == "HIGH" {
+			categories = append(categories, rating.Category)
+		}
+	}
+	return categories
+}
+
+// makeRequest sends a request and returns the response when it succeeds.
+// Rate limit and unavailability errors are retried up to RetryCount times.
+func (g *GeminiLLM) makeRequest(ctx context.Context, method, endpoint string, query url.Values, payload interface{}) (*http.Response, error) {
+	var jsonData []byte
+	if payload != nil {
+		var err error
+		if jsonData, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	requestURL := g.config.BaseURL + endpoint
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	newRequest := func() (*http.Request, error) {
+		var body io.Reader
+		if jsonData != nil {
+			body = bytes.NewReader(jsonData)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+		if err != nil {
+			return nil, err
+		}
+
+		if jsonData != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("x-goog-api-key", g.config.APIKey)
+
+		return req, nil
+	}
+
+	return sendWithRetry(ctx, g.httpClient, g.logger, g.config.RetryCount, newRequest, g.parseError)
+}
+
+func (g *GeminiLLM) parseError(resp *http.Response) *ProviderError {
+	body, _ := io.ReadAll(resp.Body)
+
+	apiErr := &ProviderError{
+		Provider:   ProviderGemini,
+		StatusCode: resp.StatusCode,
+		Message:    string(body),
+		RetryAfter: parseRetryAfter(resp.Header),
+		Kind:       errorKindForStatus(resp.StatusCode),
+	}
+
+	var errResp GeminiErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		apiErr.Type = errResp.Error.Status
+		apiErr.Message = errResp.Error.Message
+		apiErr.Kind = geminiErrorKind(errResp.Error.Status, apiErr.Kind)
+	}
+
+	return apiErr
+}
+
+// geminiErrorKind maps a Google API error status to a sentinel error
+func geminiErrorKind(status string, fallback error) error {
+	switch status {
+	case "RESOURCE_EXHAUSTED":
+		return ErrRateLimited
+	case "UNAVAILABLE":
+		return ErrOverloaded
+	case "UNAUTHENTICATED", "PERMISSION_DENIED":
+		return ErrAuthentication
+	case "INVALID_ARGUMENT", "NOT_FOUND", "FAILED_PRECONDITION":
+		return ErrInvalidRequest
+	default:
+		return fallback
+	}
+}
+
+// processStreamingResponse reads server-sent events, each carrying a partial
+// generateContent response, until the stream ends
+func (g *GeminiLLM) processStreamingResponse(ctx context.Context, body io.Reader, responseCh chan<- StreamResponse) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		responseID   string
+		finishReason string
+		usage        *TokenUsage
+	)
+
+	send := func(chunk StreamResponse) bool {
+		select {
+		case responseCh <- chunk:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var chunk GeminiGenerateResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &chunk); err != nil {
+			send(StreamResponse{ID: responseID, Error: fmt.Errorf("failed to parse Gemini stream chunk: %w", err)})
+			return
+		}
+
+		if chunk.ResponseID != "" {
+			responseID = chunk.ResponseID
+		}
+
+		if err := geminiBlockError(&chunk); err != nil {
+			send(StreamResponse{ID: responseID, Error: err})
+			return
+		}
+
+		if chunk.UsageMetadata != nil {
+			usage = &TokenUsage{
+				PromptTokens:     chunk.UsageMetadata.PromptTokenCount,
+				CompletionTokens: chunk.UsageMetadata.CandidatesTokenCount,
+				TotalTokens:      chunk.UsageMetadata.TotalTokenCount,
+			}
+		}
+
+		if len(chunk.Candidates) > 0 {
+			if chunk.Candidates[0].FinishReason != "" {
+				finishReason = chunk.Candidates[0].FinishReason
+			}
+			if text := geminiText(chunk.Candidates[0].Content); text != "" {
+				if !send(StreamResponse{ID: responseID, Content: text}) {
+					return
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		send(StreamResponse{ID: responseID, Error: fmt.Errorf("failed to read Gemini stream: %w", err)})
+		return
+	}
+
+	send(StreamResponse{
+		ID:           responseID,
+		Done:         true,
+		FinishReason: geminiFinishReason(finishReason),
+		Usage:        usage,
+	})
+}
@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGeminiServer routes requests by path to the given handlers
+func fakeGeminiServer(t *testing.T, handlers map[string]http.HandlerFunc) LLM {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.Header.Get("x-goog-api-key"))
+
+		handler, exists := handlers[r.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	llm, err := NewGeminiLLM(&LLMConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL + "/v1",
+		Model:   "gemini-1.5-flash",
+	}, newTestLogger())
+	require.NoError(t, err)
+	return llm
+}
+
+func TestGeminiComplete(t *testing.T) {
+	var received GeminiGenerateRequest
+	llm := fakeGeminiServer(t, map[string]http.HandlerFunc{
+		"/v1/models/gemini-1.5-flash:generateContent": func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			fmt.Fprint(w, `{
+				"candidates": [{"content": {"role": "model", "parts": [{"text": "Hi"}, {"text": "!"}]}, "finishReason": "STOP"}],
+				"usageMetadata": {"promptTokenCount": 6, "candidatesTokenCount": 2, "totalTokenCount": 8},
+				"modelVersion": "gemini-1.5-flash-002",
+				"responseId": "resp_1"
+			}`)
+		},
+	})
+
+	resp, err := llm.Complete(context.Background(), &CompletionRequest{
+		Messages: []Message{
+			{Role: "system", Content: "Be friendly."},
+			{Role: "user", Content: "Hello"},
+			{Role: "assistant", Content: "Hey"},
+			{Role: "user", Content: "Say hi"},
+		},
+		MaxTokens: 50,
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, received.SystemInstruction)
+	assert.Equal(t, "Be friendly.", received.SystemInstruction.Parts[0].Text)
+	require.Len(t, received.Contents, 3)
+	assert.Equal(t, "model", received.Contents[1].Role)
+	assert.Equal(t, 50, received.GenerationConfig.MaxOutputTokens)
+
+	assert.Equal(t, "resp_1", resp.ID)
+	assert.Equal(t, "Hi!", resp.Content)
+	assert.Equal(t, "gemini-1.5-flash-002", resp.Model)
+	assert.Equal(t, TokenUsage{PromptTokens: 6, CompletionTokens: 2, TotalTokens: 8}, resp.Usage)
+	assert.Equal(t, "stop", resp.Metadata["finish_reason"])
+}
+
+func TestGeminiSafetyBlocks(t *testing.T) {
+	llm := fakeGeminiServer(t, map[string]http.HandlerFunc{
+		"/v1/models/blocked-prompt:generateContent": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"promptFeedback": {"blockReason": "SAFETY", "safetyRatings": [
+				{"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "probability": "HIGH", "blocked": true},
+				{"category": "HARM_CATEGORY_HARASSMENT", "probability": "NEGLIGIBLE"}
+			]}}`)
+		},
+		"/v1/models/blocked-response:generateContent": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"candidates": [{"content": {"parts": []}, "finishReason": "RECITATION"}]}`)
+		},
+	})
+
+	_, err := llm.Complete(context.Background(), &CompletionRequest{
+		Model:    "blocked-prompt",
+		Messages: []Message{{Role: "user", Content: "..."}},
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrContentBlocked))
+
+	var blocked *ContentBlockedError
+	require.True(t, errors.As(err, &blocked))
+	assert.Equal(t, "prompt", blocked.Stage)
+	assert.Equal(t, "SAFETY", blocked.Reason)
+	assert.Equal(t, []string{"HARM_CATEGORY_DANGEROUS_CONTENT"}, blocked.Categories)
+
+	_, err = llm.Complete(context.Background(), &CompletionRequest{
+		Model:    "blocked-response",
+		Messages: []Message{{Role: "user", Content: "..."}},
+	})
+	require.True(t, errors.As(err, &blocked))
+	assert.Equal(t, "response", blocked.Stage)
+	assert.Equal(t, "RECITATION", blocked.Reason)
+}
+
+func TestGeminiStream(t *testing.T) {
+	llm := fakeGeminiServer(t, map[string]http.HandlerFunc{
+		"/v1/models/gemini-1.5-flash:streamGenerateContent": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "sse", r.URL.Query().Get("alt"))
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w,
+				`data: {"candidates": [{"content": {"parts": [{"text": "Hel"}]}}], "responseId": "resp_2"}`+"\n\n"+
+					`data: {"candidates": [{"content": {"parts": [{"text": "lo"}]}, "finishReason": "STOP"}], `+
+					`"usageMetadata": {"promptTokenCount": 3, "candidatesTokenCount": 2, "totalTokenCount": 5}}`+"\n\n")
+		},
+	})
+
+	stream, err := llm.Stream(context.Background(), &CompletionRequest{
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	})
+	require.NoError(t, err)
+
+	var content string
+	var last StreamResponse
+	for chunk := range stream {
+		require.NoError(t, chunk.Error)
+		content += chunk.Content
+		last = chunk
+	}
+
+	assert.Equal(t, "Hello", content)
+	assert.True(t, last.Done)
+	assert.Equal(t, "resp_2", last.ID)
+	assert.Equal(t, "stop", last.FinishReason)
+	assert.Equal(t, &TokenUsage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}, last.Usage)
+}
+
+func TestGeminiEmbeddingsAndModels(t *testing.T) {
+	llm := fakeGeminiServer(t, map[string]http.HandlerFunc{
+		"/v1/models/text-embedding-004:embedContent": func(w http.ResponseWriter, r *http.Request) {
+			var req GeminiEmbedRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "models/text-embedding-004", req.Model)
+			fmt.Fprintf(w, `{"embedding": {"values": [%d, 0.5]}}`, len(req.Content.Parts[0].Text))
+		},
+		"/v1/models": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("pageToken") == "" {
+				fmt.Fprint(w, `{"models": [{"name": "models/gemini-1.5-flash"}], "nextPageToken": "p2"}`)
+				return
+			}
+			fmt.Fprint(w, `{"models": [{"name": "models/text-embedding-004"}]}`)
+		},
+	})
+
+	resp, err := llm.GetEmbeddings(context.Background(), &EmbeddingRequest{Input: []string{"a", "abc"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 0.5}, {3, 0.5}}, resp.Embeddings)
+
+	models, err := llm.GetModels(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gemini-1.5-flash", "text-embedding-004"}, models)
+	assert.NoError(t, llm.ValidateModel(context.Background(), "models/gemini-1.5-flash"))
+
+	_, err = llm.Complete(context.Background(), &CompletionRequest{
+		Model:    "missing",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidRequest))
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sendWithRetry sends the request built by newRequest and returns the response
+// when it succeeds. Failed responses are converted with parseError; retryable
+// ones are retried up to retryCount times, honoring the Retry-After header and
+// backing off exponentially otherwise.
+func sendWithRetry(
+	ctx context.Context,
+	client *http.Client,
+	logger *logrus.Logger,
+	retryCount int,
+	newRequest func() (*http.Request, error),
+	parseError func(*http.Response) *ProviderError,
+) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make request: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		apiErr := parseError(resp)
+		resp.Body.Close()
+
+		if attempt >= retryCount || !apiErr.Retryable() {
+			return nil, apiErr
+		}
+
+		wait := apiErr.RetryAfter
+		if wait == 0 {
+			wait = time.Duration(1<<attempt) * 500 * time.Millisecond
+		}
+
+		logger.WithFields(logrus.Fields{
+			"provider": apiErr.Provider,
+			"status":   apiErr.StatusCode,
+			"type":     apiErr.Type,
+			"attempt":  attempt + 1,
+			"wait":     wait,
+		}).Warn("Retrying LLM request")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -17,32 +17,82 @@ const (
 
 // Message represents a single message in a conversation
 type Message struct {
-	Role      string                 `json:"role"`      // "system", "user", "assistant"
-	Content   string                 `json:"content"`   // The message content
-	Metadata  map[string]interface{} `json:"metadata"`  // Additional metadata
-	Timestamp time.Time              `json:"timestamp"` // When the message was created
+	Role       string                 `json:"role"`                   // "system", "user", "assistant", "tool"
+	Content    string                 `json:"content"`                // The message content
+	ToolCalls  []ToolCall             `json:"tool_calls,omitempty"`   // Tools the assistant asked to call
+	ToolCallID string                 `json:"tool_call_id,omitempty"` // The call a "tool" message answers
+	Name       string                 `json:"name,omitempty"`         // The tool that produced a "tool" message
+	Metadata   map[string]interface{} `json:"metadata"`               // Additional metadata
+	Timestamp  time.Time              `json:"timestamp"`              // When the message was created
 }
 
+// ToolDefinition describes a function the model may call
+type ToolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"` // JSON Schema of the arguments object
+}
+
+// ToolCall represents a function call requested by the model
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON-encoded arguments object
+}
+
+// ToolCallDelta is a streamed fragment of a tool call. Fragments with the same
+// index belong to the same call; arguments arrive in pieces to be concatenated.
+type ToolCallDelta struct {
+	Index          int    `json:"index"`
+	ID             string `json:"id,omitempty"`
+	Name           string `json:"name,omitempty"`
+	ArgumentsDelta string `json:"arguments_delta,omitempty"`
+}
+
+// Tool choice modes for CompletionRequest.ToolChoice; any other value names
+// the tool that must be called
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
 // CompletionRequest represents a request for LLM completion
 type CompletionRequest struct {
-	Messages    []Message              `json:"messages"`
-	Model       string                 `json:"model"`
-	MaxTokens   int                    `json:"max_tokens,omitempty"`
-	Temperature float64                `json:"temperature,omitempty"`
-	TopP        float64                `json:"top_p,omitempty"`
-	Stop        []string               `json:"stop,omitempty"`
-	Stream      bool                   `json:"stream,omitempty"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Messages    []Message        `json:"messages"`
+	Model       string           `json:"model"`
+	MaxTokens   int              `json:"max_tokens,omitempty"`
+	Temperature float64          `json:"temperature,omitempty"`
+	TopP        float64          `json:"top_p,omitempty"`
+	Stop        []string         `json:"stop,omitempty"`
+	Stream      bool             `json:"stream,omitempty"`
+	Tools       []ToolDefinition `json:"tools,omitempty"`
+	ToolChoice  string           `json:"tool_choice,omitempty"`
+	// ResponseSchema asks for a JSON reply matching the schema, using the
+	// provider's native JSON mode where available
+	ResponseSchema *ResponseSchema        `json:"response_schema,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ResponseSchema describes the JSON a completion must return
+type ResponseSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	// Strict enables strict schema adherence on providers that support it,
+	// which requires every object to list all properties as required and
+	// disallow additional properties
+	Strict bool `json:"strict,omitempty"`
 }
 
 // CompletionResponse represents the response from an LLM
 type CompletionResponse struct {
-	ID       string                 `json:"id"`
-	Content  string                 `json:"content"`
-	Model    string                 `json:"model"`
-	Usage    TokenUsage             `json:"usage"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
-	Error    error                  `json:"error,omitempty"`
+	ID        string                 `json:"id"`
+	Content   string                 `json:"content"`
+	Model     string                 `json:"model"`
+	ToolCalls []ToolCall             `json:"tool_calls,omitempty"`
+	Usage     TokenUsage             `json:"usage"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Error     error                  `json:"error,omitempty"`
 }
 
 // TokenUsage represents token usage statistics
@@ -54,10 +104,14 @@ type TokenUsage struct {
 
 // StreamResponse represents a streaming response chunk
 type StreamResponse struct {
-	ID      string `json:"id"`
-	Content string `json:"content"`
-	Done    bool   `json:"done"`
-	Error   error  `json:"error,omitempty"`
+	ID             string          `json:"id"`
+	Content        string          `json:"content"`
+	ToolCallDeltas []ToolCallDelta `json:"tool_call_deltas,omitempty"`
+	Done           bool            `json:"done"`
+	ToolCalls      []ToolCall      `json:"tool_calls,omitempty"`    // The assembled tool calls, set on the final chunk
+	FinishReason   string          `json:"finish_reason,omitempty"` // Set on the final chunk when known
+	Usage          *TokenUsage     `json:"usage,omitempty"`         // Set on the final chunk when known
+	Error          error           `json:"error,omitempty"`
 }
 
 // EmbeddingRequest represents a request for text embeddings
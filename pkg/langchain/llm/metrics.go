@@ -0,0 +1,158 @@
+package llm
+
+import (
+	"sync"
+	"time"
+)
+
+// latencySmoothing is the weight of the newest sample in the moving average latency
+const latencySmoothing = 0.2
+
+// DefaultLLMMetrics is an in-memory implementation of LLMMetrics
+type DefaultLLMMetrics struct {
+	completions map[LLMProvider]map[string]*operationStats
+	embeddings  map[LLMProvider]map[string]*operationStats
+	mu          sync.RWMutex
+}
+
+// operationStats holds statistics for one provider and model
+type operationStats struct {
+	Total          int64
+	Successful     int64
+	Failed         int64
+	Units          int64 // Tokens for completions, inputs for embeddings
+	TotalDuration  time.Duration
+	AverageLatency time.Duration // Exponential moving average of successful calls
+	LastFailure    time.Time
+}
+
+// NewLLMMetrics creates a new in-memory metrics collector
+func NewLLMMetrics() *DefaultLLMMetrics {
+	return &DefaultLLMMetrics{
+		completions: make(map[LLMProvider]map[string]*operationStats),
+		embeddings:  make(map[LLMProvider]map[string]*operationStats),
+	}
+}
+
+// RecordCompletion records completion metrics
+func (m *DefaultLLMMetrics) RecordCompletion(provider LLMProvider, model string, duration time.Duration, tokens int, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record(m.completions, provider, model, duration, tokens, success)
+}
+
+// RecordEmbedding records embedding metrics
+func (m *DefaultLLMMetrics) RecordEmbedding(provider LLMProvider, model string, duration time.Duration, inputCount int, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record(m.embeddings, provider, model, duration, inputCount, success)
+}
+
+// GetCompletionStats returns completion statistics for a provider. The
+// "models" entry holds the same statistics per model.
+func (m *DefaultLLMMetrics) GetCompletionStats(provider LLMProvider) map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return statsFor(m.completions[provider], "total_tokens")
+}
+
+// GetEmbeddingStats returns embedding statistics for a provider
+func (m *DefaultLLMMetrics) GetEmbeddingStats(provider LLMProvider) map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return statsFor(m.embeddings[provider], "total_inputs")
+}
+
+// AverageLatency returns the moving average latency of successful completions
+// for a provider and model, or for the whole provider when model is empty
+func (m *DefaultLLMMetrics) AverageLatency(provider LLMProvider, model string) (time.Duration, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	models := m.completions[provider]
+	if model != "" {
+		if stats, exists := models[model]; exists && stats.AverageLatency > 0 {
+			return stats.AverageLatency, true
+		}
+		return 0, false
+	}
+
+	total := mergeStats(models)
+	return total.AverageLatency, total.AverageLatency > 0
+}
+
+// Helper functions
+
+func record(stats map[LLMProvider]map[string]*operationStats, provider LLMProvider, model string, duration time.Duration, units int, success bool) {
+	if stats[provider] == nil {
+		stats[provider] = make(map[string]*operationStats)
+	}
+	entry, exists := stats[provider][model]
+	if !exists {
+		entry = &operationStats{}
+		stats[provider][model] = entry
+	}
+
+	entry.Total++
+	entry.Units += int64(units)
+	entry.TotalDuration += duration
+	if !success {
+		entry.Failed++
+		entry.LastFailure = time.Now()
+		return
+	}
+
+	entry.Successful++
+	if entry.AverageLatency == 0 {
+		entry.AverageLatency = duration
+	} else {
+		entry.AverageLatency = time.Duration(latencySmoothing*float64(duration) + (1-latencySmoothing)*float64(entry.AverageLatency))
+	}
+}
+
+func mergeStats(models map[string]*operationStats) operationStats {
+	var total operationStats
+	var weightedLatency float64
+	for _, stats := range models {
+		total.Total += stats.Total
+		total.Successful += stats.Successful
+		total.Failed += stats.Failed
+		total.Units += stats.Units
+		total.TotalDuration += stats.TotalDuration
+		weightedLatency += float64(stats.AverageLatency) * float64(stats.Successful)
+		if stats.LastFailure.After(total.LastFailure) {
+			total.LastFailure = stats.LastFailure
+		}
+	}
+	if total.Successful > 0 {
+		total.AverageLatency = time.Duration(weightedLatency / float64(total.Successful))
+	}
+	return total
+}
+
+func statsFor(models map[string]*operationStats, unitsKey string) map[string]interface{} {
+	toMap := func(stats operationStats) map[string]interface{} {
+		successRate := 0.0
+		if stats.Total > 0 {
+			successRate = float64(stats.Successful) / float64(stats.Total)
+		}
+		return map[string]interface{}{
+			"total_requests":      stats.Total,
+			"successful_requests": stats.Successful,
+			"failed_requests":     stats.Failed,
+			"success_rate":        successRate,
+			"average_latency":     stats.AverageLatency,
+			"total_duration":      stats.TotalDuration,
+			unitsKey:              stats.Units,
+		}
+	}
+
+	result := toMap(mergeStats(models))
+	perModel := make(map[string]interface{}, len(models))
+	for model, stats := range models {
+		perModel[model] = toMap(*stats)
+	}
+	result["models"] = perModel
+
+	return result
+}
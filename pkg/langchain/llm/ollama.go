@@ -25,15 +25,38 @@ type OllamaLLM struct {
 
 // OllamaMessage represents an Ollama API message
 type OllamaMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []OllamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+// OllamaToolCall represents a tool call in an Ollama message. Ollama sends
+// each call whole, with the arguments as a JSON object.
+type OllamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// OllamaTool represents a tool definition in an Ollama request
+type OllamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description,omitempty"`
+		Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	} `json:"function"`
 }
 
 // OllamaChatRequest represents an Ollama chat request
 type OllamaChatRequest struct {
 	Model    string                 `json:"model"`
 	Messages []OllamaMessage        `json:"messages"`
-	Stream   bool                   `json:"stream,omitempty"`
+	Tools    []OllamaTool           `json:"tools,omitempty"`
+	Format   interface{}            `json:"format,omitempty"` // "json" or a JSON Schema
+	Stream   bool                   `json:"stream"`
 	Options  map[string]interface{} `json:"options,omitempty"`
 }
 
@@ -43,6 +66,7 @@ type OllamaChatResponse struct {
 	CreatedAt          time.Time     `json:"created_at"`
 	Message            OllamaMessage `json:"message"`
 	Done               bool          `json:"done"`
+	DoneReason         string        `json:"done_reason,omitempty"`
 	TotalDuration      int64         `json:"total_duration,omitempty"`
 	LoadDuration       int64         `json:"load_duration,omitempty"`
 	PromptEvalCount    int           `json:"prompt_eval_count,omitempty"`
@@ -117,7 +141,11 @@ func (o *OllamaLLM) Complete(ctx context.Context, req *CompletionRequest) (*Comp
 	)
 
 	// Convert to Ollama format
-	ollamaReq := o.convertToOllamaRequest(req)
+	ollamaReq, err := o.convertToOllamaRequest(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
 
 	// Make API request
 	respBody, err := o.makeRequest(ctx, "/api/chat", ollamaReq)
@@ -156,7 +184,11 @@ func (o *OllamaLLM) Stream(ctx context.Context, req *CompletionRequest) (<-chan
 		defer close(responseCh)
 
 		// Convert to Ollama format with streaming enabled
-		ollamaReq := o.convertToOllamaRequest(req)
+		ollamaReq, err := o.convertToOllamaRequest(req)
+		if err != nil {
+			responseCh <- StreamResponse{Error: err}
+			return
+		}
 		ollamaReq.Stream = true
 
 		// Make streaming request
@@ -167,6 +199,12 @@ func (o *OllamaLLM) Stream(ctx context.Context, req *CompletionRequest) (<-chan
 		}
 		defer resp.Body.Close()
 
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			responseCh <- StreamResponse{Error: newStatusError(ProviderOllama, resp, body)}
+			return
+		}
+
 		// Process streaming response
 		o.processStreamingResponse(resp.Body, responseCh)
 	}()
@@ -248,7 +286,7 @@ func (o *OllamaLLM) GetModels(ctx context.Context) ([]string, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newStatusError(ProviderOllama, resp, body)
 	}
 
 	var modelsResp OllamaModelsResponse
@@ -288,13 +326,44 @@ func (o *OllamaLLM) Close() error {
 
 // Helper methods
 
-func (o *OllamaLLM) convertToOllamaRequest(req *CompletionRequest) *OllamaChatRequest {
+// convertToOllamaRequest converts a request to Ollama format. Ollama has no
+// tool choice setting, so ToolChoice is ignored apart from "none", which
+// leaves the tools out.
+func (o *OllamaLLM) convertToOllamaRequest(req *CompletionRequest) (*OllamaChatRequest, error) {
 	messages := make([]OllamaMessage, len(req.Messages))
 	for i, msg := range req.Messages {
 		messages[i] = OllamaMessage{
 			Role:    msg.Role,
 			Content: msg.Content,
 		}
+		if msg.Role == "tool" {
+			messages[i].ToolName = msg.Name
+		}
+		for _, call := range msg.ToolCalls {
+			arguments := call.Arguments
+			if arguments == "" {
+				arguments = "{}"
+			}
+			if !json.Valid([]byte(arguments)) {
+				return nil, fmt.Errorf("tool call %s has invalid JSON arguments", call.Name)
+			}
+
+			var toolCall OllamaToolCall
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = json.RawMessage(arguments)
+			messages[i].ToolCalls = append(messages[i].ToolCalls, toolCall)
+		}
+	}
+
+	tools := make([]OllamaTool, 0, len(req.Tools))
+	if req.ToolChoice != ToolChoiceNone {
+		for _, definition := range req.Tools {
+			tool := OllamaTool{Type: "function"}
+			tool.Function.Name = definition.Name
+			tool.Function.Description = definition.Description
+			tool.Function.Parameters = definition.Parameters
+			tools = append(tools, tool)
+		}
 	}
 
 	model := req.Model
@@ -313,19 +382,28 @@ func (o *OllamaLLM) convertToOllamaRequest(req *CompletionRequest) *OllamaChatRe
 		options["top_p"] = req.TopP
 	}
 
-	return &OllamaChatRequest{
+	ollamaReq := &OllamaChatRequest{
 		Model:    model,
 		Messages: messages,
 		Stream:   req.Stream,
 		Options:  options,
 	}
+	if len(tools) > 0 {
+		ollamaReq.Tools = tools
+	}
+	if req.ResponseSchema != nil {
+		ollamaReq.Format = req.ResponseSchema.Schema
+	}
+
+	return ollamaReq, nil
 }
 
 func (o *OllamaLLM) convertFromOllamaResponse(resp *OllamaChatResponse) *CompletionResponse {
 	return &CompletionResponse{
-		ID:      fmt.Sprintf("ollama-%d", time.Now().Unix()),
-		Content: resp.Message.Content,
-		Model:   resp.Model,
+		ID:        fmt.Sprintf("ollama-%d", time.Now().Unix()),
+		Content:   resp.Message.Content,
+		Model:     resp.Model,
+		ToolCalls: convertOllamaToolCalls(resp.Message.ToolCalls, 0),
 		Usage: TokenUsage{
 			PromptTokens:     resp.PromptEvalCount,
 			CompletionTokens: resp.EvalCount,
@@ -334,6 +412,28 @@ func (o *OllamaLLM) convertFromOllamaResponse(resp *OllamaChatResponse) *Complet
 	}
 }
 
+// convertOllamaToolCalls converts Ollama tool calls, which carry no IDs, giving
+// each an ID from its position in the response starting at offset
+func convertOllamaToolCalls(calls []OllamaToolCall, offset int) []ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	toolCalls := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		arguments := string(call.Function.Arguments)
+		if arguments == "" || arguments == "null" {
+			arguments = "{}"
+		}
+		toolCalls[i] = ToolCall{
+			ID:        fmt.Sprintf("call_%d", offset+i),
+			Name:      call.Function.Name,
+			Arguments: arguments,
+		}
+	}
+	return toolCalls
+}
+
 func (o *OllamaLLM) makeRequest(ctx context.Context, endpoint string, payload interface{}) ([]byte, error) {
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
@@ -359,7 +459,7 @@ func (o *OllamaLLM) makeRequest(ctx context.Context, endpoint string, payload in
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newStatusError(ProviderOllama, resp, body)
 	}
 
 	return body, nil
@@ -383,6 +483,8 @@ func (o *OllamaLLM) makeStreamingRequest(ctx context.Context, endpoint string, p
 
 func (o *OllamaLLM) processStreamingResponse(body io.Reader, responseCh chan<- StreamResponse) {
 	decoder := json.NewDecoder(body)
+	toolCalls := NewToolCallAccumulator()
+	callCount := 0
 
 	for {
 		var resp OllamaChatResponse
@@ -393,12 +495,32 @@ func (o *OllamaLLM) processStreamingResponse(body io.Reader, responseCh chan<- S
 			break
 		}
 
-		responseCh <- StreamResponse{
+		chunk := StreamResponse{
 			ID:      fmt.Sprintf("ollama-%d", time.Now().Unix()),
 			Content: resp.Message.Content,
 			Done:    resp.Done,
 		}
 
+		// Tool calls arrive whole, so each becomes a single complete delta
+		for _, call := range convertOllamaToolCalls(resp.Message.ToolCalls, callCount) {
+			delta := ToolCallDelta{Index: callCount, ID: call.ID, Name: call.Name, ArgumentsDelta: call.Arguments}
+			toolCalls.Add(delta)
+			chunk.ToolCallDeltas = append(chunk.ToolCallDeltas, delta)
+			callCount++
+		}
+
+		if resp.Done {
+			chunk.ToolCalls = toolCalls.ToolCalls()
+			chunk.FinishReason = resp.DoneReason
+			chunk.Usage = &TokenUsage{
+				PromptTokens:     resp.PromptEvalCount,
+				CompletionTokens: resp.EvalCount,
+				TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+			}
+		}
+
+		responseCh <- chunk
+
 		if resp.Done {
 			break
 		}
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOllama(t *testing.T, handler http.HandlerFunc) LLM {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	llm, err := NewOllamaLLM(&LLMConfig{BaseURL: server.URL}, newTestLogger())
+	require.NoError(t, err)
+	return llm
+}
+
+func TestOllamaToolCalls(t *testing.T) {
+	var received OllamaChatRequest
+	llm := newTestOllama(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprint(w, `{"model": "llama3.1", "message": {"role": "assistant", "content": "", "tool_calls": [
+			{"function": {"name": "get_weather", "arguments": {"city": "Kyiv"}}}
+		]}, "done": true, "done_reason": "stop", "prompt_eval_count": 12, "eval_count": 4}`)
+	})
+
+	previousCall := ToolCall{ID: "call_0", Name: "get_weather", Arguments: `{"city":"Lviv"}`}
+	resp, err := llm.Complete(context.Background(), &CompletionRequest{
+		Messages: []Message{
+			{Role: "user", Content: "Weather in Lviv and Kyiv?"},
+			{Role: "assistant", ToolCalls: []ToolCall{previousCall}},
+			NewToolResultMessage(previousCall, "sunny"),
+		},
+		Tools: []ToolDefinition{weatherTool},
+	})
+	require.NoError(t, err)
+
+	assert.False(t, received.Stream)
+	require.Len(t, received.Tools, 1)
+	assert.Equal(t, "get_weather", received.Tools[0].Function.Name)
+	assert.JSONEq(t, `{"city":"Lviv"}`, string(received.Messages[1].ToolCalls[0].Function.Arguments))
+	assert.Equal(t, "get_weather", received.Messages[2].ToolName)
+
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "get_weather", resp.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"city":"Kyiv"}`, resp.ToolCalls[0].Arguments)
+
+	_, err = llm.Complete(context.Background(), &CompletionRequest{
+		Messages: []Message{{Role: "assistant", ToolCalls: []ToolCall{{Name: "get_weather", Arguments: "{not json"}}}},
+	})
+	assert.Error(t, err)
+}
+
+func TestOllamaStreamToolCalls(t *testing.T) {
+	llm := newTestOllama(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "get_weather", "arguments": {"city": "Kyiv"}}}]}, "done": false}`)
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": ""}, "done": true, "done_reason": "stop", "prompt_eval_count": 5, "eval_count": 3}`)
+	})
+
+	stream, err := llm.Stream(context.Background(), &CompletionRequest{
+		Messages: []Message{{Role: "user", Content: "Weather in Kyiv?"}},
+		Tools:    []ToolDefinition{weatherTool},
+	})
+	require.NoError(t, err)
+
+	var deltas []ToolCallDelta
+	var last StreamResponse
+	for chunk := range stream {
+		require.NoError(t, chunk.Error)
+		deltas = append(deltas, chunk.ToolCallDeltas...)
+		last = chunk
+	}
+
+	require.Len(t, deltas, 1)
+	assert.Equal(t, "call_0", deltas[0].ID)
+	assert.True(t, last.Done)
+	require.Len(t, last.ToolCalls, 1)
+	assert.JSONEq(t, `{"city":"Kyiv"}`, last.ToolCalls[0].Arguments)
+	assert.Equal(t, &TokenUsage{PromptTokens: 5, CompletionTokens: 3, TotalTokens: 8}, last.Usage)
+}
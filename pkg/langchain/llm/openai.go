@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -26,19 +27,55 @@ type OpenAILLM struct {
 
 // OpenAIMessage represents an OpenAI API message
 type OpenAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Name       string           `json:"name,omitempty"`
+}
+
+// OpenAITool represents a tool definition in an OpenAI request
+type OpenAITool struct {
+	Type     string         `json:"type"`
+	Function OpenAIFunction `json:"function"`
+}
+
+// OpenAIFunction represents a function tool definition
+type OpenAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// OpenAIToolCall represents a tool call in an OpenAI message or stream delta
+type OpenAIToolCall struct {
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 // OpenAICompletionRequest represents an OpenAI completion request
 type OpenAICompletionRequest struct {
-	Model       string          `json:"model"`
-	Messages    []OpenAIMessage `json:"messages"`
-	MaxTokens   int             `json:"max_tokens,omitempty"`
-	Temperature float64         `json:"temperature,omitempty"`
-	TopP        float64         `json:"top_p,omitempty"`
-	Stop        []string        `json:"stop,omitempty"`
-	Stream      bool            `json:"stream,omitempty"`
+	Model          string               `json:"model"`
+	Messages       []OpenAIMessage      `json:"messages"`
+	MaxTokens      int                  `json:"max_tokens,omitempty"`
+	Temperature    float64              `json:"temperature,omitempty"`
+	TopP           float64              `json:"top_p,omitempty"`
+	Stop           []string             `json:"stop,omitempty"`
+	Stream         bool                 `json:"stream,omitempty"`
+	StreamOptions  *OpenAIStreamOptions `json:"stream_options,omitempty"`
+	Tools          []OpenAITool         `json:"tools,omitempty"`
+	ToolChoice     interface{}          `json:"tool_choice,omitempty"`
+	ResponseFormat interface{}          `json:"response_format,omitempty"`
+}
+
+// OpenAIStreamOptions represents the options of a streaming request
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // OpenAICompletionResponse represents an OpenAI completion response
@@ -50,8 +87,9 @@ type OpenAICompletionResponse struct {
 	Choices []struct {
 		Index   int `json:"index"`
 		Message struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
+			Role      string           `json:"role"`
+			Content   string           `json:"content"`
+			ToolCalls []OpenAIToolCall `json:"tool_calls"`
 		} `json:"message"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
@@ -62,6 +100,26 @@ type OpenAICompletionResponse struct {
 	} `json:"usage"`
 }
 
+// OpenAIStreamChunk represents a chunk of an OpenAI streaming response
+type OpenAIStreamChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Role      string           `json:"role"`
+			Content   string           `json:"content"`
+			ToolCalls []OpenAIToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage,omitempty"`
+}
+
 // OpenAIEmbeddingRequest represents an OpenAI embedding request
 type OpenAIEmbeddingRequest struct {
 	Input []string `json:"input"`
@@ -164,8 +222,9 @@ func (o *OpenAILLM) Stream(ctx context.Context, req *CompletionRequest) (<-chan
 		defer close(responseCh)
 
 		// Convert to OpenAI format with streaming enabled
-		openaiReq := o.convertToOpenAIRequest(req)
-		openaiReq.Stream = true
+		streamReq := *req
+		streamReq.Stream = true
+		openaiReq := o.convertToOpenAIRequest(&streamReq)
 
 		// Make streaming request
 		resp, err := o.makeStreamingRequest(ctx, "/chat/completions", openaiReq)
@@ -175,6 +234,12 @@ func (o *OpenAILLM) Stream(ctx context.Context, req *CompletionRequest) (<-chan
 		}
 		defer resp.Body.Close()
 
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			responseCh <- StreamResponse{Error: newStatusError(ProviderOpenAI, resp, body)}
+			return
+		}
+
 		// Process streaming response
 		o.processStreamingResponse(resp.Body, responseCh)
 	}()
@@ -273,8 +338,18 @@ func (o *OpenAILLM) convertToOpenAIRequest(req *CompletionRequest) *OpenAIComple
 	messages := make([]OpenAIMessage, len(req.Messages))
 	for i, msg := range req.Messages {
 		messages[i] = OpenAIMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+		}
+		if msg.Role == "tool" {
+			messages[i].Name = msg.Name
+		}
+		for _, call := range msg.ToolCalls {
+			toolCall := OpenAIToolCall{ID: call.ID, Type: "function"}
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = call.Arguments
+			messages[i].ToolCalls = append(messages[i].ToolCalls, toolCall)
 		}
 	}
 
@@ -283,7 +358,7 @@ func (o *OpenAILLM) convertToOpenAIRequest(req *CompletionRequest) *OpenAIComple
 		model = o.config.Model
 	}
 
-	return &OpenAICompletionRequest{
+	openaiReq := &OpenAICompletionRequest{
 		Model:       model,
 		Messages:    messages,
 		MaxTokens:   req.MaxTokens,
@@ -292,24 +367,77 @@ func (o *OpenAILLM) convertToOpenAIRequest(req *CompletionRequest) *OpenAIComple
 		Stop:        req.Stop,
 		Stream:      req.Stream,
 	}
-}
 
-func (o *OpenAILLM) convertFromOpenAIResponse(resp *OpenAICompletionResponse) *CompletionResponse {
-	content := ""
-	if len(resp.Choices) > 0 {
-		content = resp.Choices[0].Message.Content
+	// Streams only report token usage, which budgets are charged from, when asked to
+	if req.Stream {
+		openaiReq.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
 	}
 
-	return &CompletionResponse{
-		ID:      resp.ID,
-		Content: content,
-		Model:   resp.Model,
+	for _, tool := range req.Tools {
+		openaiReq.Tools = append(openaiReq.Tools, OpenAITool{
+			Type: "function",
+			Function: OpenAIFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	switch req.ToolChoice {
+	case "":
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		openaiReq.ToolChoice = req.ToolChoice
+	default:
+		openaiReq.ToolChoice = map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": req.ToolChoice},
+		}
+	}
+
+	if req.ResponseSchema != nil {
+		name := req.ResponseSchema.Name
+		if name == "" {
+			name = "response"
+		}
+		openaiReq.ResponseFormat = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   name,
+				"schema": req.ResponseSchema.Schema,
+				"strict": req.ResponseSchema.Strict,
+			},
+		}
+	}
+
+	return openaiReq
+}
+
+func (o *OpenAILLM) convertFromOpenAIResponse(resp *OpenAICompletionResponse) *CompletionResponse {
+	response := &CompletionResponse{
+		ID:    resp.ID,
+		Model: resp.Model,
 		Usage: TokenUsage{
 			PromptTokens:     resp.Usage.PromptTokens,
 			CompletionTokens: resp.Usage.CompletionTokens,
 			TotalTokens:      resp.Usage.TotalTokens,
 		},
 	}
+
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		response.Content = choice.Message.Content
+		for _, call := range choice.Message.ToolCalls {
+			response.ToolCalls = append(response.ToolCalls, ToolCall{
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			})
+		}
+		response.Metadata = map[string]interface{}{"finish_reason": choice.FinishReason}
+	}
+
+	return response
 }
 
 func (o *OpenAILLM) makeRequest(ctx context.Context, endpoint string, payload interface{}) ([]byte, error) {
@@ -338,7 +466,7 @@ func (o *OpenAILLM) makeRequest(ctx context.Context, endpoint string, payload in
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newStatusError(ProviderOpenAI, resp, body)
 	}
 
 	return body, nil
@@ -362,29 +490,97 @@ func (o *OpenAILLM) makeStreamingRequest(ctx context.Context, endpoint string, p
 	return o.httpClient.Do(req)
 }
 
+// processStreamingResponse reads server-sent events until the [DONE] marker,
+// forwarding content and tool call deltas as they arrive
 func (o *OpenAILLM) processStreamingResponse(body io.Reader, responseCh chan<- StreamResponse) {
-	// Simple streaming response processing
-	// In a real implementation, you would parse Server-Sent Events properly
-	buf := make([]byte, 4096)
-	for {
-		n, err := body.Read(buf)
-		if err != nil {
-			if err != io.EOF {
-				responseCh <- StreamResponse{Error: err}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		id           string
+		finishReason string
+		usage        *TokenUsage
+	)
+	toolCalls := NewToolCallAccumulator()
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			responseCh <- StreamResponse{
+				ID:           id,
+				Done:         true,
+				ToolCalls:    toolCalls.ToolCalls(),
+				FinishReason: finishReason,
+				Usage:        usage,
 			}
-			break
+			return
 		}
 
-		content := string(buf[:n])
-		if strings.Contains(content, "data: [DONE]") {
-			responseCh <- StreamResponse{Done: true}
-			break
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			responseCh <- StreamResponse{ID: id, Error: fmt.Errorf("failed to parse OpenAI stream chunk: %w", err)}
+			return
 		}
 
-		// Parse and send chunk
-		responseCh <- StreamResponse{
-			Content: content,
-			Done:    false,
+		if chunk.ID != "" {
+			id = chunk.ID
 		}
+		if chunk.Usage != nil {
+			usage = &TokenUsage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		choice := chunk.Choices[0]
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+
+		deltas := make([]ToolCallDelta, 0, len(choice.Delta.ToolCalls))
+		for i, call := range choice.Delta.ToolCalls {
+			delta := ToolCallDelta{
+				Index:          i,
+				ID:             call.ID,
+				Name:           call.Function.Name,
+				ArgumentsDelta: call.Function.Arguments,
+			}
+			if call.Index != nil {
+				delta.Index = *call.Index
+			}
+			toolCalls.Add(delta)
+			deltas = append(deltas, delta)
+		}
+
+		if choice.Delta.Content != "" || len(deltas) > 0 {
+			chunkResponse := StreamResponse{ID: id, Content: choice.Delta.Content}
+			if len(deltas) > 0 {
+				chunkResponse.ToolCallDeltas = deltas
+			}
+			responseCh <- chunkResponse
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		responseCh <- StreamResponse{ID: id, Error: err}
+		return
+	}
+
+	// The stream ended without a [DONE] marker
+	responseCh <- StreamResponse{
+		ID:           id,
+		Done:         true,
+		ToolCalls:    toolCalls.ToolCalls(),
+		FinishReason: finishReason,
+		Usage:        usage,
 	}
 }
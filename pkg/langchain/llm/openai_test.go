@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var weatherTool = ToolDefinition{
+	Name:        "get_weather",
+	Description: "Get the current weather for a city",
+	Parameters: map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+		"required":   []interface{}{"city"},
+	},
+}
+
+func newTestOpenAI(t *testing.T, handler http.HandlerFunc) LLM {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	llm, err := NewOpenAILLM(&LLMConfig{APIKey: "test-key", BaseURL: server.URL}, newTestLogger())
+	require.NoError(t, err)
+	return llm
+}
+
+func TestOpenAIToolCalls(t *testing.T) {
+	var received map[string]interface{}
+	llm := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprint(w, `{
+			"id": "chatcmpl-1", "model": "gpt-4",
+			"choices": [{"message": {"role": "assistant", "content": "", "tool_calls": [
+				{"id": "call_abc", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Kyiv\"}"}}
+			]}, "finish_reason": "tool_calls"}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 10, "total_tokens": 30}
+		}`)
+	})
+
+	previousCall := ToolCall{ID: "call_prev", Name: "get_weather", Arguments: `{"city":"Lviv"}`}
+	resp, err := llm.Complete(context.Background(), &CompletionRequest{
+		Messages: []Message{
+			{Role: "user", Content: "Weather in Lviv and Kyiv?"},
+			{Role: "assistant", ToolCalls: []ToolCall{previousCall}},
+			NewToolResultMessage(previousCall, "sunny"),
+		},
+		Tools:      []ToolDefinition{weatherTool},
+		ToolChoice: "get_weather",
+	})
+	require.NoError(t, err)
+
+	tools := received["tools"].([]interface{})
+	require.Len(t, tools, 1)
+	assert.Equal(t, "get_weather", tools[0].(map[string]interface{})["function"].(map[string]interface{})["name"])
+	assert.Equal(t, map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": "get_weather"}}, received["tool_choice"])
+	assert.NotContains(t, received, "stream_options")
+
+	messages := received["messages"].([]interface{})
+	assistant := messages[1].(map[string]interface{})
+	assert.Equal(t, "call_prev", assistant["tool_calls"].([]interface{})[0].(map[string]interface{})["id"])
+	toolResult := messages[2].(map[string]interface{})
+	assert.Equal(t, "tool", toolResult["role"])
+	assert.Equal(t, "call_prev", toolResult["tool_call_id"])
+
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, ToolCall{ID: "call_abc", Name: "get_weather", Arguments: `{"city":"Kyiv"}`}, resp.ToolCalls[0])
+	assert.Equal(t, "tool_calls", resp.Metadata["finish_reason"])
+
+	var args struct {
+		City string `json:"city"`
+	}
+	require.NoError(t, resp.ToolCalls[0].DecodeArguments(&args))
+	assert.Equal(t, "Kyiv", args.City)
+}
+
+func TestOpenAIStreamToolCallDeltas(t *testing.T) {
+	var received map[string]interface{}
+	llm := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"id": "c1", "choices": [{"delta": {"role": "assistant", "tool_calls": [{"index": 0, "id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": ""}}]}}]}`,
+			`{"id": "c1", "choices": [{"delta": {"tool_calls": [{"index": 0, "function": {"arguments": "{\"ci"}}]}}]}`,
+			`{"id": "c1", "choices": [{"delta": {"tool_calls": [{"index": 0, "function": {"arguments": "ty\":\"Kyiv\"}"}}]}}]}`,
+			`{"id": "c1", "choices": [{"delta": {}, "finish_reason": "tool_calls"}]}`,
+			`{"id": "c1", "choices": [], "usage": {"prompt_tokens": 12, "completion_tokens": 8, "total_tokens": 20}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	stream, err := llm.Stream(context.Background(), &CompletionRequest{
+		Messages: []Message{{Role: "user", Content: "Weather in Kyiv?"}},
+		Tools:    []ToolDefinition{weatherTool},
+	})
+	require.NoError(t, err)
+
+	var deltas []ToolCallDelta
+	var last StreamResponse
+	for chunk := range stream {
+		require.NoError(t, chunk.Error)
+		deltas = append(deltas, chunk.ToolCallDeltas...)
+		last = chunk
+	}
+
+	require.Len(t, deltas, 3)
+	assert.Equal(t, "get_weather", deltas[0].Name)
+	assert.Equal(t, `{"ci`, deltas[1].ArgumentsDelta)
+	assert.True(t, last.Done)
+	assert.Equal(t, "tool_calls", last.FinishReason)
+	assert.Equal(t, []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"Kyiv"}`}}, last.ToolCalls)
+
+	// Usage is only sent when requested, in a final chunk without choices
+	assert.Equal(t, true, received["stream"])
+	assert.Equal(t, map[string]interface{}{"include_usage": true}, received["stream_options"])
+	assert.Equal(t, &TokenUsage{PromptTokens: 12, CompletionTokens: 8, TotalTokens: 20}, last.Usage)
+}
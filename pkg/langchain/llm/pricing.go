@@ -0,0 +1,132 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModelPrice is the price of a model in US dollars per million tokens
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million" yaml:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million" yaml:"output_per_million"`
+}
+
+// Cost returns the price of the given token usage
+func (p ModelPrice) Cost(usage TokenUsage) float64 {
+	return (float64(usage.PromptTokens)*p.InputPerMillion + float64(usage.CompletionTokens)*p.OutputPerMillion) / 1e6
+}
+
+// PriceTable maps model names to prices. Lookups fall back to the longest
+// entry the model name starts with, so dated snapshots such as
+// "gpt-4o-2024-08-06" use the "gpt-4o" price.
+type PriceTable struct {
+	prices map[string]ModelPrice
+	mu     sync.RWMutex
+}
+
+// NewPriceTable creates a price table from the given prices
+func NewPriceTable(prices map[string]ModelPrice) *PriceTable {
+	table := &PriceTable{prices: make(map[string]ModelPrice, len(prices))}
+	for model, price := range prices {
+		table.prices[model] = price
+	}
+	return table
+}
+
+// DefaultPriceTable returns list prices of common hosted models. Prices
+// change, so deployments should load their own table with LoadPriceTable.
+func DefaultPriceTable() *PriceTable {
+	return NewPriceTable(map[string]ModelPrice{
+		"gpt-4o":                 {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+		"gpt-4o-mini":            {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+		"gpt-4-turbo":            {InputPerMillion: 10.00, OutputPerMillion: 30.00},
+		"gpt-4":                  {InputPerMillion: 30.00, OutputPerMillion: 60.00},
+		"gpt-3.5-turbo":          {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+		"claude-3-5-sonnet":      {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+		"claude-3-5-haiku":       {InputPerMillion: 0.80, OutputPerMillion: 4.00},
+		"claude-3-opus":          {InputPerMillion: 15.00, OutputPerMillion: 75.00},
+		"claude-3-sonnet":        {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+		"claude-3-haiku":         {InputPerMillion: 0.25, OutputPerMillion: 1.25},
+		"claude-2":               {InputPerMillion: 8.00, OutputPerMillion: 24.00},
+		"claude-instant":         {InputPerMillion: 0.80, OutputPerMillion: 2.40},
+		"gemini-1.5-pro":         {InputPerMillion: 1.25, OutputPerMillion: 5.00},
+		"gemini-1.5-flash":       {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+		"gemini-1.0-pro":         {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+		"gemini-pro":             {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+		"text-embedding-3-small": {InputPerMillion: 0.02},
+		"text-embedding-3-large": {InputPerMillion: 0.13},
+		"text-embedding-ada-002": {InputPerMillion: 0.10},
+		"text-embedding-004":     {},
+	})
+}
+
+// LoadPriceTable reads a YAML file mapping model names to prices
+func LoadPriceTable(path string) (*PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	var prices map[string]ModelPrice
+	if err := yaml.Unmarshal(data, &prices); err != nil {
+		return nil, fmt.Errorf("failed to parse price table: %w", err)
+	}
+
+	return NewPriceTable(prices), nil
+}
+
+// SetPrice sets the price of a model
+func (t *PriceTable) SetPrice(model string, price ModelPrice) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prices[model] = price
+}
+
+// GetPrice returns the price of a model
+func (t *PriceTable) GetPrice(model string) (ModelPrice, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if price, exists := t.prices[model]; exists {
+		return price, true
+	}
+
+	best := ""
+	for name := range t.prices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return t.prices[best], true
+}
+
+// Cost returns the price of the token usage on a model. Unknown models cost
+// nothing and report false.
+func (t *PriceTable) Cost(model string, usage TokenUsage) (float64, bool) {
+	price, exists := t.GetPrice(model)
+	if !exists {
+		return 0, false
+	}
+	return price.Cost(usage), true
+}
+
+// Models returns the models in the table in name order
+func (t *PriceTable) Models() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	models := make([]string, 0, len(t.prices))
+	for model := range t.prices {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
@@ -0,0 +1,509 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RoutingStrategy decides the order in which managed LLMs are tried
+type RoutingStrategy string
+
+const (
+	// RoutingStrategyPriority tries the fallback order, or only the default LLM when none is set
+	RoutingStrategyPriority RoutingStrategy = "priority"
+	// RoutingStrategyLatency tries LLMs with the lowest recorded average latency first
+	RoutingStrategyLatency RoutingStrategy = "latency"
+	// RoutingStrategyCost tries LLMs with the lowest cost tier first
+	RoutingStrategyCost RoutingStrategy = "cost"
+)
+
+// Capabilities an LLM can declare in its route options
+const (
+	CapabilityChat       = "chat"
+	CapabilityTools      = "tools"
+	CapabilityStreaming  = "streaming"
+	CapabilityEmbeddings = "embeddings"
+	CapabilityVision     = "vision"
+	CapabilityJSON       = "json"
+)
+
+// CompletionRequest.Metadata keys read by the LLM manager for per-request routing
+const (
+	RouteLLMKey          = "route_llm"           // string: pin the request to a named LLM
+	RouteCapabilitiesKey = "route_capabilities"  // []string: capabilities the LLM must declare
+	RouteMaxCostTierKey  = "route_max_cost_tier" // CostTier or int: most expensive tier allowed
+)
+
+// CostTier ranks LLMs by price, cheapest first
+type CostTier int
+
+const (
+	CostTierUnknown CostTier = iota
+	CostTierLow
+	CostTierMedium
+	CostTierHigh
+)
+
+// ErrCircuitOpen is returned when every candidate LLM is behind an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// ErrNoRoute is returned when no managed LLM satisfies the routing constraints
+var ErrNoRoute = errors.New("no LLM matches the routing constraints")
+
+// LLMRouteOptions describes a managed LLM for routing decisions
+type LLMRouteOptions struct {
+	Model        string   `json:"model,omitempty"`        // Model used for latency lookups in LLMMetrics
+	Capabilities []string `json:"capabilities,omitempty"` // Empty means the LLM is assumed capable of anything
+	CostTier     CostTier `json:"cost_tier,omitempty"`
+}
+
+// HasCapability reports whether the LLM declares the capability
+func (o LLMRouteOptions) HasCapability(capability string) bool {
+	if len(o.Capabilities) == 0 {
+		return true
+	}
+	for _, declared := range o.Capabilities {
+		if declared == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// CircuitBreakerConfig configures the per-provider circuit breakers
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `json:"failure_threshold"` // Consecutive failures that open the circuit; 0 disables
+	OpenTimeout      time.Duration `json:"open_timeout"`      // Time before a half-open trial request is allowed
+}
+
+// RoutingConfig configures how the LLM manager routes and falls back
+type RoutingConfig struct {
+	Strategy RoutingStrategy `json:"strategy"`
+
+	// FallbackOrder lists LLM names in the order they are tried. With the
+	// latency and cost strategies it restricts the candidates; when empty
+	// those strategies consider every managed LLM.
+	FallbackOrder []string `json:"fallback_order,omitempty"`
+
+	// AttemptTimeout bounds each completion or embedding attempt so a slow
+	// provider falls through to the next one. Zero means no limit.
+	AttemptTimeout time.Duration `json:"attempt_timeout,omitempty"`
+
+	CircuitBreaker CircuitBreakerConfig `json:"circuit_breaker"`
+
+	// ShouldFallback classifies errors that move on to the next LLM.
+	// Defaults to IsFallbackError.
+	ShouldFallback func(error) bool `json:"-"`
+}
+
+// DefaultRoutingConfig returns the routing configuration used by a new manager
+func DefaultRoutingConfig() *RoutingConfig {
+	return &RoutingConfig{
+		Strategy: RoutingStrategyPriority,
+		CircuitBreaker: CircuitBreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      30 * time.Second,
+		},
+	}
+}
+
+// IsFallbackError reports whether another LLM should be tried after err:
+// retryable provider errors, timeouts and network failures
+func IsFallbackError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if IsRetryable(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// CircuitState is the state of a circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// circuitBreaker stops sending requests to a failing provider until it has
+// had time to recover, then lets a single trial request through
+type circuitBreaker struct {
+	config   CircuitBreakerConfig
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	mu       sync.Mutex
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{config: config, state: CircuitClosed}
+}
+
+// allow reports whether a request may be sent
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.config.FailureThreshold <= 0 {
+		return true
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.config.OpenTimeout {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// onSuccess closes the circuit
+func (b *circuitBreaker) onSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// onFailure counts a provider failure, opening the circuit at the threshold
+// or straight away when a half-open trial fails
+func (b *circuitBreaker) onFailure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.config.FailureThreshold <= 0 {
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = CircuitOpen
+		b.openedAt = now
+	}
+	b.probing = false
+}
+
+// release ends a trial request whose outcome says nothing about provider health
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *circuitBreaker) currentState() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// routeRequest holds the constraints a request places on LLM selection
+type routeRequest struct {
+	llm          string
+	capabilities []string
+	maxCostTier  CostTier
+}
+
+// routeForCompletion reads routing constraints from a completion request
+func routeForCompletion(req *CompletionRequest) routeRequest {
+	var route routeRequest
+
+	if req.Metadata != nil {
+		if name, ok := req.Metadata[RouteLLMKey].(string); ok {
+			route.llm = name
+		}
+
+		switch capabilities := req.Metadata[RouteCapabilitiesKey].(type) {
+		case []string:
+			route.capabilities = append(route.capabilities, capabilities...)
+		case []interface{}:
+			for _, capability := range capabilities {
+				if s, ok := capability.(string); ok {
+					route.capabilities = append(route.capabilities, s)
+				}
+			}
+		case string:
+			for _, capability := range strings.Split(capabilities, ",") {
+				if capability = strings.TrimSpace(capability); capability != "" {
+					route.capabilities = append(route.capabilities, capability)
+				}
+			}
+		}
+
+		switch tier := req.Metadata[RouteMaxCostTierKey].(type) {
+		case CostTier:
+			route.maxCostTier = tier
+		case int:
+			route.maxCostTier = CostTier(tier)
+		case float64:
+			route.maxCostTier = CostTier(tier)
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		route.capabilities = append(route.capabilities, CapabilityTools)
+	}
+
+	return route
+}
+
+// accepts reports whether an LLM with the given options satisfies the route
+func (r routeRequest) accepts(options LLMRouteOptions) bool {
+	for _, capability := range r.capabilities {
+		if !options.HasCapability(capability) {
+			return false
+		}
+	}
+	if r.maxCostTier != CostTierUnknown && options.CostTier > r.maxCostTier {
+		return false
+	}
+	return true
+}
+
+// latencyReporter is implemented by metrics collectors that track average latency
+type latencyReporter interface {
+	AverageLatency(provider LLMProvider, model string) (time.Duration, bool)
+}
+
+// averageLatency looks up the recorded latency of a provider and model
+func averageLatency(metrics LLMMetrics, provider LLMProvider, model string) (time.Duration, bool) {
+	if metrics == nil {
+		return 0, false
+	}
+	if reporter, ok := metrics.(latencyReporter); ok {
+		return reporter.AverageLatency(provider, model)
+	}
+
+	stats := metrics.GetCompletionStats(provider)
+	if model != "" {
+		models, _ := stats["models"].(map[string]interface{})
+		modelStats, _ := models[model].(map[string]interface{})
+		stats = modelStats
+	}
+	latency, ok := stats["average_latency"].(time.Duration)
+	return latency, ok && latency > 0
+}
+
+// sortCandidates orders candidate names for the latency and cost strategies.
+// The sort is stable so ties keep the fallback order.
+func sortCandidates(names []string, strategy RoutingStrategy, options map[string]LLMRouteOptions, llms map[string]LLM, metrics LLMMetrics) {
+	switch strategy {
+	case RoutingStrategyLatency:
+		latencies := make(map[string]time.Duration, len(names))
+		for _, name := range names {
+			if latency, ok := averageLatency(metrics, llms[name].GetProvider(), options[name].Model); ok {
+				latencies[name] = latency
+			}
+		}
+		sort.SliceStable(names, func(i, j int) bool {
+			li, iok := latencies[names[i]]
+			lj, jok := latencies[names[j]]
+			if iok != jok {
+				return iok // LLMs without measurements go last
+			}
+			return li < lj
+		})
+	case RoutingStrategyCost:
+		sort.SliceStable(names, func(i, j int) bool {
+			return costRank(options[names[i]].CostTier) < costRank(options[names[j]].CostTier)
+		})
+	}
+}
+
+// costRank places LLMs with an unknown cost tier after the known ones
+func costRank(tier CostTier) int {
+	if tier == CostTierUnknown {
+		return int(CostTierHigh) + 1
+	}
+	return int(tier)
+}
+
+// noRouteError describes why no candidate was tried
+func noRouteError(route routeRequest) error {
+	if route.llm != "" {
+		return fmt.Errorf("%w: LLM %s", ErrNoRoute, route.llm)
+	}
+	return fmt.Errorf("%w: capabilities %v, max cost tier %d", ErrNoRoute, route.capabilities, route.maxCostTier)
+}
+
+// routeCandidate is a managed LLM that may serve a request
+type routeCandidate struct {
+	name string
+	llm  LLM
+}
+
+// candidates returns the LLMs to try for a request, in order
+func (m *DefaultLLMManager) candidates(route routeRequest) ([]routeCandidate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.llms) == 0 {
+		return nil, fmt.Errorf("no default LLM configured")
+	}
+
+	if route.llm != "" {
+		llm, exists := m.llms[route.llm]
+		if !exists || !route.accepts(m.routes[route.llm]) {
+			return nil, noRouteError(route)
+		}
+		return []routeCandidate{{name: route.llm, llm: llm}}, nil
+	}
+
+	allNames := make([]string, 0, len(m.llms))
+	for name := range m.llms {
+		if name != m.defaultLLM {
+			allNames = append(allNames, name)
+		}
+	}
+	sort.Strings(allNames)
+	if m.defaultLLM != "" {
+		allNames = append([]string{m.defaultLLM}, allNames...)
+	}
+
+	var names []string
+	switch {
+	case len(m.routing.FallbackOrder) > 0:
+		names = m.routing.FallbackOrder
+	case m.routing.Strategy == RoutingStrategyPriority:
+		names = []string{m.defaultLLM}
+		if !route.accepts(m.routes[m.defaultLLM]) {
+			// The default cannot serve this request, so look for one that can
+			names = allNames
+		}
+	default:
+		names = allNames
+	}
+
+	seen := make(map[string]bool, len(names))
+	selected := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, exists := m.llms[name]; !exists || seen[name] || !route.accepts(m.routes[name]) {
+			continue
+		}
+		seen[name] = true
+		selected = append(selected, name)
+	}
+	if len(selected) == 0 {
+		return nil, noRouteError(route)
+	}
+
+	sortCandidates(selected, m.routing.Strategy, m.routes, m.llms, m.metrics)
+
+	candidates := make([]routeCandidate, len(selected))
+	for i, name := range selected {
+		candidates[i] = routeCandidate{name: name, llm: m.llms[name]}
+	}
+	return candidates, nil
+}
+
+// execute tries the candidates for a request in order until one succeeds or
+// fails with an error that should not fall back. It returns the name of the
+// LLM that produced the result. Attempts are bounded by the attempt timeout
+// when bounded is set.
+func (m *DefaultLLMManager) execute(ctx context.Context, route routeRequest, bounded bool, call func(ctx context.Context, name string, llm LLM) error) (string, error) {
+	candidates, err := m.candidates(route)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.RLock()
+	config := m.routing
+	m.mu.RUnlock()
+
+	shouldFallback := config.ShouldFallback
+	if shouldFallback == nil {
+		shouldFallback = IsFallbackError
+	}
+
+	var lastErr error
+	var lastName string
+	for _, candidate := range candidates {
+		provider := candidate.llm.GetProvider()
+		breaker := m.breakerFor(provider)
+		if !breaker.allow(time.Now()) {
+			m.logger.WithFields(logrus.Fields{
+				"name":     candidate.name,
+				"provider": provider,
+			}).Debug("Skipping LLM with open circuit breaker")
+			continue
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if bounded && config.AttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, config.AttemptTimeout)
+		}
+		err := call(attemptCtx, candidate.name, candidate.llm)
+		cancel()
+
+		switch {
+		case err == nil:
+			breaker.onSuccess()
+			return candidate.name, nil
+		case ctx.Err() != nil:
+			// The caller gave up, which says nothing about the provider
+			breaker.release()
+			return candidate.name, err
+		case !shouldFallback(err):
+			// The provider answered, so the error is the request's fault
+			breaker.onSuccess()
+			return candidate.name, err
+		}
+
+		breaker.onFailure(time.Now())
+		lastErr, lastName = err, candidate.name
+
+		m.logger.WithError(err).WithFields(logrus.Fields{
+			"name":     candidate.name,
+			"provider": provider,
+		}).Warn("LLM request failed, falling back to next candidate")
+	}
+
+	if lastErr == nil {
+		return "", fmt.Errorf("%w for all %d candidate LLMs", ErrCircuitOpen, len(candidates))
+	}
+	return lastName, fmt.Errorf("all candidate LLMs failed, last error from %s: %w", lastName, lastErr)
+}
+
+// breakerFor returns the circuit breaker of a provider, creating it on first use
+func (m *DefaultLLMManager) breakerFor(provider LLMProvider) *circuitBreaker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	breaker, exists := m.breakers[provider]
+	if !exists {
+		breaker = newCircuitBreaker(m.routing.CircuitBreaker)
+		m.breakers[provider] = breaker
+	}
+	return breaker
+}
+
+// modelFor returns the model declared in the route options of an LLM
+func (m *DefaultLLMManager) modelFor(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.routes[name].Model
+}
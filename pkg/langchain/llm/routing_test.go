@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routedLLM answers with its name, or fails with err, after an optional delay
+type routedLLM struct {
+	scriptedLLM
+	name     LLMProvider
+	err      error
+	delay    time.Duration
+	requests int
+}
+
+func (r *routedLLM) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	r.requests++
+	if r.delay > 0 {
+		select {
+		case <-time.After(r.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &CompletionResponse{Content: string(r.name), Model: string(r.name)}, nil
+}
+
+func (r *routedLLM) GetProvider() LLMProvider {
+	return r.name
+}
+
+func newRoutedManager(t *testing.T, config *RoutingConfig, llms ...*routedLLM) *DefaultLLMManager {
+	manager := NewLLMManager(nil, newTestLogger()).(*DefaultLLMManager)
+	for _, llm := range llms {
+		require.NoError(t, manager.AddLLM(string(llm.name), llm))
+	}
+	if config != nil {
+		require.NoError(t, manager.SetRoutingConfig(config))
+	}
+	return manager
+}
+
+func TestLLMManagerFallback(t *testing.T) {
+	overloaded := &ProviderError{Provider: "primary", StatusCode: 503, Message: "busy", Kind: ErrOverloaded}
+
+	t.Run("RetryableErrorFallsBack", func(t *testing.T) {
+		primary := &routedLLM{name: "primary", err: overloaded}
+		secondary := &routedLLM{name: "secondary"}
+		manager := newRoutedManager(t, &RoutingConfig{FallbackOrder: []string{"primary", "secondary"}}, primary, secondary)
+
+		resp, err := manager.Complete(context.Background(), &CompletionRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "secondary", resp.Content)
+		assert.Equal(t, "secondary", resp.Metadata["llm"])
+		assert.Equal(t, 1, primary.requests)
+	})
+
+	t.Run("InvalidRequestDoesNotFallBack", func(t *testing.T) {
+		primary := &routedLLM{name: "primary", err: &ProviderError{Provider: "primary", StatusCode: 400, Kind: ErrInvalidRequest}}
+		secondary := &routedLLM{name: "secondary"}
+		manager := newRoutedManager(t, &RoutingConfig{FallbackOrder: []string{"primary", "secondary"}}, primary, secondary)
+
+		_, err := manager.Complete(context.Background(), &CompletionRequest{})
+		assert.True(t, errors.Is(err, ErrInvalidRequest))
+		assert.Equal(t, 0, secondary.requests)
+	})
+
+	t.Run("AttemptTimeoutFallsBack", func(t *testing.T) {
+		slow := &routedLLM{name: "slow", delay: time.Second}
+		fast := &routedLLM{name: "fast"}
+		manager := newRoutedManager(t, &RoutingConfig{
+			FallbackOrder:  []string{"slow", "fast"},
+			AttemptTimeout: 20 * time.Millisecond,
+		}, slow, fast)
+
+		resp, err := manager.Complete(context.Background(), &CompletionRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "fast", resp.Content)
+	})
+
+	t.Run("AllFail", func(t *testing.T) {
+		primary := &routedLLM{name: "primary", err: overloaded}
+		secondary := &routedLLM{name: "secondary", err: overloaded}
+		manager := newRoutedManager(t, &RoutingConfig{FallbackOrder: []string{"primary", "secondary"}}, primary, secondary)
+
+		_, err := manager.Complete(context.Background(), &CompletionRequest{})
+		assert.True(t, errors.Is(err, ErrOverloaded))
+		assert.Contains(t, err.Error(), "last error from secondary")
+	})
+}
+
+func TestLLMManagerCircuitBreaker(t *testing.T) {
+	primary := &routedLLM{name: "primary", err: &ProviderError{Provider: "primary", StatusCode: 500, Kind: ErrProviderFailed}}
+	secondary := &routedLLM{name: "secondary"}
+	manager := newRoutedManager(t, &RoutingConfig{
+		FallbackOrder:  []string{"primary", "secondary"},
+		CircuitBreaker: CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond},
+	}, primary, secondary)
+
+	for i := 0; i < 4; i++ {
+		_, err := manager.Complete(context.Background(), &CompletionRequest{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, primary.requests, "open circuit skips the provider")
+	assert.Equal(t, CircuitOpen, manager.GetCircuitState("primary"))
+
+	// After the cooldown a trial request closes the circuit again
+	time.Sleep(60 * time.Millisecond)
+	primary.err = nil
+	resp, err := manager.Complete(context.Background(), &CompletionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "primary", resp.Content)
+	assert.Equal(t, CircuitClosed, manager.GetCircuitState("primary"))
+}
+
+func TestLLMManagerRouting(t *testing.T) {
+	cheap := &routedLLM{name: "cheap"}
+	smart := &routedLLM{name: "smart"}
+	manager := NewLLMManager(nil, newTestLogger()).(*DefaultLLMManager)
+	require.NoError(t, manager.AddLLMWithOptions("cheap", cheap, LLMRouteOptions{Capabilities: []string{CapabilityChat}, CostTier: CostTierLow}))
+	require.NoError(t, manager.AddLLMWithOptions("smart", smart, LLMRouteOptions{Capabilities: []string{CapabilityChat, CapabilityTools}, CostTier: CostTierHigh}))
+
+	complete := func(req *CompletionRequest) (string, error) {
+		resp, err := manager.Complete(context.Background(), req)
+		if err != nil {
+			return "", err
+		}
+		return resp.Content, nil
+	}
+
+	// Requests with tools skip the default LLM that cannot call them
+	content, err := complete(&CompletionRequest{Tools: []ToolDefinition{weatherTool}})
+	require.NoError(t, err)
+	assert.Equal(t, "smart", content)
+
+	// Pinned LLM and cost ceiling
+	content, err = complete(&CompletionRequest{Metadata: map[string]interface{}{RouteLLMKey: "smart"}})
+	require.NoError(t, err)
+	assert.Equal(t, "smart", content)
+
+	_, err = complete(&CompletionRequest{
+		Tools:    []ToolDefinition{weatherTool},
+		Metadata: map[string]interface{}{RouteMaxCostTierKey: CostTierMedium},
+	})
+	assert.True(t, errors.Is(err, ErrNoRoute))
+
+	// Latency strategy prefers the LLM with the lowest recorded latency
+	require.NoError(t, manager.SetRoutingConfig(&RoutingConfig{Strategy: RoutingStrategyLatency}))
+	metrics := NewLLMMetrics()
+	manager.SetMetrics(metrics)
+	metrics.RecordCompletion("cheap", "", 300*time.Millisecond, 10, true)
+	metrics.RecordCompletion("smart", "", 100*time.Millisecond, 10, true)
+
+	content, err = complete(&CompletionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "smart", content)
+}
@@ -0,0 +1,432 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidateJSONSchema validates a decoded JSON value against a JSON Schema and
+// returns one message per violation, prefixed with the JSON pointer of the
+// offending value. The supported keywords are type, enum, const, properties,
+// required, additionalProperties, items, minItems, maxItems, uniqueItems,
+// minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, allOf, anyOf, oneOf and not.
+func ValidateJSONSchema(schema map[string]interface{}, value interface{}) []string {
+	// Round-trip both through JSON so Go literals such as []string or int
+	// compare like decoded JSON
+	normalizedSchema, _ := normalizeJSON(schema).(map[string]interface{})
+	return validateNormalized(normalizedSchema, normalizeJSON(value))
+}
+
+func validateNormalized(schema map[string]interface{}, value interface{}) []string {
+	v := &schemaValidator{errors: make([]string, 0)}
+	v.validate(schema, value, "")
+	return v.errors
+}
+
+type schemaValidator struct {
+	errors []string
+}
+
+func (v *schemaValidator) fail(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "/"
+	}
+	v.errors = append(v.errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *schemaValidator) validate(schema map[string]interface{}, value interface{}, path string) {
+	if schema == nil {
+		return
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if jsonTypeMatches(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "expected %s, got %s", strings.Join(types, " or "), jsonTypeOf(value))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range enum {
+			if jsonEqual(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "must be one of %s", compactJSON(enum))
+		}
+	}
+
+	if constant, ok := schema["const"]; ok && !jsonEqual(constant, value) {
+		v.fail(path, "must equal %s", compactJSON(constant))
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(schema, typed, path)
+	case []interface{}:
+		v.validateArray(schema, typed, path)
+	case string:
+		v.validateString(schema, typed, path)
+	case float64:
+		v.validateNumber(schema, typed, path)
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			v.validate(asSchema(sub), value, path)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		if countMatches(anyOf, value) == 0 {
+			v.fail(path, "does not match any of the allowed schemas")
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if matches := countMatches(oneOf, value); matches != 1 {
+			v.fail(path, "must match exactly one schema, matched %d", matches)
+		}
+	}
+
+	if not, ok := schema["not"].(map[string]interface{}); ok && len(validateNormalized(not, value)) == 0 {
+		v.fail(path, "must not match the excluded schema")
+	}
+}
+
+func (v *schemaValidator) validateObject(schema map[string]interface{}, object map[string]interface{}, path string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	for _, name := range stringList(schema["required"]) {
+		if _, exists := object[name]; !exists {
+			v.fail(path, "missing required property %q", name)
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "/" + escapePointer(key)
+		if propertySchema, exists := properties[key]; exists {
+			v.validate(asSchema(propertySchema), object[key], childPath)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(path, "unexpected property %q", key)
+			}
+		case map[string]interface{}:
+			v.validate(additional, object[key], childPath)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(schema map[string]interface{}, array []interface{}, path string) {
+	if minItems, ok := schemaNumber(schema["minItems"]); ok && float64(len(array)) < minItems {
+		v.fail(path, "must have at least %v items", minItems)
+	}
+	if maxItems, ok := schemaNumber(schema["maxItems"]); ok && float64(len(array)) > maxItems {
+		v.fail(path, "must have at most %v items", maxItems)
+	}
+
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range array {
+			for j := i + 1; j < len(array); j++ {
+				if jsonEqual(array[i], array[j]) {
+					v.fail(path, "items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range array {
+			v.validate(items, item, fmt.Sprintf("%s/%d", path, i))
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(schema map[string]interface{}, value, path string) {
+	length := float64(len([]rune(value)))
+	if minLength, ok := schemaNumber(schema["minLength"]); ok && length < minLength {
+		v.fail(path, "must be at least %v characters", minLength)
+	}
+	if maxLength, ok := schemaNumber(schema["maxLength"]); ok && length > maxLength {
+		v.fail(path, "must be at most %v characters", maxLength)
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.fail(path, "schema pattern %q is invalid: %v", pattern, err)
+		} else if !re.MatchString(value) {
+			v.fail(path, "must match pattern %q", pattern)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(schema map[string]interface{}, value float64, path string) {
+	if minimum, ok := schemaNumber(schema["minimum"]); ok && value < minimum {
+		v.fail(path, "must be >= %v", minimum)
+	}
+	if maximum, ok := schemaNumber(schema["maximum"]); ok && value > maximum {
+		v.fail(path, "must be <= %v", maximum)
+	}
+	if minimum, ok := schemaNumber(schema["exclusiveMinimum"]); ok && value <= minimum {
+		v.fail(path, "must be > %v", minimum)
+	}
+	if maximum, ok := schemaNumber(schema["exclusiveMaximum"]); ok && value >= maximum {
+		v.fail(path, "must be < %v", maximum)
+	}
+	if multiple, ok := schemaNumber(schema["multipleOf"]); ok && multiple > 0 {
+		if quotient := value / multiple; math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			v.fail(path, "must be a multiple of %v", multiple)
+		}
+	}
+}
+
+// SchemaFor derives a JSON Schema from a Go value's type. Struct fields use
+// their json names; fields without omitempty are required and unknown
+// properties are rejected. A "description" struct tag is copied into the schema.
+func SchemaFor(v interface{}) map[string]interface{} {
+	return schemaForType(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			// Recursive types are left open rather than expanded forever
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := make(map[string]interface{})
+		required := make([]interface{}, 0)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Name
+			omitEmpty := false
+			if tag := field.Tag.Get("json"); tag != "" {
+				parts := strings.Split(tag, ",")
+				if parts[0] == "-" {
+					continue
+				}
+				if parts[0] != "" {
+					name = parts[0]
+				}
+				for _, option := range parts[1:] {
+					if option == "omitempty" {
+						omitEmpty = true
+					}
+				}
+			}
+
+			property := schemaForType(field.Type, seen)
+			if description := field.Tag.Get("description"); description != "" {
+				property["description"] = description
+			}
+			properties[name] = property
+			if !omitEmpty {
+				required = append(required, name)
+			}
+		}
+
+		schema := map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// Helper functions
+
+func asSchema(value interface{}) map[string]interface{} {
+	schema, _ := value.(map[string]interface{})
+	return schema
+}
+
+func countMatches(schemas []interface{}, value interface{}) int {
+	matches := 0
+	for _, sub := range schemas {
+		if len(validateNormalized(asSchema(sub), value)) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+func schemaTypes(value interface{}) []string {
+	switch typed := value.(type) {
+	case string:
+		return []string{typed}
+	case []interface{}:
+		return stringList(typed)
+	case []string:
+		return typed
+	}
+	return nil
+}
+
+func stringList(value interface{}) []string {
+	switch typed := value.(type) {
+	case []string:
+		return typed
+	case []interface{}:
+		list := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func schemaNumber(value interface{}) (float64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case int:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	}
+	return 0, false
+}
+
+func jsonTypeMatches(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonEqual compares values after normalizing them through JSON, so schema
+// literals written as Go ints match decoded float64s
+func jsonEqual(a, b interface{}) bool {
+	return compactJSON(a) == compactJSON(b)
+}
+
+func compactJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+func normalizeJSON(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRepairAttempts is the number of re-asks when no limit is given
+const DefaultMaxRepairAttempts = 2
+
+// ErrSchemaValidation is matched by errors.Is when a reply never satisfied its schema
+var ErrSchemaValidation = errors.New("response does not match schema")
+
+// SchemaValidationError is returned when every attempt produced invalid JSON
+// or JSON that does not match the response schema
+type SchemaValidationError struct {
+	Errors   []string `json:"errors"`
+	Raw      string   `json:"raw"` // The last reply
+	Attempts int      `json:"attempts"`
+}
+
+// Error implements the error interface
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("response does not match schema after %d attempts: %s", e.Attempts, strings.Join(e.Errors, "; "))
+}
+
+// Unwrap returns ErrSchemaValidation
+func (e *SchemaValidationError) Unwrap() error { return ErrSchemaValidation }
+
+// StructuredResponse is a completion whose content was validated against a schema
+type StructuredResponse struct {
+	Value    interface{}         `json:"value"` // The decoded JSON value
+	Raw      string              `json:"raw"`   // The JSON text of the value
+	Response *CompletionResponse `json:"response"`
+	Attempts int                 `json:"attempts"`
+	Usage    TokenUsage          `json:"usage"` // Summed over all attempts
+}
+
+// CompleteStructured runs a completion that must return JSON matching
+// req.ResponseSchema. When the reply is not valid JSON or fails validation,
+// the errors are fed back to the model and it is asked again, up to
+// maxRepairAttempts times (DefaultMaxRepairAttempts when zero, none when negative).
+func CompleteStructured(ctx context.Context, model LLM, req *CompletionRequest, maxRepairAttempts int) (*StructuredResponse, error) {
+	if req.ResponseSchema == nil || req.ResponseSchema.Schema == nil {
+		return nil, fmt.Errorf("response schema is required")
+	}
+	if maxRepairAttempts == 0 {
+		maxRepairAttempts = DefaultMaxRepairAttempts
+	}
+	if maxRepairAttempts < 0 {
+		maxRepairAttempts = 0
+	}
+
+	attemptReq := *req
+	attemptReq.Messages = append(append([]Message(nil), req.Messages...), schemaInstruction(req.ResponseSchema))
+
+	result := &StructuredResponse{}
+	for attempt := 1; ; attempt++ {
+		resp, err := model.Complete(ctx, &attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Attempts = attempt
+		result.Response = resp
+		result.Usage.PromptTokens += resp.Usage.PromptTokens
+		result.Usage.CompletionTokens += resp.Usage.CompletionTokens
+		result.Usage.TotalTokens += resp.Usage.TotalTokens
+
+		raw := ExtractJSON(resp.Content)
+		var value interface{}
+		var problems []string
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			problems = []string{fmt.Sprintf("reply is not valid JSON: %v", err)}
+		} else {
+			problems = ValidateJSONSchema(req.ResponseSchema.Schema, value)
+		}
+
+		if len(problems) == 0 {
+			result.Value = value
+			result.Raw = raw
+			return result, nil
+		}
+
+		if attempt > maxRepairAttempts {
+			return nil, &SchemaValidationError{Errors: problems, Raw: resp.Content, Attempts: attempt}
+		}
+
+		attemptReq.Messages = append(attemptReq.Messages,
+			Message{Role: "assistant", Content: resp.Content, Timestamp: time.Now()},
+			Message{
+				Role: "user",
+				Content: "Your reply did not match the required JSON schema:\n- " + strings.Join(problems, "\n- ") +
+					"\nReply again with only the corrected JSON.",
+				Timestamp: time.Now(),
+			},
+		)
+	}
+}
+
+// CompleteTyped runs a structured completion and decodes the result into T.
+// When req.ResponseSchema is nil, the schema is derived from T with SchemaFor.
+func CompleteTyped[T any](ctx context.Context, model LLM, req *CompletionRequest, maxRepairAttempts int) (T, *StructuredResponse, error) {
+	var value T
+
+	typedReq := *req
+	if typedReq.ResponseSchema == nil {
+		name := strings.ToLower(fmt.Sprintf("%T", value))
+		name = name[strings.LastIndex(name, ".")+1:]
+		typedReq.ResponseSchema = &ResponseSchema{Name: strings.Trim(name, "*[]"), Schema: SchemaFor(value)}
+	}
+
+	result, err := CompleteStructured(ctx, model, &typedReq, maxRepairAttempts)
+	if err != nil {
+		return value, nil, err
+	}
+
+	if err := json.Unmarshal([]byte(result.Raw), &value); err != nil {
+		return value, result, fmt.Errorf("failed to decode structured response: %w", err)
+	}
+
+	return value, result, nil
+}
+
+// ExtractJSON returns the JSON part of a reply, removing markdown code fences
+// and any prose before the first or after the last bracket
+func ExtractJSON(content string) string {
+	text := strings.TrimSpace(content)
+
+	if start := strings.Index(text, "```"); start >= 0 {
+		rest := text[start+3:]
+		if newline := strings.Index(rest, "\n"); newline >= 0 {
+			rest = rest[newline+1:]
+		}
+		if end := strings.Index(rest, "```"); end >= 0 {
+			rest = rest[:end]
+		}
+		text = strings.TrimSpace(rest)
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return text
+	}
+	closing := "}"
+	if text[start] == '[' {
+		closing = "]"
+	}
+	if end := strings.LastIndex(text, closing); end > start {
+		return text[start : end+1]
+	}
+	return text[start:]
+}
+
+func schemaInstruction(schema *ResponseSchema) Message {
+	schemaJSON, _ := json.Marshal(schema.Schema)
+	return Message{
+		Role: "system",
+		Content: "Reply with only a JSON value, without markdown or commentary, that matches this JSON Schema:\n" +
+			string(schemaJSON),
+		Timestamp: time.Now(),
+	}
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedLLM replies with canned contents in order and records the requests
+type scriptedLLM struct {
+	replies  []string
+	requests []*CompletionRequest
+}
+
+func (s *scriptedLLM) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	s.requests = append(s.requests, req)
+	reply := s.replies[0]
+	if len(s.replies) > 1 {
+		s.replies = s.replies[1:]
+	}
+	return &CompletionResponse{Content: reply, Usage: TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}, nil
+}
+
+func (s *scriptedLLM) Stream(ctx context.Context, req *CompletionRequest) (<-chan StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *scriptedLLM) GetEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *scriptedLLM) GetProvider() LLMProvider {
+	return "scripted"
+}
+
+func (s *scriptedLLM) GetModels(ctx context.Context) ([]string, error) {
+	return []string{"scripted"}, nil
+}
+
+func (s *scriptedLLM) ValidateModel(ctx context.Context, model string) error {
+	return nil
+}
+
+func (s *scriptedLLM) Close() error {
+	return nil
+}
+
+type ticketTriage struct {
+	Category string   `json:"category"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"category", "priority"},
+		"properties": map[string]interface{}{
+			"category": map[string]interface{}{"type": "string", "enum": []string{"billing", "bug"}},
+			"priority": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 5},
+			"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"additionalProperties": false,
+	}
+
+	assert.Empty(t, ValidateJSONSchema(schema, map[string]interface{}{"category": "bug", "priority": 2}))
+	assert.ElementsMatch(t, []string{
+		`/: missing required property "priority"`,
+		`/: unexpected property "extra"`,
+		`/category: must be one of ["billing","bug"]`,
+		`/tags/1: expected string, got number`,
+	}, ValidateJSONSchema(schema, map[string]interface{}{
+		"category": "other",
+		"tags":     []interface{}{"a", 1.0},
+		"extra":    true,
+	}))
+	assert.Equal(t, []string{"/priority: expected integer, got number"},
+		ValidateJSONSchema(schema, map[string]interface{}{"category": "bug", "priority": 2.5}))
+
+	// Schemas derived from structs validate their own values
+	derived := SchemaFor(ticketTriage{})
+	assert.Equal(t, []interface{}{"category", "priority"}, derived["required"])
+	assert.Empty(t, ValidateJSONSchema(derived, ticketTriage{Category: "bug", Priority: 1}))
+}
+
+func TestCompleteStructured(t *testing.T) {
+	schema := &ResponseSchema{Name: "triage", Schema: SchemaFor(ticketTriage{})}
+
+	t.Run("RepairsInvalidReply", func(t *testing.T) {
+		model := &scriptedLLM{replies: []string{
+			`Sure! {"category": "bug"}`,
+			"```json\n{\"category\": \"bug\", \"priority\": 3}\n```",
+		}}
+
+		result, err := CompleteStructured(context.Background(), model, &CompletionRequest{
+			Messages:       []Message{{Role: "user", Content: "The app crashes"}},
+			ResponseSchema: schema,
+		}, 0)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, result.Attempts)
+		assert.Equal(t, map[string]interface{}{"category": "bug", "priority": 3.0}, result.Value)
+		assert.Equal(t, 30, result.Usage.TotalTokens)
+
+		// The second attempt sees the first reply and the validation error
+		retry := model.requests[1].Messages
+		require.Len(t, retry, 4)
+		assert.Equal(t, "system", retry[1].Role)
+		assert.Equal(t, "assistant", retry[2].Role)
+		assert.Contains(t, retry[3].Content, `missing required property "priority"`)
+	})
+
+	t.Run("GivesUp", func(t *testing.T) {
+		model := &scriptedLLM{replies: []string{"not json"}}
+
+		_, err := CompleteStructured(context.Background(), model, &CompletionRequest{
+			Messages:       []Message{{Role: "user", Content: "?"}},
+			ResponseSchema: schema,
+		}, 1)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrSchemaValidation))
+
+		var validationErr *SchemaValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, 2, validationErr.Attempts)
+		assert.Equal(t, "not json", validationErr.Raw)
+	})
+
+	t.Run("Typed", func(t *testing.T) {
+		model := &scriptedLLM{replies: []string{`{"category": "billing", "priority": 1, "tags": ["refund"]}`}}
+
+		triage, result, err := CompleteTyped[ticketTriage](context.Background(), model, &CompletionRequest{
+			Messages: []Message{{Role: "user", Content: "Refund please"}},
+		}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, ticketTriage{Category: "billing", Priority: 1, Tags: []string{"refund"}}, triage)
+		assert.Equal(t, 1, result.Attempts)
+		assert.Equal(t, "tickettriage", model.requests[0].ResponseSchema.Name)
+	})
+}
@@ -0,0 +1,266 @@
+package llm
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens a model sees for a piece of text
+type Tokenizer interface {
+	// CountTokens returns the number of tokens in text
+	CountTokens(text string) int
+
+	// Name returns the tokenizer name
+	Name() string
+}
+
+// Token overhead of the chat message format used by OpenAI-style models
+const (
+	tokensPerMessage = 3 // Role and message delimiters
+	tokensPerName    = 1
+	tokensPerReply   = 3 // The primed assistant reply
+)
+
+// CountMessageTokens estimates the prompt tokens of a conversation, including
+// the overhead of the chat format and any tool calls
+func CountMessageTokens(tokenizer Tokenizer, messages []Message) int {
+	total := tokensPerReply
+	for _, message := range messages {
+		total += tokensPerMessage
+		total += tokenizer.CountTokens(message.Role)
+		total += tokenizer.CountTokens(message.Content)
+		if message.Name != "" {
+			total += tokensPerName + tokenizer.CountTokens(message.Name)
+		}
+		for _, call := range message.ToolCalls {
+			total += tokenizer.CountTokens(call.Name) + tokenizer.CountTokens(call.Arguments)
+		}
+	}
+	return total
+}
+
+// HeuristicTokenizer estimates tokens without a vocabulary. Words are counted
+// as one token per CharsPerToken characters and every punctuation or symbol
+// character as its own token, which tracks BPE tokenizers closely for English
+// prose and overestimates slightly for code.
+type HeuristicTokenizer struct {
+	CharsPerToken float64
+}
+
+// NewHeuristicTokenizer creates a heuristic tokenizer with four characters per token
+func NewHeuristicTokenizer() *HeuristicTokenizer {
+	return &HeuristicTokenizer{CharsPerToken: 4}
+}
+
+// CountTokens returns the estimated number of tokens in text
+func (t *HeuristicTokenizer) CountTokens(text string) int {
+	charsPerToken := t.CharsPerToken
+	if charsPerToken <= 0 {
+		charsPerToken = 4
+	}
+
+	tokens := 0
+	wordLength := 0
+	flush := func() {
+		if wordLength > 0 {
+			tokens += int(math.Ceil(float64(wordLength) / charsPerToken))
+			wordLength = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if r > unicode.MaxLatin1 && !unicode.Is(unicode.Latin, r) {
+				// Non-Latin scripts take roughly a token per character
+				flush()
+				tokens++
+				continue
+			}
+			wordLength++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// Name returns the tokenizer name
+func (t *HeuristicTokenizer) Name() string {
+	return "heuristic"
+}
+
+// bpePattern splits text into the pieces that are encoded independently. It
+// follows the cl100k pattern without the lookahead Go's regexp lacks; see
+// splitPieces for the correction.
+var bpePattern = regexp.MustCompile(`'(?i:[sdmt]|ll|ve|re)|[^\r\n\pL\pN]?\pL+|\pN{1,3}| ?[^\s\pL\pN]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// BPETokenizer is a byte pair encoding tokenizer using an OpenAI-style
+// vocabulary, as distributed in the .tiktoken format: one base64-encoded
+// token and its rank per line.
+type BPETokenizer struct {
+	name    string
+	encoder map[string]int
+	decoder map[int]string
+}
+
+// LoadBPETokenizer loads a BPE vocabulary from a .tiktoken file
+func LoadBPETokenizer(path string) (*BPETokenizer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary: %w", err)
+	}
+	defer file.Close()
+
+	name := path[strings.LastIndexAny(path, `/\`)+1:]
+	return NewBPETokenizer(strings.TrimSuffix(name, ".tiktoken"), file)
+}
+
+// NewBPETokenizer reads a BPE vocabulary in the .tiktoken format
+func NewBPETokenizer(name string, vocabulary io.Reader) (*BPETokenizer, error) {
+	tokenizer := &BPETokenizer{
+		name:    name,
+		encoder: make(map[string]int),
+		decoder: make(map[int]string),
+	}
+
+	scanner := bufio.NewScanner(vocabulary)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid vocabulary line %d", lineNumber)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid token on vocabulary line %d: %w", lineNumber, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank on vocabulary line %d: %w", lineNumber, err)
+		}
+
+		tokenizer.encoder[string(token)] = rank
+		tokenizer.decoder[rank] = string(token)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+	if len(tokenizer.encoder) == 0 {
+		return nil, fmt.Errorf("vocabulary is empty")
+	}
+
+	return tokenizer, nil
+}
+
+// Encode returns the token ids of text
+func (t *BPETokenizer) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range splitPieces(text) {
+		if rank, exists := t.encoder[piece]; exists {
+			tokens = append(tokens, rank)
+			continue
+		}
+		tokens = append(tokens, t.bytePairEncode(piece)...)
+	}
+	return tokens
+}
+
+// Decode returns the text of token ids, skipping unknown ids
+func (t *BPETokenizer) Decode(tokens []int) string {
+	var builder strings.Builder
+	for _, token := range tokens {
+		builder.WriteString(t.decoder[token])
+	}
+	return builder.String()
+}
+
+// CountTokens returns the number of tokens in text
+func (t *BPETokenizer) CountTokens(text string) int {
+	return len(t.Encode(text))
+}
+
+// Name returns the vocabulary name
+func (t *BPETokenizer) Name() string {
+	return t.name
+}
+
+// bytePairEncode merges the bytes of a piece, lowest ranked pair first, until
+// no adjacent pair is in the vocabulary
+func (t *BPETokenizer) bytePairEncode(piece string) []int {
+	parts := make([]string, len(piece))
+	for i := 0; i < len(piece); i++ {
+		parts[i] = piece[i : i+1]
+	}
+
+	for len(parts) > 1 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, exists := t.encoder[parts[i]+parts[i+1]]; exists && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+
+	tokens := make([]int, 0, len(parts))
+	for _, part := range parts {
+		if rank, exists := t.encoder[part]; exists {
+			tokens = append(tokens, rank)
+			continue
+		}
+		// Vocabularies cover every single byte, so this only happens with
+		// incomplete files; count the byte as one token regardless
+		tokens = append(tokens, -1)
+	}
+	return tokens
+}
+
+// splitPieces splits text with bpePattern. The original pattern leaves the
+// last space of a whitespace run to the following word, which a lookahead
+// does there; this moves it across after matching.
+func splitPieces(text string) []string {
+	matches := bpePattern.FindAllStringIndex(text, -1)
+	pieces := make([]string, 0, len(matches))
+	for i, match := range matches {
+		start, end := match[0], match[1]
+		piece := text[start:end]
+		if i+1 < len(matches) && end == matches[i+1][0] && strings.TrimSpace(piece) == "" &&
+			!strings.ContainsAny(piece, "\r\n") && utf8.RuneCountInString(piece) > 1 {
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			// Words and punctuation take a leading space, numbers do not
+			if !unicode.IsNumber(next) && !unicode.IsSpace(next) {
+				_, size := utf8.DecodeLastRuneInString(piece)
+				piece = piece[:len(piece)-size]
+				matches[i+1][0] -= size
+			}
+		}
+		if piece != "" {
+			pieces = append(pieces, piece)
+		}
+	}
+	return pieces
+}
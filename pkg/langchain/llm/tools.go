@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NewToolResultMessage creates a "tool" message answering a tool call
+func NewToolResultMessage(call ToolCall, content string) Message {
+	return Message{
+		Role:       "tool",
+		Content:    content,
+		ToolCallID: call.ID,
+		Name:       call.Name,
+		Timestamp:  time.Now(),
+	}
+}
+
+// DecodeArguments unmarshals the call's JSON arguments into v
+func (c ToolCall) DecodeArguments(v interface{}) error {
+	arguments := c.Arguments
+	if arguments == "" {
+		arguments = "{}"
+	}
+	if err := json.Unmarshal([]byte(arguments), v); err != nil {
+		return fmt.Errorf("invalid arguments for tool %s: %w", c.Name, err)
+	}
+	return nil
+}
+
+// ToolCallAccumulator assembles streamed tool call deltas into complete calls
+type ToolCallAccumulator struct {
+	calls []ToolCall
+	index map[int]int
+}
+
+// NewToolCallAccumulator creates a new tool call accumulator
+func NewToolCallAccumulator() *ToolCallAccumulator {
+	return &ToolCallAccumulator{index: make(map[int]int)}
+}
+
+// Add merges a delta into the call with the same index
+func (a *ToolCallAccumulator) Add(delta ToolCallDelta) {
+	position, exists := a.index[delta.Index]
+	if !exists {
+		position = len(a.calls)
+		a.index[delta.Index] = position
+		a.calls = append(a.calls, ToolCall{})
+	}
+
+	call := &a.calls[position]
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Name != "" {
+		call.Name = delta.Name
+	}
+	call.Arguments += delta.ArgumentsDelta
+}
+
+// ToolCalls returns the assembled calls in the order they started
+func (a *ToolCallAccumulator) ToolCalls() []ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	return append([]ToolCall(nil), a.calls...)
+}
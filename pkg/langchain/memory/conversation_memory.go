@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -331,14 +332,22 @@ func (m *DefaultConversationMemory) loadFromStore(ctx context.Context) error {
 
 	data, err := m.store.Retrieve(ctx, "conversation_messages")
 	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil // New session
+		}
 		return err
 	}
 
-	if messages, ok := data.([]llm.Message); ok {
-		m.messages = messages
-		m.trimMessages()
+	messages, ok := data.([]llm.Message)
+	if !ok {
+		if err := decodeStored(data, &messages); err != nil {
+			return err
+		}
 	}
 
+	m.messages = messages
+	m.trimMessages()
+
 	return nil
 }
 
@@ -0,0 +1,692 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// entityStoreKey is the store key holding all entities
+const entityStoreKey = "entity_memory"
+
+// DefaultEntityMemory implements EntityMemory interface. Each saved turn is
+// sent to an LLM that extracts named entities and facts about them; facts are
+// merged per entity and condensed into the entity description.
+type DefaultEntityMemory struct {
+	entities               map[string]*Entity // Keyed by normalized name
+	llm                    llm.LLM
+	maxEntities            int
+	maxFactsPerEntity      int
+	maxRelevantEntities    int
+	deterministicSummaries bool
+	memoryKeys             []string
+	store                  MemoryStore
+	logger                 *logrus.Logger
+	tracer                 trace.Tracer
+	mu                     sync.RWMutex
+}
+
+// EntityMemoryConfig represents configuration for entity memory
+type EntityMemoryConfig struct {
+	LLM                 llm.LLM     `json:"-"`
+	MaxEntities         int         `json:"max_entities,omitempty"`
+	MaxFactsPerEntity   int         `json:"max_facts_per_entity,omitempty"`
+	MaxRelevantEntities int         `json:"max_relevant_entities,omitempty"`
+	MemoryKeys          []string    `json:"memory_keys,omitempty"`
+	Store               MemoryStore `json:"-"`
+	Persistent          bool        `json:"persistent,omitempty"`
+
+	// DeterministicSummaries joins an entity's facts into its description
+	// instead of asking the LLM to rewrite the summary after each update
+	DeterministicSummaries bool `json:"deterministic_summaries,omitempty"`
+}
+
+// extractedEntities is the JSON the LLM returns when extracting entities
+type extractedEntities struct {
+	Entities []extractedEntity `json:"entities"`
+}
+
+type extractedEntity struct {
+	Name  string   `json:"name" description:"The entity as it is usually referred to, e.g. a full name or an order number"`
+	Type  string   `json:"type" description:"person, organization, product, order, location, preference or other"`
+	Facts []string `json:"facts" description:"Short standalone facts about the entity stated in the text"`
+}
+
+const entityExtractionPrompt = `Extract the named entities mentioned in the conversation turn below, such as people, organizations, products, order or ticket numbers and locations, together with facts the turn states about them, including preferences. Only include facts stated in the text. Return an empty list when there are none.
+
+%s`
+
+const entitySummaryPrompt = `Update the summary of %s using the new facts. Keep every detail that is still true, replace details the new facts contradict, and reply with the summary only, in at most three sentences.
+
+CURRENT SUMMARY:
+%s
+
+NEW FACTS:
+- %s
+
+UPDATED SUMMARY:`
+
+// NewEntityMemory creates a new entity memory
+func NewEntityMemory(config *EntityMemoryConfig, logger *logrus.Logger) (EntityMemory, error) {
+	if config.LLM == nil {
+		return nil, fmt.Errorf("LLM is required for entity extraction")
+	}
+
+	if config.MaxEntities <= 0 {
+		config.MaxEntities = 100 // Default max entities
+	}
+
+	if config.MaxFactsPerEntity <= 0 {
+		config.MaxFactsPerEntity = 20 // Default facts kept per entity
+	}
+
+	if config.MaxRelevantEntities <= 0 {
+		config.MaxRelevantEntities = 5 // Default entities injected per prompt
+	}
+
+	memoryKeys := config.MemoryKeys
+	if len(memoryKeys) == 0 {
+		memoryKeys = []string{"entities"}
+	}
+
+	memory := &DefaultEntityMemory{
+		entities:               make(map[string]*Entity),
+		llm:                    config.LLM,
+		maxEntities:            config.MaxEntities,
+		maxFactsPerEntity:      config.MaxFactsPerEntity,
+		maxRelevantEntities:    config.MaxRelevantEntities,
+		deterministicSummaries: config.DeterministicSummaries,
+		memoryKeys:             memoryKeys,
+		store:                  config.Store,
+		logger:                 logger,
+		tracer:                 otel.Tracer("langchain.memory.entity"),
+	}
+
+	// Load existing entities if store is provided
+	if config.Persistent && config.Store != nil {
+		if err := memory.loadFromStore(context.Background()); err != nil {
+			logger.WithError(err).Warn("Failed to load entities from store")
+		}
+	}
+
+	return memory, nil
+}
+
+// LoadMemoryVariables returns the entities most relevant to the input
+func (m *DefaultEntityMemory) LoadMemoryVariables(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	ctx, span := m.tracer.Start(ctx, "entity_memory.load_variables")
+	defer span.End()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	query := ""
+	if userInput, exists := input["input"]; exists {
+		query = fmt.Sprintf("%v", userInput)
+	}
+
+	relevant := m.relevantEntities(query)
+
+	variables := make(map[string]interface{})
+	for _, key := range m.memoryKeys {
+		switch key {
+		case "entity_list":
+			variables[key] = relevant
+		default:
+			variables[key] = formatEntities(relevant)
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("memory.entity_count", len(m.entities)),
+		attribute.Int("memory.relevant_entities", len(relevant)),
+	)
+
+	return variables, nil
+}
+
+// SaveContext extracts entities from the turn and merges them into memory
+func (m *DefaultEntityMemory) SaveContext(ctx context.Context, input map[string]interface{}, output map[string]interface{}) error {
+	ctx, span := m.tracer.Start(ctx, "entity_memory.save_context")
+	defer span.End()
+
+	var turn strings.Builder
+	if userInput, exists := input["input"]; exists {
+		fmt.Fprintf(&turn, "Human: %v\n", userInput)
+	}
+	if assistantOutput, exists := output["text"]; exists {
+		fmt.Fprintf(&turn, "Assistant: %v\n", assistantOutput)
+	}
+	if turn.Len() == 0 {
+		return nil
+	}
+
+	extracted, err := m.ExtractEntities(ctx, turn.String())
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	for _, entity := range extracted {
+		if err := m.mergeEntity(ctx, entity); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.trimEntities()
+
+	if m.store != nil {
+		if err := m.saveToStore(ctx); err != nil {
+			m.logger.WithError(err).Error("Failed to save entities to store")
+			return err
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("memory.extracted_entities", len(extracted)),
+		attribute.Int("memory.entity_count", len(m.entities)),
+	)
+
+	return nil
+}
+
+// Clear clears all memory
+func (m *DefaultEntityMemory) Clear(ctx context.Context) error {
+	ctx, span := m.tracer.Start(ctx, "entity_memory.clear")
+	defer span.End()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entities = make(map[string]*Entity)
+
+	if m.store != nil {
+		if err := m.store.Delete(ctx, entityStoreKey); err != nil {
+			return fmt.Errorf("failed to clear entities from store: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetMemoryKeys returns the keys that this memory system provides
+func (m *DefaultEntityMemory) GetMemoryKeys() []string {
+	return m.memoryKeys
+}
+
+// GetMemoryType returns the type of this memory system
+func (m *DefaultEntityMemory) GetMemoryType() string {
+	return "entity"
+}
+
+// AddEntity adds an entity, merging its facts into an existing one of the same name
+func (m *DefaultEntityMemory) AddEntity(ctx context.Context, entity *Entity) error {
+	if entity == nil || strings.TrimSpace(entity.Name) == "" {
+		return fmt.Errorf("entity name cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := normalizeEntityName(entity.Name)
+	if existing, exists := m.entities[key]; exists {
+		m.mergeFacts(existing, entityFacts(entity))
+		if entity.Description != "" {
+			existing.Description = entity.Description
+		}
+		if entity.Type != "" {
+			existing.Type = entity.Type
+		}
+		existing.LastUpdated = time.Now()
+	} else {
+		stored := copyEntity(entity)
+		stored.LastUpdated = time.Now()
+		m.entities[key] = stored
+		m.trimEntities()
+	}
+
+	if m.store != nil {
+		if err := m.saveToStore(ctx); err != nil {
+			return fmt.Errorf("failed to save entity to store: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetEntity retrieves an entity by name, ignoring case
+func (m *DefaultEntityMemory) GetEntity(ctx context.Context, name string) (*Entity, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entity, exists := m.entities[normalizeEntityName(name)]
+	if !exists {
+		return nil, fmt.Errorf("entity not found: %s", name)
+	}
+
+	return copyEntity(entity), nil
+}
+
+// GetEntities retrieves all entities, most recently updated first
+func (m *DefaultEntityMemory) GetEntities(ctx context.Context) ([]*Entity, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entities := make([]*Entity, 0, len(m.entities))
+	for _, entity := range m.entities {
+		entities = append(entities, copyEntity(entity))
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].LastUpdated.After(entities[j].LastUpdated)
+	})
+
+	return entities, nil
+}
+
+// UpdateEntity replaces an entity's description
+func (m *DefaultEntityMemory) UpdateEntity(ctx context.Context, name string, info string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entity, exists := m.entities[normalizeEntityName(name)]
+	if !exists {
+		return fmt.Errorf("entity not found: %s", name)
+	}
+
+	entity.Description = info
+	entity.LastUpdated = time.Now()
+
+	if m.store != nil {
+		if err := m.saveToStore(ctx); err != nil {
+			return fmt.Errorf("failed to save entity to store: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteEntity deletes an entity
+func (m *DefaultEntityMemory) DeleteEntity(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := normalizeEntityName(name)
+	if _, exists := m.entities[key]; !exists {
+		return fmt.Errorf("entity not found: %s", name)
+	}
+	delete(m.entities, key)
+
+	if m.store != nil {
+		if err := m.saveToStore(ctx); err != nil {
+			return fmt.Errorf("failed to save entities to store: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ExtractEntities asks the LLM for the entities in text and the facts stated
+// about them. The facts are returned in the "facts" property.
+func (m *DefaultEntityMemory) ExtractEntities(ctx context.Context, text string) ([]*Entity, error) {
+	ctx, span := m.tracer.Start(ctx, "entity_memory.extract_entities")
+	defer span.End()
+
+	req := &llm.CompletionRequest{
+		Messages: []llm.Message{
+			{
+				Role:      "user",
+				Content:   fmt.Sprintf(entityExtractionPrompt, text),
+				Timestamp: time.Now(),
+			},
+		},
+	}
+
+	result, _, err := llm.CompleteTyped[extractedEntities](ctx, m.llm, req, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract entities: %w", err)
+	}
+
+	now := time.Now()
+	entities := make([]*Entity, 0, len(result.Entities))
+	for _, extracted := range result.Entities {
+		name := strings.TrimSpace(extracted.Name)
+		if name == "" {
+			continue
+		}
+
+		facts := make([]string, 0, len(extracted.Facts))
+		for _, fact := range extracted.Facts {
+			if fact = strings.TrimSpace(fact); fact != "" {
+				facts = append(facts, fact)
+			}
+		}
+
+		entities = append(entities, &Entity{
+			Name:        name,
+			Type:        strings.ToLower(strings.TrimSpace(extracted.Type)),
+			Properties:  map[string]interface{}{"facts": facts},
+			LastUpdated: now,
+		})
+	}
+
+	span.SetAttributes(attribute.Int("memory.extracted_entities", len(entities)))
+
+	return entities, nil
+}
+
+// Helper methods
+
+// mergeEntity merges an extracted entity and refreshes its summary when it
+// gained new facts
+func (m *DefaultEntityMemory) mergeEntity(ctx context.Context, extracted *Entity) error {
+	m.mu.Lock()
+	key := normalizeEntityName(extracted.Name)
+	entity, exists := m.entities[key]
+	if !exists {
+		entity = &Entity{
+			Name:       extracted.Name,
+			Type:       extracted.Type,
+			Properties: map[string]interface{}{},
+		}
+		m.entities[key] = entity
+	}
+	if entity.Type == "" || entity.Type == "other" {
+		entity.Type = extracted.Type
+	}
+	entity.Properties["mentions"] = entityMentions(entity) + 1
+	entity.LastUpdated = time.Now()
+
+	newFacts := m.mergeFacts(entity, entityFacts(extracted))
+	name, summary := entity.Name, entity.Description
+	allFacts := entityFacts(entity)
+	m.mu.Unlock()
+
+	if len(newFacts) == 0 {
+		return nil
+	}
+
+	// A single fact is its own summary
+	if m.deterministicSummaries || summary == "" && len(allFacts) == 1 {
+		summary = strings.Join(allFacts, ". ")
+	} else {
+		updated, err := m.summarize(ctx, name, summary, newFacts)
+		if err != nil {
+			return err
+		}
+		summary = updated
+	}
+
+	m.mu.Lock()
+	entity.Description = summary
+	m.mu.Unlock()
+
+	return nil
+}
+
+// summarize asks the LLM to fold new facts into an entity summary
+func (m *DefaultEntityMemory) summarize(ctx context.Context, name, summary string, facts []string) (string, error) {
+	if summary == "" {
+		summary = "(none)"
+	}
+
+	req := &llm.CompletionRequest{
+		Messages: []llm.Message{
+			{
+				Role:      "user",
+				Content:   fmt.Sprintf(entitySummaryPrompt, name, summary, strings.Join(facts, "\n- ")),
+				Timestamp: time.Now(),
+			},
+		},
+	}
+
+	response, err := m.llm.Complete(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize entity %s: %w", name, err)
+	}
+
+	return strings.TrimSpace(response.Content), nil
+}
+
+// mergeFacts appends facts the entity does not know yet, keeping the newest
+// maxFactsPerEntity, and returns the facts that were added
+func (m *DefaultEntityMemory) mergeFacts(entity *Entity, facts []string) []string {
+	existing := entityFacts(entity)
+	known := make(map[string]bool, len(existing))
+	for _, fact := range existing {
+		known[strings.ToLower(fact)] = true
+	}
+
+	var added []string
+	for _, fact := range facts {
+		if !known[strings.ToLower(fact)] {
+			known[strings.ToLower(fact)] = true
+			added = append(added, fact)
+		}
+	}
+
+	merged := append(existing, added...)
+	if len(merged) > m.maxFactsPerEntity {
+		merged = merged[len(merged)-m.maxFactsPerEntity:]
+	}
+	if entity.Properties == nil {
+		entity.Properties = make(map[string]interface{})
+	}
+	entity.Properties["facts"] = merged
+
+	return added
+}
+
+// relevantEntities scores entities against the query: a mention of the name
+// counts most, then words shared with the name and facts, with recency
+// breaking ties. Without a query the most recent entities are returned.
+func (m *DefaultEntityMemory) relevantEntities(query string) []*Entity {
+	type scored struct {
+		entity *Entity
+		score  float64
+	}
+
+	lowerQuery := strings.ToLower(query)
+	queryWords := entityWords(query)
+
+	candidates := make([]scored, 0, len(m.entities))
+	for _, entity := range m.entities {
+		score := 0.0
+		if query == "" {
+			score = 1
+		} else {
+			if strings.Contains(lowerQuery, strings.ToLower(entity.Name)) {
+				score += 10
+			}
+			for word := range entityWords(entity.Name) {
+				if queryWords[word] {
+					score += 2
+				}
+			}
+			factWords := entityWords(strings.Join(entityFacts(entity), " ") + " " + entity.Description)
+			for word := range queryWords {
+				if factWords[word] {
+					score++
+				}
+			}
+		}
+
+		if score > 0 {
+			candidates = append(candidates, scored{entity: entity, score: score})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].entity.LastUpdated.After(candidates[j].entity.LastUpdated)
+	})
+
+	if len(candidates) > m.maxRelevantEntities {
+		candidates = candidates[:m.maxRelevantEntities]
+	}
+
+	relevant := make([]*Entity, len(candidates))
+	for i, candidate := range candidates {
+		relevant[i] = copyEntity(candidate.entity)
+	}
+	return relevant
+}
+
+// trimEntities evicts the least recently updated entities over the limit
+func (m *DefaultEntityMemory) trimEntities() {
+	if len(m.entities) <= m.maxEntities {
+		return
+	}
+
+	keys := make([]string, 0, len(m.entities))
+	for key := range m.entities {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return m.entities[keys[i]].LastUpdated.Before(m.entities[keys[j]].LastUpdated)
+	})
+
+	for _, key := range keys[:len(keys)-m.maxEntities] {
+		delete(m.entities, key)
+	}
+}
+
+func (m *DefaultEntityMemory) saveToStore(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+
+	entities := make([]*Entity, 0, len(m.entities))
+	for _, entity := range m.entities {
+		entities = append(entities, entity)
+	}
+
+	return m.store.Store(ctx, entityStoreKey, entities)
+}
+
+func (m *DefaultEntityMemory) loadFromStore(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+
+	data, err := m.store.Retrieve(ctx, entityStoreKey)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil // New session
+		}
+		return err
+	}
+
+	entities, ok := data.([]*Entity)
+	if !ok {
+		if err := decodeStored(data, &entities); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, entity := range entities {
+		if entity != nil && entity.Name != "" {
+			m.entities[normalizeEntityName(entity.Name)] = entity
+		}
+	}
+	m.trimEntities()
+
+	return nil
+}
+
+// formatEntities renders entities for prompt injection
+func formatEntities(entities []*Entity) string {
+	var builder strings.Builder
+	for _, entity := range entities {
+		builder.WriteString(entity.Name)
+		if entity.Type != "" {
+			fmt.Fprintf(&builder, " (%s)", entity.Type)
+		}
+		builder.WriteString(": ")
+		if entity.Description != "" {
+			builder.WriteString(entity.Description)
+		} else {
+			builder.WriteString(strings.Join(entityFacts(entity), ". "))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// entityFacts returns the facts property, which holds []interface{} after a
+// round trip through JSON
+func entityFacts(entity *Entity) []string {
+	switch facts := entity.Properties["facts"].(type) {
+	case []string:
+		return append([]string(nil), facts...)
+	case []interface{}:
+		result := make([]string, 0, len(facts))
+		for _, fact := range facts {
+			if s, ok := fact.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func entityMentions(entity *Entity) int {
+	switch mentions := entity.Properties["mentions"].(type) {
+	case int:
+		return mentions
+	case float64:
+		return int(mentions)
+	default:
+		return 0
+	}
+}
+
+func copyEntity(entity *Entity) *Entity {
+	copied := *entity
+	copied.Properties = make(map[string]interface{}, len(entity.Properties))
+	for key, value := range entity.Properties {
+		copied.Properties[key] = value
+	}
+	if facts := entityFacts(entity); facts != nil {
+		copied.Properties["facts"] = facts
+	}
+	return &copied
+}
+
+func normalizeEntityName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// entityWords returns the lowercase words of text longer than two characters
+func entityWords(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len(word) > 2 {
+			words[word] = true
+		}
+	}
+	return words
+}
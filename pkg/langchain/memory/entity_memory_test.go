@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLLM answers entity extraction prompts with the next canned JSON reply
+// and summary prompts with a fixed summary
+type fakeLLM struct {
+	extractions []string
+	summary     string
+	prompts     []string
+}
+
+func (f *fakeLLM) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	prompt := req.Messages[0].Content
+	f.prompts = append(f.prompts, prompt)
+	if strings.HasPrefix(prompt, "Update the summary") {
+		return &llm.CompletionResponse{Content: f.summary}, nil
+	}
+	reply := f.extractions[0]
+	f.extractions = f.extractions[1:]
+	return &llm.CompletionResponse{Content: reply}, nil
+}
+
+func (f *fakeLLM) Stream(ctx context.Context, req *llm.CompletionRequest) (<-chan llm.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *fakeLLM) GetEmbeddings(ctx context.Context, req *llm.EmbeddingRequest) (*llm.EmbeddingResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *fakeLLM) GetProvider() llm.LLMProvider {
+	return "fake"
+}
+
+func (f *fakeLLM) GetModels(ctx context.Context) ([]string, error) {
+	return []string{"fake"}, nil
+}
+
+func (f *fakeLLM) ValidateModel(ctx context.Context, model string) error {
+	return nil
+}
+
+func (f *fakeLLM) Close() error {
+	return nil
+}
+
+func TestEntityMemory(t *testing.T) {
+	ctx := context.Background()
+	model := &fakeLLM{
+		extractions: []string{
+			`{"entities": [
+				{"name": "Maria Lopez", "type": "person", "facts": ["Prefers email contact"]},
+				{"name": "Order 1042", "type": "order", "facts": ["Arrived damaged"]}
+			]}`,
+			`{"entities": [{"name": "maria lopez", "type": "person", "facts": ["Prefers email contact", "Lives in Lisbon"]}]}`,
+		},
+		summary: "Maria Lopez lives in Lisbon and prefers email contact.",
+	}
+
+	mem, err := NewEntityMemory(&EntityMemoryConfig{LLM: model}, logrus.New())
+	require.NoError(t, err)
+
+	require.NoError(t, mem.SaveContext(ctx,
+		map[string]interface{}{"input": "Hi, I'm Maria Lopez. Order 1042 arrived damaged, please email me."},
+		map[string]interface{}{"text": "Sorry to hear that, Maria."}))
+	require.NoError(t, mem.SaveContext(ctx,
+		map[string]interface{}{"input": "I live in Lisbon by the way."},
+		map[string]interface{}{"text": "Noted."}))
+
+	// Facts are merged case-insensitively and the summary is rewritten once new facts arrive
+	maria, err := mem.GetEntity(ctx, "MARIA LOPEZ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Prefers email contact", "Lives in Lisbon"}, maria.Properties["facts"])
+	assert.Equal(t, model.summary, maria.Description)
+	assert.Equal(t, 2, maria.Properties["mentions"])
+
+	// Only entities relevant to the input are injected
+	variables, err := mem.LoadMemoryVariables(ctx, map[string]interface{}{"input": "Any update on order 1042?"})
+	require.NoError(t, err)
+	assert.Equal(t, "Order 1042 (order): Arrived damaged\n", variables["entities"])
+
+	entities, err := mem.GetEntities(ctx)
+	require.NoError(t, err)
+	assert.Len(t, entities, 2)
+}
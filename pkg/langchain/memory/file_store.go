@@ -0,0 +1,564 @@
+package memory
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FileSessionStore implements SessionStore with one JSON lines file per
+// session under a directory per user. Every write appends a record, so a
+// session file is a log replayed on first access; files are compacted once
+// superseded records outnumber live ones. The most recently used sessions
+// are kept in memory; evicted ones are replayed again on their next access.
+type FileSessionStore struct {
+	baseDir   string
+	ttl       time.Duration
+	sessions  map[string]*fileSession // Loaded sessions keyed by file path
+	recent    *list.List              // File paths of loaded sessions, most recently used first
+	cacheSize int
+	logger    *logrus.Logger
+	tracer    trace.Tracer
+	mu        sync.Mutex
+}
+
+// DefaultFileSessionCacheSize is how many sessions a FileSessionStore keeps
+// in memory unless configured otherwise
+const DefaultFileSessionCacheSize = 1024
+
+// fileRecord is one line of a session file
+type fileRecord struct {
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Deleted   bool            `json:"deleted,omitempty"`
+}
+
+func (r *fileRecord) expired(now time.Time) bool {
+	return r.ExpiresAt != nil && !now.Before(*r.ExpiresAt)
+}
+
+// fileSession is the replayed state of a session file
+type fileSession struct {
+	records map[string]*fileRecord
+	lines   int
+	element *list.Element // Position in recent while cached
+}
+
+// NewFileSessionStore creates a file-backed session store. Keys expire ttl
+// after their last write; zero keeps them forever.
+func NewFileSessionStore(baseDir string, ttl time.Duration, logger *logrus.Logger) (*FileSessionStore, error) {
+	if baseDir == "" {
+		return nil, fmt.Errorf("base directory cannot be empty")
+	}
+
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create memory directory: %w", err)
+	}
+
+	return &FileSessionStore{
+		baseDir:   baseDir,
+		ttl:       ttl,
+		sessions:  make(map[string]*fileSession),
+		recent:    list.New(),
+		cacheSize: DefaultFileSessionCacheSize,
+		logger:    logger,
+		tracer:    otel.Tracer("langchain.memory.store.file"),
+	}, nil
+}
+
+// SetCacheSize sets how many sessions are kept in memory, evicting the least
+// recently used ones beyond it. Values below one use the default.
+func (s *FileSessionStore) SetCacheSize(size int) {
+	if size < 1 {
+		size = DefaultFileSessionCacheSize
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cacheSize = size
+	s.evict()
+}
+
+// Session returns a store confined to one session of a user
+func (s *FileSessionStore) Session(userID, sessionID string) MemoryStore {
+	return newSessionView(s, userID, sessionID)
+}
+
+// ListSessions lists the unexpired sessions of a user, most recently updated first
+func (s *FileSessionStore) ListSessions(ctx context.Context, userID string) ([]SessionInfo, error) {
+	_, span := s.tracer.Start(ctx, "file_session_store.list_sessions")
+	defer span.End()
+
+	if userID == "" {
+		userID = DefaultUserID
+	}
+	userDir, err := s.userDir(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(userDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []SessionInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]SessionInfo, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		sessionID, err := url.PathUnescape(strings.TrimSuffix(name, ".jsonl"))
+		if err != nil {
+			continue
+		}
+
+		// Listing reads every session; only those already cached stay cached
+		session, err := s.peek(filepath.Join(userDir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		info := SessionInfo{UserID: userID, SessionID: sessionID}
+		for _, record := range session.records {
+			if record.expired(now) {
+				continue
+			}
+			info.Keys++
+			if info.CreatedAt.IsZero() || record.CreatedAt.Before(info.CreatedAt) {
+				info.CreatedAt = record.CreatedAt
+			}
+			if record.UpdatedAt.After(info.UpdatedAt) {
+				info.UpdatedAt = record.UpdatedAt
+			}
+			if record.ExpiresAt != nil && (info.ExpiresAt == nil || record.ExpiresAt.After(*info.ExpiresAt)) {
+				expiresAt := *record.ExpiresAt
+				info.ExpiresAt = &expiresAt
+			}
+		}
+		if info.Keys > 0 {
+			if s.ttl == 0 {
+				info.ExpiresAt = nil
+			}
+			sessions = append(sessions, info)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+
+	return sessions, nil
+}
+
+// DeleteSession deletes a session and all its keys
+func (s *FileSessionStore) DeleteSession(ctx context.Context, userID, sessionID string) error {
+	_, span := s.tracer.Start(ctx, "file_session_store.delete_session")
+	defer span.End()
+
+	if userID == "" {
+		userID = DefaultUserID
+	}
+	path, err := s.sessionPath(userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.forget(path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes expired keys, removing sessions left empty
+func (s *FileSessionStore) PurgeExpired(ctx context.Context) (int, error) {
+	_, span := s.tracer.Start(ctx, "file_session_store.purge_expired")
+	defer span.End()
+
+	paths, err := filepath.Glob(filepath.Join(s.baseDir, "*", "*.jsonl"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	purged := 0
+	for _, path := range paths {
+		session, err := s.peek(path)
+		if err != nil {
+			return purged, err
+		}
+
+		expired := 0
+		for key, record := range session.records {
+			if record.expired(now) {
+				delete(session.records, key)
+				expired++
+			}
+		}
+		if expired == 0 {
+			continue
+		}
+		purged += expired
+
+		if len(session.records) == 0 {
+			s.forget(path)
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return purged, fmt.Errorf("failed to delete session: %w", err)
+			}
+			continue
+		}
+		if err := s.compact(path, session); err != nil {
+			return purged, err
+		}
+	}
+
+	if purged > 0 {
+		s.logger.WithField("keys", purged).Info("Purged expired memory keys")
+	}
+
+	return purged, nil
+}
+
+// Close releases the loaded sessions
+func (s *FileSessionStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions = make(map[string]*fileSession)
+	s.recent.Init()
+	return nil
+}
+
+// Backend methods used by the session views
+
+func (s *FileSessionStore) put(ctx context.Context, userID, sessionID, key string, value []byte) error {
+	path, err := s.sessionPath(userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.load(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	record := &fileRecord{Key: key, Value: value, CreatedAt: now, UpdatedAt: now}
+	if existing, exists := session.records[key]; exists && !existing.expired(now) {
+		record.CreatedAt = existing.CreatedAt
+	}
+	if s.ttl > 0 {
+		expiresAt := now.Add(s.ttl)
+		record.ExpiresAt = &expiresAt
+	}
+
+	return s.appendRecord(path, session, record)
+}
+
+func (s *FileSessionStore) get(ctx context.Context, userID, sessionID, key string) ([]byte, error) {
+	path, err := s.sessionPath(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	record, exists := session.records[key]
+	if !exists || record.expired(time.Now()) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+
+	return record.Value, nil
+}
+
+func (s *FileSessionStore) remove(ctx context.Context, userID, sessionID, key string) error {
+	path, err := s.sessionPath(userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.load(path)
+	if err != nil {
+		return err
+	}
+	if _, exists := session.records[key]; !exists {
+		return nil
+	}
+
+	now := time.Now()
+	return s.appendRecord(path, session, &fileRecord{Key: key, UpdatedAt: now, Deleted: true})
+}
+
+func (s *FileSessionStore) keys(ctx context.Context, userID, sessionID, prefix string) ([]string, error) {
+	path, err := s.sessionPath(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	keys := make([]string, 0, len(session.records))
+	for key, record := range session.records {
+		if strings.HasPrefix(key, prefix) && !record.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Helper methods
+
+// load returns the replayed session of a file, reading it when it is not
+// cached and marking it most recently used. Callers hold the lock.
+func (s *FileSessionStore) load(path string) (*fileSession, error) {
+	if session, exists := s.sessions[path]; exists {
+		s.recent.MoveToFront(session.element)
+		return session, nil
+	}
+
+	session, err := s.read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	session.element = s.recent.PushFront(path)
+	s.sessions[path] = session
+	s.evict()
+
+	return session, nil
+}
+
+// peek returns the cached session of a file, or reads it without caching it.
+// Callers hold the lock.
+func (s *FileSessionStore) peek(path string) (*fileSession, error) {
+	if session, exists := s.sessions[path]; exists {
+		return session, nil
+	}
+	return s.read(path)
+}
+
+// forget drops a session from the cache. Callers hold the lock.
+func (s *FileSessionStore) forget(path string) {
+	if session, exists := s.sessions[path]; exists {
+		s.recent.Remove(session.element)
+		delete(s.sessions, path)
+	}
+}
+
+// evict drops the least recently used sessions beyond the cache size. Every
+// change is appended to its file first, so nothing is lost. Callers hold the lock.
+func (s *FileSessionStore) evict() {
+	for s.recent.Len() > s.cacheSize {
+		s.forget(s.recent.Back().Value.(string))
+	}
+}
+
+// read replays a session file; a missing file is an empty session
+func (s *FileSessionStore) read(path string) (*fileSession, error) {
+	session := &fileSession{records: make(map[string]*fileRecord)}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return session, nil
+		}
+		return nil, fmt.Errorf("failed to open session: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		session.lines++
+
+		var record fileRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			// A crash mid-append leaves a partial last line; skip it
+			s.logger.WithError(err).WithField("path", path).Warn("Skipping corrupt memory record")
+			continue
+		}
+		if record.Deleted {
+			delete(session.records, record.Key)
+		} else {
+			session.records[record.Key] = &record
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	return session, nil
+}
+
+// appendRecord appends a record to a session file and applies it to the
+// session, compacting the file when most of its lines are superseded. The
+// record is applied before compacting so the compacted file keeps it.
+func (s *FileSessionStore) appendRecord(path string, session *fileSession, record *fileRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory record: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create user directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+	session.lines++
+
+	if record.Deleted {
+		delete(session.records, record.Key)
+	} else {
+		session.records[record.Key] = record
+	}
+
+	if session.lines > 2*(len(session.records)+1)+16 {
+		return s.compact(path, session)
+	}
+	return nil
+}
+
+// compact rewrites a session file with only its live records
+func (s *FileSessionStore) compact(path string, session *fileSession) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	writer := bufio.NewWriter(tmp)
+	keys := make([]string, 0, len(session.records))
+	for key := range session.records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		line, err := json.Marshal(session.records[key])
+		if err == nil {
+			_, err = writer.Write(append(line, '\n'))
+		}
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpName)
+			return fmt.Errorf("failed to compact session: %w", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to compact session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to compact session: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to replace session: %w", err)
+	}
+
+	session.lines = len(keys)
+	return nil
+}
+
+func (s *FileSessionStore) userDir(userID string) (string, error) {
+	name, err := storageName(userID)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.baseDir, name), nil
+}
+
+func (s *FileSessionStore) sessionPath(userID, sessionID string) (string, error) {
+	userDir, err := s.userDir(userID)
+	if err != nil {
+		return "", err
+	}
+	name, err := storageName(sessionID)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userDir, name+".jsonl"), nil
+}
+
+// storageName escapes an identifier into a single path element
+func storageName(id string) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("identifier cannot be empty")
+	}
+	name := url.PathEscape(id)
+	name = strings.ReplaceAll(name, `\`, "%5C")
+	if name == "." || name == ".." || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid identifier: %s", id)
+	}
+	return name, nil
+}
@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultMemoryManager implements MemoryManager. It also owns the session
+// store selected by its configuration and creates conversation memories
+// persisted in it.
+type DefaultMemoryManager struct {
+	memories map[string]Memory
+	store    SessionStore
+	logger   *logrus.Logger
+	tracer   trace.Tracer
+	mu       sync.RWMutex
+}
+
+// MemoryManagerConfig represents configuration for the memory manager
+type MemoryManagerConfig struct {
+	Store StoreConfig `json:"store" yaml:"store"`
+}
+
+// NewMemoryManager creates a new memory manager with the configured store
+func NewMemoryManager(config *MemoryManagerConfig, logger *logrus.Logger) (*DefaultMemoryManager, error) {
+	if config == nil {
+		config = &MemoryManagerConfig{}
+	}
+
+	store, err := NewSessionStore(&config.Store, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DefaultMemoryManager{
+		memories: make(map[string]Memory),
+		store:    store,
+		logger:   logger,
+		tracer:   otel.Tracer("langchain.memory.manager"),
+	}, nil
+}
+
+// AddMemory adds a memory system
+func (m *DefaultMemoryManager) AddMemory(name string, memory Memory) error {
+	if memory == nil {
+		return fmt.Errorf("memory cannot be nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.memories[name]; exists {
+		return fmt.Errorf("memory already exists: %s", name)
+	}
+	m.memories[name] = memory
+
+	return nil
+}
+
+// GetMemory retrieves a memory system by name
+func (m *DefaultMemoryManager) GetMemory(name string) (Memory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	memory, exists := m.memories[name]
+	if !exists {
+		return nil, fmt.Errorf("memory not found: %s", name)
+	}
+
+	return memory, nil
+}
+
+// RemoveMemory removes a memory system
+func (m *DefaultMemoryManager) RemoveMemory(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.memories[name]; !exists {
+		return fmt.Errorf("memory not found: %s", name)
+	}
+	delete(m.memories, name)
+
+	return nil
+}
+
+// LoadAllMemoryVariables loads variables from all memory systems. Memories
+// are visited in name order, so later names win on key clashes.
+func (m *DefaultMemoryManager) LoadAllMemoryVariables(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	ctx, span := m.tracer.Start(ctx, "memory_manager.load_all_variables")
+	defer span.End()
+
+	variables := make(map[string]interface{})
+	for _, name := range m.memoryNames() {
+		memory, err := m.GetMemory(name)
+		if err != nil {
+			continue
+		}
+
+		memoryVariables, err := memory.LoadMemoryVariables(ctx, input)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to load variables from memory %s: %w", name, err)
+		}
+		for key, value := range memoryVariables {
+			variables[key] = value
+		}
+	}
+
+	span.SetAttributes(attribute.Int("memory.variables_count", len(variables)))
+
+	return variables, nil
+}
+
+// SaveAllContext saves context to all memory systems, continuing past
+// failures and returning the last error
+func (m *DefaultMemoryManager) SaveAllContext(ctx context.Context, input map[string]interface{}, output map[string]interface{}) error {
+	ctx, span := m.tracer.Start(ctx, "memory_manager.save_all_context")
+	defer span.End()
+
+	var lastErr error
+	for _, name := range m.memoryNames() {
+		memory, err := m.GetMemory(name)
+		if err != nil {
+			continue
+		}
+
+		if err := memory.SaveContext(ctx, input, output); err != nil {
+			m.logger.WithError(err).WithField("memory", name).Error("Failed to save context")
+			span.RecordError(err)
+			lastErr = fmt.Errorf("failed to save context to memory %s: %w", name, err)
+		}
+	}
+
+	return lastErr
+}
+
+// ClearAll clears all memory systems
+func (m *DefaultMemoryManager) ClearAll(ctx context.Context) error {
+	var lastErr error
+	for _, name := range m.memoryNames() {
+		memory, err := m.GetMemory(name)
+		if err != nil {
+			continue
+		}
+
+		if err := memory.Clear(ctx); err != nil {
+			m.logger.WithError(err).WithField("memory", name).Error("Failed to clear memory")
+			lastErr = fmt.Errorf("failed to clear memory %s: %w", name, err)
+		}
+	}
+
+	return lastErr
+}
+
+// GetMemoryTypes returns the distinct types of the managed memories
+func (m *DefaultMemoryManager) GetMemoryTypes() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	types := make([]string, 0, len(m.memories))
+	for _, memory := range m.memories {
+		memoryType := memory.GetMemoryType()
+		if !seen[memoryType] {
+			seen[memoryType] = true
+			types = append(types, memoryType)
+		}
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// GetSessionStore returns the configured session store, or nil when memory
+// is not persisted
+func (m *DefaultMemoryManager) GetSessionStore() SessionStore {
+	return m.store
+}
+
+// SessionStore returns the store of one session of a user, or nil when
+// memory is not persisted
+func (m *DefaultMemoryManager) SessionStore(userID, sessionID string) MemoryStore {
+	if m.store == nil {
+		return nil
+	}
+	return m.store.Session(userID, sessionID)
+}
+
+// NewConversationMemory creates a conversation memory persisted in a session
+// of the configured store, loading the history saved there
+func (m *DefaultMemoryManager) NewConversationMemory(userID, sessionID string, config *ConversationMemoryConfig) (ConversationMemory, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("session ID cannot be empty")
+	}
+	if config == nil {
+		config = &ConversationMemoryConfig{}
+	}
+
+	sessionConfig := *config
+	if store := m.SessionStore(userID, sessionID); store != nil {
+		sessionConfig.Store = store
+		sessionConfig.Persistent = true
+	}
+
+	return NewConversationMemory(&sessionConfig, m.logger)
+}
+
+// NewBufferWindowMemory creates a buffer window memory persisted in a session
+func (m *DefaultMemoryManager) NewBufferWindowMemory(userID, sessionID string, windowSize int) (*BufferWindowMemory, error) {
+	baseMemory, err := m.NewConversationMemory(userID, sessionID, &ConversationMemoryConfig{
+		MaxMessages: windowSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &BufferWindowMemory{
+		DefaultConversationMemory: baseMemory.(*DefaultConversationMemory),
+		windowSize:                windowSize,
+	}, nil
+}
+
+// NewTokenBufferMemory creates a token buffer memory persisted in a session
+func (m *DefaultMemoryManager) NewTokenBufferMemory(userID, sessionID string, tokenLimit int) (*TokenBufferMemory, error) {
+	baseMemory, err := m.NewConversationMemory(userID, sessionID, &ConversationMemoryConfig{
+		MaxTokens: tokenLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenBufferMemory{
+		DefaultConversationMemory: baseMemory.(*DefaultConversationMemory),
+		tokenLimit:                tokenLimit,
+	}, nil
+}
+
+// ListSessions lists the stored sessions of a user
+func (m *DefaultMemoryManager) ListSessions(ctx context.Context, userID string) ([]SessionInfo, error) {
+	if m.store == nil {
+		return []SessionInfo{}, nil
+	}
+	return m.store.ListSessions(ctx, userID)
+}
+
+// DeleteSession deletes a stored session of a user
+func (m *DefaultMemoryManager) DeleteSession(ctx context.Context, userID, sessionID string) error {
+	if m.store == nil {
+		return nil
+	}
+	return m.store.DeleteSession(ctx, userID, sessionID)
+}
+
+// Close closes the session store
+func (m *DefaultMemoryManager) Close() error {
+	if m.store == nil {
+		return nil
+	}
+	return m.store.Close()
+}
+
+func (m *DefaultMemoryManager) memoryNames() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.memories))
+	for name := range m.memories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PostgresSessionStore implements SessionStore on PostgreSQL.
+// The table is created by scripts/migrations/000005_langchain_memory.up.sql.
+type PostgresSessionStore struct {
+	db     *sqlx.DB
+	ownsDB bool // Whether Close closes db
+	ttl    time.Duration
+	logger *logrus.Logger
+	tracer trace.Tracer
+}
+
+// NewPostgresSessionStore creates a PostgreSQL-backed session store. Keys
+// expire ttl after their last write; zero keeps them forever. The connection
+// belongs to the caller and is left open by Close.
+func NewPostgresSessionStore(db *sqlx.DB, ttl time.Duration, logger *logrus.Logger) *PostgresSessionStore {
+	return &PostgresSessionStore{
+		db:     db,
+		ttl:    ttl,
+		logger: logger,
+		tracer: otel.Tracer("langchain.memory.store.postgres"),
+	}
+}
+
+// Session returns a store confined to one session of a user
+func (s *PostgresSessionStore) Session(userID, sessionID string) MemoryStore {
+	return newSessionView(s, userID, sessionID)
+}
+
+// ListSessions lists the unexpired sessions of a user, most recently updated first
+func (s *PostgresSessionStore) ListSessions(ctx context.Context, userID string) ([]SessionInfo, error) {
+	ctx, span := s.tracer.Start(ctx, "postgres_session_store.list_sessions")
+	defer span.End()
+
+	if userID == "" {
+		userID = DefaultUserID
+	}
+
+	query := `
+		SELECT user_id, session_id, COUNT(*) AS keys,
+			MIN(created_at) AS created_at, MAX(updated_at) AS updated_at,
+			CASE WHEN BOOL_OR(expires_at IS NULL) THEN NULL ELSE MAX(expires_at) END AS expires_at
+		FROM aios.langchain_memory_entries
+		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
+		GROUP BY user_id, session_id
+		ORDER BY MAX(updated_at) DESC
+	`
+
+	sessions := []SessionInfo{}
+	if err := s.db.SelectContext(ctx, &sessions, query, userID); err != nil {
+		s.logger.WithError(err).Error("Failed to list memory sessions")
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession deletes a session and all its keys
+func (s *PostgresSessionStore) DeleteSession(ctx context.Context, userID, sessionID string) error {
+	ctx, span := s.tracer.Start(ctx, "postgres_session_store.delete_session")
+	defer span.End()
+
+	if userID == "" {
+		userID = DefaultUserID
+	}
+
+	query := `DELETE FROM aios.langchain_memory_entries WHERE user_id = $1 AND session_id = $2`
+	if _, err := s.db.ExecContext(ctx, query, userID, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes expired keys
+func (s *PostgresSessionStore) PurgeExpired(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "postgres_session_store.purge_expired")
+	defer span.End()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM aios.langchain_memory_entries WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired keys: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		s.logger.WithField("keys", rowsAffected).Info("Purged expired memory keys")
+	}
+
+	return int(rowsAffected), nil
+}
+
+// Close closes the database connection when the store opened it
+func (s *PostgresSessionStore) Close() error {
+	if !s.ownsDB {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Backend methods used by the session views
+
+func (s *PostgresSessionStore) put(ctx context.Context, userID, sessionID, key string, value []byte) error {
+	var expiresAt *time.Time
+	if s.ttl > 0 {
+		expires := time.Now().Add(s.ttl)
+		expiresAt = &expires
+	}
+
+	// An expired row is replaced as if it were new
+	query := `
+		INSERT INTO aios.langchain_memory_entries (user_id, session_id, key, value, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, session_id, key) DO UPDATE SET
+			value = EXCLUDED.value,
+			created_at = CASE
+				WHEN aios.langchain_memory_entries.expires_at <= NOW() THEN NOW()
+				ELSE aios.langchain_memory_entries.created_at
+			END,
+			updated_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, userID, sessionID, key, value, expiresAt); err != nil {
+		s.logger.WithError(err).Error("Failed to store memory key")
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *PostgresSessionStore) get(ctx context.Context, userID, sessionID, key string) ([]byte, error) {
+	query := `
+		SELECT value FROM aios.langchain_memory_entries
+		WHERE user_id = $1 AND session_id = $2 AND key = $3
+			AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	var value []byte
+	if err := s.db.GetContext(ctx, &value, query, userID, sessionID, key); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to retrieve %s: %w", key, err)
+	}
+
+	return value, nil
+}
+
+func (s *PostgresSessionStore) remove(ctx context.Context, userID, sessionID, key string) error {
+	query := `DELETE FROM aios.langchain_memory_entries WHERE user_id = $1 AND session_id = $2 AND key = $3`
+	if _, err := s.db.ExecContext(ctx, query, userID, sessionID, key); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *PostgresSessionStore) keys(ctx context.Context, userID, sessionID, prefix string) ([]string, error) {
+	query := `
+		SELECT key FROM aios.langchain_memory_entries
+		WHERE user_id = $1 AND session_id = $2 AND LEFT(key, LENGTH($3)) = $3
+			AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY key
+	`
+
+	keys := []string{}
+	if err := s.db.SelectContext(ctx, &keys, query, userID, sessionID, prefix); err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aios/aios/pkg/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrKeyNotFound is returned by MemoryStore.Retrieve for missing or expired keys
+var ErrKeyNotFound = errors.New("key not found")
+
+// DefaultUserID owns sessions created without a user
+const DefaultUserID = "default"
+
+// Store types selectable in StoreConfig
+const (
+	StoreTypeNone     = "none"
+	StoreTypeFile     = "file"
+	StoreTypePostgres = "postgres"
+)
+
+// SessionInfo describes a stored session
+type SessionInfo struct {
+	UserID    string     `json:"user_id" db:"user_id"`
+	SessionID string     `json:"session_id" db:"session_id"`
+	Keys      int        `json:"keys" db:"keys"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"` // When the last key expires; nil without TTL
+}
+
+// SessionStore persists memory grouped into sessions owned by users. Memory
+// systems use the MemoryStore returned by Session, which only sees the keys
+// of that session.
+type SessionStore interface {
+	// Session returns a store confined to one session of a user
+	Session(userID, sessionID string) MemoryStore
+
+	// ListSessions lists the unexpired sessions of a user, most recently updated first
+	ListSessions(ctx context.Context, userID string) ([]SessionInfo, error)
+
+	// DeleteSession deletes a session and all its keys
+	DeleteSession(ctx context.Context, userID, sessionID string) error
+
+	// PurgeExpired deletes expired keys and returns how many were removed
+	PurgeExpired(ctx context.Context) (int, error)
+
+	// Close closes the store
+	Close() error
+}
+
+// StoreConfig selects and configures a session store
+type StoreConfig struct {
+	Type      string          `json:"type" yaml:"type"`                                 // "none", "file" or "postgres"
+	Directory string          `json:"directory,omitempty" yaml:"directory,omitempty"`   // Root directory of the file store
+	TTL       time.Duration   `json:"ttl,omitempty" yaml:"ttl,omitempty"`               // Keys expire this long after their last write; zero keeps them forever
+	CacheSize int             `json:"cache_size,omitempty" yaml:"cache_size,omitempty"` // Sessions the file store keeps in memory; zero uses DefaultFileSessionCacheSize
+	Database  database.Config `json:"database,omitempty" yaml:"database,omitempty"`     // Connection used by the postgres store when DB is nil
+	DB        *sqlx.DB        `json:"-" yaml:"-"`                                       // Existing connection for the postgres store
+}
+
+// NewSessionStore creates the session store selected by config. The "none"
+// type, or an empty one, returns a nil store and keeps memory in process only.
+func NewSessionStore(config *StoreConfig, logger *logrus.Logger) (SessionStore, error) {
+	switch config.Type {
+	case "", StoreTypeNone:
+		return nil, nil
+	case StoreTypeFile:
+		store, err := NewFileSessionStore(config.Directory, config.TTL, logger)
+		if err != nil {
+			return nil, err
+		}
+		store.SetCacheSize(config.CacheSize)
+		return store, nil
+	case StoreTypePostgres:
+		if config.DB != nil {
+			return NewPostgresSessionStore(config.DB, config.TTL, logger), nil
+		}
+
+		db, err := database.NewConnection(config.Database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect memory store: %w", err)
+		}
+		store := NewPostgresSessionStore(db, config.TTL, logger)
+		store.ownsDB = true
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unsupported memory store type: %s", config.Type)
+	}
+}
+
+// sessionBackend is the storage behind the per-session MemoryStore views
+type sessionBackend interface {
+	put(ctx context.Context, userID, sessionID, key string, value []byte) error
+	get(ctx context.Context, userID, sessionID, key string) ([]byte, error)
+	remove(ctx context.Context, userID, sessionID, key string) error
+	keys(ctx context.Context, userID, sessionID, prefix string) ([]string, error)
+}
+
+// sessionView is a MemoryStore confined to one session. Values are stored as
+// JSON and retrieved as json.RawMessage; see decodeStored.
+type sessionView struct {
+	backend   sessionBackend
+	userID    string
+	sessionID string
+}
+
+func newSessionView(backend sessionBackend, userID, sessionID string) *sessionView {
+	if userID == "" {
+		userID = DefaultUserID
+	}
+	return &sessionView{backend: backend, userID: userID, sessionID: sessionID}
+}
+
+// Store stores data with a key
+func (v *sessionView) Store(ctx context.Context, key string, data interface{}) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+	return v.backend.put(ctx, v.userID, v.sessionID, key, value)
+}
+
+// Retrieve retrieves data by key as json.RawMessage
+func (v *sessionView) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	value, err := v.backend.get(ctx, v.userID, v.sessionID, key)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(value), nil
+}
+
+// Delete deletes data by key
+func (v *sessionView) Delete(ctx context.Context, key string) error {
+	return v.backend.remove(ctx, v.userID, v.sessionID, key)
+}
+
+// List lists all keys with optional prefix
+func (v *sessionView) List(ctx context.Context, prefix string) ([]string, error) {
+	return v.backend.keys(ctx, v.userID, v.sessionID, prefix)
+}
+
+// Exists checks if a key exists
+func (v *sessionView) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := v.backend.get(ctx, v.userID, v.sessionID, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Close does nothing; the session store owns the resources
+func (v *sessionView) Close() error {
+	return nil
+}
+
+// decodeStored decodes a value returned by MemoryStore.Retrieve into target.
+// Stores that serialize values return JSON, others the value as stored.
+func decodeStored(data interface{}, target interface{}) error {
+	var raw []byte
+	switch value := data.(type) {
+	case json.RawMessage:
+		raw = value
+	case []byte:
+		raw = value
+	default:
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("failed to decode stored value: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(raw, target); err != nil {
+		return fmt.Errorf("failed to decode stored value: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSessionStore(t *testing.T) {
+	ctx := context.Background()
+	config := &MemoryManagerConfig{Store: StoreConfig{Type: StoreTypeFile, Directory: t.TempDir()}}
+
+	manager, err := NewMemoryManager(config, logrus.New())
+	require.NoError(t, err)
+	var _ MemoryManager = manager
+
+	mem, err := manager.NewConversationMemory("alice", "support", nil)
+	require.NoError(t, err)
+	require.NoError(t, mem.AddMessage(ctx, llm.Message{Role: "user", Content: "My order is late"}))
+	require.NoError(t, mem.AddMessage(ctx, llm.Message{Role: "assistant", Content: "Let me check"}))
+	require.NoError(t, manager.Close())
+
+	// History survives a restart and stays scoped to its user
+	manager, err = NewMemoryManager(config, logrus.New())
+	require.NoError(t, err)
+	defer manager.Close()
+
+	mem, err = manager.NewConversationMemory("alice", "support", nil)
+	require.NoError(t, err)
+	messages, err := mem.GetMessages(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "My order is late", messages[0].Content)
+
+	other, err := manager.NewConversationMemory("bob", "support", nil)
+	require.NoError(t, err)
+	messages, err = other.GetMessages(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	sessions, err := manager.ListSessions(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "support", sessions[0].SessionID)
+
+	sessions, err = manager.ListSessions(ctx, "bob")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	require.NoError(t, manager.DeleteSession(ctx, "alice", "support"))
+	sessions, err = manager.ListSessions(ctx, "alice")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestFileSessionStoreCache(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileSessionStore(t.TempDir(), 0, logrus.New())
+	require.NoError(t, err)
+	defer store.Close()
+	store.SetCacheSize(2)
+
+	for _, sessionID := range []string{"a", "b", "c"} {
+		require.NoError(t, store.Session("alice", sessionID).Store(ctx, "topic", sessionID))
+	}
+	assert.Len(t, store.sessions, 2)
+	assert.NotContains(t, store.sessions, filepath.Join(store.baseDir, "alice", "a.jsonl"))
+
+	// The evicted session is replayed from its file
+	value, err := store.Session("alice", "a").Retrieve(ctx, "topic")
+	require.NoError(t, err)
+	assert.JSONEq(t, `"a"`, string(value.(json.RawMessage)))
+	assert.Len(t, store.sessions, 2)
+
+	// Listing reads every session without caching them
+	sessions, err := store.ListSessions(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 3)
+	assert.Len(t, store.sessions, 2)
+
+	require.NoError(t, store.DeleteSession(ctx, "alice", "a"))
+	assert.Len(t, store.sessions, 1)
+	assert.Equal(t, 1, store.recent.Len())
+}
+
+func TestFileSessionStoreCompaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileSessionStore(dir, 0, logrus.New())
+	require.NoError(t, err)
+	defer store.Close()
+
+	// reloaded replays the session from disk in a fresh store
+	reloaded := func() MemoryStore {
+		fresh, err := NewFileSessionStore(dir, 0, logrus.New())
+		require.NoError(t, err)
+		return fresh.Session("alice", "notes")
+	}
+
+	// Check the file after every write, so the writes that trigger a
+	// compaction are checked too
+	session := store.Session("alice", "notes")
+	for i := 1; i <= 30; i++ {
+		require.NoError(t, session.Store(ctx, "counter", i))
+		value, err := reloaded().Retrieve(ctx, "counter")
+		require.NoError(t, err)
+		require.JSONEq(t, fmt.Sprint(i), string(value.(json.RawMessage)), "after write %d", i)
+
+		require.NoError(t, session.Store(ctx, "scratch", i))
+		require.NoError(t, session.Delete(ctx, "scratch"))
+		exists, err := reloaded().Exists(ctx, "scratch")
+		require.NoError(t, err)
+		require.False(t, exists, "deleted key restored after write %d", i)
+	}
+}
+
+func TestPostgresSessionStoreKeepsSharedDB(t *testing.T) {
+	// Opening does not connect, so no server is needed
+	db, err := sqlx.Open("postgres", "host=127.0.0.1 port=1 sslmode=disable")
+	require.NoError(t, err)
+	defer db.Close()
+
+	store, err := NewSessionStore(&StoreConfig{Type: StoreTypePostgres, DB: db}, logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// A closed pool fails before dialing; an open one fails to dial
+	_, err = db.Conn(context.Background())
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "database is closed")
+}
+
+func TestFileSessionStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileSessionStore(t.TempDir(), 50*time.Millisecond, logrus.New())
+	require.NoError(t, err)
+	defer store.Close()
+
+	session := store.Session("alice", "scratch")
+	require.NoError(t, session.Store(ctx, "note", "remember the milk"))
+
+	exists, err := session.Exists(ctx, "note")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = session.Retrieve(ctx, "note")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	sessions, err := store.ListSessions(ctx, "alice")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	purged, err := store.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
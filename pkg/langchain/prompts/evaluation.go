@@ -0,0 +1,739 @@
+package prompts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+)
+
+// Check types supported by EvalCheck
+const (
+	CheckExactMatch = "exact_match"
+	CheckContains   = "contains"
+	CheckRegex      = "regex"
+	CheckJSONSchema = "json_schema"
+	CheckLLMJudge   = "llm_judge"
+)
+
+// EvalCheck is a rubric check applied to a model output. Every check scores
+// between 0 and 1; deterministic checks score either 0 or 1.
+type EvalCheck struct {
+	Type       string                 `json:"type" yaml:"type"`
+	Value      string                 `json:"value,omitempty" yaml:"value,omitempty"`             // Expected text, substring or pattern; exact_match defaults to the case's expected output
+	Schema     map[string]interface{} `json:"schema,omitempty" yaml:"schema,omitempty"`           // For json_schema
+	Rubric     string                 `json:"rubric,omitempty" yaml:"rubric,omitempty"`           // Grading criteria for llm_judge
+	IgnoreCase bool                   `json:"ignore_case,omitempty" yaml:"ignore_case,omitempty"` // For exact_match and contains
+	Weight     float64                `json:"weight,omitempty" yaml:"weight,omitempty"`           // Defaults to 1
+}
+
+// EvalCase is one dataset entry
+type EvalCase struct {
+	ID        string                 `json:"id" yaml:"id"`
+	Variables map[string]interface{} `json:"variables" yaml:"variables"`
+	Expected  string                 `json:"expected,omitempty" yaml:"expected,omitempty"`
+	Checks    []EvalCheck            `json:"checks,omitempty" yaml:"checks,omitempty"` // Applied in addition to the dataset checks
+}
+
+// EvalDataset is a set of cases and the checks applied to every case. A
+// case with an expected output and no checks at all gets an exact match.
+type EvalDataset struct {
+	Name   string      `json:"name" yaml:"name"`
+	Cases  []EvalCase  `json:"cases" yaml:"cases"`
+	Checks []EvalCheck `json:"checks,omitempty" yaml:"checks,omitempty"`
+}
+
+// LoadEvalDataset loads a dataset from a YAML or JSON file
+func LoadEvalDataset(path string) (*EvalDataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	var dataset EvalDataset
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &dataset)
+	} else {
+		err = yaml.Unmarshal(data, &dataset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dataset: %w", err)
+	}
+	if dataset.Name == "" {
+		dataset.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return &dataset, nil
+}
+
+// Validate checks the dataset for missing IDs, unknown check types and
+// invalid patterns
+func (d *EvalDataset) Validate() error {
+	if len(d.Cases) == 0 {
+		return fmt.Errorf("dataset has no cases")
+	}
+
+	seen := make(map[string]bool, len(d.Cases))
+	for i, evalCase := range d.Cases {
+		if evalCase.ID == "" {
+			return fmt.Errorf("case %d has no ID", i)
+		}
+		if seen[evalCase.ID] {
+			return fmt.Errorf("duplicate case ID: %s", evalCase.ID)
+		}
+		seen[evalCase.ID] = true
+
+		checks := d.checksFor(evalCase)
+		if len(checks) == 0 {
+			return fmt.Errorf("case %s has no expected output or checks", evalCase.ID)
+		}
+		for _, check := range checks {
+			if err := check.validate(evalCase); err != nil {
+				return fmt.Errorf("case %s: %w", evalCase.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *EvalDataset) checksFor(evalCase EvalCase) []EvalCheck {
+	checks := append(append([]EvalCheck(nil), d.Checks...), evalCase.Checks...)
+	if len(checks) == 0 && evalCase.Expected != "" {
+		checks = []EvalCheck{{Type: CheckExactMatch}}
+	}
+	return checks
+}
+
+func (c EvalCheck) validate(evalCase EvalCase) error {
+	switch c.Type {
+	case CheckExactMatch:
+		if c.Value == "" && evalCase.Expected == "" {
+			return fmt.Errorf("exact_match needs a value or an expected output")
+		}
+	case CheckContains:
+		if c.Value == "" {
+			return fmt.Errorf("contains needs a value")
+		}
+	case CheckRegex:
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return fmt.Errorf("invalid regex check: %w", err)
+		}
+	case CheckJSONSchema:
+		if c.Schema == nil {
+			return fmt.Errorf("json_schema needs a schema")
+		}
+	case CheckLLMJudge:
+		if c.Rubric == "" {
+			return fmt.Errorf("llm_judge needs a rubric")
+		}
+	default:
+		return fmt.Errorf("unknown check type: %s", c.Type)
+	}
+	return nil
+}
+
+func (c EvalCheck) weight() float64 {
+	if c.Weight <= 0 {
+		return 1
+	}
+	return c.Weight
+}
+
+// PromptVariant is a prompt version under evaluation
+type PromptVariant struct {
+	Name        string         `json:"name"`
+	Template    PromptTemplate `json:"-"`
+	Model       string         `json:"model,omitempty"`
+	Temperature float64        `json:"temperature,omitempty"`
+	MaxTokens   int            `json:"max_tokens,omitempty"`
+}
+
+// VariantsFromRegistry creates a variant for each version of a registered
+// prompt, named "<prompt>@<version>"
+func VariantsFromRegistry(registry PromptVersioning, name string, versions ...string) ([]PromptVariant, error) {
+	variants := make([]PromptVariant, 0, len(versions))
+	for _, version := range versions {
+		template, err := registry.GetVersion(name, version)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, PromptVariant{Name: name + "@" + version, Template: template})
+	}
+	return variants, nil
+}
+
+// CheckResult is the outcome of one check on one output
+type CheckResult struct {
+	Type   string  `json:"type"`
+	Score  float64 `json:"score"`
+	Passed bool    `json:"passed"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// CaseResult is the outcome of one case for one variant
+type CaseResult struct {
+	CaseID  string         `json:"case_id"`
+	Output  string         `json:"output"`
+	Score   float64        `json:"score"` // Weighted mean of the check scores
+	Passed  bool           `json:"passed"`
+	Checks  []CheckResult  `json:"checks"`
+	Latency time.Duration  `json:"latency"`
+	Usage   llm.TokenUsage `json:"usage"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// VariantReport aggregates the results of one variant
+type VariantReport struct {
+	Name        string             `json:"name"`
+	Cases       []CaseResult       `json:"cases"`
+	MeanScore   float64            `json:"mean_score"`
+	PassRate    float64            `json:"pass_rate"`
+	Errors      int                `json:"errors"`
+	MeanLatency time.Duration      `json:"mean_latency"`
+	P95Latency  time.Duration      `json:"p95_latency"`
+	Usage       llm.TokenUsage     `json:"usage"`
+	CheckScores map[string]float64 `json:"check_scores"` // Mean score per check type
+}
+
+// EvalReport is the result of an evaluation run
+type EvalReport struct {
+	Dataset   string          `json:"dataset"`
+	StartedAt time.Time       `json:"started_at"`
+	Duration  time.Duration   `json:"duration"`
+	Variants  []VariantReport `json:"variants"`
+	Best      string          `json:"best"` // Variant with the highest mean score
+}
+
+// JSON renders the report as indented JSON
+func (r *EvalReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Markdown renders the report as markdown tables
+func (r *EvalReport) Markdown() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# Prompt evaluation: %s\n\n", r.Dataset)
+	sb.WriteString("| Variant | Mean score | Pass rate | Errors | Mean latency | p95 latency | Prompt tokens | Completion tokens |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|---|\n")
+	for _, variant := range r.Variants {
+		fmt.Fprintf(&sb, "| %s | %.3f | %.0f%% | %d | %s | %s | %d | %d |\n",
+			variant.Name, variant.MeanScore, variant.PassRate*100, variant.Errors,
+			variant.MeanLatency.Round(time.Millisecond), variant.P95Latency.Round(time.Millisecond),
+			variant.Usage.PromptTokens, variant.Usage.CompletionTokens)
+	}
+	if r.Best != "" {
+		fmt.Fprintf(&sb, "\nBest variant: **%s**\n", r.Best)
+	}
+
+	if len(r.Variants) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\n## Cases\n\n| Case |")
+	for _, variant := range r.Variants {
+		fmt.Fprintf(&sb, " %s |", variant.Name)
+	}
+	sb.WriteString("\n|---|")
+	for range r.Variants {
+		sb.WriteString("---|")
+	}
+	sb.WriteString("\n")
+
+	for i, caseResult := range r.Variants[0].Cases {
+		fmt.Fprintf(&sb, "| %s |", caseResult.CaseID)
+		for _, variant := range r.Variants {
+			result := variant.Cases[i]
+			switch {
+			case result.Error != "":
+				sb.WriteString(" error |")
+			case result.Passed:
+				fmt.Fprintf(&sb, " %.2f pass |", result.Score)
+			default:
+				fmt.Fprintf(&sb, " %.2f fail |", result.Score)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// Evaluator runs prompt variants over a dataset and scores the outputs
+type Evaluator struct {
+	llm            llm.LLM
+	judge          llm.LLM
+	tokenizer      llm.Tokenizer
+	maxConcurrency int
+	passThreshold  float64
+	metrics        PromptMetrics
+	logger         *logrus.Logger
+	tracer         trace.Tracer
+}
+
+// EvaluatorConfig represents configuration for an evaluator
+type EvaluatorConfig struct {
+	LLM            llm.LLM       `json:"-"`
+	JudgeLLM       llm.LLM       `json:"-"` // Grades llm_judge checks; defaults to LLM
+	Tokenizer      llm.Tokenizer `json:"-"` // Estimates usage when the provider reports none; defaults to llm.NewHeuristicTokenizer()
+	MaxConcurrency int           `json:"max_concurrency,omitempty"`
+	PassThreshold  float64       `json:"pass_threshold,omitempty"` // Minimum check score to pass; defaults to 0.5
+	Metrics        PromptMetrics `json:"-"`                        // Receives usage and performance of every run
+}
+
+// NewEvaluator creates a new evaluator
+func NewEvaluator(config *EvaluatorConfig, logger *logrus.Logger) (*Evaluator, error) {
+	if config.LLM == nil {
+		return nil, fmt.Errorf("LLM is required")
+	}
+
+	evaluator := &Evaluator{
+		llm:            config.LLM,
+		judge:          config.JudgeLLM,
+		tokenizer:      config.Tokenizer,
+		maxConcurrency: config.MaxConcurrency,
+		passThreshold:  config.PassThreshold,
+		metrics:        config.Metrics,
+		logger:         logger,
+		tracer:         otel.Tracer("langchain.prompts.evaluation"),
+	}
+
+	if evaluator.judge == nil {
+		evaluator.judge = config.LLM
+	}
+	if evaluator.tokenizer == nil {
+		evaluator.tokenizer = llm.NewHeuristicTokenizer()
+	}
+	if evaluator.maxConcurrency <= 0 {
+		evaluator.maxConcurrency = 4
+	}
+	if evaluator.passThreshold <= 0 {
+		evaluator.passThreshold = 0.5
+	}
+
+	return evaluator, nil
+}
+
+// Run evaluates every variant on every case of the dataset. Model errors
+// are recorded on the case and score zero; they do not stop the run.
+func (e *Evaluator) Run(ctx context.Context, dataset *EvalDataset, variants []PromptVariant) (*EvalReport, error) {
+	ctx, span := e.tracer.Start(ctx, "evaluator.run")
+	defer span.End()
+
+	if err := dataset.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid dataset: %w", err)
+	}
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("at least one variant is required")
+	}
+	names := make(map[string]bool, len(variants))
+	for _, variant := range variants {
+		if variant.Name == "" || variant.Template == nil {
+			return nil, fmt.Errorf("variants need a name and a template")
+		}
+		if names[variant.Name] {
+			return nil, fmt.Errorf("duplicate variant name: %s", variant.Name)
+		}
+		names[variant.Name] = true
+	}
+
+	span.SetAttributes(
+		attribute.String("eval.dataset", dataset.Name),
+		attribute.Int("eval.cases", len(dataset.Cases)),
+		attribute.Int("eval.variants", len(variants)),
+	)
+
+	report := &EvalReport{Dataset: dataset.Name, StartedAt: time.Now()}
+	for _, variant := range variants {
+		variantReport, err := e.runVariant(ctx, dataset, variant)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		report.Variants = append(report.Variants, *variantReport)
+	}
+	report.Duration = time.Since(report.StartedAt)
+
+	best := -1.0
+	for _, variant := range report.Variants {
+		if variant.MeanScore > best {
+			best = variant.MeanScore
+			report.Best = variant.Name
+		}
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"dataset":  dataset.Name,
+		"variants": len(variants),
+		"cases":    len(dataset.Cases),
+		"best":     report.Best,
+		"duration": report.Duration,
+	}).Info("Prompt evaluation completed")
+
+	return report, nil
+}
+
+func (e *Evaluator) runVariant(ctx context.Context, dataset *EvalDataset, variant PromptVariant) (*VariantReport, error) {
+	results := make([]CaseResult, len(dataset.Cases))
+	semaphore := make(chan struct{}, e.maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, evalCase := range dataset.Cases {
+		wg.Add(1)
+		go func(i int, evalCase EvalCase) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[i] = e.runCase(ctx, dataset, variant, evalCase)
+		}(i, evalCase)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("evaluation cancelled: %w", err)
+	}
+
+	return summarizeVariant(variant.Name, results), nil
+}
+
+func (e *Evaluator) runCase(ctx context.Context, dataset *EvalDataset, variant PromptVariant, evalCase EvalCase) CaseResult {
+	result := CaseResult{CaseID: evalCase.ID}
+
+	value, err := variant.Template.FormatPrompt(evalCase.Variables)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to format prompt: %v", err)
+		return result
+	}
+
+	req := &llm.CompletionRequest{
+		Messages:    value.ToMessages(),
+		Model:       variant.Model,
+		Temperature: variant.Temperature,
+		MaxTokens:   variant.MaxTokens,
+	}
+
+	start := time.Now()
+	response, err := e.llm.Complete(ctx, req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		e.recordMetrics(variant.Name, evalCase.Variables, result)
+		return result
+	}
+
+	result.Output = response.Content
+	result.Usage = response.Usage
+	if result.Usage.TotalTokens == 0 {
+		result.Usage.PromptTokens = llm.CountMessageTokens(e.tokenizer, req.Messages)
+		result.Usage.CompletionTokens = e.tokenizer.CountTokens(response.Content)
+		result.Usage.TotalTokens = result.Usage.PromptTokens + result.Usage.CompletionTokens
+	}
+
+	var weighted, totalWeight float64
+	result.Passed = true
+	for _, check := range dataset.checksFor(evalCase) {
+		checkResult := e.runCheck(ctx, check, evalCase, result.Output)
+		result.Checks = append(result.Checks, checkResult)
+		weighted += checkResult.Score * check.weight()
+		totalWeight += check.weight()
+		result.Passed = result.Passed && checkResult.Passed
+	}
+	result.Score = weighted / totalWeight
+
+	e.recordMetrics(variant.Name, evalCase.Variables, result)
+
+	return result
+}
+
+func (e *Evaluator) runCheck(ctx context.Context, check EvalCheck, evalCase EvalCase, output string) CheckResult {
+	result := CheckResult{Type: check.Type}
+	pass := func(passed bool, detail string) CheckResult {
+		if passed {
+			result.Score = 1
+		}
+		result.Passed = passed
+		if !passed {
+			result.Detail = detail
+		}
+		return result
+	}
+
+	switch check.Type {
+	case CheckExactMatch:
+		expected := check.Value
+		if expected == "" {
+			expected = evalCase.Expected
+		}
+		actual := strings.TrimSpace(output)
+		expected = strings.TrimSpace(expected)
+		if check.IgnoreCase {
+			return pass(strings.EqualFold(actual, expected), fmt.Sprintf("expected %q", expected))
+		}
+		return pass(actual == expected, fmt.Sprintf("expected %q", expected))
+
+	case CheckContains:
+		if check.IgnoreCase {
+			return pass(strings.Contains(strings.ToLower(output), strings.ToLower(check.Value)), fmt.Sprintf("missing %q", check.Value))
+		}
+		return pass(strings.Contains(output, check.Value), fmt.Sprintf("missing %q", check.Value))
+
+	case CheckRegex:
+		re := regexp.MustCompile(check.Value) // Validated with the dataset
+		return pass(re.MatchString(output), fmt.Sprintf("no match for %s", check.Value))
+
+	case CheckJSONSchema:
+		var value interface{}
+		if err := json.Unmarshal([]byte(llm.ExtractJSON(output)), &value); err != nil {
+			return pass(false, fmt.Sprintf("invalid JSON: %v", err))
+		}
+		errs := llm.ValidateJSONSchema(check.Schema, value)
+		return pass(len(errs) == 0, strings.Join(errs, "; "))
+
+	case CheckLLMJudge:
+		verdict, err := e.judgeOutput(ctx, check, evalCase, output)
+		if err != nil {
+			result.Detail = fmt.Sprintf("judge failed: %v", err)
+			return result
+		}
+		result.Score = math.Max(0, math.Min(1, verdict.Score))
+		result.Passed = result.Score >= e.passThreshold
+		result.Detail = verdict.Reasoning
+		return result
+	}
+
+	result.Detail = "unknown check type"
+	return result
+}
+
+// judgeVerdict is the structured reply of the judge model
+type judgeVerdict struct {
+	Score     float64 `json:"score"`
+	Reasoning string  `json:"reasoning"`
+}
+
+var judgeSchema = &llm.ResponseSchema{
+	Name: "verdict",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"score":     map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+			"reasoning": map[string]interface{}{"type": "string"},
+		},
+		"required":             []interface{}{"score", "reasoning"},
+		"additionalProperties": false,
+	},
+	Strict: true,
+}
+
+func (e *Evaluator) judgeOutput(ctx context.Context, check EvalCheck, evalCase EvalCase, output string) (*judgeVerdict, error) {
+	variables, _ := json.Marshal(evalCase.Variables)
+
+	var prompt strings.Builder
+	prompt.WriteString("You are grading the output of a language model against a rubric.\n\n")
+	fmt.Fprintf(&prompt, "Rubric:\n%s\n\n", check.Rubric)
+	fmt.Fprintf(&prompt, "Input variables:\n%s\n\n", variables)
+	if evalCase.Expected != "" {
+		fmt.Fprintf(&prompt, "Reference answer:\n%s\n\n", evalCase.Expected)
+	}
+	fmt.Fprintf(&prompt, "Output to grade:\n%s\n\n", output)
+	prompt.WriteString("Score the output from 0 (fails the rubric) to 1 (fully meets it) and explain briefly.")
+
+	verdict, _, err := llm.CompleteTyped[judgeVerdict](ctx, e.judge, &llm.CompletionRequest{
+		Messages:       []llm.Message{{Role: "user", Content: prompt.String(), Timestamp: time.Now()}},
+		ResponseSchema: judgeSchema,
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &verdict, nil
+}
+
+func (e *Evaluator) recordMetrics(variant string, variables map[string]interface{}, result CaseResult) {
+	if e.metrics == nil {
+		return
+	}
+	e.metrics.RecordPromptUsage(variant, variables, result.Latency)
+	e.metrics.RecordPromptPerformance(variant, result.Usage.TotalTokens, 0, result.Error == "" && result.Passed)
+}
+
+func summarizeVariant(name string, results []CaseResult) *VariantReport {
+	report := &VariantReport{
+		Name:        name,
+		Cases:       results,
+		CheckScores: make(map[string]float64),
+	}
+
+	var totalScore float64
+	var totalLatency time.Duration
+	passed := 0
+	checkTotals := make(map[string]float64)
+	checkCounts := make(map[string]int)
+	latencies := make([]time.Duration, 0, len(results))
+
+	for _, result := range results {
+		if result.Error != "" {
+			report.Errors++
+		}
+		if result.Passed {
+			passed++
+		}
+		totalScore += result.Score
+		totalLatency += result.Latency
+		latencies = append(latencies, result.Latency)
+
+		report.Usage.PromptTokens += result.Usage.PromptTokens
+		report.Usage.CompletionTokens += result.Usage.CompletionTokens
+		report.Usage.TotalTokens += result.Usage.TotalTokens
+
+		for _, check := range result.Checks {
+			checkTotals[check.Type] += check.Score
+			checkCounts[check.Type]++
+		}
+	}
+
+	if len(results) > 0 {
+		report.MeanScore = totalScore / float64(len(results))
+		report.PassRate = float64(passed) / float64(len(results))
+		report.MeanLatency = totalLatency / time.Duration(len(results))
+
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		index := int(math.Ceil(0.95*float64(len(latencies)))) - 1
+		report.P95Latency = latencies[max(index, 0)]
+	}
+	for checkType, total := range checkTotals {
+		report.CheckScores[checkType] = total / float64(checkCounts[checkType])
+	}
+
+	return report
+}
+
+// DefaultPromptMetrics implements PromptMetrics in memory
+type DefaultPromptMetrics struct {
+	stats map[string]*promptStats
+	mu    sync.RWMutex
+}
+
+type promptStats struct {
+	uses          int64
+	totalDuration time.Duration
+	lastUsed      time.Time
+	runs          int64
+	successes     int64
+	totalTokens   int64
+	totalCost     float64
+}
+
+// NewPromptMetrics creates a new in-memory prompt metrics collector
+func NewPromptMetrics() *DefaultPromptMetrics {
+	return &DefaultPromptMetrics{stats: make(map[string]*promptStats)}
+}
+
+func (m *DefaultPromptMetrics) statsFor(templateName string) *promptStats {
+	stats, exists := m.stats[templateName]
+	if !exists {
+		stats = &promptStats{}
+		m.stats[templateName] = stats
+	}
+	return stats
+}
+
+// RecordPromptUsage records usage of a prompt template
+func (m *DefaultPromptMetrics) RecordPromptUsage(templateName string, variables map[string]interface{}, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.statsFor(templateName)
+	stats.uses++
+	stats.totalDuration += duration
+	stats.lastUsed = time.Now()
+}
+
+// RecordPromptPerformance records performance metrics for a prompt
+func (m *DefaultPromptMetrics) RecordPromptPerformance(templateName string, tokenCount int, cost float64, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.statsFor(templateName)
+	stats.runs++
+	stats.totalTokens += int64(tokenCount)
+	stats.totalCost += cost
+	if success {
+		stats.successes++
+	}
+}
+
+// GetUsageStats returns usage statistics for a prompt template
+func (m *DefaultPromptMetrics) GetUsageStats(templateName string) map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats, exists := m.stats[templateName]
+	if !exists {
+		return map[string]interface{}{"uses": int64(0)}
+	}
+
+	result := map[string]interface{}{
+		"uses":      stats.uses,
+		"last_used": stats.lastUsed,
+	}
+	if stats.uses > 0 {
+		result["average_duration"] = stats.totalDuration / time.Duration(stats.uses)
+	}
+	return result
+}
+
+// GetPerformanceStats returns performance statistics for a prompt template
+func (m *DefaultPromptMetrics) GetPerformanceStats(templateName string) map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats, exists := m.stats[templateName]
+	if !exists || stats.runs == 0 {
+		return map[string]interface{}{"runs": int64(0)}
+	}
+
+	return map[string]interface{}{
+		"runs":           stats.runs,
+		"success_rate":   float64(stats.successes) / float64(stats.runs),
+		"average_tokens": float64(stats.totalTokens) / float64(stats.runs),
+		"total_cost":     stats.totalCost,
+	}
+}
+
+// GetTopTemplates returns the most used templates
+func (m *DefaultPromptMetrics) GetTopTemplates(limit int) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.stats))
+	for name := range m.stats {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if m.stats[names[i]].uses != m.stats[names[j]].uses {
+			return m.stats[names[i]].uses > m.stats[names[j]].uses
+		}
+		return names[i] < names[j]
+	})
+
+	if limit > 0 && len(names) > limit {
+		names = names[:limit]
+	}
+	return names
+}
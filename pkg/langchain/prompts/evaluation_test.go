@@ -0,0 +1,180 @@
+package prompts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLLM answers deterministically from the text of the first message
+type fakeLLM struct {
+	reply func(prompt string) (string, error)
+}
+
+func (f *fakeLLM) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	content, err := f.reply(req.Messages[0].Content)
+	if err != nil {
+		return nil, err
+	}
+	return &llm.CompletionResponse{Content: content}, nil
+}
+
+func (f *fakeLLM) Stream(ctx context.Context, req *llm.CompletionRequest) (<-chan llm.StreamResponse, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (f *fakeLLM) GetEmbeddings(ctx context.Context, req *llm.EmbeddingRequest) (*llm.EmbeddingResponse, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (f *fakeLLM) GetProvider() llm.LLMProvider                          { return "fake" }
+func (f *fakeLLM) GetModels(ctx context.Context) ([]string, error)       { return []string{"fake"}, nil }
+func (f *fakeLLM) ValidateModel(ctx context.Context, model string) error { return nil }
+func (f *fakeLLM) Close() error                                          { return nil }
+
+func severityOf(prompt string) string {
+	switch {
+	case strings.Contains(prompt, "outage"):
+		return "critical"
+	case strings.Contains(prompt, "timeout"):
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+func TestEvaluatorComparesVariants(t *testing.T) {
+	model := &fakeLLM{reply: func(prompt string) (string, error) {
+		switch {
+		case strings.Contains(prompt, "grading"):
+			// The judge rewards answers that explain themselves
+			if strings.Contains(prompt, "because") {
+				return `{"score": 0.9, "reasoning": "explains the severity"}`, nil
+			}
+			return `{"score": 0.2, "reasoning": "no explanation"}`, nil
+		case strings.Contains(prompt, "disk"):
+			return "", fmt.Errorf("rate limited")
+		case strings.HasPrefix(prompt, "Reply in JSON"):
+			return fmt.Sprintf("```json\n{\"severity\": %q, \"reason\": \"%s because of the report\"}\n```", severityOf(prompt), severityOf(prompt)), nil
+		default:
+			return severityOf(prompt), nil
+		}
+	}}
+
+	dataset := &EvalDataset{Name: "severity"}
+	cases := map[string]string{"outage": "critical", "timeout": "error", "disk": "warning"}
+	for _, id := range []string{"outage", "timeout", "disk"} {
+		dataset.Cases = append(dataset.Cases, EvalCase{
+			ID:        id,
+			Variables: map[string]interface{}{"report": "Observed " + id + " in the API"},
+			Expected:  cases[id],
+			Checks: []EvalCheck{
+				{Type: CheckContains, Value: cases[id], IgnoreCase: true, Weight: 2},
+				{Type: CheckLLMJudge, Rubric: "The answer explains why the severity was chosen."},
+			},
+		})
+	}
+
+	metrics := NewPromptMetrics()
+	evaluator, err := NewEvaluator(&EvaluatorConfig{LLM: model, Metrics: metrics}, logrus.New())
+	require.NoError(t, err)
+
+	variants := []PromptVariant{
+		{Name: "plain", Template: newTemplate(t, "Classify the severity of: {report}")},
+		{Name: "json", Template: newTemplate(t, "Reply in JSON with the severity of: {report}")},
+	}
+	report, err := evaluator.Run(context.Background(), dataset, variants)
+	require.NoError(t, err)
+	require.Len(t, report.Variants, 2)
+
+	plain, structured := report.Variants[0], report.Variants[1]
+	assert.Equal(t, "json", report.Best)
+
+	// Two cases answer correctly; the judge fails the terse answers
+	assert.InDelta(t, (2*(2+0.2)/3)/3, plain.MeanScore, 1e-9)
+	assert.Equal(t, 0.0, plain.PassRate)
+	assert.InDelta(t, (2*(2+0.9)/3)/3, structured.MeanScore, 1e-9)
+	assert.InDelta(t, 2.0/3, structured.PassRate, 1e-9)
+	assert.Equal(t, 1, structured.Errors)
+	assert.Equal(t, "rate limited", structured.Cases[2].Error)
+	assert.InDelta(t, 1.0, structured.CheckScores[CheckContains], 1e-9)
+
+	// Usage is estimated when the provider reports none
+	assert.Positive(t, plain.Usage.PromptTokens)
+	assert.Equal(t, plain.Usage.PromptTokens+plain.Usage.CompletionTokens, plain.Usage.TotalTokens)
+
+	assert.Equal(t, int64(3), metrics.GetUsageStats("json")["uses"])
+	assert.InDelta(t, 2.0/3, metrics.GetPerformanceStats("json")["success_rate"], 1e-9)
+
+	markdown := report.Markdown()
+	assert.Contains(t, markdown, "| plain | 0.489 | 0% | 1 |")
+	assert.Contains(t, markdown, "Best variant: **json**")
+	assert.Contains(t, markdown, "| outage | 0.73 fail | 0.97 pass |")
+	assert.Contains(t, markdown, "| disk | error | error |")
+
+	data, err := report.JSON()
+	require.NoError(t, err)
+	var decoded EvalReport
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "json", decoded.Best)
+	assert.Equal(t, "explains the severity", decoded.Variants[1].Cases[0].Checks[1].Detail)
+}
+
+func TestEvaluatorDeterministicChecks(t *testing.T) {
+	model := &fakeLLM{reply: func(prompt string) (string, error) {
+		return strings.TrimPrefix(prompt, "Echo: "), nil
+	}}
+	evaluator, err := NewEvaluator(&EvaluatorConfig{LLM: model}, logrus.New())
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "echo.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+cases:
+  - id: exact
+    variables: {text: "ok"}
+    expected: "ok"
+  - id: regex
+    variables: {text: "ticket INC-1234"}
+    checks:
+      - {type: regex, value: "INC-\\d{4}"}
+  - id: schema
+    variables: {text: '{"count": "three"}'}
+    checks:
+      - type: json_schema
+        schema:
+          type: object
+          properties:
+            count: {type: integer}
+          required: [count]
+`), 0o644))
+
+	dataset, err := LoadEvalDataset(path)
+	require.NoError(t, err)
+	assert.Equal(t, "echo", dataset.Name)
+
+	report, err := evaluator.Run(context.Background(), dataset, []PromptVariant{
+		{Name: "echo", Template: newTemplate(t, "Echo: {text}")},
+	})
+	require.NoError(t, err)
+
+	cases := report.Variants[0].Cases
+	assert.True(t, cases[0].Passed)
+	assert.True(t, cases[1].Passed)
+	assert.False(t, cases[2].Passed)
+	assert.Contains(t, cases[2].Checks[0].Detail, "count")
+
+	// Invalid datasets are rejected before any model call
+	_, err = evaluator.Run(context.Background(), &EvalDataset{Cases: []EvalCase{
+		{ID: "bad", Checks: []EvalCheck{{Type: CheckRegex, Value: "("}}},
+	}}, []PromptVariant{{Name: "echo", Template: newTemplate(t, "Echo: {text}")}})
+	assert.Error(t, err)
+}
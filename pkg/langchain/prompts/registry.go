@@ -0,0 +1,837 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Version tags understood by the registry; any other tag name is allowed too
+const (
+	TagProduction = "production"
+	TagStaging    = "staging"
+)
+
+var (
+	// ErrPromptNotFound is returned for unknown prompt names
+	ErrPromptNotFound = errors.New("prompt not found")
+
+	// ErrVersionNotFound is returned for unknown versions or tags
+	ErrVersionNotFound = errors.New("prompt version not found")
+
+	// ErrVariablesChanged is returned when a new version changes the input
+	// variables of a prompt
+	ErrVariablesChanged = errors.New("prompt input variables changed")
+)
+
+// TemplateSpec is the serializable form of a prompt or chat prompt template
+type TemplateSpec struct {
+	Type             string                 `json:"type" yaml:"type"` // "prompt" or "chat"
+	Template         string                 `json:"template,omitempty" yaml:"template,omitempty"`
+	Messages         []MessageTemplate      `json:"messages,omitempty" yaml:"messages,omitempty"`
+	InputVariables   []string               `json:"input_variables" yaml:"input_variables"`
+	PartialVariables map[string]interface{} `json:"partial_variables,omitempty" yaml:"partial_variables,omitempty"`
+	TemplateFormat   string                 `json:"template_format,omitempty" yaml:"template_format,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// NewTemplateSpec captures a template created by this package
+func NewTemplateSpec(template PromptTemplate) (*TemplateSpec, error) {
+	switch t := template.(type) {
+	case *DefaultChatPromptTemplate:
+		return &TemplateSpec{
+			Type:             "chat",
+			Messages:         append([]MessageTemplate(nil), t.messages...),
+			InputVariables:   append([]string(nil), t.inputVariables...),
+			PartialVariables: t.partialVariables,
+			TemplateFormat:   t.templateFormat,
+			Metadata:         t.metadata,
+		}, nil
+	case *DefaultPromptTemplate:
+		return &TemplateSpec{
+			Type:             "prompt",
+			Template:         t.template,
+			InputVariables:   append([]string(nil), t.inputVariables...),
+			PartialVariables: t.partialVariables,
+			TemplateFormat:   t.templateFormat,
+			Metadata:         t.metadata,
+		}, nil
+	case nil:
+		return nil, fmt.Errorf("template cannot be nil")
+	default:
+		return nil, fmt.Errorf("unsupported template type %T", template)
+	}
+}
+
+// Build creates the template described by the spec
+func (s *TemplateSpec) Build() (PromptTemplate, error) {
+	switch s.Type {
+	case "", "prompt":
+		return NewPromptTemplate(&PromptTemplateConfig{
+			Template:         s.Template,
+			InputVariables:   append([]string(nil), s.InputVariables...),
+			PartialVariables: s.PartialVariables,
+			TemplateFormat:   s.TemplateFormat,
+			Metadata:         s.Metadata,
+		})
+	case "chat":
+		if len(s.Messages) == 0 {
+			return nil, fmt.Errorf("chat template must have at least one message")
+		}
+		format := s.TemplateFormat
+		if format == "" {
+			format = "f-string"
+		}
+		chat := &DefaultChatPromptTemplate{
+			DefaultPromptTemplate: &DefaultPromptTemplate{
+				inputVariables:   append([]string(nil), s.InputVariables...),
+				partialVariables: s.PartialVariables,
+				templateFormat:   format,
+				metadata:         s.Metadata,
+			},
+			messages: append([]MessageTemplate(nil), s.Messages...),
+		}
+		if len(chat.inputVariables) == 0 {
+			chat.inputVariables = chat.extractVariablesFromMessages()
+		}
+		return chat, nil
+	default:
+		return nil, fmt.Errorf("unsupported template type: %s", s.Type)
+	}
+}
+
+// Text renders the template text for diffs; chat messages are prefixed
+// with their role
+func (s *TemplateSpec) Text() string {
+	if s.Type != "chat" {
+		return s.Template
+	}
+	var sb strings.Builder
+	for _, message := range s.Messages {
+		fmt.Fprintf(&sb, "[%s]\n%s\n", message.Role, strings.TrimSuffix(message.Template, "\n"))
+	}
+	return sb.String()
+}
+
+// PromptVersion is one saved version of a prompt
+type PromptVersion struct {
+	Version   string       `json:"version" yaml:"version"`
+	Template  TemplateSpec `json:"template" yaml:"template"`
+	CreatedAt time.Time    `json:"created_at" yaml:"created_at"`
+}
+
+// TagChange records a tag being moved to a version
+type TagChange struct {
+	Tag       string    `json:"tag" yaml:"tag"`
+	Version   string    `json:"version" yaml:"version"`
+	Previous  string    `json:"previous,omitempty" yaml:"previous,omitempty"`
+	Rollback  bool      `json:"rollback,omitempty" yaml:"rollback,omitempty"`
+	ChangedAt time.Time `json:"changed_at" yaml:"changed_at"`
+}
+
+// PromptRecord holds all versions and tags of a prompt. It is the content
+// of the prompt's YAML file.
+type PromptRecord struct {
+	Name       string            `json:"name" yaml:"name"`
+	Versions   []PromptVersion   `json:"versions" yaml:"versions"`
+	Tags       map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	TagHistory []TagChange       `json:"tag_history,omitempty" yaml:"tag_history,omitempty"`
+}
+
+func (r *PromptRecord) version(version string) (*PromptVersion, bool) {
+	for i := range r.Versions {
+		if r.Versions[i].Version == version {
+			return &r.Versions[i], true
+		}
+	}
+	return nil, false
+}
+
+func (r *PromptRecord) latest() *PromptVersion {
+	return &r.Versions[len(r.Versions)-1]
+}
+
+// current is the production version, or the latest one when untagged
+func (r *PromptRecord) current() *PromptVersion {
+	if version, exists := r.version(r.Tags[TagProduction]); exists {
+		return version
+	}
+	return r.latest()
+}
+
+func (r *PromptRecord) clone() *PromptRecord {
+	clone := &PromptRecord{
+		Name:       r.Name,
+		Versions:   append([]PromptVersion(nil), r.Versions...),
+		Tags:       make(map[string]string, len(r.Tags)),
+		TagHistory: append([]TagChange(nil), r.TagHistory...),
+	}
+	for tag, version := range r.Tags {
+		clone.Tags[tag] = version
+	}
+	return clone
+}
+
+// promptBundle is the format of LoadFromFile and SaveToFile
+type promptBundle struct {
+	Prompts []*PromptRecord `yaml:"prompts"`
+}
+
+// PromptRegistry implements PromptTemplateManager and PromptVersioning. Each
+// prompt is kept in <directory>/<name>.yaml with all its versions and tags;
+// files are replaced atomically, so a rollback is never half applied.
+type PromptRegistry struct {
+	directory            string
+	allowVariableChanges bool
+	records              map[string]*PromptRecord
+	logger               *logrus.Logger
+	mu                   sync.RWMutex
+}
+
+// PromptRegistryConfig represents configuration for a prompt registry
+type PromptRegistryConfig struct {
+	// Directory holding the prompt files; empty keeps prompts in memory
+	Directory string `json:"directory" yaml:"directory"`
+
+	// AllowVariableChanges lets new versions change the input variables
+	AllowVariableChanges bool `json:"allow_variable_changes,omitempty" yaml:"allow_variable_changes,omitempty"`
+}
+
+// NewPromptRegistry creates a prompt registry and loads the prompts stored
+// in its directory
+func NewPromptRegistry(config *PromptRegistryConfig, logger *logrus.Logger) (*PromptRegistry, error) {
+	registry := &PromptRegistry{
+		directory:            config.Directory,
+		allowVariableChanges: config.AllowVariableChanges,
+		records:              make(map[string]*PromptRecord),
+		logger:               logger,
+	}
+
+	if registry.directory == "" {
+		return registry, nil
+	}
+
+	if err := os.MkdirAll(registry.directory, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create prompt directory: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(registry.directory, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt files: %w", err)
+	}
+	for _, path := range paths {
+		record, err := readPromptRecord(path)
+		if err != nil {
+			return nil, err
+		}
+		registry.records[record.Name] = record
+	}
+
+	logger.WithFields(logrus.Fields{
+		"directory": registry.directory,
+		"prompts":   len(registry.records),
+	}).Info("Prompt registry loaded")
+
+	return registry, nil
+}
+
+// SaveVersion saves a new version of a prompt template. An empty version is
+// numbered after the existing ones. New versions must keep the input
+// variables of the latest version unless AllowVariableChanges is set.
+func (r *PromptRegistry) SaveVersion(name string, template PromptTemplate, version string) error {
+	spec, err := NewTemplateSpec(template)
+	if err != nil {
+		return err
+	}
+	if _, err := spec.Build(); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err = r.saveVersionLocked(name, *spec, version)
+	return err
+}
+
+// SaveNewVersion saves a new version, numbered after the existing ones, and
+// returns its name
+func (r *PromptRegistry) SaveNewVersion(name string, template PromptTemplate) (string, error) {
+	spec, err := NewTemplateSpec(template)
+	if err != nil {
+		return "", err
+	}
+	if _, err := spec.Build(); err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.saveVersionLocked(name, *spec, "")
+}
+
+func (r *PromptRegistry) saveVersionLocked(name string, spec TemplateSpec, version string) (string, error) {
+	if err := validatePromptName(name); err != nil {
+		return "", err
+	}
+
+	record := &PromptRecord{Name: name, Tags: make(map[string]string)}
+	if existing, exists := r.records[name]; exists {
+		record = existing.clone()
+	}
+
+	if version == "" {
+		version = nextVersion(record)
+	}
+	if _, exists := record.version(version); exists {
+		return "", fmt.Errorf("version %s of prompt %s already exists", version, name)
+	}
+
+	if len(record.Versions) > 0 && !r.allowVariableChanges {
+		if err := compareVariables(record.latest().Template.InputVariables, spec.InputVariables); err != nil {
+			return "", fmt.Errorf("version %s of prompt %s: %w", version, name, err)
+		}
+	}
+
+	record.Versions = append(record.Versions, PromptVersion{
+		Version:   version,
+		Template:  spec,
+		CreatedAt: time.Now(),
+	})
+
+	if err := r.commit(record); err != nil {
+		return "", err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"prompt":  name,
+		"version": version,
+	}).Info("Prompt version saved")
+
+	return version, nil
+}
+
+// GetVersion retrieves a specific version of a prompt template
+func (r *PromptRegistry) GetVersion(name string, version string) (PromptTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, err := r.record(name)
+	if err != nil {
+		return nil, err
+	}
+	saved, exists := record.version(version)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s@%s", ErrVersionNotFound, name, version)
+	}
+
+	return saved.Template.Build()
+}
+
+// GetLatestVersion retrieves the most recently saved version of a prompt template
+func (r *PromptRegistry) GetLatestVersion(name string) (PromptTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, err := r.record(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.latest().Template.Build()
+}
+
+// ListVersions returns all versions of a prompt template, oldest first
+func (r *PromptRegistry) ListVersions(name string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, err := r.record(name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, len(record.Versions))
+	for i, version := range record.Versions {
+		versions[i] = version.Version
+	}
+	return versions, nil
+}
+
+// CompareVersions compares two versions of a prompt template. Differences
+// holds the line diff from version1 to version2 in unified diff notation.
+func (r *PromptRegistry) CompareVersions(name string, version1, version2 string) (*VersionComparison, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, err := r.record(name)
+	if err != nil {
+		return nil, err
+	}
+	v1, exists := record.version(version1)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s@%s", ErrVersionNotFound, name, version1)
+	}
+	v2, exists := record.version(version2)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s@%s", ErrVersionNotFound, name, version2)
+	}
+
+	diff := DiffLines(v1.Template.Text(), v2.Template.Text())
+	differences := make([]string, 0, len(diff))
+	added, removed := 0, 0
+	for _, line := range diff {
+		switch line.Op {
+		case DiffInsert:
+			added++
+		case DiffDelete:
+			removed++
+		}
+		differences = append(differences, line.String())
+	}
+
+	variablesAdded, variablesRemoved := variableChanges(v1.Template.InputVariables, v2.Template.InputVariables)
+
+	return &VersionComparison{
+		Name:        name,
+		Version1:    version1,
+		Version2:    version2,
+		Differences: differences,
+		Metrics: map[string]interface{}{
+			"lines_added":       added,
+			"lines_removed":     removed,
+			"variables_added":   variablesAdded,
+			"variables_removed": variablesRemoved,
+			"format_changed":    v1.Template.TemplateFormat != v2.Template.TemplateFormat,
+			"type_changed":      v1.Template.Type != v2.Template.Type,
+		},
+	}, nil
+}
+
+// RollbackVersion points the production tag back at a saved version
+func (r *PromptRegistry) RollbackVersion(name string, version string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.moveTagLocked(name, TagProduction, version, true)
+}
+
+// TagVersion points a tag, such as "production" or "staging", at a version
+func (r *PromptRegistry) TagVersion(name, tag, version string) error {
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.moveTagLocked(name, tag, version, false)
+}
+
+// Promote points the to tag at the version of the from tag, e.g. staging to production
+func (r *PromptRegistry) Promote(name, from, to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, err := r.record(name)
+	if err != nil {
+		return err
+	}
+	version, exists := record.Tags[from]
+	if !exists {
+		return fmt.Errorf("%w: %s has no %s tag", ErrVersionNotFound, name, from)
+	}
+
+	return r.moveTagLocked(name, to, version, false)
+}
+
+func (r *PromptRegistry) moveTagLocked(name, tag, version string, rollback bool) error {
+	existing, err := r.record(name)
+	if err != nil {
+		return err
+	}
+	if _, exists := existing.version(version); !exists {
+		return fmt.Errorf("%w: %s@%s", ErrVersionNotFound, name, version)
+	}
+
+	record := existing.clone()
+	previous := record.Tags[tag]
+	record.Tags[tag] = version
+	record.TagHistory = append(record.TagHistory, TagChange{
+		Tag:       tag,
+		Version:   version,
+		Previous:  previous,
+		Rollback:  rollback,
+		ChangedAt: time.Now(),
+	})
+
+	if err := r.commit(record); err != nil {
+		return err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"prompt":   name,
+		"tag":      tag,
+		"version":  version,
+		"previous": previous,
+		"rollback": rollback,
+	}).Info("Prompt tag moved")
+
+	return nil
+}
+
+// GetTaggedVersion retrieves the version a tag points at
+func (r *PromptRegistry) GetTaggedVersion(name, tag string) (PromptTemplate, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, err := r.record(name)
+	if err != nil {
+		return nil, "", err
+	}
+	version, exists := record.Tags[tag]
+	if !exists {
+		return nil, "", fmt.Errorf("%w: %s has no %s tag", ErrVersionNotFound, name, tag)
+	}
+	saved, _ := record.version(version)
+
+	template, err := saved.Template.Build()
+	return template, version, err
+}
+
+// GetRecord returns a copy of everything stored for a prompt
+func (r *PromptRegistry) GetRecord(name string) (*PromptRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, err := r.record(name)
+	if err != nil {
+		return nil, err
+	}
+	return record.clone(), nil
+}
+
+// RegisterTemplate saves the template as a new version of the named prompt
+func (r *PromptRegistry) RegisterTemplate(name string, template PromptTemplate) error {
+	_, err := r.SaveNewVersion(name, template)
+	return err
+}
+
+// GetTemplate retrieves the production version of a prompt, or the latest
+// version when none is tagged production
+func (r *PromptRegistry) GetTemplate(name string) (PromptTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, err := r.record(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.current().Template.Build()
+}
+
+// ListTemplates returns all prompt names
+func (r *PromptRegistry) ListTemplates() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.records))
+	for name := range r.records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DeleteTemplate deletes a prompt with all its versions
+func (r *PromptRegistry) DeleteTemplate(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.record(name); err != nil {
+		return err
+	}
+
+	if r.directory != "" {
+		if err := os.Remove(r.path(name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete prompt %s: %w", name, err)
+		}
+	}
+	delete(r.records, name)
+
+	r.logger.WithField("prompt", name).Info("Prompt deleted")
+
+	return nil
+}
+
+// LoadFromFile imports prompts from a YAML file holding either a single
+// prompt record or a "prompts" list of them. Versions new to the registry
+// are added and tags are taken from the file; a version that exists with a
+// different template is an error.
+func (r *PromptRegistry) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt file: %w", err)
+	}
+
+	var bundle promptBundle
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("failed to parse prompt file: %w", err)
+	}
+	if len(bundle.Prompts) == 0 {
+		var record PromptRecord
+		if err := yaml.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("failed to parse prompt file: %w", err)
+		}
+		if record.Name == "" {
+			return fmt.Errorf("prompt file %s holds no prompts", path)
+		}
+		bundle.Prompts = []*PromptRecord{&record}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, imported := range bundle.Prompts {
+		if err := r.importLocked(imported); err != nil {
+			return fmt.Errorf("failed to import prompt %s: %w", imported.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *PromptRegistry) importLocked(imported *PromptRecord) error {
+	if err := validatePromptName(imported.Name); err != nil {
+		return err
+	}
+
+	record := &PromptRecord{Name: imported.Name, Tags: make(map[string]string)}
+	if existing, exists := r.records[imported.Name]; exists {
+		record = existing.clone()
+	}
+
+	for _, version := range imported.Versions {
+		if version.Version == "" {
+			return fmt.Errorf("version name cannot be empty")
+		}
+		if saved, exists := record.version(version.Version); exists {
+			if saved.Template.Text() != version.Template.Text() {
+				return fmt.Errorf("version %s differs from the stored one", version.Version)
+			}
+			continue
+		}
+		if _, err := version.Template.Build(); err != nil {
+			return fmt.Errorf("invalid template in version %s: %w", version.Version, err)
+		}
+		if len(record.Versions) > 0 && !r.allowVariableChanges {
+			if err := compareVariables(record.latest().Template.InputVariables, version.Template.InputVariables); err != nil {
+				return fmt.Errorf("version %s: %w", version.Version, err)
+			}
+		}
+		if version.CreatedAt.IsZero() {
+			version.CreatedAt = time.Now()
+		}
+		record.Versions = append(record.Versions, version)
+	}
+
+	if len(record.Versions) == 0 {
+		return fmt.Errorf("prompt has no versions")
+	}
+
+	for tag, version := range imported.Tags {
+		if _, exists := record.version(version); !exists {
+			return fmt.Errorf("%w: tag %s points at %s", ErrVersionNotFound, tag, version)
+		}
+		if record.Tags[tag] != version {
+			record.TagHistory = append(record.TagHistory, TagChange{
+				Tag:       tag,
+				Version:   version,
+				Previous:  record.Tags[tag],
+				ChangedAt: time.Now(),
+			})
+			record.Tags[tag] = version
+		}
+	}
+
+	return r.commit(record)
+}
+
+// SaveToFile exports all prompts to a YAML file
+func (r *PromptRegistry) SaveToFile(path string) error {
+	r.mu.RLock()
+	bundle := promptBundle{Prompts: make([]*PromptRecord, 0, len(r.records))}
+	for _, record := range r.records {
+		bundle.Prompts = append(bundle.Prompts, record)
+	}
+	sort.Slice(bundle.Prompts, func(i, j int) bool {
+		return bundle.Prompts[i].Name < bundle.Prompts[j].Name
+	})
+	data, err := yaml.Marshal(bundle)
+	r.mu.RUnlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal prompts: %w", err)
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// Clone creates an in-memory copy of the registry
+func (r *PromptRegistry) Clone() PromptTemplateManager {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clone := &PromptRegistry{
+		allowVariableChanges: r.allowVariableChanges,
+		records:              make(map[string]*PromptRecord, len(r.records)),
+		logger:               r.logger,
+	}
+	for name, record := range r.records {
+		clone.records[name] = record.clone()
+	}
+	return clone
+}
+
+// Helper methods
+
+func (r *PromptRegistry) record(name string) (*PromptRecord, error) {
+	record, exists := r.records[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrPromptNotFound, name)
+	}
+	return record, nil
+}
+
+// commit writes the record to disk and then replaces the in-memory copy, so
+// a failed write leaves both unchanged
+func (r *PromptRegistry) commit(record *PromptRecord) error {
+	if r.directory != "" {
+		data, err := yaml.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal prompt %s: %w", record.Name, err)
+		}
+		if err := writeFileAtomic(r.path(record.Name), data); err != nil {
+			return err
+		}
+	}
+	r.records[record.Name] = record
+	return nil
+}
+
+func (r *PromptRegistry) path(name string) string {
+	return filepath.Join(r.directory, name+".yaml")
+}
+
+func readPromptRecord(path string) (*PromptRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt file: %w", err)
+	}
+
+	var record PromptRecord
+	if err := yaml.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt file %s: %w", filepath.Base(path), err)
+	}
+	if record.Name == "" || len(record.Versions) == 0 {
+		return nil, fmt.Errorf("prompt file %s has no name or versions", filepath.Base(path))
+	}
+	if record.Tags == nil {
+		record.Tags = make(map[string]string)
+	}
+	return &record, nil
+}
+
+// writeFileAtomic writes a file by renaming a temporary file into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to sync %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to close %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+func validatePromptName(name string) error {
+	if name == "" {
+		return fmt.Errorf("prompt name cannot be empty")
+	}
+	if strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid prompt name: %s", name)
+	}
+	return nil
+}
+
+// nextVersion numbers a new version after the highest numeric one
+func nextVersion(record *PromptRecord) string {
+	highest := 0
+	for _, version := range record.Versions {
+		if n, err := strconv.Atoi(version.Version); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return strconv.Itoa(highest + 1)
+}
+
+// variableChanges returns the variables only in next and only in previous
+func variableChanges(previous, next []string) (added, removed []string) {
+	previousSet := make(map[string]bool, len(previous))
+	for _, variable := range previous {
+		previousSet[variable] = true
+	}
+	nextSet := make(map[string]bool, len(next))
+	for _, variable := range next {
+		nextSet[variable] = true
+		if !previousSet[variable] {
+			added = append(added, variable)
+		}
+	}
+	for _, variable := range previous {
+		if !nextSet[variable] {
+			removed = append(removed, variable)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func compareVariables(previous, next []string) error {
+	added, removed := variableChanges(previous, next)
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: added %v, removed %v", ErrVariablesChanged, added, removed)
+}
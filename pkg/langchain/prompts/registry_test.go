@@ -0,0 +1,99 @@
+package prompts
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTemplate(t *testing.T, text string) PromptTemplate {
+	template, err := NewPromptTemplate(&PromptTemplateConfig{Template: text})
+	require.NoError(t, err)
+	return template
+}
+
+func TestPromptRegistryVersioning(t *testing.T) {
+	dir := t.TempDir()
+	registry, err := NewPromptRegistry(&PromptRegistryConfig{Directory: dir}, logrus.New())
+	require.NoError(t, err)
+	var _ PromptVersioning = registry
+	var _ PromptTemplateManager = registry
+
+	require.NoError(t, registry.RegisterTemplate("incident_summary", newTemplate(t, "Summarize the incident.\n{report}")))
+	require.NoError(t, registry.RegisterTemplate("incident_summary", newTemplate(t, "Summarize the incident in three bullets.\n{report}")))
+	require.NoError(t, registry.TagVersion("incident_summary", TagProduction, "2"))
+	require.NoError(t, registry.TagVersion("incident_summary", TagStaging, "2"))
+
+	// New versions must keep the input variables
+	err = registry.RegisterTemplate("incident_summary", newTemplate(t, "Summarize {report} for {audience}"))
+	assert.ErrorIs(t, err, ErrVariablesChanged)
+
+	comparison, err := registry.CompareVersions("incident_summary", "1", "2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"- Summarize the incident.", "+ Summarize the incident in three bullets.", "  {report}"}, comparison.Differences)
+	assert.Equal(t, 1, comparison.Metrics["lines_added"])
+
+	// Rollback survives a restart
+	require.NoError(t, registry.RollbackVersion("incident_summary", "1"))
+	registry, err = NewPromptRegistry(&PromptRegistryConfig{Directory: dir}, logrus.New())
+	require.NoError(t, err)
+
+	template, err := registry.GetTemplate("incident_summary")
+	require.NoError(t, err)
+	value, err := template.Format(map[string]interface{}{"report": "DB failover"})
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize the incident.\nDB failover", value.ToString())
+
+	_, staging, err := registry.GetTaggedVersion("incident_summary", TagStaging)
+	require.NoError(t, err)
+	assert.Equal(t, "2", staging)
+
+	record, err := registry.GetRecord("incident_summary")
+	require.NoError(t, err)
+	last := record.TagHistory[len(record.TagHistory)-1]
+	assert.True(t, last.Rollback)
+	assert.Equal(t, "2", last.Previous)
+
+	versions, err := registry.ListVersions("incident_summary")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, versions)
+}
+
+func TestPromptRegistryFileExchange(t *testing.T) {
+	source, err := NewPromptRegistry(&PromptRegistryConfig{}, logrus.New())
+	require.NoError(t, err)
+
+	chat := &TemplateSpec{Type: "chat", Messages: []MessageTemplate{
+		{Role: "system", Template: "You triage {service} alerts."},
+		{Role: "user", Template: "{alert}"},
+	}}
+	chatTemplate, err := chat.Build()
+	require.NoError(t, err)
+	require.NoError(t, source.SaveVersion("triage", chatTemplate, "2024-06-01"))
+	require.NoError(t, source.TagVersion("triage", TagProduction, "2024-06-01"))
+
+	path := filepath.Join(t.TempDir(), "prompts.yaml")
+	require.NoError(t, source.SaveToFile(path))
+
+	target, err := NewPromptRegistry(&PromptRegistryConfig{Directory: t.TempDir()}, logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, target.LoadFromFile(path))
+
+	template, err := target.GetTemplate("triage")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"service", "alert"}, template.GetInputVariables())
+	value, err := template.FormatPrompt(map[string]interface{}{"service": "billing", "alert": "5xx spike"})
+	require.NoError(t, err)
+	messages := value.ToMessages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "You triage billing alerts.", messages[0].Content)
+
+	// Importing the same file again is a no-op
+	require.NoError(t, target.LoadFromFile(path))
+	versions, err := target.ListVersions("triage")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-06-01"}, versions)
+}
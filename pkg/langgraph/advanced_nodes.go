@@ -110,6 +110,59 @@ func (n *EndNode) Validate() error {
 	return nil
 }
 
+// JoinNode marks where parallel branches converge. The executor merges the
+// branch states with its reducers before the node runs; the node itself
+// passes the merged state through.
+type JoinNode struct {
+	id         string
+	reducers   map[string]StateReducer
+	inputKeys  []string
+	outputKeys []string
+	logger     *logrus.Logger
+	tracer     trace.Tracer
+}
+
+// NewJoinNode creates a new join node
+func NewJoinNode(id string, reducers map[string]StateReducer, logger *logrus.Logger) *JoinNode {
+	if reducers == nil {
+		reducers = make(map[string]StateReducer)
+	}
+
+	return &JoinNode{
+		id:         id,
+		reducers:   reducers,
+		inputKeys:  []string{},
+		outputKeys: []string{},
+		logger:     logger,
+		tracer:     otel.Tracer("langgraph.nodes.join"),
+	}
+}
+
+func (n *JoinNode) Execute(ctx context.Context, state GraphState) (GraphState, error) {
+	ctx, span := n.tracer.Start(ctx, "join_node.execute")
+	defer span.End()
+
+	newState := make(GraphState)
+	for k, v := range state {
+		newState[k] = v
+	}
+
+	return newState, nil
+}
+
+func (n *JoinNode) GetID() string                               { return n.id }
+func (n *JoinNode) GetType() string                             { return "join" }
+func (n *JoinNode) GetInputKeys() []string                      { return n.inputKeys }
+func (n *JoinNode) GetOutputKeys() []string                     { return n.outputKeys }
+func (n *JoinNode) GetReducers() map[string]StateReducer        { return n.reducers }
+func (n *JoinNode) SetReducer(key string, reducer StateReducer) { n.reducers[key] = reducer }
+func (n *JoinNode) Validate() error {
+	if n.id == "" {
+		return fmt.Errorf("node ID cannot be empty")
+	}
+	return nil
+}
+
 // ParallelNode executes multiple nodes in parallel
 type ParallelNode struct {
 	id         string
@@ -262,9 +315,16 @@ func (n *LoopNode) Execute(ctx context.Context, state GraphState) (GraphState, e
 			return state, fmt.Errorf("loop node execution failed at iteration %d: %w", iterations, err)
 		}
 
+		iterations++
+		if newState == nil {
+			newState = make(GraphState)
+		}
+
+		// Expose the running count so the loop condition can observe it
+		newState["iterations"] = iterations
+
 		results = append(results, newState)
 		currentState = newState
-		iterations++
 	}
 
 	// Update state with results
@@ -0,0 +1,477 @@
+package langgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// GraphConfigError lists every problem found while validating a graph
+// configuration
+type GraphConfigError struct {
+	GraphID  string   `json:"graph_id"`
+	Problems []string `json:"problems"`
+}
+
+func (e *GraphConfigError) Error() string {
+	return fmt.Sprintf("invalid graph configuration %s: %s", e.GraphID, strings.Join(e.Problems, "; "))
+}
+
+// DefaultGraphComposer implements GraphComposer. Graphs built from
+// configuration resolve node types, conditions and resources through a
+// NodeRegistry.
+type DefaultGraphComposer struct {
+	registry    *NodeRegistry
+	edgeFactory *EdgeFactory
+	logger      *logrus.Logger
+}
+
+// NewGraphComposer creates a new graph composer. A registry with only the
+// built-in node types is used when registry is nil.
+func NewGraphComposer(registry *NodeRegistry, logger *logrus.Logger) *DefaultGraphComposer {
+	if registry == nil {
+		registry = NewNodeRegistry(logger)
+	}
+
+	return &DefaultGraphComposer{
+		registry:    registry,
+		edgeFactory: NewEdgeFactory(logger),
+		logger:      logger,
+	}
+}
+
+// GetNodeRegistry returns the registry used to resolve configurations
+func (c *DefaultGraphComposer) GetNodeRegistry() *NodeRegistry {
+	return c.registry
+}
+
+// LoadGraphConfig reads a graph configuration from a .json, .yaml or .yml file
+func LoadGraphConfig(path string) (*GraphConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph config: %w", err)
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	return ParseGraphConfig(data, format)
+}
+
+// ParseGraphConfig parses a graph configuration in "json" or "yaml" format.
+// Unknown fields are rejected so that typos do not silently change a graph.
+func ParseGraphConfig(data []byte, format string) (*GraphConfig, error) {
+	switch format {
+	case "json":
+	case "yaml", "yml":
+		// YAML is converted to JSON so both formats share the struct tags
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML graph config: %w", err)
+		}
+
+		converted, err := json.Marshal(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert YAML graph config: %w", err)
+		}
+		data = converted
+	default:
+		return nil, fmt.Errorf("unsupported graph config format: %s", format)
+	}
+
+	var config GraphConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse graph config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// ComposeFromFile loads a graph configuration file and composes the graph
+func (c *DefaultGraphComposer) ComposeFromFile(path string) (Graph, error) {
+	config, err := LoadGraphConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ComposeFromConfig(*config)
+}
+
+// ComposeFromConfig creates a graph from configuration. The configuration is
+// validated first and all problems are reported together as a
+// *GraphConfigError.
+func (c *DefaultGraphComposer) ComposeFromConfig(config GraphConfig) (Graph, error) {
+	if err := c.ValidateConfig(config); err != nil {
+		return nil, err
+	}
+
+	graphID := config.ID
+	if graphID == "" {
+		graphID = config.Name
+	}
+	if graphID == "" {
+		graphID = "graph-" + fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+
+	graph := NewGraph(graphID)
+
+	for _, nodeConfig := range config.Nodes {
+		node, err := c.registry.CreateNode(nodeConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := graph.AddNode(node); err != nil {
+			return nil, fmt.Errorf("failed to add node %s: %w", nodeConfig.ID, err)
+		}
+	}
+
+	for _, edgeConfig := range config.Edges {
+		edge, err := c.createEdge(edgeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create edge %s->%s: %w", edgeConfig.From, edgeConfig.To, err)
+		}
+		if err := graph.AddEdge(edge); err != nil {
+			return nil, fmt.Errorf("failed to add edge %s->%s: %w", edgeConfig.From, edgeConfig.To, err)
+		}
+	}
+
+	if defaultGraph, ok := graph.(*DefaultGraph); ok {
+		if len(config.EntryPoints) > 0 {
+			if err := defaultGraph.SetEntryPoints(config.EntryPoints); err != nil {
+				return nil, err
+			}
+		}
+		if len(config.ExitPoints) > 0 {
+			if err := defaultGraph.SetExitPoints(config.ExitPoints); err != nil {
+				return nil, err
+			}
+		}
+		if config.RecursionLimit > 0 {
+			if err := defaultGraph.SetRecursionLimit(config.RecursionLimit); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := graph.Validate(); err != nil {
+		return nil, fmt.Errorf("composed graph %s is invalid: %w", graphID, err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"graph_id":   graphID,
+		"version":    config.Version,
+		"node_count": len(config.Nodes),
+		"edge_count": len(config.Edges),
+	}).Info("Composed graph from configuration")
+
+	return graph, nil
+}
+
+// edgeTypes are the edge types EdgeFactory can create
+var edgeTypes = map[string]bool{
+	"default": true, "conditional": true, "weighted": true, "parallel": true,
+	"loop": true, "timeout": true, "error": true,
+}
+
+// ValidateConfig checks a configuration without building nodes: node types
+// must be registered, IDs unique, edges and entry/exit points must refer to
+// existing nodes, and every node must be reachable from an entry point.
+func (c *DefaultGraphComposer) ValidateConfig(config GraphConfig) error {
+	var problems []string
+
+	if len(config.Nodes) == 0 {
+		problems = append(problems, "graph must have at least one node")
+	}
+
+	nodes := make(map[string]bool)
+	for i, nodeConfig := range config.Nodes {
+		switch {
+		case nodeConfig.ID == "":
+			problems = append(problems, fmt.Sprintf("node %d has no ID", i))
+			continue
+		case nodes[nodeConfig.ID]:
+			problems = append(problems, fmt.Sprintf("duplicate node ID %s", nodeConfig.ID))
+		case !c.registry.HasNodeType(nodeConfig.Type):
+			problems = append(problems, fmt.Sprintf("node %s has unknown type %q", nodeConfig.ID, nodeConfig.Type))
+		}
+		nodes[nodeConfig.ID] = true
+	}
+
+	successors := make(map[string][]string)
+	hasIncoming := make(map[string]bool)
+	for _, edgeConfig := range config.Edges {
+		name := fmt.Sprintf("%s->%s", edgeConfig.From, edgeConfig.To)
+
+		dangling := false
+		for _, endpoint := range []string{edgeConfig.From, edgeConfig.To} {
+			if !nodes[endpoint] {
+				problems = append(problems, fmt.Sprintf("edge %s refers to unknown node %q", name, endpoint))
+				dangling = true
+			}
+		}
+		if edgeConfig.Type != "" && !edgeTypes[edgeConfig.Type] {
+			problems = append(problems, fmt.Sprintf("edge %s has unknown type %q", name, edgeConfig.Type))
+		}
+		if _, err := c.registry.ParseCondition(edgeConfig.Condition); err != nil {
+			problems = append(problems, fmt.Sprintf("edge %s: %v", name, err))
+		}
+		if dangling {
+			continue
+		}
+
+		successors[edgeConfig.From] = append(successors[edgeConfig.From], edgeConfig.To)
+		// Loop edges close intentional cycles and do not make a node a successor
+		if edgeConfig.Type != "loop" {
+			hasIncoming[edgeConfig.To] = true
+		}
+	}
+
+	entryPoints := config.EntryPoints
+	for _, nodeID := range config.EntryPoints {
+		if !nodes[nodeID] {
+			problems = append(problems, fmt.Sprintf("entry point %q is not a node", nodeID))
+		}
+	}
+	for _, nodeID := range config.ExitPoints {
+		if !nodes[nodeID] {
+			problems = append(problems, fmt.Sprintf("exit point %q is not a node", nodeID))
+		}
+	}
+
+	if len(entryPoints) == 0 {
+		for _, nodeConfig := range config.Nodes {
+			if nodeConfig.ID != "" && !hasIncoming[nodeConfig.ID] {
+				entryPoints = append(entryPoints, nodeConfig.ID)
+			}
+		}
+		if len(config.Nodes) > 0 && len(entryPoints) == 0 {
+			problems = append(problems, "graph has no entry point")
+		}
+	}
+
+	// Every node must be reachable from an entry point
+	reachable := make(map[string]bool)
+	queue := make([]string, 0, len(entryPoints))
+	for _, nodeID := range entryPoints {
+		if nodes[nodeID] && !reachable[nodeID] {
+			reachable[nodeID] = true
+			queue = append(queue, nodeID)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range successors[current] {
+			if !reachable[next] {
+				reachable[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	if len(entryPoints) > 0 {
+		for _, nodeConfig := range config.Nodes {
+			if nodeConfig.ID != "" && !reachable[nodeConfig.ID] {
+				problems = append(problems, fmt.Sprintf("node %s is unreachable from the entry points", nodeConfig.ID))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		graphID := config.ID
+		if graphID == "" {
+			graphID = config.Name
+		}
+		return &GraphConfigError{GraphID: graphID, Problems: problems}
+	}
+
+	return nil
+}
+
+// createEdge creates an edge through the edge factory. Edges with a condition
+// default to conditional edges; JSON numbers and duration strings in the edge
+// config are converted to the types the factory expects.
+func (c *DefaultGraphComposer) createEdge(edgeConfig EdgeConfig) (Edge, error) {
+	condition, err := c.registry.ParseCondition(edgeConfig.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	edgeType := edgeConfig.Type
+	if edgeType == "" {
+		edgeType = "default"
+		if condition != nil {
+			edgeType = "conditional"
+		}
+	}
+
+	weight := edgeConfig.Weight
+	if weight == 0 {
+		weight = 1.0
+	}
+
+	options := map[string]interface{}{
+		"weight":   weight,
+		"metadata": edgeConfig.Metadata,
+	}
+	if condition != nil {
+		options["condition"] = condition
+	}
+
+	for key, value := range edgeConfig.Config {
+		switch key {
+		case "priority", "max_concurrency", "max_iterations":
+			options[key] = configInt(edgeConfig.Config, key, 0)
+		case "timeout":
+			duration, err := time.ParseDuration(fmt.Sprintf("%v", value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout: %w", err)
+			}
+			options[key] = duration
+		case "error_types":
+			items, _ := value.([]interface{})
+			errorTypes := make([]string, 0, len(items))
+			for _, item := range items {
+				errorTypes = append(errorTypes, fmt.Sprintf("%v", item))
+			}
+			options[key] = errorTypes
+		default:
+			options[key] = value
+		}
+	}
+
+	return c.edgeFactory.CreateEdge(edgeType, edgeConfig.From, edgeConfig.To, options)
+}
+
+// ComposeSequential creates a sequential graph from nodes
+func (c *DefaultGraphComposer) ComposeSequential(nodes []Node) (Graph, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("at least one node is required")
+	}
+
+	graph := NewGraph("sequential-" + fmt.Sprintf("%d", time.Now().UnixNano()))
+	for i, node := range nodes {
+		if err := graph.AddNode(node); err != nil {
+			return nil, fmt.Errorf("failed to add node %s: %w", node.GetID(), err)
+		}
+		if i > 0 {
+			if err := graph.AddEdge(NewEdge(nodes[i-1].GetID(), node.GetID(), nil, 1.0)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := graph.Validate(); err != nil {
+		return nil, fmt.Errorf("composed graph is invalid: %w", err)
+	}
+
+	return graph, nil
+}
+
+// ComposeParallel creates a graph that runs nodes as parallel branches between
+// a "parallel_start" node and a "parallel_join" node
+func (c *DefaultGraphComposer) ComposeParallel(nodes []Node) (Graph, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("at least one node is required")
+	}
+
+	graph := NewGraph("parallel-" + fmt.Sprintf("%d", time.Now().UnixNano()))
+	start := NewPassthroughNode("parallel_start", c.logger)
+	join := NewJoinNode("parallel_join", nil, c.logger)
+
+	for _, node := range append([]Node{start, join}, nodes...) {
+		if err := graph.AddNode(node); err != nil {
+			return nil, fmt.Errorf("failed to add node %s: %w", node.GetID(), err)
+		}
+	}
+
+	for _, node := range nodes {
+		if err := graph.AddEdge(NewParallelEdge(start.GetID(), node.GetID(), len(nodes), c.logger)); err != nil {
+			return nil, err
+		}
+		if err := graph.AddEdge(NewEdge(node.GetID(), join.GetID(), nil, 1.0)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := graph.Validate(); err != nil {
+		return nil, fmt.Errorf("composed graph is invalid: %w", err)
+	}
+
+	return graph, nil
+}
+
+// ComposeConditional creates a graph whose "condition" node routes to the
+// entry points of trueGraph when condition holds and of falseGraph otherwise
+func (c *DefaultGraphComposer) ComposeConditional(condition Condition, trueGraph, falseGraph Graph) (Graph, error) {
+	if condition == nil {
+		return nil, fmt.Errorf("condition is required")
+	}
+	if trueGraph == nil || falseGraph == nil {
+		return nil, fmt.Errorf("both branches are required")
+	}
+
+	graph, err := c.MergeGraphs([]Graph{trueGraph, falseGraph})
+	if err != nil {
+		return nil, err
+	}
+
+	root := NewPassthroughNode("condition", c.logger)
+	if err := graph.AddNode(root); err != nil {
+		return nil, fmt.Errorf("failed to add condition node: %w", err)
+	}
+
+	negated := NewCompositeCondition([]Condition{condition}, "not", c.logger)
+	for _, entry := range trueGraph.GetEntryPoints() {
+		if err := graph.AddEdge(NewConditionalEdge(root.GetID(), entry, condition, 1.0, c.logger)); err != nil {
+			return nil, err
+		}
+	}
+	for _, entry := range falseGraph.GetEntryPoints() {
+		if err := graph.AddEdge(NewConditionalEdge(root.GetID(), entry, negated, 1.0, c.logger)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := graph.Validate(); err != nil {
+		return nil, fmt.Errorf("composed graph is invalid: %w", err)
+	}
+
+	return graph, nil
+}
+
+// MergeGraphs merges multiple graphs into one. Node IDs must be unique
+// across the graphs.
+func (c *DefaultGraphComposer) MergeGraphs(graphs []Graph) (Graph, error) {
+	if len(graphs) == 0 {
+		return nil, fmt.Errorf("at least one graph is required")
+	}
+
+	merged := NewGraph("merged-" + fmt.Sprintf("%d", time.Now().UnixNano()))
+
+	for _, graph := range graphs {
+		for _, node := range graph.GetNodes() {
+			if err := merged.AddNode(node); err != nil {
+				return nil, fmt.Errorf("failed to merge node %s: %w", node.GetID(), err)
+			}
+		}
+	}
+
+	for _, graph := range graphs {
+		for nodeID := range graph.GetNodes() {
+			for _, edge := range graph.GetEdges(nodeID) {
+				if err := merged.AddEdge(edge); err != nil {
+					return nil, fmt.Errorf("failed to merge edge %s->%s: %w", edge.GetFrom(), edge.GetTo(), err)
+				}
+			}
+		}
+	}
+
+	return merged, nil
+}
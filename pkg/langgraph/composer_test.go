@@ -0,0 +1,176 @@
+package langgraph
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const supportGraphYAML = `
+name: support-triage
+version: "1.2.0"
+nodes:
+  - id: start
+    type: start
+  - id: classify
+    type: function
+    config:
+      function: classify
+  - id: billing
+    type: escalate
+    config:
+      team: billing
+  - id: route_other
+    type: switch
+    config:
+      cases:
+        - condition: priority >= 3
+          node: {id: urgent, type: escalate, config: {team: oncall}}
+      default: {id: normal, type: passthrough}
+  - id: done
+    type: end
+edges:
+  - {from: start, to: classify}
+  - {from: classify, to: billing, condition: 'category == "billing"'}
+  - {from: classify, to: route_other, condition: 'category != "billing"'}
+  - {from: billing, to: done}
+  - {from: route_other, to: done}
+`
+
+func newSupportComposer(t *testing.T) *DefaultGraphComposer {
+	logger := newTestLogger()
+	registry := NewNodeRegistry(logger)
+
+	require.NoError(t, registry.RegisterResource("classify", func(ctx context.Context, state GraphState) (GraphState, error) {
+		newState := make(GraphState)
+		for k, v := range state {
+			newState[k] = v
+		}
+		newState["category"] = state["topic"]
+		return newState, nil
+	}))
+
+	// A user-defined node type
+	require.NoError(t, registry.RegisterNodeType("escalate", func(config NodeConfig, registry *NodeRegistry) (Node, error) {
+		team := configString(config.Config, "team")
+		return setKeyNode(config.ID, "escalated_to", team, logger), nil
+	}))
+
+	return NewGraphComposer(registry, logger)
+}
+
+func TestComposeFromConfig(t *testing.T) {
+	ctx := context.Background()
+	composer := newSupportComposer(t)
+
+	path := filepath.Join(t.TempDir(), "support.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(supportGraphYAML), 0o644))
+
+	graph, err := composer.ComposeFromFile(path)
+	require.NoError(t, err)
+	assert.Len(t, graph.GetNodes(), 5)
+
+	executor := NewGraphExecutor(&ExecutorConfig{}, newTestLogger())
+
+	result, err := executor.Execute(ctx, graph, GraphState{"topic": "billing"})
+	require.NoError(t, err)
+	assert.Equal(t, "billing", result.FinalState["escalated_to"])
+
+	result, err = executor.Execute(ctx, graph, GraphState{"topic": "login", "priority": 5})
+	require.NoError(t, err)
+	assert.Equal(t, "oncall", result.FinalState["escalated_to"])
+	assert.Equal(t, []string{"start", "classify", "route_other", "done"}, result.ExecutedPath)
+}
+
+func TestValidateGraphConfig(t *testing.T) {
+	composer := newSupportComposer(t)
+
+	config, err := ParseGraphConfig([]byte(`{
+		"name": "broken",
+		"nodes": [
+			{"id": "a", "type": "passthrough"},
+			{"id": "b", "type": "passthrough"},
+			{"id": "island", "type": "passthrough"},
+			{"id": "c", "type": "teleport"}
+		],
+		"edges": [
+			{"from": "a", "to": "b"},
+			{"from": "b", "to": "missing"}
+		],
+		"entry_points": ["a"]
+	}`), "json")
+	require.NoError(t, err)
+
+	_, err = composer.ComposeFromConfig(*config)
+	require.Error(t, err)
+
+	var configErr *GraphConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Contains(t, configErr.Problems, `node c has unknown type "teleport"`)
+	assert.Contains(t, configErr.Problems, `edge b->missing refers to unknown node "missing"`)
+	assert.Contains(t, configErr.Problems, "node island is unreachable from the entry points")
+
+	_, err = ParseGraphConfig([]byte(`{"name": "typo", "nodes": [], "edegs": []}`), "json")
+	assert.Error(t, err)
+}
+
+func TestGraphRegistryVersions(t *testing.T) {
+	logger := newTestLogger()
+	registry := NewGraphRegistry(logger)
+
+	newGraph := func(id string) Graph {
+		graph, err := NewGraphBuilder(logger).AddNode(NewPassthroughNode(id, logger)).Build()
+		require.NoError(t, err)
+		return graph
+	}
+
+	require.NoError(t, registry.RegisterGraph("triage@1.9.0", newGraph("v190")))
+	require.NoError(t, registry.RegisterGraph("triage@1.10.0", newGraph("v1100")))
+	require.NoError(t, registry.RegisterGraphVersion("triage", "1.2.0", newGraph("v120")))
+	assert.Error(t, registry.RegisterGraph("triage@1.2.0", newGraph("dup")))
+
+	assert.Equal(t, []string{"1.2.0", "1.9.0", "1.10.0"}, registry.ListVersions("triage"))
+
+	latest, err := registry.GetGraph("triage")
+	require.NoError(t, err)
+	assert.Contains(t, latest.GetNodes(), "v1100")
+
+	pinned, err := registry.GetGraph("triage@1.9.0")
+	require.NoError(t, err)
+	assert.Contains(t, pinned.GetNodes(), "v190")
+
+	// Unversioned registrations get the next major version
+	require.NoError(t, registry.RegisterGraph("triage", newGraph("v2")))
+	assert.Equal(t, "2", registry.ListVersions("triage")[3])
+
+	require.NoError(t, registry.UnregisterGraph("triage@2"))
+	latest, err = registry.GetGraph("triage")
+	require.NoError(t, err)
+	assert.Contains(t, latest.GetNodes(), "v1100")
+
+	require.NoError(t, registry.UnregisterGraph("triage"))
+	assert.Empty(t, registry.ListGraphs())
+
+	// Concurrent unversioned registrations each get their own version
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- registry.RegisterGraph("router", newGraph(fmt.Sprintf("r%d", i)))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4", "5", "6", "7", "8"}, registry.ListVersions("router"))
+}
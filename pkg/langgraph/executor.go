@@ -2,7 +2,10 @@ package langgraph
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +16,10 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// DefaultRecursionLimit is the maximum number of node executions per run
+// when neither the executor config nor the graph sets a limit
+const DefaultRecursionLimit = 100
+
 // DefaultGraphExecutor implements the GraphExecutor interface
 type DefaultGraphExecutor struct {
 	logger           *logrus.Logger
@@ -20,22 +27,86 @@ type DefaultGraphExecutor struct {
 	executionHistory []ExecutionRecord
 	metrics          ExecutionMetrics
 	stateManager     StateManager
+	recursionLimit   int
+	router           Router
+	maxConcurrency   int
+	reducers         map[string]StateReducer
+	interruptBefore  map[string]bool
+	interruptAfter   map[string]bool
+	configErr        error // invalid configuration, returned by every run
 	mu               sync.RWMutex
 }
 
 // ExecutorConfig represents configuration for the graph executor
 type ExecutorConfig struct {
-	StateManager StateManager `json:"-"`
-	MaxHistory   int          `json:"max_history,omitempty"`
+	StateManager   StateManager `json:"-"`
+	MaxHistory     int          `json:"max_history,omitempty"`
+	RecursionLimit int          `json:"recursion_limit,omitempty"`
+
+	// Router, when set, chooses the next node(s) instead of the edge weights;
+	// returning several nodes (e.g. ParallelRouter) fans out
+	Router Router `json:"-"`
+
+	// MaxConcurrency bounds how many branches of a fan-out run at once (0 = unlimited)
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+
+	// Reducers merge state keys written by parallel branches; join nodes may override them
+	Reducers map[string]StateReducer `json:"-"`
+
+	// InterruptBefore and InterruptAfter suspend a run before or after the
+	// listed nodes until it is resumed or rejected. They require a StateManager
+	// implementing CheckpointStore.
+	InterruptBefore []string `json:"interrupt_before,omitempty"`
+	InterruptAfter  []string `json:"interrupt_after,omitempty"`
+}
+
+// RecursionLimitedGraph is implemented by graphs that override the
+// executor's recursion limit
+type RecursionLimitedGraph interface {
+	GetRecursionLimit() int
+}
+
+// IterationLimitedEdge is implemented by edges that bound how many times
+// they may be traversed in a single run, such as LoopEdge
+type IterationLimitedEdge interface {
+	GetMaxIterations() int
+}
+
+// FanOutEdge is implemented by edges whose targets run concurrently with the
+// targets of the other valid fan-out edges of the same node, such as ParallelEdge
+type FanOutEdge interface {
+	GetMaxConcurrency() int
+}
+
+// RecursionLimitError is returned when a run exceeds its graph-wide step
+// limit or traverses a bounded edge too many times
+type RecursionLimitError struct {
+	Scope  string `json:"scope"` // "graph" or "edge"
+	NodeID string `json:"node_id,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Limit  int    `json:"limit"`
+}
+
+func (e *RecursionLimitError) Error() string {
+	if e.Scope == "edge" {
+		return fmt.Sprintf("recursion limit exceeded: edge %s->%s traversed more than %d times", e.From, e.To, e.Limit)
+	}
+	return fmt.Sprintf("recursion limit exceeded: %d node executions reached before node %s", e.Limit, e.NodeID)
 }
 
-// NewGraphExecutor creates a new graph executor
+// NewGraphExecutor creates a new graph executor. Interrupt points require a
+// state manager that implements CheckpointStore; without one, every run
+// fails before it starts.
 func NewGraphExecutor(config *ExecutorConfig, logger *logrus.Logger) GraphExecutor {
 	if config.MaxHistory <= 0 {
 		config.MaxHistory = 1000 // Default max history
 	}
+	if config.RecursionLimit <= 0 {
+		config.RecursionLimit = DefaultRecursionLimit
+	}
 
-	return &DefaultGraphExecutor{
+	executor := &DefaultGraphExecutor{
 		logger:           logger,
 		tracer:           otel.Tracer("langgraph.executor"),
 		executionHistory: make([]ExecutionRecord, 0),
@@ -43,8 +114,30 @@ func NewGraphExecutor(config *ExecutorConfig, logger *logrus.Logger) GraphExecut
 			NodeMetrics:  make(map[string]NodeExecutionMetrics),
 			GraphMetrics: make(map[string]ExecutionMetrics),
 		},
-		stateManager: config.StateManager,
+		stateManager:    config.StateManager,
+		recursionLimit:  config.RecursionLimit,
+		router:          config.Router,
+		maxConcurrency:  config.MaxConcurrency,
+		reducers:        config.Reducers,
+		interruptBefore: make(map[string]bool),
+		interruptAfter:  make(map[string]bool),
+	}
+
+	for _, nodeID := range config.InterruptBefore {
+		executor.interruptBefore[nodeID] = true
+	}
+	for _, nodeID := range config.InterruptAfter {
+		executor.interruptAfter[nodeID] = true
 	}
+
+	// Interrupted runs are resumed from their checkpoint, so interrupts need a checkpoint store
+	if len(config.InterruptBefore) > 0 || len(config.InterruptAfter) > 0 {
+		if _, ok := config.StateManager.(CheckpointStore); !ok {
+			executor.configErr = fmt.Errorf("interrupts require a state manager that implements CheckpointStore")
+		}
+	}
+
+	return executor
 }
 
 // Execute executes a graph with the given initial state
@@ -54,10 +147,159 @@ func (e *DefaultGraphExecutor) Execute(ctx context.Context, graph Graph, initial
 
 // ExecuteWithCallback executes a graph with callback support
 func (e *DefaultGraphExecutor) ExecuteWithCallback(ctx context.Context, graph Graph, initialState GraphState, callback ExecutionCallback) (*ExecutionResult, error) {
+	// Copy initial state
+	currentState := make(GraphState)
+	for k, v := range initialState {
+		currentState[k] = v
+	}
+
+	run := &executionRun{
+		id:           uuid.New().String(),
+		state:        currentState,
+		executedPath: make([]string, 0),
+	}
+
+	return e.run(ctx, graph, run, callback)
+}
+
+// ResumeExecution resumes an interrupted execution from its latest checkpoint.
+// The state manager must implement CheckpointStore.
+func (e *DefaultGraphExecutor) ResumeExecution(ctx context.Context, graph Graph, executionID string, callback ExecutionCallback) (*ExecutionResult, error) {
+	store, ok := e.stateManager.(CheckpointStore)
+	if !ok {
+		return nil, fmt.Errorf("state manager does not support checkpoints")
+	}
+
+	checkpoint, err := store.GetLatestCheckpoint(ctx, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	switch checkpoint.Status {
+	case CheckpointStatusCompleted:
+		return nil, fmt.Errorf("execution %s has already completed", executionID)
+	case CheckpointStatusRejected:
+		return nil, fmt.Errorf("execution %s was rejected", executionID)
+	}
+
+	currentState := make(GraphState)
+	for k, v := range checkpoint.State {
+		currentState[k] = v
+	}
+
+	executedPath := make([]string, len(checkpoint.ExecutedPath))
+	copy(executedPath, checkpoint.ExecutedPath)
+
+	e.logger.WithFields(logrus.Fields{
+		"execution_id":  executionID,
+		"checkpoint_id": checkpoint.ID,
+		"last_node":     checkpoint.NodeID,
+	}).Info("Resuming graph execution from checkpoint")
+
+	run := &executionRun{
+		id:           executionID,
+		state:        currentState,
+		executedPath: executedPath,
+		lastNode:     checkpoint.NodeID,
+		nextNode:     checkpoint.NextNode,
+		step:         checkpoint.Step + 1,
+		resumedFrom:  checkpoint.ID,
+	}
+
+	// Resuming is the approval of a pending interrupt, so do not stop before
+	// the same node again
+	if checkpoint.Status == CheckpointStatusInterrupted {
+		run.approvedNode = checkpoint.NextNode
+	}
+
+	return e.run(ctx, graph, run, callback)
+}
+
+// executionRun tracks the progress of a single execution. Parallel branches
+// share the run, so the counters and recorded results are guarded by mu.
+type executionRun struct {
+	id           string
+	state        GraphState
+	executedPath []string
+	lastNode     string
+	nextNode     string
+	step         int
+	resumedFrom  string
+	approvedNode string
+	interrupt    *interruptSignal
+
+	result         *ExecutionResult
+	recursionLimit int
+	steps          int
+	edgeTraversals map[string]int
+	mu             sync.Mutex
+}
+
+// beginStep counts a node execution against the recursion limit
+func (r *executionRun) beginStep(nodeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.steps >= r.recursionLimit {
+		return &RecursionLimitError{Scope: "graph", NodeID: nodeID, Limit: r.recursionLimit}
+	}
+	r.steps++
+
+	return nil
+}
+
+// consumeApproval reports whether nodeID is the interrupted node a resume
+// approved, clearing the approval so it applies only once
+func (r *executionRun) consumeApproval(nodeID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.approvedNode != nodeID {
+		return false
+	}
+	r.approvedNode = ""
+
+	return true
+}
+
+// traverse counts an edge traversal against the edge's iteration limit, if any
+func (r *executionRun) traverse(edge Edge) error {
+	limited, ok := edge.(IterationLimitedEdge)
+	if !ok || limited.GetMaxIterations() <= 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := edge.GetFrom() + "->" + edge.GetTo()
+	r.edgeTraversals[key]++
+	if r.edgeTraversals[key] > limited.GetMaxIterations() {
+		return &RecursionLimitError{
+			Scope: "edge",
+			From:  edge.GetFrom(),
+			To:    edge.GetTo(),
+			Limit: limited.GetMaxIterations(),
+		}
+	}
+
+	return nil
+}
+
+// recordNode records a completed node in the result and executed path
+func (r *executionRun) recordNode(nodeResult *NodeResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.result.NodeResults[nodeResult.NodeID] = *nodeResult
+	r.executedPath = append(r.executedPath, nodeResult.NodeID)
+}
+
+func (e *DefaultGraphExecutor) run(ctx context.Context, graph Graph, run *executionRun, callback ExecutionCallback) (*ExecutionResult, error) {
 	ctx, span := e.tracer.Start(ctx, "graph_executor.execute")
 	defer span.End()
 
-	executionID := uuid.New().String()
+	executionID := run.id
 	startTime := time.Now()
 
 	span.SetAttributes(
@@ -70,6 +312,11 @@ func (e *DefaultGraphExecutor) ExecuteWithCallback(ctx context.Context, graph Gr
 		"node_count":   len(graph.GetNodes()),
 	}).Info("Starting graph execution")
 
+	if e.configErr != nil {
+		span.RecordError(e.configErr)
+		return nil, e.configErr
+	}
+
 	// Validate graph
 	if err := graph.Validate(); err != nil {
 		span.RecordError(err)
@@ -86,22 +333,23 @@ func (e *DefaultGraphExecutor) ExecuteWithCallback(ctx context.Context, graph Gr
 		NodeResults:  make(map[string]NodeResult),
 		Metadata:     make(map[string]interface{}),
 	}
+	run.result = result
 
-	// Copy initial state
-	currentState := make(GraphState)
-	for k, v := range initialState {
-		currentState[k] = v
+	if run.resumedFrom != "" {
+		result.Metadata["resumed_from"] = run.resumedFrom
 	}
 
+	currentState := run.state
+
 	// Call execution start callback
 	if callback != nil {
-		if err := callback.OnExecutionStart(ctx, graph, initialState); err != nil {
+		if err := callback.OnExecutionStart(ctx, graph, currentState); err != nil {
 			e.logger.WithError(err).Error("Execution start callback failed")
 		}
 	}
 
 	// Save initial state if state manager is available
-	if e.stateManager != nil {
+	if e.stateManager != nil && run.resumedFrom == "" {
 		if err := e.stateManager.SaveState(ctx, executionID, currentState); err != nil {
 			e.logger.WithError(err).Error("Failed to save initial state")
 		}
@@ -113,7 +361,8 @@ func (e *DefaultGraphExecutor) ExecuteWithCallback(ctx context.Context, graph Gr
 		// Finalize result
 		result.EndTime = time.Now()
 		result.Duration = result.EndTime.Sub(result.StartTime)
-		result.Success = executionError == nil
+		result.Success = executionError == nil && run.interrupt == nil
+		result.ExecutedPath = run.executedPath
 
 		if executionError != nil {
 			result.Error = executionError.Error()
@@ -125,6 +374,34 @@ func (e *DefaultGraphExecutor) ExecuteWithCallback(ctx context.Context, graph Gr
 			result.FinalState[k] = v
 		}
 
+		// Record the outcome so a failed or interrupted run can be inspected and resumed
+		status := CheckpointStatusCompleted
+		switch {
+		case executionError != nil:
+			status = CheckpointStatusFailed
+		case run.interrupt != nil:
+			status = CheckpointStatusInterrupted
+		}
+		checkpointID := e.saveCheckpoint(context.WithoutCancel(ctx), run, status, executionError)
+
+		if run.interrupt != nil {
+			result.Interrupt = &Interrupt{
+				ExecutionID:  executionID,
+				CheckpointID: checkpointID,
+				NodeID:       run.interrupt.nodeID,
+				When:         run.interrupt.when,
+				State:        result.FinalState,
+				ExecutedPath: result.ExecutedPath,
+				CreatedAt:    result.EndTime,
+			}
+
+			e.logger.WithFields(logrus.Fields{
+				"execution_id": executionID,
+				"node_id":      run.interrupt.nodeID,
+				"when":         run.interrupt.when,
+			}).Info("Graph execution interrupted")
+		}
+
 		// Call execution end callback
 		if callback != nil {
 			if err := callback.OnExecutionEnd(ctx, result); err != nil {
@@ -149,45 +426,117 @@ func (e *DefaultGraphExecutor) ExecuteWithCallback(ctx context.Context, graph Gr
 		}).Info("Graph execution completed")
 	}()
 
-	// Get entry points
-	entryPoints := graph.GetEntryPoints()
-	if len(entryPoints) == 0 {
-		executionError = fmt.Errorf("graph has no entry points")
+	// Cycles are allowed, so runs are bounded by a graph-wide step limit and by
+	// per-edge iteration limits. Counters are rebuilt from the executed path
+	// when resuming.
+	run.recursionLimit = e.recursionLimit
+	if limited, ok := graph.(RecursionLimitedGraph); ok && limited.GetRecursionLimit() > 0 {
+		run.recursionLimit = limited.GetRecursionLimit()
+	}
+
+	run.steps = len(run.executedPath)
+	run.edgeTraversals = make(map[string]int)
+	for i := 1; i < len(run.executedPath); i++ {
+		run.edgeTraversals[run.executedPath[i-1]+"->"+run.executedPath[i]]++
+	}
+
+	// Determine where to start: the first entry point for a new run, or for a
+	// resumed one the recorded next node or the successor of the last completed node
+	var currentNode string
+	switch {
+	case run.nextNode != "":
+		currentNode = run.nextNode
+		run.nextNode = ""
+	case run.lastNode == "":
+		entryPoints := graph.GetEntryPoints()
+		if len(entryPoints) == 0 {
+			executionError = fmt.Errorf("graph has no entry points")
+			return result, executionError
+		}
+
+		// For simplicity, start with the first entry point
+		// In a more sophisticated implementation, you might execute all entry points in parallel
+		currentNode = entryPoints[0]
+	default:
+		nextEdges, err := e.getNextEdges(ctx, graph, run.lastNode, currentState)
+		if err != nil {
+			executionError = fmt.Errorf("failed to determine next node: %w", err)
+			return result, executionError
+		}
+
+		switch len(nextEdges) {
+		case 0:
+			return result, nil
+		case 1:
+			if err := run.traverse(nextEdges[0]); err != nil {
+				executionError = err
+				return result, executionError
+			}
+			currentNode = nextEdges[0].GetTo()
+		default:
+			joinNode, err := e.fanOut(ctx, graph, run, run.lastNode, nextEdges, currentState, true, callback)
+			if err != nil {
+				executionError = err
+				return result, executionError
+			}
+			currentNode = joinNode
+		}
+	}
+
+	if err := e.walk(ctx, graph, run, currentNode, "", currentState, true, callback); err != nil {
+		var signal *interruptSignal
+		if errors.As(err, &signal) {
+			// Suspend the run; an interrupt before a node resumes at that node
+			run.interrupt = signal
+			if signal.when == InterruptBefore {
+				run.nextNode = signal.nodeID
+			}
+			return result, nil
+		}
+
+		executionError = err
 		return result, executionError
 	}
 
-	// Execute starting from entry points
-	visited := make(map[string]bool)
+	return result, nil
+}
+
+// walk executes nodes sequentially starting at currentNode, following the
+// selected outgoing edge after each node, until it reaches stopAt or a node
+// without a valid outgoing edge. Fan-outs along the way run concurrently and
+// are merged before the walk continues at their join node. The state is
+// updated in place. Only the main path (checkpoint set) is checkpointed.
+func (e *DefaultGraphExecutor) walk(ctx context.Context, graph Graph, run *executionRun, currentNode, stopAt string, currentState GraphState, checkpoint bool, callback ExecutionCallback) error {
+	for currentNode != "" && currentNode != stopAt {
+		// Stop between nodes if the run was cancelled; the last checkpoint allows resuming
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("execution cancelled before node %s: %w", currentNode, err)
+		}
 
-	// For simplicity, start with the first entry point
-	// In a more sophisticated implementation, you might execute all entry points in parallel
-	currentNode := entryPoints[0]
+		if !run.consumeApproval(currentNode) {
+			if err := e.interruptAt(run, currentNode, InterruptBefore, checkpoint); err != nil {
+				return err
+			}
+		}
 
-	for currentNode != "" {
-		// Check if we've already visited this node (cycle detection)
-		if visited[currentNode] {
-			e.logger.WithField("node_id", currentNode).Warn("Cycle detected, stopping execution")
-			break
+		if err := run.beginStep(currentNode); err != nil {
+			return err
 		}
 
 		// Get the node
 		node, err := graph.GetNode(currentNode)
 		if err != nil {
-			executionError = fmt.Errorf("failed to get node %s: %w", currentNode, err)
-			return result, executionError
+			return fmt.Errorf("failed to get node %s: %w", currentNode, err)
 		}
 
 		// Execute the node
 		nodeResult, newState, err := e.executeNode(ctx, node, currentState, callback)
 		if err != nil {
-			executionError = fmt.Errorf("node %s execution failed: %w", currentNode, err)
-			return result, executionError
+			return fmt.Errorf("node %s execution failed: %w", currentNode, err)
 		}
 
 		// Record node execution
-		result.NodeResults[currentNode] = *nodeResult
-		result.ExecutedPath = append(result.ExecutedPath, currentNode)
-		visited[currentNode] = true
+		run.recordNode(nodeResult)
 
 		// Update state
 		oldState := make(GraphState)
@@ -206,24 +555,41 @@ func (e *DefaultGraphExecutor) ExecuteWithCallback(ctx context.Context, graph Gr
 			}
 		}
 
-		// Save state if state manager is available
-		if e.stateManager != nil {
-			if err := e.stateManager.SaveState(ctx, executionID, currentState); err != nil {
-				e.logger.WithError(err).Error("Failed to save state")
-			}
+		// Checkpoint after every node so the run can be resumed from here
+		if checkpoint {
+			run.lastNode = currentNode
+			e.saveCheckpoint(ctx, run, CheckpointStatusRunning, nil)
+			run.step++
+		}
+
+		if err := e.interruptAt(run, currentNode, InterruptAfter, checkpoint); err != nil {
+			return err
 		}
 
-		// Determine next node
-		nextNode, err := e.getNextNode(ctx, graph, currentNode, currentState)
+		// Determine next node(s)
+		nextEdges, err := e.getNextEdges(ctx, graph, currentNode, currentState)
 		if err != nil {
-			executionError = fmt.Errorf("failed to determine next node: %w", err)
-			return result, executionError
+			return fmt.Errorf("failed to determine next node: %w", err)
 		}
 
-		currentNode = nextNode
+		switch len(nextEdges) {
+		case 0:
+			return nil
+		case 1:
+			if err := run.traverse(nextEdges[0]); err != nil {
+				return err
+			}
+			currentNode = nextEdges[0].GetTo()
+		default:
+			joinNode, err := e.fanOut(ctx, graph, run, currentNode, nextEdges, currentState, checkpoint, callback)
+			if err != nil {
+				return err
+			}
+			currentNode = joinNode
+		}
 	}
 
-	return result, nil
+	return nil
 }
 
 // ExecuteStream executes a graph with streaming updates
@@ -239,12 +605,14 @@ func (e *DefaultGraphExecutor) ExecuteStream(ctx context.Context, graph Graph, i
 
 		result, err := e.ExecuteWithCallback(ctx, graph, initialState, callback)
 
-		// Send final update
+		// Send final update; result is nil when the run never started
 		finalUpdate := ExecutionUpdate{
-			Type:        "execution_complete",
-			ExecutionID: result.ID,
-			State:       result.FinalState,
-			Timestamp:   time.Now(),
+			Type:      "execution_complete",
+			Timestamp: time.Now(),
+		}
+		if result != nil {
+			finalUpdate.ExecutionID = result.ID
+			finalUpdate.State = result.FinalState
 		}
 
 		if err != nil {
@@ -364,49 +732,379 @@ func (e *DefaultGraphExecutor) executeNode(ctx context.Context, node Node, state
 	return nodeResult, newState, nil
 }
 
-func (e *DefaultGraphExecutor) getNextNode(ctx context.Context, graph Graph, currentNode string, state GraphState) (string, error) {
+// getNextEdges selects the outgoing edges to follow from currentNode. With a
+// router configured, every node it returns is followed. Otherwise all valid
+// fan-out edges are followed concurrently if there are any, and failing that
+// the valid edge with the highest weight is followed.
+func (e *DefaultGraphExecutor) getNextEdges(ctx context.Context, graph Graph, currentNode string, state GraphState) ([]Edge, error) {
 	edges := graph.GetEdges(currentNode)
 
 	if len(edges) == 0 {
 		// No outgoing edges, execution ends
-		return "", nil
+		return nil, nil
+	}
+
+	if e.router != nil {
+		targets, err := e.router.Route(ctx, currentNode, state, edges)
+		if err != nil {
+			return nil, fmt.Errorf("%s router failed: %w", e.router.GetType(), err)
+		}
+
+		selected := make([]Edge, 0, len(targets))
+		seen := make(map[string]bool)
+		for _, target := range targets {
+			for _, edge := range edges {
+				if edge.GetTo() == target && !seen[target] {
+					selected = append(selected, edge)
+					seen[target] = true
+				}
+			}
+		}
+
+		return selected, nil
 	}
 
 	// Evaluate conditions and find the best edge
 	var bestEdge Edge
 	bestWeight := -1.0
+	var fanOutEdges []Edge
 
 	for _, edge := range edges {
-		condition := edge.GetCondition()
-
 		// If no condition, edge is always valid
-		if condition == nil {
-			if edge.GetWeight() > bestWeight {
-				bestEdge = edge
-				bestWeight = edge.GetWeight()
+		if condition := edge.GetCondition(); condition != nil {
+			matches, err := condition.Evaluate(ctx, state)
+			if err != nil {
+				e.logger.WithError(err).WithField("edge", fmt.Sprintf("%s->%s", edge.GetFrom(), edge.GetTo())).Error("Failed to evaluate edge condition")
+				continue
+			}
+			if !matches {
+				continue
 			}
-			continue
 		}
 
-		// Evaluate condition
-		matches, err := condition.Evaluate(ctx, state)
-		if err != nil {
-			e.logger.WithError(err).WithField("edge", fmt.Sprintf("%s->%s", edge.GetFrom(), edge.GetTo())).Error("Failed to evaluate edge condition")
+		if _, ok := edge.(FanOutEdge); ok {
+			fanOutEdges = append(fanOutEdges, edge)
 			continue
 		}
 
-		if matches && edge.GetWeight() > bestWeight {
+		if edge.GetWeight() > bestWeight {
 			bestEdge = edge
 			bestWeight = edge.GetWeight()
 		}
 	}
 
+	if len(fanOutEdges) > 0 {
+		return fanOutEdges, nil
+	}
+
 	if bestEdge == nil {
 		// No valid edge found
-		return "", nil
+		return nil, nil
+	}
+
+	return []Edge{bestEdge}, nil
+}
+
+// fanOut runs the targets of edges concurrently, each on its own copy of the
+// state, until they reach their join node. The branch states are then merged
+// into state with the reducers for the join node. It returns the join node, or
+// "" when the branches never converge.
+func (e *DefaultGraphExecutor) fanOut(ctx context.Context, graph Graph, run *executionRun, fromNode string, edges []Edge, state GraphState, checkpoint bool, callback ExecutionCallback) (string, error) {
+	targets := make([]string, len(edges))
+	for i, edge := range edges {
+		if err := run.traverse(edge); err != nil {
+			return "", err
+		}
+		targets[i] = edge.GetTo()
+	}
+
+	joinNode := findJoinNode(graph, targets)
+
+	e.logger.WithFields(logrus.Fields{
+		"from":     fromNode,
+		"branches": targets,
+		"join":     joinNode,
+	}).Debug("Executing parallel branches")
+
+	base := make(GraphState)
+	for k, v := range state {
+		base[k] = v
+	}
+
+	branchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var semaphore chan struct{}
+	if limit := e.fanOutLimit(edges); limit > 0 {
+		semaphore = make(chan struct{}, limit)
+	}
+
+	branchStates := make([]GraphState, len(targets))
+	var firstErr error
+	var errMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+
+			if semaphore != nil {
+				select {
+				case semaphore <- struct{}{}:
+					defer func() { <-semaphore }()
+				case <-branchCtx.Done():
+					return
+				}
+			}
+
+			branchState := make(GraphState)
+			for k, v := range base {
+				branchState[k] = v
+			}
+
+			if err := e.walk(branchCtx, graph, run, target, joinNode, branchState, false, callback); err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("parallel branch %s failed: %w", target, err)
+				}
+				errMu.Unlock()
+
+				// Stop the sibling branches
+				cancel()
+				return
+			}
+
+			branchStates[i] = branchState
+		}(i, target)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return "", firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("execution cancelled during parallel branches: %w", err)
+	}
+
+	if err := mergeBranchStates(state, base, branchStates, e.reducersFor(graph, joinNode)); err != nil {
+		return "", fmt.Errorf("failed to join parallel branches at %s: %w", joinNode, err)
+	}
+
+	// Checkpoint the joined state so a resumed run continues at the join node
+	// instead of running the branches again
+	if checkpoint && joinNode != "" {
+		run.nextNode = joinNode
+		e.saveCheckpoint(ctx, run, CheckpointStatusRunning, nil)
+		run.nextNode = ""
+		run.step++
+	}
+
+	return joinNode, nil
+}
+
+// fanOutLimit returns how many branches of a fan-out may run at once: the
+// smallest positive limit of the fan-out edges, capped by the executor's
+// limit. It returns 0 when neither sets a limit.
+func (e *DefaultGraphExecutor) fanOutLimit(edges []Edge) int {
+	limit := e.maxConcurrency
+	for _, edge := range edges {
+		fanOut, ok := edge.(FanOutEdge)
+		if !ok {
+			continue
+		}
+		if edgeLimit := fanOut.GetMaxConcurrency(); edgeLimit > 0 && (limit <= 0 || edgeLimit < limit) {
+			limit = edgeLimit
+		}
+	}
+	return limit
+}
+
+// reducersFor returns the reducers used to merge branches joining at joinNode:
+// the executor's reducers overridden by those of the join node
+func (e *DefaultGraphExecutor) reducersFor(graph Graph, joinNode string) map[string]StateReducer {
+	reducers := make(map[string]StateReducer)
+	for key, reducer := range e.reducers {
+		reducers[key] = reducer
+	}
+
+	if joinNode == "" {
+		return reducers
+	}
+
+	if node, err := graph.GetNode(joinNode); err == nil {
+		if provider, ok := node.(ReducerProvider); ok {
+			for key, reducer := range provider.GetReducers() {
+				reducers[key] = reducer
+			}
+		}
+	}
+
+	return reducers
+}
+
+// mergeBranchStates merges the keys each branch changed relative to base into
+// state, in branch order. Keys without a reducer use last-write-wins.
+func mergeBranchStates(state, base GraphState, branches []GraphState, reducers map[string]StateReducer) error {
+	for _, branch := range branches {
+		for key, value := range branch {
+			baseValue, existed := base[key]
+			if existed && reflect.DeepEqual(baseValue, value) {
+				continue
+			}
+
+			reducer, ok := reducers[key]
+			if !ok || reducer == nil {
+				reducer = LastWriteWinsReducer
+			}
+
+			merged, err := reducer(baseValue, state[key], value)
+			if err != nil {
+				return fmt.Errorf("failed to merge state key %s: %w", key, err)
+			}
+			state[key] = merged
+		}
+	}
+
+	return nil
+}
+
+// findJoinNode returns the node where branches starting at targets converge:
+// the node reachable from every target with the smallest maximum distance.
+// Bounded loop edges are not followed. It returns "" when there is none.
+func findJoinNode(graph Graph, targets []string) string {
+	if len(targets) == 0 {
+		return ""
+	}
+
+	distances := make([]map[string]int, len(targets))
+	for i, target := range targets {
+		distances[i] = forwardDistances(graph, target)
+	}
+
+	joinNode := ""
+	bestMax, bestSum := 0, 0
+
+	for nodeID, distance := range distances[0] {
+		maxDistance, sumDistance := distance, distance
+		reachable := true
+
+		for _, other := range distances[1:] {
+			d, ok := other[nodeID]
+			if !ok {
+				reachable = false
+				break
+			}
+			if d > maxDistance {
+				maxDistance = d
+			}
+			sumDistance += d
+		}
+
+		if !reachable {
+			continue
+		}
+
+		if joinNode == "" || maxDistance < bestMax ||
+			(maxDistance == bestMax && (sumDistance < bestSum || (sumDistance == bestSum && nodeID < joinNode))) {
+			joinNode = nodeID
+			bestMax = maxDistance
+			bestSum = sumDistance
+		}
+	}
+
+	return joinNode
+}
+
+// forwardDistances returns the BFS distance from start to every reachable
+// node, without following bounded loop edges
+func forwardDistances(graph Graph, start string) map[string]int {
+	distances := map[string]int{start: 0}
+	queue := []string{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range graph.GetEdges(current) {
+			if limited, ok := edge.(IterationLimitedEdge); ok && limited.GetMaxIterations() > 0 {
+				continue
+			}
+
+			to := edge.GetTo()
+			if _, seen := distances[to]; !seen {
+				distances[to] = distances[current] + 1
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	return distances
+}
+
+// saveCheckpoint persists the progress of a run and returns the checkpoint ID.
+// State managers implementing CheckpointStore receive the full checkpoint;
+// others only receive the state.
+func (e *DefaultGraphExecutor) saveCheckpoint(ctx context.Context, run *executionRun, status string, execErr error) string {
+	if e.stateManager == nil {
+		return ""
+	}
+
+	label := run.lastNode
+	switch {
+	case status != CheckpointStatusRunning || label == "":
+		label = status
+	case run.nextNode != "":
+		label = "next-" + run.nextNode
+	}
+	label = strings.NewReplacer("/", "_", `\`, "_").Replace(label)
+	checkpointID := fmt.Sprintf("%04d-%s", run.step, label)
+
+	store, ok := e.stateManager.(CheckpointStore)
+	if !ok {
+		if err := e.stateManager.SaveState(ctx, run.id, run.state); err != nil {
+			e.logger.WithError(err).Error("Failed to save state")
+		}
+		if status == CheckpointStatusRunning {
+			if err := e.stateManager.CreateCheckpoint(ctx, run.id, checkpointID, run.state); err != nil {
+				e.logger.WithError(err).Error("Failed to create checkpoint")
+			}
+		}
+		return checkpointID
+	}
+
+	executedPath := make([]string, len(run.executedPath))
+	copy(executedPath, run.executedPath)
+
+	checkpoint := &Checkpoint{
+		ID:           checkpointID,
+		ExecutionID:  run.id,
+		NodeID:       run.lastNode,
+		NextNode:     run.nextNode,
+		Step:         run.step,
+		ExecutedPath: executedPath,
+		State:        run.state,
+		Status:       status,
+	}
+	if execErr != nil {
+		checkpoint.Metadata = map[string]interface{}{"error": execErr.Error()}
+	}
+	if status == CheckpointStatusInterrupted && run.interrupt != nil {
+		checkpoint.Metadata = map[string]interface{}{
+			"interrupt_node": run.interrupt.nodeID,
+			"interrupt_when": run.interrupt.when,
+		}
+	}
+
+	if err := store.SaveCheckpoint(ctx, checkpoint); err != nil {
+		e.logger.WithError(err).WithFields(logrus.Fields{
+			"execution_id":  run.id,
+			"checkpoint_id": checkpointID,
+		}).Error("Failed to save checkpoint")
 	}
 
-	return bestEdge.GetTo(), nil
+	return checkpointID
 }
 
 func (e *DefaultGraphExecutor) recordExecution(result *ExecutionResult) {
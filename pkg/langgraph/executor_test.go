@@ -0,0 +1,565 @@
+package langgraph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLogger returns a logger that only reports errors
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return logger
+}
+
+// setKeyNode returns a function node that sets key to value
+func setKeyNode(id, key string, value interface{}, logger *logrus.Logger) *FunctionNode {
+	return NewFunctionNode(id, func(ctx context.Context, state GraphState) (GraphState, error) {
+		newState := make(GraphState)
+		for k, v := range state {
+			newState[k] = v
+		}
+		newState[key] = value
+		return newState, nil
+	}, logger)
+}
+
+func TestFileStateManager(t *testing.T) {
+	logger := newTestLogger()
+	ctx := context.Background()
+
+	manager, err := NewFileStateManager(t.TempDir(), logger)
+	require.NoError(t, err)
+
+	t.Run("StateRoundTrip", func(t *testing.T) {
+		require.NoError(t, manager.SaveState(ctx, "exec-1", GraphState{"answer": "42"}))
+
+		state, err := manager.LoadState(ctx, "exec-1")
+		require.NoError(t, err)
+		assert.Equal(t, "42", state["answer"])
+
+		ids, err := manager.ListStates(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, ids, "exec-1")
+	})
+
+	t.Run("Checkpoints", func(t *testing.T) {
+		require.NoError(t, manager.CreateCheckpoint(ctx, "exec-2", "first", GraphState{"step": "one"}))
+		require.NoError(t, manager.CreateCheckpoint(ctx, "exec-2", "second", GraphState{"step": "two"}))
+
+		state, err := manager.RestoreCheckpoint(ctx, "exec-2", "first")
+		require.NoError(t, err)
+		assert.Equal(t, "one", state["step"])
+
+		latest, err := manager.GetLatestCheckpoint(ctx, "exec-2")
+		require.NoError(t, err)
+		assert.Equal(t, "second", latest.ID)
+		assert.Equal(t, 1, latest.Step)
+	})
+
+	t.Run("RejectsPathTraversal", func(t *testing.T) {
+		assert.Error(t, manager.SaveState(ctx, "../escape", GraphState{}))
+		_, err := manager.RestoreCheckpoint(ctx, "exec-2", "../../state")
+		assert.Error(t, err)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, manager.DeleteState(ctx, "exec-2"))
+		_, err := manager.LoadState(ctx, "exec-2")
+		assert.Error(t, err)
+	})
+}
+
+func TestExecutorCheckpointResume(t *testing.T) {
+	logger := newTestLogger()
+	ctx := context.Background()
+
+	manager, err := NewFileStateManager(t.TempDir(), logger)
+	require.NoError(t, err)
+
+	executor := NewGraphExecutor(&ExecutorConfig{StateManager: manager}, logger)
+
+	// The middle node fails on its first attempt to simulate a crashed run
+	attempts := 0
+	flaky := NewFunctionNode("flaky", func(ctx context.Context, state GraphState) (GraphState, error) {
+		attempts++
+		if attempts == 1 {
+			return state, fmt.Errorf("transient failure")
+		}
+		newState := make(GraphState)
+		for k, v := range state {
+			newState[k] = v
+		}
+		newState["flaky"] = "done"
+		return newState, nil
+	}, logger)
+
+	graph, err := NewGraphBuilder(logger).
+		AddNode(setKeyNode("first", "first", "done", logger)).
+		AddNode(flaky).
+		AddNode(setKeyNode("last", "last", "done", logger)).
+		AddEdge(NewEdge("first", "flaky", nil, 1.0)).
+		AddEdge(NewEdge("flaky", "last", nil, 1.0)).
+		Build()
+	require.NoError(t, err)
+
+	result, err := executor.Execute(ctx, graph, GraphState{"input": "test"})
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, []string{"first"}, result.ExecutedPath)
+
+	latest, err := manager.GetLatestCheckpoint(ctx, result.ID)
+	require.NoError(t, err)
+	assert.Equal(t, CheckpointStatusFailed, latest.Status)
+	assert.Equal(t, "first", latest.NodeID)
+
+	resumed, err := executor.ResumeExecution(ctx, graph, result.ID, nil)
+	require.NoError(t, err)
+	assert.True(t, resumed.Success)
+	assert.Equal(t, result.ID, resumed.ID)
+	assert.Equal(t, []string{"first", "flaky", "last"}, resumed.ExecutedPath)
+	assert.Equal(t, "done", resumed.FinalState["first"])
+	assert.Equal(t, "done", resumed.FinalState["last"])
+	assert.Equal(t, "test", resumed.FinalState["input"])
+	assert.Equal(t, 2, attempts)
+
+	// A completed execution cannot be resumed again
+	_, err = executor.ResumeExecution(ctx, graph, result.ID, nil)
+	assert.Error(t, err)
+}
+
+// counterLoopGraph builds work -> check with a loop edge back to work while
+// counter < target, and an exit edge to done otherwise
+func counterLoopGraph(t *testing.T, target, maxIterations int, logger *logrus.Logger) Graph {
+	increment := NewFunctionNode("work", func(ctx context.Context, state GraphState) (GraphState, error) {
+		newState := make(GraphState)
+		for k, v := range state {
+			newState[k] = v
+		}
+		counter, _ := state["counter"].(int)
+		newState["counter"] = counter + 1
+		return newState, nil
+	}, logger)
+
+	loopCondition := NewStateCondition("counter", "lt", target, logger)
+	exitCondition := NewStateCondition("counter", "ge", target, logger)
+
+	graph, err := NewGraphBuilder(logger).
+		AddNode(increment).
+		AddNode(NewPassthroughNode("check", logger)).
+		AddNode(NewEndNode("done", logger)).
+		AddEdge(NewEdge("work", "check", nil, 1.0)).
+		AddEdge(NewLoopEdge("check", "work", loopCondition, maxIterations, logger)).
+		AddEdge(NewConditionalEdge("check", "done", exitCondition, 1.0, logger)).
+		Build()
+	require.NoError(t, err)
+
+	return graph
+}
+
+func TestExecutorCycles(t *testing.T) {
+	logger := newTestLogger()
+	ctx := context.Background()
+
+	t.Run("UnboundedCycleRejected", func(t *testing.T) {
+		graph, err := NewGraphBuilder(logger).
+			AddNode(NewPassthroughNode("a", logger)).
+			AddNode(NewPassthroughNode("b", logger)).
+			AddEdge(NewEdge("a", "b", nil, 1.0)).
+			AddEdge(NewEdge("b", "a", nil, 1.0)).
+			Build()
+		require.NoError(t, err)
+		assert.Error(t, graph.Validate())
+	})
+
+	t.Run("RecursionLimitBoundsRetryLoop", func(t *testing.T) {
+		executor := NewGraphExecutor(&ExecutorConfig{}, logger)
+
+		// check retries work through a plain conditional edge
+		graph, err := NewGraphBuilder(logger).
+			AddNode(NewPassthroughNode("start", logger)).
+			AddNode(NewFunctionNode("work", func(ctx context.Context, state GraphState) (GraphState, error) {
+				counter, _ := state["counter"].(int)
+				return GraphState{"counter": counter + 1}, nil
+			}, logger)).
+			AddNode(NewPassthroughNode("check", logger)).
+			AddNode(NewEndNode("done", logger)).
+			AddEdge(NewEdge("start", "work", nil, 1.0)).
+			AddEdge(NewEdge("work", "check", nil, 1.0)).
+			AddEdge(NewConditionalEdge("check", "work", NewStateCondition("counter", "lt", 3, logger), 1.0, logger)).
+			AddEdge(NewConditionalEdge("check", "done", NewStateCondition("counter", "ge", 3, logger), 1.0, logger)).
+			Build()
+		require.NoError(t, err)
+		require.Error(t, graph.Validate())
+
+		require.NoError(t, graph.(*DefaultGraph).SetRecursionLimit(20))
+		require.NoError(t, graph.Validate())
+
+		result, err := executor.Execute(ctx, graph, GraphState{"counter": 0})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.FinalState["counter"])
+		assert.Equal(t, []string{"start", "work", "check", "work", "check", "work", "check", "done"}, result.ExecutedPath)
+
+		// The limit still stops a retry loop that never succeeds
+		require.NoError(t, graph.(*DefaultGraph).SetRecursionLimit(5))
+		_, err = executor.Execute(ctx, graph, GraphState{"counter": -100})
+		var limitErr *RecursionLimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, "graph", limitErr.Scope)
+	})
+
+	t.Run("BoundedLoopRuns", func(t *testing.T) {
+		executor := NewGraphExecutor(&ExecutorConfig{}, logger)
+		graph := counterLoopGraph(t, 3, 5, logger)
+
+		result, err := executor.Execute(ctx, graph, GraphState{"counter": 0})
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, 3, result.FinalState["counter"])
+		assert.Equal(t, []string{"work", "check", "work", "check", "work", "check", "done"}, result.ExecutedPath)
+	})
+
+	t.Run("EdgeLimitExceeded", func(t *testing.T) {
+		executor := NewGraphExecutor(&ExecutorConfig{}, logger)
+		graph := counterLoopGraph(t, 10, 2, logger)
+
+		_, err := executor.Execute(ctx, graph, GraphState{"counter": 0})
+		require.Error(t, err)
+
+		var limitErr *RecursionLimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, "edge", limitErr.Scope)
+		assert.Equal(t, "check", limitErr.From)
+		assert.Equal(t, 2, limitErr.Limit)
+	})
+
+	t.Run("GraphLimitExceeded", func(t *testing.T) {
+		executor := NewGraphExecutor(&ExecutorConfig{RecursionLimit: 100}, logger)
+		graph := counterLoopGraph(t, 10, 20, logger)
+		require.NoError(t, graph.(*DefaultGraph).SetRecursionLimit(4))
+
+		result, err := executor.Execute(ctx, graph, GraphState{"counter": 0})
+		require.Error(t, err)
+		assert.Len(t, result.ExecutedPath, 4)
+
+		var limitErr *RecursionLimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, "graph", limitErr.Scope)
+		assert.Equal(t, 4, limitErr.Limit)
+	})
+}
+
+// appendItemNode returns a function node that appends item to the "items" list
+func appendItemNode(id, item string, logger *logrus.Logger) *FunctionNode {
+	return NewFunctionNode(id, func(ctx context.Context, state GraphState) (GraphState, error) {
+		newState := make(GraphState)
+		for k, v := range state {
+			newState[k] = v
+		}
+		items, _ := state["items"].([]interface{})
+		newState["items"] = append(append([]interface{}{}, items...), item)
+		return newState, nil
+	}, logger)
+}
+
+func TestExecutorFanOut(t *testing.T) {
+	logger := newTestLogger()
+	ctx := context.Background()
+
+	t.Run("MergesBranchesAtJoin", func(t *testing.T) {
+		manager, err := NewFileStateManager(t.TempDir(), logger)
+		require.NoError(t, err)
+		executor := NewGraphExecutor(&ExecutorConfig{StateManager: manager}, logger)
+
+		// Both branches must be running at the same time to get past the barrier
+		var barrier sync.WaitGroup
+		barrier.Add(2)
+		waitForSibling := func(id, item string) *FunctionNode {
+			return NewFunctionNode(id, func(ctx context.Context, state GraphState) (GraphState, error) {
+				barrier.Done()
+				barrier.Wait()
+				return appendItemNode(id, item, logger).Execute(ctx, state)
+			}, logger)
+		}
+
+		graph, err := NewGraphBuilder(logger).
+			AddNode(appendItemNode("split", "split", logger)).
+			AddNode(waitForSibling("left", "left")).
+			AddNode(waitForSibling("right", "right")).
+			AddNode(setKeyNode("right_more", "side", "right", logger)).
+			AddNode(NewJoinNode("join", map[string]StateReducer{"items": AppendReducer}, logger)).
+			AddNode(NewEndNode("done", logger)).
+			AddEdge(NewParallelEdge("split", "left", 0, logger)).
+			AddEdge(NewParallelEdge("split", "right", 0, logger)).
+			AddEdge(NewEdge("left", "join", nil, 1.0)).
+			AddEdge(NewEdge("right", "right_more", nil, 1.0)).
+			AddEdge(NewEdge("right_more", "join", nil, 1.0)).
+			AddEdge(NewEdge("join", "done", nil, 1.0)).
+			Build()
+		require.NoError(t, err)
+
+		result, err := executor.Execute(ctx, graph, GraphState{})
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+
+		assert.ElementsMatch(t, []interface{}{"split", "left", "right"}, result.FinalState["items"])
+		assert.Equal(t, "split", result.FinalState["items"].([]interface{})[0])
+		assert.Equal(t, "right", result.FinalState["side"])
+		assert.Len(t, result.ExecutedPath, 6)
+		assert.Equal(t, "join", result.ExecutedPath[4])
+
+		checkpoints, err := manager.ListCheckpoints(ctx, result.ID)
+		require.NoError(t, err)
+
+		var joined *Checkpoint
+		for _, checkpoint := range checkpoints {
+			if checkpoint.NextNode == "join" {
+				joined = checkpoint
+			}
+		}
+		require.NotNil(t, joined, "expected a checkpoint after the branches joined")
+		assert.Len(t, joined.State["items"], 3)
+	})
+
+	t.Run("BranchFailureFailsExecution", func(t *testing.T) {
+		executor := NewGraphExecutor(&ExecutorConfig{MaxConcurrency: 1}, logger)
+
+		failing := NewFunctionNode("bad", func(ctx context.Context, state GraphState) (GraphState, error) {
+			return state, fmt.Errorf("branch failed")
+		}, logger)
+
+		graph, err := NewGraphBuilder(logger).
+			AddNode(NewPassthroughNode("split", logger)).
+			AddNode(failing).
+			AddNode(setKeyNode("good", "good", true, logger)).
+			AddNode(NewJoinNode("join", nil, logger)).
+			AddEdge(NewParallelEdge("split", "bad", 0, logger)).
+			AddEdge(NewParallelEdge("split", "good", 0, logger)).
+			AddEdge(NewEdge("bad", "join", nil, 1.0)).
+			AddEdge(NewEdge("good", "join", nil, 1.0)).
+			Build()
+		require.NoError(t, err)
+
+		result, err := executor.Execute(ctx, graph, GraphState{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parallel branch bad failed")
+		assert.False(t, result.Success)
+		assert.NotContains(t, result.ExecutedPath, "join")
+	})
+}
+
+func TestExecutorFanOutConcurrencyLimit(t *testing.T) {
+	logger := newTestLogger()
+	ctx := context.Background()
+
+	// runGraph fans out to four branches that record how many of them are
+	// running at once, and returns the peak
+	runGraph := func(t *testing.T, executorLimit, edgeLimit int) int {
+		var mu sync.Mutex
+		running, peak := 0, 0
+		branch := func(id string) *FunctionNode {
+			return NewFunctionNode(id, func(ctx context.Context, state GraphState) (GraphState, error) {
+				mu.Lock()
+				running++
+				if running > peak {
+					peak = running
+				}
+				mu.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return state, nil
+			}, logger)
+		}
+
+		builder := NewGraphBuilder(logger).
+			AddNode(NewPassthroughNode("split", logger)).
+			AddNode(NewJoinNode("join", nil, logger))
+		for i := 0; i < 4; i++ {
+			id := fmt.Sprintf("branch_%d", i)
+			builder = builder.
+				AddNode(branch(id)).
+				AddEdge(NewParallelEdge("split", id, edgeLimit, logger)).
+				AddEdge(NewEdge(id, "join", nil, 1.0))
+		}
+		graph, err := builder.Build()
+		require.NoError(t, err)
+
+		executor := NewGraphExecutor(&ExecutorConfig{MaxConcurrency: executorLimit}, logger)
+		result, err := executor.Execute(ctx, graph, GraphState{})
+		require.NoError(t, err)
+		require.True(t, result.Success)
+
+		return peak
+	}
+
+	t.Run("EdgeLimit", func(t *testing.T) {
+		assert.Equal(t, 2, runGraph(t, 0, 2))
+	})
+
+	t.Run("ExecutorLimitCapsEdgeLimit", func(t *testing.T) {
+		assert.Equal(t, 1, runGraph(t, 1, 3))
+	})
+}
+
+func TestAppendReducer(t *testing.T) {
+	merged, err := AppendReducer([]string{"a"}, []string{"a", "b"}, []string{"a", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, merged)
+
+	merged, err = AppendReducer(nil, nil, "x")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"x"}, merged)
+}
+
+func TestExecutorInterrupts(t *testing.T) {
+	logger := newTestLogger()
+	ctx := context.Background()
+
+	// deleteGraph builds plan -> delete -> report, where delete records the target it deleted
+	deleteGraph := func(t *testing.T) Graph {
+		deleteNode := NewFunctionNode("delete", func(ctx context.Context, state GraphState) (GraphState, error) {
+			newState := make(GraphState)
+			for k, v := range state {
+				newState[k] = v
+			}
+			newState["deleted"] = state["target"]
+			return newState, nil
+		}, logger)
+
+		graph, err := NewGraphBuilder(logger).
+			AddNode(setKeyNode("plan", "target", "production", logger)).
+			AddNode(deleteNode).
+			AddNode(setKeyNode("report", "reported", true, logger)).
+			AddEdge(NewEdge("plan", "delete", nil, 1.0)).
+			AddEdge(NewEdge("delete", "report", nil, 1.0)).
+			Build()
+		require.NoError(t, err)
+
+		return graph
+	}
+
+	newExecutor := func(t *testing.T, config *ExecutorConfig) GraphExecutor {
+		manager, err := NewFileStateManager(t.TempDir(), logger)
+		require.NoError(t, err)
+		config.StateManager = manager
+		return NewGraphExecutor(config, logger)
+	}
+
+	t.Run("EditAndResume", func(t *testing.T) {
+		executor := newExecutor(t, &ExecutorConfig{InterruptBefore: []string{"delete"}})
+		graph := deleteGraph(t)
+
+		result, err := executor.Execute(ctx, graph, GraphState{})
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		require.NotNil(t, result.Interrupt)
+		assert.Equal(t, "delete", result.Interrupt.NodeID)
+		assert.Equal(t, InterruptBefore, result.Interrupt.When)
+		assert.Equal(t, []string{"plan"}, result.ExecutedPath)
+		assert.NotContains(t, result.FinalState, "deleted")
+
+		pending, err := executor.GetInterrupt(ctx, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "delete", pending.NodeID)
+		assert.Equal(t, "production", pending.State["target"])
+
+		_, err = executor.UpdateInterruptedState(ctx, result.ID, GraphState{"target": "staging"})
+		require.NoError(t, err)
+
+		resumed, err := executor.ResumeExecution(ctx, graph, result.ID, nil)
+		require.NoError(t, err)
+		assert.True(t, resumed.Success)
+		assert.Nil(t, resumed.Interrupt)
+		assert.Equal(t, []string{"plan", "delete", "report"}, resumed.ExecutedPath)
+		assert.Equal(t, "staging", resumed.FinalState["deleted"])
+
+		_, err = executor.GetInterrupt(ctx, result.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		executor := newExecutor(t, &ExecutorConfig{InterruptBefore: []string{"delete"}})
+		graph := deleteGraph(t)
+
+		result, err := executor.Execute(ctx, graph, GraphState{})
+		require.NoError(t, err)
+		require.NotNil(t, result.Interrupt)
+
+		require.NoError(t, executor.RejectExecution(ctx, result.ID, "target is production"))
+
+		_, err = executor.ResumeExecution(ctx, graph, result.ID, nil)
+		assert.Error(t, err)
+		assert.Error(t, executor.RejectExecution(ctx, result.ID, "again"))
+	})
+
+	t.Run("InterruptAfter", func(t *testing.T) {
+		executor := newExecutor(t, &ExecutorConfig{InterruptAfter: []string{"delete"}})
+		graph := deleteGraph(t)
+
+		result, err := executor.Execute(ctx, graph, GraphState{})
+		require.NoError(t, err)
+		require.NotNil(t, result.Interrupt)
+		assert.Equal(t, InterruptAfter, result.Interrupt.When)
+		assert.Equal(t, []string{"plan", "delete"}, result.ExecutedPath)
+
+		resumed, err := executor.ResumeExecution(ctx, graph, result.ID, nil)
+		require.NoError(t, err)
+		assert.True(t, resumed.Success)
+		assert.Equal(t, []string{"plan", "delete", "report"}, resumed.ExecutedPath)
+	})
+
+	t.Run("ResumeIntoFanOut", func(t *testing.T) {
+		executor := newExecutor(t, &ExecutorConfig{InterruptBefore: []string{"split"}})
+		graph, err := NewGraphBuilder(logger).
+			AddNode(NewPassthroughNode("split", logger)).
+			AddNode(appendItemNode("left", "left", logger)).
+			AddNode(appendItemNode("right", "right", logger)).
+			AddNode(NewJoinNode("join", map[string]StateReducer{"items": AppendReducer}, logger)).
+			AddEdge(NewParallelEdge("split", "left", 0, logger)).
+			AddEdge(NewParallelEdge("split", "right", 0, logger)).
+			AddEdge(NewEdge("left", "join", nil, 1.0)).
+			AddEdge(NewEdge("right", "join", nil, 1.0)).
+			Build()
+		require.NoError(t, err)
+
+		result, err := executor.Execute(ctx, graph, GraphState{})
+		require.NoError(t, err)
+		require.NotNil(t, result.Interrupt)
+
+		// The branches walk concurrently right after the approval is consumed
+		resumed, err := executor.ResumeExecution(ctx, graph, result.ID, nil)
+		require.NoError(t, err)
+		assert.True(t, resumed.Success)
+		assert.ElementsMatch(t, []interface{}{"left", "right"}, resumed.FinalState["items"])
+	})
+
+	t.Run("RequiresCheckpointStore", func(t *testing.T) {
+		executor := NewGraphExecutor(&ExecutorConfig{InterruptAfter: []string{"delete"}}, logger)
+		_, err := executor.Execute(ctx, deleteGraph(t), GraphState{})
+		assert.ErrorContains(t, err, "CheckpointStore")
+
+		// The run fails before it starts, so a stream only reports the error
+		executor = NewGraphExecutor(&ExecutorConfig{InterruptBefore: []string{"delete"}}, logger)
+		updates, err := executor.ExecuteStream(ctx, deleteGraph(t), GraphState{})
+		require.NoError(t, err)
+
+		var received []ExecutionUpdate
+		for update := range updates {
+			received = append(received, update)
+		}
+		require.Len(t, received, 1)
+		assert.Equal(t, "execution_complete", received[0].Type)
+		assert.Contains(t, received[0].Error, "CheckpointStore")
+	})
+}
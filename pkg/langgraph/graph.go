@@ -13,7 +13,9 @@ type DefaultGraph struct {
 	entryPoints []string
 	exitPoints  []string
 	metadata    map[string]interface{}
-	mu          sync.RWMutex
+	// recursionLimit overrides the executor's recursion limit when positive
+	recursionLimit int
+	mu             sync.RWMutex
 }
 
 // DefaultEdge implements the Edge interface
@@ -226,10 +228,14 @@ func (g *DefaultGraph) GetEntryPoints() []string {
 		return entryPoints
 	}
 
-	// If no explicit entry points, find nodes with no incoming edges
+	// If no explicit entry points, find nodes with no incoming edges.
+	// Bounded loop edges are back edges and do not count.
 	incomingEdges := make(map[string]bool)
 	for _, edges := range g.edges {
 		for _, edge := range edges {
+			if limited, ok := edge.(IterationLimitedEdge); ok && limited.GetMaxIterations() > 0 {
+				continue
+			}
 			incomingEdges[edge.GetTo()] = true
 		}
 	}
@@ -282,9 +288,11 @@ func (g *DefaultGraph) Validate() error {
 		}
 	}
 
-	// Check for cycles (simple DFS-based cycle detection)
-	if g.hasCycles() {
-		return fmt.Errorf("graph contains cycles")
+	// Check for cycles (simple DFS-based cycle detection). Cycles closed by a
+	// bounded edge such as LoopEdge are intentional and allowed, and so is
+	// any cycle once the graph's recursion limit bounds every run.
+	if g.recursionLimit == 0 && g.hasCycles() {
+		return fmt.Errorf("graph contains cycles that are not bounded by a loop edge or a recursion limit")
 	}
 
 	// Validate that all nodes are reachable from entry points
@@ -307,12 +315,13 @@ func (g *DefaultGraph) Clone() Graph {
 	defer g.mu.RUnlock()
 
 	clone := &DefaultGraph{
-		id:          g.id + "_clone",
-		nodes:       make(map[string]Node),
-		edges:       make(map[string][]Edge),
-		entryPoints: make([]string, len(g.entryPoints)),
-		exitPoints:  make([]string, len(g.exitPoints)),
-		metadata:    make(map[string]interface{}),
+		id:             g.id + "_clone",
+		nodes:          make(map[string]Node),
+		edges:          make(map[string][]Edge),
+		entryPoints:    make([]string, len(g.entryPoints)),
+		exitPoints:     make([]string, len(g.exitPoints)),
+		metadata:       make(map[string]interface{}),
+		recursionLimit: g.recursionLimit,
 	}
 
 	// Copy entry and exit points
@@ -374,6 +383,28 @@ func (g *DefaultGraph) SetExitPoints(exitPoints []string) error {
 	return nil
 }
 
+// SetRecursionLimit sets the maximum number of node executions per run for this graph
+func (g *DefaultGraph) SetRecursionLimit(limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("recursion limit cannot be negative")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.recursionLimit = limit
+
+	return nil
+}
+
+// GetRecursionLimit returns the graph's recursion limit (0 means the executor default)
+func (g *DefaultGraph) GetRecursionLimit() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.recursionLimit
+}
+
 // Helper methods
 
 func (g *DefaultGraph) removeFromSlice(slice []string, item string) []string {
@@ -406,6 +437,11 @@ func (g *DefaultGraph) hasCyclesUtil(nodeID string, visited, recStack map[string
 	recStack[nodeID] = true
 
 	for _, edge := range g.edges[nodeID] {
+		// Bounded edges are allowed to close a cycle
+		if limited, ok := edge.(IterationLimitedEdge); ok && limited.GetMaxIterations() > 0 {
+			continue
+		}
+
 		to := edge.GetTo()
 		if !visited[to] {
 			if g.hasCyclesUtil(to, visited, recStack) {
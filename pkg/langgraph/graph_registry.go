@@ -0,0 +1,296 @@
+package langgraph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultGraphRegistry implements GraphRegistry with versioned entries.
+// Graphs are looked up as "name" for the latest version or "name@version"
+// for a specific one. Versions are compared segment by segment, numerically
+// where possible, so "1.10" is newer than "1.9".
+type DefaultGraphRegistry struct {
+	graphs map[string][]registeredGraph
+	logger *logrus.Logger
+	mu     sync.RWMutex
+}
+
+// registeredGraph is one version of a registered graph
+type registeredGraph struct {
+	version      string
+	graph        Graph
+	registeredAt time.Time
+}
+
+// NewGraphRegistry creates a new graph registry
+func NewGraphRegistry(logger *logrus.Logger) *DefaultGraphRegistry {
+	return &DefaultGraphRegistry{
+		graphs: make(map[string][]registeredGraph),
+		logger: logger,
+	}
+}
+
+// RegisterGraph registers a graph. The name may carry a version as
+// "name@version"; without one the graph becomes the next major version.
+func (r *DefaultGraphRegistry) RegisterGraph(name string, graph Graph) error {
+	name, version := splitGraphRef(name)
+	if version != "" {
+		return r.RegisterGraphVersion(name, version, graph)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The next version is picked under the same lock as the insert, so
+	// concurrent registrations of a name get distinct versions
+	next := 1
+	if versions := r.graphs[name]; len(versions) > 0 {
+		latest := versions[len(versions)-1].version
+		major, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(latest, "v"), ".", 2)[0])
+		if err == nil {
+			next = major + 1
+		} else {
+			next = len(versions) + 1
+		}
+	}
+
+	return r.registerLocked(name, strconv.Itoa(next), graph)
+}
+
+// RegisterGraphVersion registers a specific version of a graph. Registered
+// versions are immutable.
+func (r *DefaultGraphRegistry) RegisterGraphVersion(name, version string, graph Graph) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.registerLocked(name, version, graph)
+}
+
+// registerLocked validates and inserts a graph version. The caller must hold
+// the write lock.
+func (r *DefaultGraphRegistry) registerLocked(name, version string, graph Graph) error {
+	if name == "" {
+		return fmt.Errorf("graph name cannot be empty")
+	}
+	if version == "" {
+		return fmt.Errorf("graph version cannot be empty")
+	}
+	if strings.Contains(name, "@") || strings.Contains(version, "@") {
+		return fmt.Errorf("graph name and version cannot contain @")
+	}
+	if graph == nil {
+		return fmt.Errorf("graph cannot be nil")
+	}
+	if err := graph.Validate(); err != nil {
+		return fmt.Errorf("graph %s@%s is invalid: %w", name, version, err)
+	}
+
+	for _, existing := range r.graphs[name] {
+		if existing.version == version {
+			return fmt.Errorf("graph %s@%s already registered", name, version)
+		}
+	}
+
+	versions := append(r.graphs[name], registeredGraph{
+		version:      version,
+		graph:        graph,
+		registeredAt: time.Now(),
+	})
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compareVersions(versions[i].version, versions[j].version) < 0
+	})
+	r.graphs[name] = versions
+
+	r.logger.WithFields(logrus.Fields{
+		"graph":   name,
+		"version": version,
+	}).Info("Graph registered")
+
+	return nil
+}
+
+// GetGraph retrieves a graph by "name" (latest version) or "name@version"
+func (r *DefaultGraphRegistry) GetGraph(name string) (Graph, error) {
+	name, version := splitGraphRef(name)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.graphs[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("graph %s not found", name)
+	}
+
+	if version == "" || version == "latest" {
+		return versions[len(versions)-1].graph, nil
+	}
+
+	for _, registered := range versions {
+		if registered.version == version {
+			return registered.graph, nil
+		}
+	}
+
+	return nil, fmt.Errorf("graph %s@%s not found", name, version)
+}
+
+// GetGraphVersion retrieves a specific version of a graph
+func (r *DefaultGraphRegistry) GetGraphVersion(name, version string) (Graph, error) {
+	return r.GetGraph(name + "@" + version)
+}
+
+// ListGraphs returns all registered graph names
+func (r *DefaultGraphRegistry) ListGraphs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.graphs))
+	for name := range r.graphs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ListVersions returns the registered versions of a graph, oldest first
+func (r *DefaultGraphRegistry) ListVersions(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := make([]string, 0, len(r.graphs[name]))
+	for _, registered := range r.graphs[name] {
+		versions = append(versions, registered.version)
+	}
+
+	return versions
+}
+
+// UnregisterGraph removes every version of a graph, or a single version when
+// given "name@version"
+func (r *DefaultGraphRegistry) UnregisterGraph(name string) error {
+	name, version := splitGraphRef(name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, exists := r.graphs[name]
+	if !exists {
+		return fmt.Errorf("graph %s not found", name)
+	}
+
+	if version == "" {
+		delete(r.graphs, name)
+		return nil
+	}
+
+	for i, registered := range versions {
+		if registered.version == version {
+			versions = append(versions[:i:i], versions[i+1:]...)
+			if len(versions) == 0 {
+				delete(r.graphs, name)
+			} else {
+				r.graphs[name] = versions
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("graph %s@%s not found", name, version)
+}
+
+// Clone creates a copy of the registry. Graphs are shared, not copied.
+func (r *DefaultGraphRegistry) Clone() GraphRegistry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clone := NewGraphRegistry(r.logger)
+	for name, versions := range r.graphs {
+		clone.graphs[name] = append([]registeredGraph(nil), versions...)
+	}
+
+	return clone
+}
+
+// RegisterFromFile composes the graph in a configuration file and registers it
+// under the configuration's name (or ID) and version
+func (r *DefaultGraphRegistry) RegisterFromFile(composer *DefaultGraphComposer, path string) (Graph, error) {
+	config, err := LoadGraphConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := composer.ComposeFromConfig(*config)
+	if err != nil {
+		return nil, err
+	}
+
+	name := config.Name
+	if name == "" {
+		name = config.ID
+	}
+	if name == "" {
+		return nil, fmt.Errorf("graph config %s has no name or ID", path)
+	}
+
+	if config.Version != "" {
+		err = r.RegisterGraphVersion(name, config.Version, graph)
+	} else {
+		err = r.RegisterGraph(name, graph)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return graph, nil
+}
+
+// splitGraphRef splits "name@version" into its parts
+func splitGraphRef(ref string) (string, string) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// compareVersions compares dotted versions such as "1.2.0" or "v2",
+// numerically where both segments are numbers and lexically otherwise
+func compareVersions(a, b string) int {
+	partsA := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	partsB := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var partA, partB string
+		if i < len(partsA) {
+			partA = partsA[i]
+		}
+		if i < len(partsB) {
+			partB = partsB[i]
+		}
+
+		numA, errA := strconv.Atoi(partA)
+		numB, errB := strconv.Atoi(partB)
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case partA != partB:
+			if partA < partB {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
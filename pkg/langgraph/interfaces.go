@@ -114,6 +114,18 @@ type GraphExecutor interface {
 	// ExecuteStream executes a graph with streaming updates
 	ExecuteStream(ctx context.Context, graph Graph, initialState GraphState) (<-chan ExecutionUpdate, error)
 
+	// ResumeExecution resumes an execution from its latest checkpoint
+	ResumeExecution(ctx context.Context, graph Graph, executionID string, callback ExecutionCallback) (*ExecutionResult, error)
+
+	// GetInterrupt returns the pending interrupt of a suspended execution
+	GetInterrupt(ctx context.Context, executionID string) (*Interrupt, error)
+
+	// UpdateInterruptedState edits the state of a suspended execution before it is resumed
+	UpdateInterruptedState(ctx context.Context, executionID string, updates GraphState) (*Interrupt, error)
+
+	// RejectExecution ends a suspended execution without resuming it
+	RejectExecution(ctx context.Context, executionID string, reason string) error
+
 	// GetExecutionHistory returns the execution history
 	GetExecutionHistory() []ExecutionRecord
 
@@ -132,6 +144,7 @@ type ExecutionResult struct {
 	EndTime      time.Time              `json:"end_time"`
 	Duration     time.Duration          `json:"duration"`
 	NodeResults  map[string]NodeResult  `json:"node_results"`
+	Interrupt    *Interrupt             `json:"interrupt,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -297,6 +310,7 @@ type GraphAnalysis struct {
 	Bottlenecks    []string               `json:"bottlenecks"`
 	Suggestions    []string               `json:"suggestions"`
 	OptimizedGraph Graph                  `json:"optimized_graph,omitempty"`
+	Stats          *GraphStats            `json:"stats,omitempty"`
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -348,14 +362,16 @@ type GraphComposer interface {
 
 // GraphConfig represents configuration for graph composition
 type GraphConfig struct {
-	ID          string                 `json:"id"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Nodes       []NodeConfig           `json:"nodes"`
-	Edges       []EdgeConfig           `json:"edges"`
-	EntryPoints []string               `json:"entry_points"`
-	ExitPoints  []string               `json:"exit_points"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	Version        string                 `json:"version,omitempty"`
+	Description    string                 `json:"description"`
+	Nodes          []NodeConfig           `json:"nodes"`
+	Edges          []EdgeConfig           `json:"edges"`
+	EntryPoints    []string               `json:"entry_points"`
+	ExitPoints     []string               `json:"exit_points"`
+	RecursionLimit int                    `json:"recursion_limit,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // NodeConfig represents configuration for a node
@@ -372,8 +388,10 @@ type NodeConfig struct {
 type EdgeConfig struct {
 	From      string                 `json:"from"`
 	To        string                 `json:"to"`
+	Type      string                 `json:"type,omitempty"`
 	Condition string                 `json:"condition,omitempty"`
 	Weight    float64                `json:"weight,omitempty"`
+	Config    map[string]interface{} `json:"config,omitempty"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -0,0 +1,197 @@
+package langgraph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Interrupt points
+const (
+	InterruptBefore = "before"
+	InterruptAfter  = "after"
+)
+
+// Interrupt describes an execution suspended at an interrupt point and waiting
+// for a human to resume or reject it
+type Interrupt struct {
+	ExecutionID  string     `json:"execution_id"`
+	CheckpointID string     `json:"checkpoint_id"`
+	NodeID       string     `json:"node_id"`
+	When         string     `json:"when"`
+	State        GraphState `json:"state"`
+	ExecutedPath []string   `json:"executed_path"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// interruptSignal stops a walk at an interrupt point
+type interruptSignal struct {
+	nodeID string
+	when   string
+}
+
+func (s *interruptSignal) Error() string {
+	return fmt.Sprintf("execution interrupted %s node %s", s.when, s.nodeID)
+}
+
+// interruptAt returns the signal for an interrupt point configured at nodeID,
+// or nil. Interrupts cannot suspend a single parallel branch, so inside one
+// (checkpoint unset) they are reported as an error instead.
+func (e *DefaultGraphExecutor) interruptAt(run *executionRun, nodeID, when string, checkpoint bool) error {
+	points := e.interruptAfter
+	if when == InterruptBefore {
+		points = e.interruptBefore
+	}
+
+	if !points[nodeID] {
+		return nil
+	}
+
+	if !checkpoint {
+		return fmt.Errorf("interrupt %s node %s is not supported inside a parallel branch", when, nodeID)
+	}
+
+	return &interruptSignal{nodeID: nodeID, when: when}
+}
+
+// GetInterrupt returns the pending interrupt of a suspended execution
+func (e *DefaultGraphExecutor) GetInterrupt(ctx context.Context, executionID string) (*Interrupt, error) {
+	_, checkpoint, err := e.interruptedCheckpoint(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return interruptFromCheckpoint(checkpoint), nil
+}
+
+// UpdateInterruptedState merges updates into the state of a suspended
+// execution; a nil value removes the key. The edited state is used when the
+// execution is resumed.
+func (e *DefaultGraphExecutor) UpdateInterruptedState(ctx context.Context, executionID string, updates GraphState) (*Interrupt, error) {
+	store, checkpoint, err := e.interruptedCheckpoint(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(GraphState)
+	for k, v := range checkpoint.State {
+		state[k] = v
+	}
+	for k, v := range updates {
+		if v == nil {
+			delete(state, k)
+			continue
+		}
+		state[k] = v
+	}
+
+	metadata := make(map[string]interface{})
+	for k, v := range checkpoint.Metadata {
+		metadata[k] = v
+	}
+	metadata["edited"] = true
+
+	edited := &Checkpoint{
+		ID:           fmt.Sprintf("%04d-edited", checkpoint.Step+1),
+		ExecutionID:  executionID,
+		NodeID:       checkpoint.NodeID,
+		NextNode:     checkpoint.NextNode,
+		Step:         checkpoint.Step + 1,
+		ExecutedPath: checkpoint.ExecutedPath,
+		State:        state,
+		Status:       CheckpointStatusInterrupted,
+		Metadata:     metadata,
+	}
+
+	if err := store.SaveCheckpoint(ctx, edited); err != nil {
+		return nil, fmt.Errorf("failed to save edited state: %w", err)
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"keys":         len(updates),
+	}).Info("Updated state of interrupted execution")
+
+	return interruptFromCheckpoint(edited), nil
+}
+
+// RejectExecution ends a suspended execution without running the remaining
+// nodes. A rejected execution cannot be resumed.
+func (e *DefaultGraphExecutor) RejectExecution(ctx context.Context, executionID string, reason string) error {
+	store, checkpoint, err := e.interruptedCheckpoint(ctx, executionID)
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]interface{})
+	for k, v := range checkpoint.Metadata {
+		metadata[k] = v
+	}
+	metadata["reason"] = reason
+
+	rejected := &Checkpoint{
+		ID:           fmt.Sprintf("%04d-%s", checkpoint.Step+1, CheckpointStatusRejected),
+		ExecutionID:  executionID,
+		NodeID:       checkpoint.NodeID,
+		NextNode:     checkpoint.NextNode,
+		Step:         checkpoint.Step + 1,
+		ExecutedPath: checkpoint.ExecutedPath,
+		State:        checkpoint.State,
+		Status:       CheckpointStatusRejected,
+		Metadata:     metadata,
+	}
+
+	if err := store.SaveCheckpoint(ctx, rejected); err != nil {
+		return fmt.Errorf("failed to reject execution: %w", err)
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"reason":       reason,
+	}).Info("Rejected interrupted execution")
+
+	return nil
+}
+
+// interruptedCheckpoint loads the latest checkpoint of an execution and
+// checks that it is waiting at an interrupt
+func (e *DefaultGraphExecutor) interruptedCheckpoint(ctx context.Context, executionID string) (CheckpointStore, *Checkpoint, error) {
+	store, ok := e.stateManager.(CheckpointStore)
+	if !ok {
+		return nil, nil, fmt.Errorf("state manager does not support checkpoints")
+	}
+
+	checkpoint, err := store.GetLatestCheckpoint(ctx, executionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	if checkpoint.Status != CheckpointStatusInterrupted {
+		return nil, nil, fmt.Errorf("execution %s is not interrupted (status: %s)", executionID, checkpoint.Status)
+	}
+
+	return store, checkpoint, nil
+}
+
+func interruptFromCheckpoint(checkpoint *Checkpoint) *Interrupt {
+	interrupt := &Interrupt{
+		ExecutionID:  checkpoint.ExecutionID,
+		CheckpointID: checkpoint.ID,
+		NodeID:       checkpoint.NodeID,
+		When:         InterruptAfter,
+		State:        checkpoint.State,
+		ExecutedPath: checkpoint.ExecutedPath,
+		CreatedAt:    checkpoint.CreatedAt,
+	}
+
+	if nodeID, ok := checkpoint.Metadata["interrupt_node"].(string); ok {
+		interrupt.NodeID = nodeID
+	}
+	if when, ok := checkpoint.Metadata["interrupt_when"].(string); ok {
+		interrupt.When = when
+	}
+
+	return interrupt
+}
@@ -146,6 +146,14 @@ func (b *NodeBuilder) BuildPassthrough() (*PassthroughNode, error) {
 	return NewPassthroughNode(b.id, b.logger), nil
 }
 
+// BuildJoin creates a join node
+func (b *NodeBuilder) BuildJoin(reducers map[string]StateReducer) (*JoinNode, error) {
+	if b.id == "" {
+		return nil, fmt.Errorf("node ID is required")
+	}
+	return NewJoinNode(b.id, reducers, b.logger), nil
+}
+
 // BuildParallel creates a parallel node
 func (b *NodeBuilder) BuildParallel(nodes []Node) (*ParallelNode, error) {
 	if b.id == "" {
@@ -243,6 +251,25 @@ func (f *NodeFactory) CreateNode(nodeType string, id string, options map[string]
 		return builder.BuildEnd()
 	case "passthrough":
 		return builder.BuildPassthrough()
+	case "join":
+		reducers := make(map[string]StateReducer)
+		switch configured := options["reducers"].(type) {
+		case nil:
+		case map[string]StateReducer:
+			reducers = configured
+		case map[string]string:
+			for key, name := range configured {
+				reducer, err := GetReducer(name)
+				if err != nil {
+					return nil, err
+				}
+				reducers[key] = reducer
+			}
+		default:
+			return nil, fmt.Errorf("invalid reducers type")
+		}
+
+		return builder.BuildJoin(reducers)
 	case "llm":
 		llmInstance, llmExists := options["llm"]
 		promptTemplate, promptExists := options["prompt"]
@@ -0,0 +1,543 @@
+package langgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aios/aios/pkg/langchain/llm"
+	"github.com/aios/aios/pkg/langchain/memory"
+	"github.com/aios/aios/pkg/langchain/prompts"
+	"github.com/sirupsen/logrus"
+)
+
+// NodeFactoryFunc creates a node from its declarative configuration. The
+// registry is passed in so factories can resolve resources, conditions and
+// nested nodes.
+type NodeFactoryFunc func(config NodeConfig, registry *NodeRegistry) (Node, error)
+
+// NodeRegistry resolves what graph configurations refer to by name: node
+// types, conditions and resources such as LLMs, tools, memories and functions
+type NodeRegistry struct {
+	factories  map[string]NodeFactoryFunc
+	conditions map[string]Condition
+	resources  map[string]interface{}
+	logger     *logrus.Logger
+	mu         sync.RWMutex
+}
+
+// NewNodeRegistry creates a registry with the built-in node types registered
+func NewNodeRegistry(logger *logrus.Logger) *NodeRegistry {
+	registry := &NodeRegistry{
+		factories:  make(map[string]NodeFactoryFunc),
+		conditions: make(map[string]Condition),
+		resources:  make(map[string]interface{}),
+		logger:     logger,
+	}
+
+	builtins := map[string]NodeFactoryFunc{
+		"start":       createStartNode,
+		"end":         createEndNode,
+		"passthrough": createPassthroughNode,
+		"join":        createJoinNode,
+		"conditional": createConditionalNode,
+		"llm":         createLLMNode,
+		"tool":        createToolNode,
+		"function":    createFunctionNode,
+		"memory":      createMemoryNode,
+		"switch":      createSwitchNode,
+		"loop":        createLoopNode,
+		"parallel":    createParallelNode,
+	}
+	for nodeType, factory := range builtins {
+		registry.factories[nodeType] = factory
+	}
+
+	return registry
+}
+
+// RegisterNodeType registers a factory for a node type, replacing any
+// existing factory for that type
+func (r *NodeRegistry) RegisterNodeType(nodeType string, factory NodeFactoryFunc) error {
+	if nodeType == "" {
+		return fmt.Errorf("node type cannot be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("factory cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.factories[nodeType] = factory
+	return nil
+}
+
+// RegisterCondition registers a named condition that edges and nodes can
+// refer to in place of an expression
+func (r *NodeRegistry) RegisterCondition(name string, condition Condition) error {
+	if name == "" {
+		return fmt.Errorf("condition name cannot be empty")
+	}
+	if condition == nil {
+		return fmt.Errorf("condition cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.conditions[name] = condition
+	return nil
+}
+
+// RegisterResource registers a named resource (llm.LLM, Tool, memory.Memory
+// or node function) that node configurations can refer to
+func (r *NodeRegistry) RegisterResource(name string, resource interface{}) error {
+	if name == "" {
+		return fmt.Errorf("resource name cannot be empty")
+	}
+	if resource == nil {
+		return fmt.Errorf("resource cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resources[name] = resource
+	return nil
+}
+
+// GetResource returns a registered resource
+func (r *NodeRegistry) GetResource(name string) (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	resource, exists := r.resources[name]
+	if !exists {
+		return nil, fmt.Errorf("resource %s not registered", name)
+	}
+
+	return resource, nil
+}
+
+// HasNodeType reports whether a factory is registered for nodeType
+func (r *NodeRegistry) HasNodeType(nodeType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.factories[nodeType]
+	return exists
+}
+
+// NodeTypes returns the registered node types in alphabetical order
+func (r *NodeRegistry) NodeTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.factories))
+	for nodeType := range r.factories {
+		types = append(types, nodeType)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// CreateNode creates a node from its configuration using the factory
+// registered for its type
+func (r *NodeRegistry) CreateNode(config NodeConfig) (Node, error) {
+	if config.ID == "" {
+		return nil, fmt.Errorf("node ID is required")
+	}
+
+	r.mu.RLock()
+	factory, exists := r.factories[config.Type]
+	r.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown node type %q for node %s", config.Type, config.ID)
+	}
+
+	node, err := factory(config, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s node %s: %w", config.Type, config.ID, err)
+	}
+
+	if setter, ok := node.(interface{ SetInputKeys([]string) }); ok && len(config.InputKeys) > 0 {
+		setter.SetInputKeys(config.InputKeys)
+	}
+	if setter, ok := node.(interface{ SetOutputKeys([]string) }); ok && len(config.OutputKeys) > 0 {
+		setter.SetOutputKeys(config.OutputKeys)
+	}
+
+	return node, nil
+}
+
+// ParseCondition resolves a condition reference. It accepts the name of a
+// registered condition, "always" or "never", or an expression of clauses
+// such as "status eq \"approved\"", "score >= 0.8" or "result exists",
+// combined with && and || and negated with a leading !. Values are parsed as
+// JSON and fall back to plain strings. An empty reference yields nil.
+func (r *NodeRegistry) ParseCondition(expression string) (Condition, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	condition, exists := r.conditions[expression]
+	r.mu.RUnlock()
+	if exists {
+		return condition, nil
+	}
+
+	return r.parseOr(expression)
+}
+
+func (r *NodeRegistry) parseOr(expression string) (Condition, error) {
+	parts := strings.Split(expression, "||")
+	if len(parts) == 1 {
+		return r.parseAnd(expression)
+	}
+
+	conditions := make([]Condition, 0, len(parts))
+	for _, part := range parts {
+		condition, err := r.parseAnd(part)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return NewCompositeCondition(conditions, "or", r.logger), nil
+}
+
+func (r *NodeRegistry) parseAnd(expression string) (Condition, error) {
+	parts := strings.Split(expression, "&&")
+	if len(parts) == 1 {
+		return r.parseClause(expression)
+	}
+
+	conditions := make([]Condition, 0, len(parts))
+	for _, part := range parts {
+		condition, err := r.parseClause(part)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return NewCompositeCondition(conditions, "and", r.logger), nil
+}
+
+// conditionOperators maps the operators accepted in expressions to
+// StateCondition operators
+var conditionOperators = map[string]string{
+	"==": "eq", "!=": "ne", ">": "gt", ">=": "ge", "<": "lt", "<=": "le",
+	"eq": "eq", "equals": "eq", "ne": "ne", "not_equals": "ne",
+	"gt": "gt", "greater_than": "gt", "ge": "ge", "greater_equal": "ge",
+	"lt": "lt", "less_than": "lt", "le": "le", "less_equal": "le",
+	"contains": "contains", "starts_with": "starts_with", "ends_with": "ends_with", "matches": "matches",
+}
+
+func (r *NodeRegistry) parseClause(clause string) (Condition, error) {
+	clause = strings.TrimSpace(clause)
+
+	if strings.HasPrefix(clause, "!") {
+		inner, err := r.parseClause(clause[1:])
+		if err != nil {
+			return nil, err
+		}
+		return NewCompositeCondition([]Condition{inner}, "not", r.logger), nil
+	}
+
+	r.mu.RLock()
+	named, exists := r.conditions[clause]
+	r.mu.RUnlock()
+	if exists {
+		return named, nil
+	}
+
+	switch clause {
+	case "always", "true":
+		return &AlwaysTrueCondition{}, nil
+	case "never", "false":
+		return &AlwaysFalseCondition{}, nil
+	}
+
+	fields := strings.Fields(clause)
+	switch {
+	case len(fields) == 2 && (fields[1] == "exists" || fields[1] == "not_exists"):
+		return NewStateCondition(fields[0], fields[1], nil, r.logger), nil
+	case len(fields) < 3:
+		return nil, fmt.Errorf("invalid condition %q", clause)
+	}
+
+	key := fields[0]
+	operator, ok := conditionOperators[fields[1]]
+	if !ok {
+		return nil, fmt.Errorf("unsupported operator %q in condition %q", fields[1], clause)
+	}
+
+	// Keep the raw value text, including inner whitespace
+	rest := strings.TrimSpace(clause[len(key):])
+	rawValue := strings.TrimSpace(rest[len(fields[1]):])
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(rawValue), &value); err != nil {
+		value = rawValue
+	}
+
+	// State values are often ints while JSON numbers decode as float64, so
+	// numeric equality is checked as a range instead of with reflect.DeepEqual
+	if _, isNumber := value.(float64); isNumber && (operator == "eq" || operator == "ne") {
+		equals := NewCompositeCondition([]Condition{
+			NewStateCondition(key, "ge", value, r.logger),
+			NewStateCondition(key, "le", value, r.logger),
+		}, "and", r.logger)
+		if operator == "ne" {
+			return NewCompositeCondition([]Condition{equals}, "not", r.logger), nil
+		}
+		return equals, nil
+	}
+
+	return NewStateCondition(key, operator, value, r.logger), nil
+}
+
+// Config helpers
+
+func configString(config map[string]interface{}, key string) string {
+	value, _ := config[key].(string)
+	return value
+}
+
+func configInt(config map[string]interface{}, key string, defaultValue int) int {
+	switch value := config[key].(type) {
+	case int:
+		return value
+	case int64:
+		return int(value)
+	case float64:
+		return int(value)
+	default:
+		return defaultValue
+	}
+}
+
+// configNode decodes a nested node configuration
+func configNode(value interface{}) (NodeConfig, error) {
+	var nodeConfig NodeConfig
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nodeConfig, fmt.Errorf("invalid nested node: %w", err)
+	}
+	if err := json.Unmarshal(data, &nodeConfig); err != nil {
+		return nodeConfig, fmt.Errorf("invalid nested node: %w", err)
+	}
+
+	return nodeConfig, nil
+}
+
+func (r *NodeRegistry) nestedNode(value interface{}) (Node, error) {
+	nodeConfig, err := configNode(value)
+	if err != nil {
+		return nil, err
+	}
+	return r.CreateNode(nodeConfig)
+}
+
+// Built-in node factories
+
+func createStartNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	return NewStartNode(config.ID, registry.logger), nil
+}
+
+func createEndNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	return NewEndNode(config.ID, registry.logger), nil
+}
+
+func createPassthroughNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	return NewPassthroughNode(config.ID, registry.logger), nil
+}
+
+func createJoinNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	reducers := make(map[string]StateReducer)
+
+	if configured, ok := config.Config["reducers"].(map[string]interface{}); ok {
+		for key, name := range configured {
+			nameStr, _ := name.(string)
+			reducer, err := GetReducer(nameStr)
+			if err != nil {
+				return nil, err
+			}
+			reducers[key] = reducer
+		}
+	}
+
+	return NewJoinNode(config.ID, reducers, registry.logger), nil
+}
+
+func createConditionalNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	condition, err := registry.ParseCondition(configString(config.Config, "condition"))
+	if err != nil {
+		return nil, err
+	}
+	if condition == nil {
+		return nil, fmt.Errorf("condition is required")
+	}
+
+	return NewConditionalNode(config.ID, condition, registry.logger), nil
+}
+
+func createLLMNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	resource, err := registry.GetResource(configString(config.Config, "llm"))
+	if err != nil {
+		return nil, err
+	}
+
+	llmInstance, ok := resource.(llm.LLM)
+	if !ok {
+		return nil, fmt.Errorf("resource %s is not an LLM", configString(config.Config, "llm"))
+	}
+
+	var promptTemplate prompts.PromptTemplate
+	if template := configString(config.Config, "prompt"); template != "" {
+		promptTemplate, err = prompts.NewPromptTemplate(&prompts.PromptTemplateConfig{
+			Template:       template,
+			InputVariables: config.InputKeys,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt: %w", err)
+		}
+	}
+
+	return NewLLMNode(config.ID, llmInstance, promptTemplate, registry.logger), nil
+}
+
+func createToolNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	resource, err := registry.GetResource(configString(config.Config, "tool"))
+	if err != nil {
+		return nil, err
+	}
+
+	tool, ok := resource.(Tool)
+	if !ok {
+		return nil, fmt.Errorf("resource %s is not a tool", configString(config.Config, "tool"))
+	}
+
+	return NewToolNode(config.ID, tool, registry.logger), nil
+}
+
+func createFunctionNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	resource, err := registry.GetResource(configString(config.Config, "function"))
+	if err != nil {
+		return nil, err
+	}
+
+	fn, ok := resource.(func(context.Context, GraphState) (GraphState, error))
+	if !ok {
+		return nil, fmt.Errorf("resource %s is not a node function", configString(config.Config, "function"))
+	}
+
+	return NewFunctionNode(config.ID, fn, registry.logger), nil
+}
+
+func createMemoryNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	resource, err := registry.GetResource(configString(config.Config, "memory"))
+	if err != nil {
+		return nil, err
+	}
+
+	memorySystem, ok := resource.(memory.Memory)
+	if !ok {
+		return nil, fmt.Errorf("resource %s is not a memory", configString(config.Config, "memory"))
+	}
+
+	operation := configString(config.Config, "operation")
+	if operation == "" {
+		operation = "read"
+	}
+
+	return NewMemoryNode(config.ID, memorySystem, operation, registry.logger), nil
+}
+
+func createSwitchNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	rawCases, _ := config.Config["cases"].([]interface{})
+
+	cases := make([]SwitchCase, 0, len(rawCases))
+	for i, rawCase := range rawCases {
+		caseConfig, ok := rawCase.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("case %d must be an object", i)
+		}
+
+		condition, err := registry.ParseCondition(configString(caseConfig, "condition"))
+		if err != nil {
+			return nil, fmt.Errorf("case %d: %w", i, err)
+		}
+		if condition == nil {
+			return nil, fmt.Errorf("case %d: condition is required", i)
+		}
+
+		node, err := registry.nestedNode(caseConfig["node"])
+		if err != nil {
+			return nil, fmt.Errorf("case %d: %w", i, err)
+		}
+
+		cases = append(cases, SwitchCase{Condition: condition, Node: node})
+	}
+
+	var defaultNode Node
+	if rawDefault, exists := config.Config["default"]; exists {
+		node, err := registry.nestedNode(rawDefault)
+		if err != nil {
+			return nil, fmt.Errorf("default: %w", err)
+		}
+		defaultNode = node
+	}
+
+	if len(cases) == 0 && defaultNode == nil {
+		return nil, fmt.Errorf("at least one case or default node is required")
+	}
+
+	return NewSwitchNode(config.ID, cases, defaultNode, registry.logger), nil
+}
+
+func createLoopNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	node, err := registry.nestedNode(config.Config["node"])
+	if err != nil {
+		return nil, err
+	}
+
+	condition, err := registry.ParseCondition(configString(config.Config, "condition"))
+	if err != nil {
+		return nil, err
+	}
+	if condition == nil {
+		return nil, fmt.Errorf("condition is required")
+	}
+
+	return NewLoopNode(config.ID, node, condition, configInt(config.Config, "max_iterations", 100), registry.logger), nil
+}
+
+func createParallelNode(config NodeConfig, registry *NodeRegistry) (Node, error) {
+	rawNodes, _ := config.Config["nodes"].([]interface{})
+	if len(rawNodes) == 0 {
+		return nil, fmt.Errorf("at least one child node is required")
+	}
+
+	nodes := make([]Node, 0, len(rawNodes))
+	for _, rawNode := range rawNodes {
+		node, err := registry.nestedNode(rawNode)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	return NewParallelNode(config.ID, nodes, registry.logger), nil
+}
@@ -0,0 +1,813 @@
+package langgraph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Bottleneck detection thresholds
+const (
+	// slowNodeFactor flags nodes whose average duration exceeds the median node's by this factor
+	slowNodeFactor = 2.0
+
+	// failureRateThreshold flags nodes failing more often than this
+	failureRateThreshold = 0.1
+
+	// latencyVarianceFactor flags nodes whose slowest run exceeds their average by this factor
+	latencyVarianceFactor = 3.0
+
+	// minVarianceSamples is the number of executions needed before variance is judged
+	minVarianceSamples = 5
+)
+
+// GraphStats holds structural statistics of a graph
+type GraphStats struct {
+	NodeCount     int            `json:"node_count"`
+	EdgeCount     int            `json:"edge_count"`
+	Depth         int            `json:"depth"`
+	MaxFanOut     int            `json:"max_fan_out"`
+	AverageFanOut float64        `json:"average_fan_out"`
+	FanOut        map[string]int `json:"fan_out"`
+
+	// StronglyConnectedComponents lists the cycles of the graph: components
+	// with more than one node or a self-loop
+	StronglyConnectedComponents [][]string `json:"strongly_connected_components"`
+
+	// UnreachableNodes cannot be reached from a start node or declared entry
+	// point, or from any node without incoming edges when there are none of those
+	UnreachableNodes []string `json:"unreachable_nodes"`
+
+	// DeadEndNodes cannot reach an exit: an end node or declared exit point,
+	// or any node without outgoing edges when there are none of those
+	DeadEndNodes []string `json:"dead_end_nodes"`
+
+	// CollapsibleNodes are pass-through nodes in linear chains that can be removed
+	CollapsibleNodes []string `json:"collapsible_nodes"`
+
+	// ParallelizableGroups are sibling nodes that do not depend on each other
+	ParallelizableGroups [][]string `json:"parallelizable_groups"`
+}
+
+// DefaultGraphOptimizer implements GraphOptimizer with static analysis of the
+// graph structure and bottleneck detection from recorded execution metrics
+type DefaultGraphOptimizer struct {
+	logger *logrus.Logger
+	tracer trace.Tracer
+}
+
+// NewGraphOptimizer creates a new graph optimizer
+func NewGraphOptimizer(logger *logrus.Logger) *DefaultGraphOptimizer {
+	return &DefaultGraphOptimizer{
+		logger: logger,
+		tracer: otel.Tracer("langgraph.optimizer"),
+	}
+}
+
+// AnalyzeGraph analyzes the structure of a graph. Without metrics every node
+// counts as one unit of cost.
+func (o *DefaultGraphOptimizer) AnalyzeGraph(ctx context.Context, graph Graph) (*GraphAnalysis, error) {
+	return o.AnalyzeGraphWithMetrics(ctx, graph, ExecutionMetrics{})
+}
+
+// AnalyzeGraphWithMetrics analyzes a graph using recorded execution metrics,
+// such as those returned by GraphExecutor.GetMetrics. The critical path and
+// estimated cost (in seconds) are then based on average node durations.
+func (o *DefaultGraphOptimizer) AnalyzeGraphWithMetrics(ctx context.Context, graph Graph, metrics ExecutionMetrics) (*GraphAnalysis, error) {
+	ctx, span := o.tracer.Start(ctx, "graph_optimizer.analyze")
+	defer span.End()
+
+	if graph == nil {
+		return nil, fmt.Errorf("graph cannot be nil")
+	}
+
+	stats := ComputeGraphStats(graph)
+
+	weight := func(nodeID string) float64 { return 1 }
+	if len(metrics.NodeMetrics) > 0 {
+		weight = func(nodeID string) float64 {
+			return metrics.NodeMetrics[nodeID].AverageDuration.Seconds()
+		}
+	}
+	criticalPath, cost := criticalPath(graph, weight)
+
+	bottlenecks, err := o.FindBottlenecks(ctx, graph, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &GraphAnalysis{
+		GraphID:       graphIDOf(graph),
+		NodeCount:     stats.NodeCount,
+		EdgeCount:     stats.EdgeCount,
+		Complexity:    float64(stats.EdgeCount - stats.NodeCount + 2*weaklyConnectedComponents(graph)),
+		EstimatedCost: cost,
+		CriticalPath:  criticalPath,
+		Bottlenecks:   make([]string, 0, len(bottlenecks)),
+		Suggestions:   make([]string, 0),
+		Stats:         stats,
+		Metadata:      make(map[string]interface{}),
+	}
+
+	for _, bottleneck := range bottlenecks {
+		analysis.Bottlenecks = append(analysis.Bottlenecks, bottleneck.NodeID)
+		analysis.Suggestions = append(analysis.Suggestions, fmt.Sprintf("%s: %s", bottleneck.NodeID, bottleneck.Suggestion))
+	}
+
+	if len(stats.UnreachableNodes) > 0 {
+		analysis.Suggestions = append(analysis.Suggestions,
+			fmt.Sprintf("Remove or connect unreachable nodes: %s", strings.Join(stats.UnreachableNodes, ", ")))
+	}
+	if len(stats.DeadEndNodes) > 0 {
+		analysis.Suggestions = append(analysis.Suggestions,
+			fmt.Sprintf("Add an exit path from dead-end nodes: %s", strings.Join(stats.DeadEndNodes, ", ")))
+	}
+	if len(stats.CollapsibleNodes) > 0 {
+		analysis.Suggestions = append(analysis.Suggestions,
+			fmt.Sprintf("Collapse pass-through nodes: %s", strings.Join(stats.CollapsibleNodes, ", ")))
+	}
+	for _, group := range stats.ParallelizableGroups {
+		analysis.Suggestions = append(analysis.Suggestions,
+			fmt.Sprintf("Run independent nodes in parallel: %s", strings.Join(group, ", ")))
+	}
+
+	o.logger.WithFields(logrus.Fields{
+		"graph_id":    analysis.GraphID,
+		"node_count":  stats.NodeCount,
+		"depth":       stats.Depth,
+		"bottlenecks": len(bottlenecks),
+	}).Debug("Graph analyzed")
+
+	return analysis, nil
+}
+
+// FindBottlenecks identifies slow, failing and erratic nodes of the graph from
+// recorded per-node execution metrics. Results are ordered by severity.
+func (o *DefaultGraphOptimizer) FindBottlenecks(ctx context.Context, graph Graph, metrics ExecutionMetrics) ([]Bottleneck, error) {
+	_, span := o.tracer.Start(ctx, "graph_optimizer.find_bottlenecks")
+	defer span.End()
+
+	if graph == nil {
+		return nil, fmt.Errorf("graph cannot be nil")
+	}
+
+	nodeIDs := make([]string, 0)
+	averages := make([]time.Duration, 0)
+	var totalAverage time.Duration
+	for _, nodeID := range sortedNodeIDs(graph) {
+		nodeMetrics, exists := metrics.NodeMetrics[nodeID]
+		if !exists || nodeMetrics.TotalExecutions == 0 {
+			continue
+		}
+		nodeIDs = append(nodeIDs, nodeID)
+		averages = append(averages, nodeMetrics.AverageDuration)
+		totalAverage += nodeMetrics.AverageDuration
+	}
+
+	bottlenecks := make([]Bottleneck, 0)
+	if len(nodeIDs) == 0 {
+		return bottlenecks, nil
+	}
+
+	sortedAverages := append([]time.Duration(nil), averages...)
+	sort.Slice(sortedAverages, func(i, j int) bool { return sortedAverages[i] < sortedAverages[j] })
+	median := sortedAverages[len(sortedAverages)/2]
+
+	for i, nodeID := range nodeIDs {
+		nodeMetrics := metrics.NodeMetrics[nodeID]
+		average := averages[i]
+
+		if len(nodeIDs) > 1 && median > 0 && float64(average) > slowNodeFactor*float64(median) {
+			bottlenecks = append(bottlenecks, Bottleneck{
+				NodeID:   nodeID,
+				Type:     "latency",
+				Severity: float64(average) / float64(totalAverage),
+				Description: fmt.Sprintf("averages %s, %.1fx the median node (%s)",
+					average, float64(average)/float64(median), median),
+				Suggestion: "cache its results, use a cheaper model or tool, or run it in parallel with independent nodes",
+				Impact:     (average - median) * time.Duration(nodeMetrics.TotalExecutions),
+			})
+		}
+
+		failureRate := float64(nodeMetrics.FailedExecutions) / float64(nodeMetrics.TotalExecutions)
+		if failureRate > failureRateThreshold {
+			bottlenecks = append(bottlenecks, Bottleneck{
+				NodeID:      nodeID,
+				Type:        "failure_rate",
+				Severity:    failureRate,
+				Description: fmt.Sprintf("fails %.0f%% of %d executions", failureRate*100, nodeMetrics.TotalExecutions),
+				Suggestion:  "add retries or an error edge to a fallback node",
+				Impact:      average * time.Duration(nodeMetrics.FailedExecutions),
+			})
+		}
+
+		if nodeMetrics.TotalExecutions >= minVarianceSamples && average > 0 &&
+			float64(nodeMetrics.MaxDuration) > latencyVarianceFactor*float64(average) {
+			bottlenecks = append(bottlenecks, Bottleneck{
+				NodeID:   nodeID,
+				Type:     "latency_variance",
+				Severity: 1 - float64(average)/float64(nodeMetrics.MaxDuration),
+				Description: fmt.Sprintf("slowest run took %s against an average of %s",
+					nodeMetrics.MaxDuration, average),
+				Suggestion: "add a timeout edge so slow runs fall back instead of stalling the graph",
+				Impact:     nodeMetrics.MaxDuration - average,
+			})
+		}
+	}
+
+	sort.SliceStable(bottlenecks, func(i, j int) bool {
+		if bottlenecks[i].Severity != bottlenecks[j].Severity {
+			return bottlenecks[i].Severity > bottlenecks[j].Severity
+		}
+		return bottlenecks[i].NodeID < bottlenecks[j].NodeID
+	})
+
+	return bottlenecks, nil
+}
+
+// OptimizeGraph returns an optimized copy of the graph. Only rewrites that
+// cannot change results are applied: pass-through nodes in linear chains are
+// collapsed into a direct edge, and edges to independent siblings are marked
+// with "parallelizable_group" metadata naming their common parent.
+func (o *DefaultGraphOptimizer) OptimizeGraph(ctx context.Context, graph Graph) (Graph, error) {
+	_, span := o.tracer.Start(ctx, "graph_optimizer.optimize")
+	defer span.End()
+
+	if graph == nil {
+		return nil, fmt.Errorf("graph cannot be nil")
+	}
+
+	optimized := graph.Clone()
+
+	collapsed := 0
+	for _, nodeID := range collapsibleNodes(optimized) {
+		if err := collapseNode(optimized, nodeID); err != nil {
+			return nil, fmt.Errorf("failed to collapse node %s: %w", nodeID, err)
+		}
+		collapsed++
+	}
+
+	marked := 0
+	for parent, groups := range parallelizableGroups(optimized) {
+		for _, group := range groups {
+			for _, nodeID := range group {
+				for _, edge := range optimized.GetEdges(parent) {
+					if edge.GetTo() != nodeID {
+						continue
+					}
+					// Edges are shared with the source graph, so mark a copy
+					marked++
+					replacement := NewEdge(parent, nodeID, edge.GetCondition(), edge.GetWeight())
+					for k, v := range edge.GetMetadata() {
+						replacement.SetMetadata(k, v)
+					}
+					replacement.SetMetadata("parallelizable_group", parent)
+					if err := optimized.RemoveEdge(parent, nodeID); err != nil {
+						return nil, err
+					}
+					if err := optimized.AddEdge(replacement); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	if err := optimized.Validate(); err != nil {
+		return nil, fmt.Errorf("optimized graph is invalid: %w", err)
+	}
+
+	o.logger.WithFields(logrus.Fields{
+		"graph_id":        graphIDOf(graph),
+		"collapsed_nodes": collapsed,
+		"marked_edges":    marked,
+	}).Info("Graph optimized")
+
+	return optimized, nil
+}
+
+// ComputeGraphStats computes structural statistics of a graph
+func ComputeGraphStats(graph Graph) *GraphStats {
+	nodeIDs := sortedNodeIDs(graph)
+
+	stats := &GraphStats{
+		NodeCount:                   len(nodeIDs),
+		FanOut:                      make(map[string]int),
+		StronglyConnectedComponents: make([][]string, 0),
+		UnreachableNodes:            make([]string, 0),
+		DeadEndNodes:                make([]string, 0),
+		CollapsibleNodes:            collapsibleNodes(graph),
+		ParallelizableGroups:        make([][]string, 0),
+	}
+
+	for _, nodeID := range nodeIDs {
+		fanOut := len(graph.GetEdges(nodeID))
+		stats.FanOut[nodeID] = fanOut
+		stats.EdgeCount += fanOut
+		if fanOut > stats.MaxFanOut {
+			stats.MaxFanOut = fanOut
+		}
+	}
+	if stats.NodeCount > 0 {
+		stats.AverageFanOut = float64(stats.EdgeCount) / float64(stats.NodeCount)
+	}
+
+	for _, component := range stronglyConnectedComponents(graph) {
+		if len(component) > 1 || hasEdge(graph, component[0], component[0]) {
+			stats.StronglyConnectedComponents = append(stats.StronglyConnectedComponents, component)
+		}
+	}
+
+	reachable := reachableFrom(graph, entryNodes(graph))
+	for _, nodeID := range nodeIDs {
+		if !reachable[nodeID] {
+			stats.UnreachableNodes = append(stats.UnreachableNodes, nodeID)
+		}
+	}
+
+	canExit := reachesExit(graph)
+	for _, nodeID := range nodeIDs {
+		if !canExit[nodeID] {
+			stats.DeadEndNodes = append(stats.DeadEndNodes, nodeID)
+		}
+	}
+
+	unitWeight := func(string) float64 { return 1 }
+	if path, _ := criticalPath(graph, unitWeight); len(path) > 0 {
+		stats.Depth = len(path) - 1
+	}
+
+	groups := parallelizableGroups(graph)
+	parents := make([]string, 0, len(groups))
+	for parent := range groups {
+		parents = append(parents, parent)
+	}
+	sort.Strings(parents)
+	for _, parent := range parents {
+		stats.ParallelizableGroups = append(stats.ParallelizableGroups, groups[parent]...)
+	}
+
+	return stats
+}
+
+// Helper functions
+
+func graphIDOf(graph Graph) string {
+	if defaultGraph, ok := graph.(*DefaultGraph); ok {
+		return defaultGraph.id
+	}
+	return ""
+}
+
+// declaredEndpoints returns the explicit entry and exit points of a graph
+func declaredEndpoints(graph Graph) (entryPoints, exitPoints []string) {
+	defaultGraph, ok := graph.(*DefaultGraph)
+	if !ok {
+		return nil, nil
+	}
+
+	defaultGraph.mu.RLock()
+	defer defaultGraph.mu.RUnlock()
+
+	return append([]string(nil), defaultGraph.entryPoints...), append([]string(nil), defaultGraph.exitPoints...)
+}
+
+func sortedNodeIDs(graph Graph) []string {
+	nodes := graph.GetNodes()
+	nodeIDs := make([]string, 0, len(nodes))
+	for nodeID := range nodes {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+	return nodeIDs
+}
+
+func hasEdge(graph Graph, from, to string) bool {
+	for _, edge := range graph.GetEdges(from) {
+		if edge.GetTo() == to {
+			return true
+		}
+	}
+	return false
+}
+
+func reachableFrom(graph Graph, start []string) map[string]bool {
+	reachable := make(map[string]bool)
+	queue := make([]string, 0, len(start))
+	for _, nodeID := range start {
+		if !reachable[nodeID] {
+			reachable[nodeID] = true
+			queue = append(queue, nodeID)
+		}
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range graph.GetEdges(current) {
+			if to := edge.GetTo(); !reachable[to] {
+				reachable[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	return reachable
+}
+
+// entryNodes returns the declared entry points and start nodes of a graph,
+// falling back to the nodes without incoming edges when there are none
+func entryNodes(graph Graph) []string {
+	entries, _ := declaredEndpoints(graph)
+	for _, nodeID := range sortedNodeIDs(graph) {
+		if node, err := graph.GetNode(nodeID); err == nil && node.GetType() == "start" {
+			entries = append(entries, nodeID)
+		}
+	}
+	if len(entries) == 0 {
+		return graph.GetEntryPoints()
+	}
+	return entries
+}
+
+// reachesExit returns the nodes from which an exit can be reached
+func reachesExit(graph Graph) map[string]bool {
+	nodeIDs := sortedNodeIDs(graph)
+
+	_, exits := declaredEndpoints(graph)
+	for _, nodeID := range nodeIDs {
+		if node, err := graph.GetNode(nodeID); err == nil && node.GetType() == "end" {
+			exits = append(exits, nodeID)
+		}
+	}
+	if len(exits) == 0 {
+		for _, nodeID := range nodeIDs {
+			if len(graph.GetEdges(nodeID)) == 0 {
+				exits = append(exits, nodeID)
+			}
+		}
+	}
+
+	predecessors := make(map[string][]string)
+	for _, nodeID := range nodeIDs {
+		for _, edge := range graph.GetEdges(nodeID) {
+			predecessors[edge.GetTo()] = append(predecessors[edge.GetTo()], nodeID)
+		}
+	}
+
+	canExit := make(map[string]bool)
+	queue := make([]string, 0, len(exits))
+	for _, nodeID := range exits {
+		if !canExit[nodeID] {
+			canExit[nodeID] = true
+			queue = append(queue, nodeID)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, from := range predecessors[current] {
+			if !canExit[from] {
+				canExit[from] = true
+				queue = append(queue, from)
+			}
+		}
+	}
+
+	return canExit
+}
+
+// stronglyConnectedComponents returns the components of the graph using
+// Tarjan's algorithm, each sorted, in a deterministic order
+func stronglyConnectedComponents(graph Graph) [][]string {
+	index := 0
+	indices := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0)
+	components := make([][]string, 0)
+
+	var connect func(nodeID string)
+	connect = func(nodeID string) {
+		indices[nodeID] = index
+		lowLinks[nodeID] = index
+		index++
+		stack = append(stack, nodeID)
+		onStack[nodeID] = true
+
+		for _, edge := range graph.GetEdges(nodeID) {
+			to := edge.GetTo()
+			if _, visited := indices[to]; !visited {
+				connect(to)
+				if lowLinks[to] < lowLinks[nodeID] {
+					lowLinks[nodeID] = lowLinks[to]
+				}
+			} else if onStack[to] && indices[to] < lowLinks[nodeID] {
+				lowLinks[nodeID] = indices[to]
+			}
+		}
+
+		if lowLinks[nodeID] == indices[nodeID] {
+			component := make([]string, 0)
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == nodeID {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, nodeID := range sortedNodeIDs(graph) {
+		if _, visited := indices[nodeID]; !visited {
+			connect(nodeID)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	return components
+}
+
+// weaklyConnectedComponents counts the components of the undirected graph
+func weaklyConnectedComponents(graph Graph) int {
+	neighbors := make(map[string][]string)
+	for _, nodeID := range sortedNodeIDs(graph) {
+		for _, edge := range graph.GetEdges(nodeID) {
+			neighbors[nodeID] = append(neighbors[nodeID], edge.GetTo())
+			neighbors[edge.GetTo()] = append(neighbors[edge.GetTo()], nodeID)
+		}
+	}
+
+	visited := make(map[string]bool)
+	count := 0
+	for _, nodeID := range sortedNodeIDs(graph) {
+		if visited[nodeID] {
+			continue
+		}
+		count++
+		queue := []string{nodeID}
+		visited[nodeID] = true
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, next := range neighbors[current] {
+				if !visited[next] {
+					visited[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+
+	return count
+}
+
+// criticalPath returns the heaviest path from an entry point and its total
+// weight. Cycles are condensed into single steps that cost the sum of their
+// nodes, listed in sorted order.
+func criticalPath(graph Graph, weight func(nodeID string) float64) ([]string, float64) {
+	components := stronglyConnectedComponents(graph)
+	componentOf := make(map[string]int)
+	for i, component := range components {
+		for _, nodeID := range component {
+			componentOf[nodeID] = i
+		}
+	}
+
+	componentWeight := make([]float64, len(components))
+	successors := make([]map[int]bool, len(components))
+	for i, component := range components {
+		successors[i] = make(map[int]bool)
+		for _, nodeID := range component {
+			componentWeight[i] += weight(nodeID)
+			for _, edge := range graph.GetEdges(nodeID) {
+				if to := componentOf[edge.GetTo()]; to != i {
+					successors[i][to] = true
+				}
+			}
+		}
+	}
+
+	// The condensation is acyclic, so memoized DFS finds the heaviest path
+	best := make([]float64, len(components))
+	next := make([]int, len(components))
+	done := make([]bool, len(components))
+	var heaviest func(component int) float64
+	heaviest = func(component int) float64 {
+		if done[component] {
+			return best[component]
+		}
+		best[component] = componentWeight[component]
+		next[component] = -1
+
+		successorIDs := make([]int, 0, len(successors[component]))
+		for successor := range successors[component] {
+			successorIDs = append(successorIDs, successor)
+		}
+		sort.Ints(successorIDs)
+		for _, successor := range successorIDs {
+			if total := componentWeight[component] + heaviest(successor); total > best[component] {
+				best[component] = total
+				next[component] = successor
+			}
+		}
+
+		done[component] = true
+		return best[component]
+	}
+
+	start, total := -1, 0.0
+	for _, entry := range entryNodes(graph) {
+		component, exists := componentOf[entry]
+		if !exists {
+			continue
+		}
+		if cost := heaviest(component); start == -1 || cost > total {
+			start, total = component, cost
+		}
+	}
+
+	path := make([]string, 0)
+	for component := start; component != -1; component = next[component] {
+		path = append(path, components[component]...)
+	}
+
+	return path, total
+}
+
+// collapsibleNodes returns pass-through nodes with a single unconditional
+// incoming and outgoing plain edge that are neither entry nor exit points
+func collapsibleNodes(graph Graph) []string {
+	incoming := make(map[string][]Edge)
+	for _, nodeID := range sortedNodeIDs(graph) {
+		for _, edge := range graph.GetEdges(nodeID) {
+			incoming[edge.GetTo()] = append(incoming[edge.GetTo()], edge)
+		}
+	}
+
+	endpoints := make(map[string]bool)
+	entryPoints, exitPoints := declaredEndpoints(graph)
+	for _, nodeID := range append(entryPoints, exitPoints...) {
+		endpoints[nodeID] = true
+	}
+
+	collapsible := make([]string, 0)
+	for _, nodeID := range sortedNodeIDs(graph) {
+		node, err := graph.GetNode(nodeID)
+		if err != nil || node.GetType() != "passthrough" || endpoints[nodeID] {
+			continue
+		}
+
+		in, out := incoming[nodeID], graph.GetEdges(nodeID)
+		if len(in) != 1 || len(out) != 1 {
+			continue
+		}
+
+		inEdge, inPlain := in[0].(*DefaultEdge)
+		outEdge, outPlain := out[0].(*DefaultEdge)
+		if !inPlain || !outPlain || outEdge.GetCondition() != nil {
+			continue
+		}
+
+		from, to := inEdge.GetFrom(), outEdge.GetTo()
+		if from == nodeID || to == nodeID || from == to || hasEdge(graph, from, to) {
+			continue
+		}
+
+		collapsible = append(collapsible, nodeID)
+	}
+
+	return collapsible
+}
+
+// collapseNode replaces a collapsible node and its two edges with a direct
+// edge that keeps the incoming edge's condition and weight
+func collapseNode(graph Graph, nodeID string) error {
+	var inEdge Edge
+	for _, candidate := range sortedNodeIDs(graph) {
+		for _, edge := range graph.GetEdges(candidate) {
+			if edge.GetTo() == nodeID {
+				inEdge = edge
+			}
+		}
+	}
+
+	outEdges := graph.GetEdges(nodeID)
+	if inEdge == nil || len(outEdges) != 1 {
+		return fmt.Errorf("node is no longer collapsible")
+	}
+
+	from, to := inEdge.GetFrom(), outEdges[0].GetTo()
+	if hasEdge(graph, from, to) {
+		return nil
+	}
+
+	if err := graph.RemoveNode(nodeID); err != nil {
+		return err
+	}
+
+	return graph.AddEdge(NewEdge(from, to, inEdge.GetCondition(), inEdge.GetWeight()))
+}
+
+// parallelizableGroups returns, per parent node, groups of two or more
+// unconditional successors that cannot reach each other and whose declared
+// state keys do not conflict. Function nodes without declared keys are
+// treated as touching any key.
+func parallelizableGroups(graph Graph) map[string][][]string {
+	groups := make(map[string][][]string)
+
+	for _, parent := range sortedNodeIDs(graph) {
+		candidates := make([]string, 0)
+		for _, edge := range graph.GetEdges(parent) {
+			if edge.GetCondition() != nil {
+				continue
+			}
+			if _, ok := edge.(FanOutEdge); ok {
+				continue
+			}
+			if limited, ok := edge.(IterationLimitedEdge); ok && limited.GetMaxIterations() > 0 {
+				continue
+			}
+			candidates = append(candidates, edge.GetTo())
+		}
+		if len(candidates) < 2 {
+			continue
+		}
+		sort.Strings(candidates)
+
+		assigned := make(map[string]bool)
+		for i, first := range candidates {
+			if assigned[first] {
+				continue
+			}
+			group := []string{first}
+			for _, other := range candidates[i+1:] {
+				if assigned[other] {
+					continue
+				}
+				independent := true
+				for _, member := range group {
+					if !independentNodes(graph, member, other) {
+						independent = false
+						break
+					}
+				}
+				if independent {
+					group = append(group, other)
+				}
+			}
+			if len(group) > 1 {
+				for _, member := range group {
+					assigned[member] = true
+				}
+				groups[parent] = append(groups[parent], group)
+			}
+		}
+	}
+
+	return groups
+}
+
+func independentNodes(graph Graph, a, b string) bool {
+	if reachableFrom(graph, []string{a})[b] || reachableFrom(graph, []string{b})[a] {
+		return false
+	}
+
+	nodeA, errA := graph.GetNode(a)
+	nodeB, errB := graph.GetNode(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	for _, node := range []Node{nodeA, nodeB} {
+		if node.GetType() == "function" && len(node.GetInputKeys()) == 0 && len(node.GetOutputKeys()) == 0 {
+			return false
+		}
+	}
+
+	conflicts := func(writer, other Node) bool {
+		touched := make(map[string]bool)
+		for _, key := range append(append([]string{}, other.GetInputKeys()...), other.GetOutputKeys()...) {
+			touched[key] = true
+		}
+		for _, key := range writer.GetOutputKeys() {
+			if touched[key] {
+				return true
+			}
+		}
+		return false
+	}
+
+	return !conflicts(nodeA, nodeB) && !conflicts(nodeB, nodeA)
+}
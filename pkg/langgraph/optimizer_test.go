@@ -0,0 +1,150 @@
+package langgraph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOptimizerTestGraph builds start -> fetch -> relay -> {summarize, translate} -> done
+// with a retry loop on fetch and an orphan node
+func newOptimizerTestGraph(t *testing.T) Graph {
+	logger := newTestLogger()
+	graph := NewGraph("pipeline")
+
+	summarize := setKeyNode("summarize", "summary", "s", logger)
+	summarize.SetOutputKeys([]string{"summary"})
+	translate := setKeyNode("translate", "translation", "t", logger)
+	translate.SetOutputKeys([]string{"translation"})
+
+	for _, node := range []Node{
+		NewStartNode("start", logger),
+		setKeyNode("fetch", "document", "d", logger),
+		NewPassthroughNode("relay", logger),
+		summarize,
+		translate,
+		NewEndNode("done", logger),
+		NewPassthroughNode("orphan", logger),
+	} {
+		require.NoError(t, graph.AddNode(node))
+	}
+
+	for _, edge := range []Edge{
+		NewEdge("start", "fetch", nil, 1),
+		NewLoopEdge("fetch", "fetch", NewStateCondition("document", "not_exists", nil, logger), 3, logger),
+		NewEdge("fetch", "relay", nil, 1),
+		NewEdge("relay", "summarize", nil, 1),
+		NewEdge("relay", "translate", nil, 1),
+		NewEdge("summarize", "done", nil, 1),
+		NewEdge("translate", "done", nil, 1),
+	} {
+		require.NoError(t, graph.AddEdge(edge))
+	}
+
+	return graph
+}
+
+func TestGraphOptimizerAnalyze(t *testing.T) {
+	graph := newOptimizerTestGraph(t)
+	optimizer := NewGraphOptimizer(newTestLogger())
+
+	analysis, err := optimizer.AnalyzeGraph(context.Background(), graph)
+	require.NoError(t, err)
+
+	stats := analysis.Stats
+	require.NotNil(t, stats)
+	assert.Equal(t, 7, stats.NodeCount)
+	assert.Equal(t, 7, stats.EdgeCount)
+	assert.Equal(t, 2, stats.MaxFanOut)
+	assert.Equal(t, 4, stats.Depth)
+	assert.Equal(t, [][]string{{"fetch"}}, stats.StronglyConnectedComponents)
+	assert.Equal(t, []string{"orphan"}, stats.UnreachableNodes)
+	assert.Equal(t, []string{"orphan"}, stats.DeadEndNodes)
+	assert.Empty(t, stats.CollapsibleNodes)
+	assert.Equal(t, [][]string{{"summarize", "translate"}}, stats.ParallelizableGroups)
+	assert.Equal(t, []string{"start", "fetch", "relay", "summarize", "done"}, analysis.CriticalPath)
+	assert.Equal(t, 5.0, analysis.EstimatedCost)
+}
+
+func TestGraphOptimizerFindBottlenecks(t *testing.T) {
+	graph := newOptimizerTestGraph(t)
+	optimizer := NewGraphOptimizer(newTestLogger())
+
+	metrics := ExecutionMetrics{NodeMetrics: map[string]NodeExecutionMetrics{
+		"start":     {TotalExecutions: 10, SuccessfulExecutions: 10, AverageDuration: time.Millisecond, MaxDuration: time.Millisecond},
+		"fetch":     {TotalExecutions: 10, SuccessfulExecutions: 10, AverageDuration: 10 * time.Millisecond, MaxDuration: 12 * time.Millisecond},
+		"relay":     {TotalExecutions: 10, SuccessfulExecutions: 10, AverageDuration: time.Millisecond, MaxDuration: time.Millisecond},
+		"summarize": {TotalExecutions: 10, SuccessfulExecutions: 10, AverageDuration: 200 * time.Millisecond, MaxDuration: 250 * time.Millisecond},
+		"translate": {TotalExecutions: 10, SuccessfulExecutions: 7, FailedExecutions: 3, AverageDuration: 10 * time.Millisecond, MaxDuration: 50 * time.Millisecond},
+		"done":      {TotalExecutions: 10, SuccessfulExecutions: 10, AverageDuration: time.Millisecond, MaxDuration: time.Millisecond},
+		"unknown":   {TotalExecutions: 10, FailedExecutions: 10, AverageDuration: time.Second},
+	}}
+
+	bottlenecks, err := optimizer.FindBottlenecks(context.Background(), graph, metrics)
+	require.NoError(t, err)
+
+	found := make(map[string]string)
+	for _, bottleneck := range bottlenecks {
+		found[bottleneck.NodeID+"/"+bottleneck.Type] = bottleneck.Description
+	}
+	assert.Contains(t, found, "summarize/latency")
+	assert.NotContains(t, found, "fetch/latency")
+	assert.Contains(t, found, "translate/failure_rate")
+	assert.Contains(t, found, "translate/latency_variance")
+	assert.NotContains(t, found, "unknown/failure_rate")
+	assert.Equal(t, "summarize", bottlenecks[0].NodeID)
+	assert.Equal(t, 190*time.Millisecond*10, bottlenecks[0].Impact)
+
+	analysis, err := optimizer.AnalyzeGraphWithMetrics(context.Background(), graph, metrics)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start", "fetch", "relay", "summarize", "done"}, analysis.CriticalPath)
+	assert.InDelta(t, 0.213, analysis.EstimatedCost, 1e-9)
+	assert.Contains(t, analysis.Bottlenecks, "summarize")
+}
+
+func TestGraphOptimizerOptimize(t *testing.T) {
+	logger := newTestLogger()
+	graph := newOptimizerTestGraph(t)
+	require.NoError(t, graph.RemoveNode("orphan"))
+
+	// Add a second pass-through so relay and forward form a linear chain
+	require.NoError(t, graph.AddNode(NewPassthroughNode("forward", logger)))
+	require.NoError(t, graph.RemoveEdge("relay", "summarize"))
+	require.NoError(t, graph.RemoveEdge("relay", "translate"))
+	require.NoError(t, graph.AddEdge(NewEdge("relay", "forward", nil, 1)))
+	require.NoError(t, graph.AddEdge(NewEdge("forward", "summarize", nil, 1)))
+	require.NoError(t, graph.AddEdge(NewEdge("forward", "translate", nil, 1)))
+
+	optimizer := NewGraphOptimizer(logger)
+	stats := ComputeGraphStats(graph)
+	assert.Equal(t, []string{"relay"}, stats.CollapsibleNodes)
+
+	optimized, err := optimizer.OptimizeGraph(context.Background(), graph)
+	require.NoError(t, err)
+
+	_, err = optimized.GetNode("relay")
+	assert.Error(t, err)
+	require.Len(t, optimized.GetEdges("fetch"), 2)
+	assert.True(t, hasEdge(optimized, "fetch", "forward"))
+
+	for _, edge := range optimized.GetEdges("forward") {
+		assert.Equal(t, "forward", edge.GetMetadata()["parallelizable_group"])
+	}
+
+	// The source graph is left untouched
+	_, err = graph.GetNode("relay")
+	assert.NoError(t, err)
+	for _, edge := range graph.GetEdges("forward") {
+		assert.NotContains(t, edge.GetMetadata(), "parallelizable_group")
+	}
+
+	// The optimized graph still runs to the same result
+	executor := NewGraphExecutor(&ExecutorConfig{}, logger)
+	result, err := executor.Execute(context.Background(), optimized, GraphState{})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "s", result.FinalState["summary"])
+}
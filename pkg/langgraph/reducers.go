@@ -0,0 +1,78 @@
+package langgraph
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// StateReducer merges a value written by a parallel branch into the joined
+// state. base is the value before the fan-out, current is the value merged so
+// far and update is the value the branch ended with.
+type StateReducer func(base, current, update interface{}) (interface{}, error)
+
+// ReducerProvider is implemented by nodes that define how the state of
+// parallel branches joining at them is merged
+type ReducerProvider interface {
+	GetReducers() map[string]StateReducer
+}
+
+// LastWriteWinsReducer keeps the value of the last branch that changed the key.
+// It is used for keys without a reducer.
+func LastWriteWinsReducer(base, current, update interface{}) (interface{}, error) {
+	return update, nil
+}
+
+// AppendReducer concatenates the items each branch added to a list. Items
+// already present before the fan-out are kept once; non-list values are
+// appended as single items.
+func AppendReducer(base, current, update interface{}) (interface{}, error) {
+	merged := toItems(current)
+
+	items := toItems(update)
+	if baseItems := toItems(base); len(baseItems) > 0 && len(items) >= len(baseItems) &&
+		reflect.DeepEqual(items[:len(baseItems)], baseItems) {
+		items = items[len(baseItems):]
+	}
+
+	return append(merged, items...), nil
+}
+
+// ReducerFunc adapts a function of the merged and the updated value into a
+// StateReducer
+func ReducerFunc(fn func(current, update interface{}) (interface{}, error)) StateReducer {
+	return func(base, current, update interface{}) (interface{}, error) {
+		return fn(current, update)
+	}
+}
+
+// GetReducer returns a built-in reducer by name
+func GetReducer(name string) (StateReducer, error) {
+	switch name {
+	case "last_write_wins", "":
+		return LastWriteWinsReducer, nil
+	case "append":
+		return AppendReducer, nil
+	default:
+		return nil, fmt.Errorf("unknown reducer: %s", name)
+	}
+}
+
+// toItems converts a slice or array of any element type into []interface{}.
+// nil yields an empty list and any other value a single-item list.
+func toItems(value interface{}) []interface{} {
+	if value == nil {
+		return []interface{}{}
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return []interface{}{value}
+	}
+
+	items := make([]interface{}, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		items[i] = v.Index(i).Interface()
+	}
+
+	return items
+}
@@ -0,0 +1,705 @@
+package langgraph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Checkpoint statuses
+const (
+	CheckpointStatusRunning   = "running"
+	CheckpointStatusCompleted = "completed"
+	CheckpointStatusFailed    = "failed"
+
+	// CheckpointStatusInterrupted marks a run suspended at an interrupt point
+	CheckpointStatusInterrupted = "interrupted"
+
+	// CheckpointStatusRejected marks an interrupted run that was rejected
+	CheckpointStatusRejected = "rejected"
+)
+
+// Checkpoint represents a snapshot of an execution taken after a node completes
+type Checkpoint struct {
+	ID           string                 `json:"id"`
+	ExecutionID  string                 `json:"execution_id"`
+	NodeID       string                 `json:"node_id,omitempty"`
+	NextNode     string                 `json:"next_node,omitempty"`
+	Step         int                    `json:"step"`
+	ExecutedPath []string               `json:"executed_path"`
+	State        GraphState             `json:"state"`
+	Status       string                 `json:"status"`
+	CreatedAt    time.Time              `json:"created_at"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// CheckpointStore is a StateManager that also records execution progress,
+// allowing the executor to resume a run from its last completed node
+type CheckpointStore interface {
+	StateManager
+
+	// SaveCheckpoint persists a checkpoint
+	SaveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error
+
+	// GetLatestCheckpoint returns the most recent checkpoint of an execution
+	GetLatestCheckpoint(ctx context.Context, executionID string) (*Checkpoint, error)
+
+	// ListCheckpoints returns all checkpoints of an execution ordered by step
+	ListCheckpoints(ctx context.Context, executionID string) ([]*Checkpoint, error)
+}
+
+// FileStateManager implements CheckpointStore on the local filesystem.
+// Each execution gets its own directory holding the current state and one
+// JSON file per checkpoint.
+type FileStateManager struct {
+	baseDir string
+	logger  *logrus.Logger
+	tracer  trace.Tracer
+	mu      sync.RWMutex
+}
+
+// NewFileStateManager creates a new file-backed state manager
+func NewFileStateManager(baseDir string, logger *logrus.Logger) (*FileStateManager, error) {
+	if baseDir == "" {
+		return nil, fmt.Errorf("base directory cannot be empty")
+	}
+
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	return &FileStateManager{
+		baseDir: baseDir,
+		logger:  logger,
+		tracer:  otel.Tracer("langgraph.state.file"),
+	}, nil
+}
+
+// SaveState saves the current graph state
+func (m *FileStateManager) SaveState(ctx context.Context, executionID string, state GraphState) error {
+	_, span := m.tracer.Start(ctx, "file_state_manager.save_state")
+	defer span.End()
+
+	if err := validateStorageKey(executionID); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir := filepath.Join(m.baseDir, executionID)
+	if err := os.MkdirAll(filepath.Join(dir, "checkpoints"), 0o755); err != nil {
+		return fmt.Errorf("failed to create execution directory: %w", err)
+	}
+
+	return writeJSONFile(filepath.Join(dir, "state.json"), state)
+}
+
+// LoadState loads a saved graph state
+func (m *FileStateManager) LoadState(ctx context.Context, executionID string) (GraphState, error) {
+	_, span := m.tracer.Start(ctx, "file_state_manager.load_state")
+	defer span.End()
+
+	if err := validateStorageKey(executionID); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var state GraphState
+	if err := readJSONFile(filepath.Join(m.baseDir, executionID, "state.json"), &state); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("state not found for execution %s", executionID)
+		}
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// DeleteState deletes a saved graph state together with its checkpoints
+func (m *FileStateManager) DeleteState(ctx context.Context, executionID string) error {
+	_, span := m.tracer.Start(ctx, "file_state_manager.delete_state")
+	defer span.End()
+
+	if err := validateStorageKey(executionID); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir := filepath.Join(m.baseDir, executionID)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return fmt.Errorf("state not found for execution %s", executionID)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete state: %w", err)
+	}
+
+	return nil
+}
+
+// ListStates lists all saved states
+func (m *FileStateManager) ListStates(ctx context.Context) ([]string, error) {
+	_, span := m.tracer.Start(ctx, "file_state_manager.list_states")
+	defer span.End()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries, err := os.ReadDir(m.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state directory: %w", err)
+	}
+
+	executionIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			executionIDs = append(executionIDs, entry.Name())
+		}
+	}
+
+	return executionIDs, nil
+}
+
+// CreateCheckpoint creates a checkpoint of the current state
+func (m *FileStateManager) CreateCheckpoint(ctx context.Context, executionID string, checkpointID string, state GraphState) error {
+	step := 0
+	if latest, err := m.GetLatestCheckpoint(ctx, executionID); err == nil {
+		step = latest.Step + 1
+	}
+
+	return m.SaveCheckpoint(ctx, &Checkpoint{
+		ID:          checkpointID,
+		ExecutionID: executionID,
+		Step:        step,
+		State:       state,
+		Status:      CheckpointStatusRunning,
+	})
+}
+
+// RestoreCheckpoint restores state from a checkpoint
+func (m *FileStateManager) RestoreCheckpoint(ctx context.Context, executionID string, checkpointID string) (GraphState, error) {
+	_, span := m.tracer.Start(ctx, "file_state_manager.restore_checkpoint")
+	defer span.End()
+
+	if err := validateStorageKey(executionID); err != nil {
+		return nil, err
+	}
+	if err := validateStorageKey(checkpointID); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var checkpoint Checkpoint
+	path := filepath.Join(m.baseDir, executionID, "checkpoints", checkpointID+".json")
+	if err := readJSONFile(path, &checkpoint); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("checkpoint %s not found for execution %s", checkpointID, executionID)
+		}
+		return nil, err
+	}
+
+	return checkpoint.State, nil
+}
+
+// SaveCheckpoint persists a checkpoint and updates the current state
+func (m *FileStateManager) SaveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error {
+	_, span := m.tracer.Start(ctx, "file_state_manager.save_checkpoint")
+	defer span.End()
+
+	if checkpoint == nil {
+		return fmt.Errorf("checkpoint cannot be nil")
+	}
+	if err := validateStorageKey(checkpoint.ExecutionID); err != nil {
+		return err
+	}
+	if err := validateStorageKey(checkpoint.ID); err != nil {
+		return err
+	}
+
+	if checkpoint.CreatedAt.IsZero() {
+		checkpoint.CreatedAt = time.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir := filepath.Join(m.baseDir, checkpoint.ExecutionID)
+	if err := os.MkdirAll(filepath.Join(dir, "checkpoints"), 0o755); err != nil {
+		return fmt.Errorf("failed to create execution directory: %w", err)
+	}
+
+	if err := writeJSONFile(filepath.Join(dir, "checkpoints", checkpoint.ID+".json"), checkpoint); err != nil {
+		return err
+	}
+
+	return writeJSONFile(filepath.Join(dir, "state.json"), checkpoint.State)
+}
+
+// GetLatestCheckpoint returns the most recent checkpoint of an execution
+func (m *FileStateManager) GetLatestCheckpoint(ctx context.Context, executionID string) (*Checkpoint, error) {
+	checkpoints, err := m.ListCheckpoints(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(checkpoints) == 0 {
+		return nil, fmt.Errorf("no checkpoints found for execution %s", executionID)
+	}
+
+	return checkpoints[len(checkpoints)-1], nil
+}
+
+// ListCheckpoints returns all checkpoints of an execution ordered by step
+func (m *FileStateManager) ListCheckpoints(ctx context.Context, executionID string) ([]*Checkpoint, error) {
+	_, span := m.tracer.Start(ctx, "file_state_manager.list_checkpoints")
+	defer span.End()
+
+	if err := validateStorageKey(executionID); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dir := filepath.Join(m.baseDir, executionID, "checkpoints")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Checkpoint{}, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint directory: %w", err)
+	}
+
+	checkpoints := make([]*Checkpoint, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		var checkpoint Checkpoint
+		if err := readJSONFile(filepath.Join(dir, entry.Name()), &checkpoint); err != nil {
+			m.logger.WithError(err).WithField("file", entry.Name()).Warn("Skipping unreadable checkpoint")
+			continue
+		}
+		checkpoints = append(checkpoints, &checkpoint)
+	}
+
+	sortCheckpoints(checkpoints)
+
+	return checkpoints, nil
+}
+
+// PostgresStateManager implements CheckpointStore on PostgreSQL.
+// The tables are created by scripts/migrations/000003_langgraph_checkpoints.up.sql.
+type PostgresStateManager struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+	tracer trace.Tracer
+}
+
+// checkpointRow is the database representation of a checkpoint
+type checkpointRow struct {
+	ExecutionID  string    `db:"execution_id"`
+	CheckpointID string    `db:"checkpoint_id"`
+	NodeID       string    `db:"node_id"`
+	NextNode     string    `db:"next_node"`
+	Step         int       `db:"step"`
+	ExecutedPath []byte    `db:"executed_path"`
+	State        []byte    `db:"state"`
+	Status       string    `db:"status"`
+	Metadata     []byte    `db:"metadata"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// NewPostgresStateManager creates a new PostgreSQL-backed state manager
+func NewPostgresStateManager(db *sqlx.DB, logger *logrus.Logger) *PostgresStateManager {
+	return &PostgresStateManager{
+		db:     db,
+		logger: logger,
+		tracer: otel.Tracer("langgraph.state.postgres"),
+	}
+}
+
+// SaveState saves the current graph state
+func (m *PostgresStateManager) SaveState(ctx context.Context, executionID string, state GraphState) error {
+	ctx, span := m.tracer.Start(ctx, "postgres_state_manager.save_state")
+	defer span.End()
+
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	query := `
+		INSERT INTO aios.graph_execution_states (execution_id, state)
+		VALUES ($1, $2)
+		ON CONFLICT (execution_id) DO UPDATE SET state = EXCLUDED.state, updated_at = NOW()
+	`
+
+	if _, err := m.db.ExecContext(ctx, query, executionID, stateJSON); err != nil {
+		m.logger.WithError(err).Error("Failed to save graph state")
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return nil
+}
+
+// LoadState loads a saved graph state
+func (m *PostgresStateManager) LoadState(ctx context.Context, executionID string) (GraphState, error) {
+	ctx, span := m.tracer.Start(ctx, "postgres_state_manager.load_state")
+	defer span.End()
+
+	var stateJSON []byte
+	query := `SELECT state FROM aios.graph_execution_states WHERE execution_id = $1`
+
+	if err := m.db.GetContext(ctx, &stateJSON, query, executionID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("state not found for execution %s", executionID)
+		}
+		m.logger.WithError(err).Error("Failed to load graph state")
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	var state GraphState
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	return state, nil
+}
+
+// DeleteState deletes a saved graph state together with its checkpoints
+func (m *PostgresStateManager) DeleteState(ctx context.Context, executionID string) error {
+	ctx, span := m.tracer.Start(ctx, "postgres_state_manager.delete_state")
+	defer span.End()
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM aios.graph_checkpoints WHERE execution_id = $1`, executionID); err != nil {
+		return fmt.Errorf("failed to delete checkpoints: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM aios.graph_execution_states WHERE execution_id = $1`, executionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete state: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("state not found for execution %s", executionID)
+	}
+
+	return tx.Commit()
+}
+
+// ListStates lists all saved states
+func (m *PostgresStateManager) ListStates(ctx context.Context) ([]string, error) {
+	ctx, span := m.tracer.Start(ctx, "postgres_state_manager.list_states")
+	defer span.End()
+
+	var executionIDs []string
+	query := `SELECT execution_id FROM aios.graph_execution_states ORDER BY updated_at DESC`
+
+	if err := m.db.SelectContext(ctx, &executionIDs, query); err != nil {
+		m.logger.WithError(err).Error("Failed to list graph states")
+		return nil, fmt.Errorf("failed to list states: %w", err)
+	}
+
+	return executionIDs, nil
+}
+
+// CreateCheckpoint creates a checkpoint of the current state
+func (m *PostgresStateManager) CreateCheckpoint(ctx context.Context, executionID string, checkpointID string, state GraphState) error {
+	step := 0
+	if latest, err := m.GetLatestCheckpoint(ctx, executionID); err == nil {
+		step = latest.Step + 1
+	}
+
+	return m.SaveCheckpoint(ctx, &Checkpoint{
+		ID:          checkpointID,
+		ExecutionID: executionID,
+		Step:        step,
+		State:       state,
+		Status:      CheckpointStatusRunning,
+	})
+}
+
+// RestoreCheckpoint restores state from a checkpoint
+func (m *PostgresStateManager) RestoreCheckpoint(ctx context.Context, executionID string, checkpointID string) (GraphState, error) {
+	ctx, span := m.tracer.Start(ctx, "postgres_state_manager.restore_checkpoint")
+	defer span.End()
+
+	var stateJSON []byte
+	query := `SELECT state FROM aios.graph_checkpoints WHERE execution_id = $1 AND checkpoint_id = $2`
+
+	if err := m.db.GetContext(ctx, &stateJSON, query, executionID, checkpointID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("checkpoint %s not found for execution %s", checkpointID, executionID)
+		}
+		return nil, fmt.Errorf("failed to restore checkpoint: %w", err)
+	}
+
+	var state GraphState
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	return state, nil
+}
+
+// SaveCheckpoint persists a checkpoint and updates the current state in one transaction
+func (m *PostgresStateManager) SaveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error {
+	ctx, span := m.tracer.Start(ctx, "postgres_state_manager.save_checkpoint")
+	defer span.End()
+
+	if checkpoint == nil {
+		return fmt.Errorf("checkpoint cannot be nil")
+	}
+
+	if checkpoint.CreatedAt.IsZero() {
+		checkpoint.CreatedAt = time.Now()
+	}
+
+	row, err := newCheckpointRow(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO aios.graph_checkpoints (execution_id, checkpoint_id, node_id, next_node, step, executed_path, state, status, metadata, created_at)
+		VALUES (:execution_id, :checkpoint_id, :node_id, :next_node, :step, :executed_path, :state, :status, :metadata, :created_at)
+		ON CONFLICT (execution_id, checkpoint_id) DO UPDATE SET
+			node_id = EXCLUDED.node_id, next_node = EXCLUDED.next_node, step = EXCLUDED.step, executed_path = EXCLUDED.executed_path,
+			state = EXCLUDED.state, status = EXCLUDED.status, metadata = EXCLUDED.metadata
+	`
+	if _, err := tx.NamedExecContext(ctx, query, row); err != nil {
+		m.logger.WithError(err).Error("Failed to save checkpoint")
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	stateQuery := `
+		INSERT INTO aios.graph_execution_states (execution_id, state)
+		VALUES ($1, $2)
+		ON CONFLICT (execution_id) DO UPDATE SET state = EXCLUDED.state, updated_at = NOW()
+	`
+	if _, err := tx.ExecContext(ctx, stateQuery, row.ExecutionID, row.State); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetLatestCheckpoint returns the most recent checkpoint of an execution
+func (m *PostgresStateManager) GetLatestCheckpoint(ctx context.Context, executionID string) (*Checkpoint, error) {
+	ctx, span := m.tracer.Start(ctx, "postgres_state_manager.get_latest_checkpoint")
+	defer span.End()
+
+	var row checkpointRow
+	query := `
+		SELECT execution_id, checkpoint_id, node_id, next_node, step, executed_path, state, status, metadata, created_at
+		FROM aios.graph_checkpoints
+		WHERE execution_id = $1
+		ORDER BY step DESC, created_at DESC
+		LIMIT 1
+	`
+
+	if err := m.db.GetContext(ctx, &row, query, executionID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no checkpoints found for execution %s", executionID)
+		}
+		return nil, fmt.Errorf("failed to get latest checkpoint: %w", err)
+	}
+
+	return row.toCheckpoint()
+}
+
+// ListCheckpoints returns all checkpoints of an execution ordered by step
+func (m *PostgresStateManager) ListCheckpoints(ctx context.Context, executionID string) ([]*Checkpoint, error) {
+	ctx, span := m.tracer.Start(ctx, "postgres_state_manager.list_checkpoints")
+	defer span.End()
+
+	var rows []checkpointRow
+	query := `
+		SELECT execution_id, checkpoint_id, node_id, next_node, step, executed_path, state, status, metadata, created_at
+		FROM aios.graph_checkpoints
+		WHERE execution_id = $1
+		ORDER BY step ASC, created_at ASC
+	`
+
+	if err := m.db.SelectContext(ctx, &rows, query, executionID); err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	checkpoints := make([]*Checkpoint, 0, len(rows))
+	for i := range rows {
+		checkpoint, err := rows[i].toCheckpoint()
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, nil
+}
+
+// Helper functions
+
+func newCheckpointRow(checkpoint *Checkpoint) (*checkpointRow, error) {
+	stateJSON, err := json.Marshal(checkpoint.State)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	pathJSON, err := json.Marshal(checkpoint.ExecutedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal executed path: %w", err)
+	}
+
+	metadataJSON, err := json.Marshal(checkpoint.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	return &checkpointRow{
+		ExecutionID:  checkpoint.ExecutionID,
+		CheckpointID: checkpoint.ID,
+		NodeID:       checkpoint.NodeID,
+		NextNode:     checkpoint.NextNode,
+		Step:         checkpoint.Step,
+		ExecutedPath: pathJSON,
+		State:        stateJSON,
+		Status:       checkpoint.Status,
+		Metadata:     metadataJSON,
+		CreatedAt:    checkpoint.CreatedAt,
+	}, nil
+}
+
+func (r *checkpointRow) toCheckpoint() (*Checkpoint, error) {
+	checkpoint := &Checkpoint{
+		ID:          r.CheckpointID,
+		ExecutionID: r.ExecutionID,
+		NodeID:      r.NodeID,
+		NextNode:    r.NextNode,
+		Step:        r.Step,
+		Status:      r.Status,
+		CreatedAt:   r.CreatedAt,
+	}
+
+	if err := json.Unmarshal(r.State, &checkpoint.State); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+	if len(r.ExecutedPath) > 0 {
+		if err := json.Unmarshal(r.ExecutedPath, &checkpoint.ExecutedPath); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal executed path: %w", err)
+		}
+	}
+	if len(r.Metadata) > 0 {
+		if err := json.Unmarshal(r.Metadata, &checkpoint.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+
+	return checkpoint, nil
+}
+
+func sortCheckpoints(checkpoints []*Checkpoint) {
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		if checkpoints[i].Step != checkpoints[j].Step {
+			return checkpoints[i].Step < checkpoints[j].Step
+		}
+		return checkpoints[i].CreatedAt.Before(checkpoints[j].CreatedAt)
+	})
+}
+
+// validateStorageKey rejects identifiers that could escape the storage directory
+func validateStorageKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("identifier cannot be empty")
+	}
+	if key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+		return fmt.Errorf("invalid identifier: %s", key)
+	}
+	return nil
+}
+
+// writeJSONFile writes a value atomically by renaming a temporary file into place
+func writeJSONFile(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to sync %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to close %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+func readJSONFile(path string, value interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
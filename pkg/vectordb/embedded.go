@@ -0,0 +1,961 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// snapshotExtension is the file extension of collection snapshots
+const snapshotExtension = ".snapshot"
+
+// EmbeddedDB implements VectorDB in process with an HNSW index per
+// collection. It needs no server, which makes it suited to tests, local
+// development and single-node deployments. With a directory configured,
+// collections are loaded on Connect and snapshotted on Disconnect and
+// Snapshot.
+type EmbeddedDB struct {
+	config      *VectorDBConfig
+	directory   string
+	params      HNSWParams
+	collections map[string]*embeddedCollection
+	connected   bool
+	connectedAt time.Time
+	logger      *logrus.Logger
+	tracer      trace.Tracer
+	mu          sync.RWMutex
+}
+
+// embeddedCollection is a collection of the embedded database
+type embeddedCollection struct {
+	config    CollectionConfig
+	index     *hnswIndex
+	toScore   func(float32) float32
+	createdAt time.Time
+	updatedAt time.Time
+	dirty     bool
+}
+
+// EmbeddedFactory implements VectorDBFactory for the embedded database
+type EmbeddedFactory struct{}
+
+// NewEmbeddedFactory creates a new embedded database factory
+func NewEmbeddedFactory() *EmbeddedFactory {
+	return &EmbeddedFactory{}
+}
+
+// Create creates a new embedded vector database instance. Database is the
+// snapshot directory; when empty, data only lives in memory. The metadata
+// keys hnsw_m, hnsw_ef_construction and hnsw_ef_search set the default
+// index parameters of new collections.
+func (f *EmbeddedFactory) Create(config *VectorDBConfig) (VectorDB, error) {
+	if err := f.ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return &EmbeddedDB{
+		config:    config,
+		directory: config.Database,
+		params: HNSWParams{
+			M:              intParameter(config.Metadata, "hnsw_m"),
+			EfConstruction: intParameter(config.Metadata, "hnsw_ef_construction"),
+			EfSearch:       intParameter(config.Metadata, "hnsw_ef_search"),
+		}.withDefaults(),
+		collections: make(map[string]*embeddedCollection),
+		logger:      newProviderLogger(config),
+		tracer:      otel.Tracer("vectordb.embedded"),
+	}, nil
+}
+
+// GetProviderName returns the provider name
+func (f *EmbeddedFactory) GetProviderName() string {
+	return "embedded"
+}
+
+// ValidateConfig validates the embedded database configuration
+func (f *EmbeddedFactory) ValidateConfig(config *VectorDBConfig) error {
+	for _, key := range []string{"hnsw_m", "hnsw_ef_construction", "hnsw_ef_search"} {
+		if value, exists := config.Metadata[key]; exists {
+			if _, ok := toInt(value); !ok {
+				return fmt.Errorf("%s must be an integer", key)
+			}
+		}
+	}
+	return nil
+}
+
+// Connect loads the snapshots in the configured directory
+func (e *EmbeddedDB) Connect(ctx context.Context) error {
+	_, span := e.tracer.Start(ctx, "embedded.connect")
+	defer span.End()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.connected {
+		return nil
+	}
+
+	if e.directory != "" {
+		if err := os.MkdirAll(e.directory, 0o755); err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to create data directory: %w", err)
+		}
+
+		paths, err := filepath.Glob(filepath.Join(e.directory, "*"+snapshotExtension))
+		if err != nil {
+			return fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		for _, path := range paths {
+			collection, err := readSnapshot(path)
+			if err != nil {
+				span.RecordError(err)
+				return fmt.Errorf("failed to load snapshot %s: %w", filepath.Base(path), err)
+			}
+			e.collections[collection.config.Name] = collection
+		}
+	}
+
+	e.connected = true
+	e.connectedAt = time.Now()
+	e.logger.WithFields(logrus.Fields{
+		"directory":   e.directory,
+		"collections": len(e.collections),
+	}).Info("Connected to embedded vector database")
+
+	return nil
+}
+
+// Disconnect snapshots modified collections and closes the database
+func (e *EmbeddedDB) Disconnect(ctx context.Context) error {
+	if err := e.Snapshot(ctx); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.connected = false
+	if e.directory != "" {
+		// Reloaded from the snapshots on the next Connect
+		e.collections = make(map[string]*embeddedCollection)
+	}
+	e.logger.Info("Disconnected from embedded vector database")
+	return nil
+}
+
+// IsConnected returns connection status
+func (e *EmbeddedDB) IsConnected() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.connected
+}
+
+// Snapshot writes every collection modified since its last snapshot to the
+// data directory. It is a no-op without a directory.
+func (e *EmbeddedDB) Snapshot(ctx context.Context) error {
+	_, span := e.tracer.Start(ctx, "embedded.snapshot")
+	defer span.End()
+
+	if e.directory == "" {
+		return nil
+	}
+
+	// Snapshots are written under the write lock, as encoding reads the
+	// graph that writers modify
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	written := 0
+	for _, name := range e.collectionNames() {
+		collection := e.collections[name]
+		if !collection.dirty {
+			continue
+		}
+		if err := writeSnapshot(e.snapshotPath(name), collection); err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to snapshot collection %s: %w", name, err)
+		}
+		collection.dirty = false
+		written++
+	}
+
+	if written > 0 {
+		e.logger.WithFields(logrus.Fields{
+			"directory":   e.directory,
+			"collections": written,
+		}).Debug("Wrote collection snapshots")
+	}
+
+	return nil
+}
+
+// CreateCollection creates a new collection
+func (e *EmbeddedDB) CreateCollection(ctx context.Context, config *CollectionConfig) error {
+	_, span := e.tracer.Start(ctx, "embedded.create_collection")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", config.Name),
+		attribute.Int("collection.dimension", config.Dimension),
+		attribute.String("collection.metric", config.Metric),
+	)
+
+	if err := validateCollectionName(config.Name); err != nil {
+		return err
+	}
+	if config.Dimension <= 0 {
+		return fmt.Errorf("dimension must be positive")
+	}
+	distance, toScore, err := metricDistance(config.Metric)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.checkConnected(); err != nil {
+		return err
+	}
+	if _, exists := e.collections[config.Name]; exists {
+		return fmt.Errorf("collection already exists: %s", config.Name)
+	}
+
+	collectionConfig := *config
+	if collectionConfig.Metric == "" {
+		collectionConfig.Metric = "cosine"
+	}
+	now := time.Now()
+	e.collections[config.Name] = &embeddedCollection{
+		config:    collectionConfig,
+		index:     newHNSWIndex(e.params, true, distance),
+		toScore:   toScore,
+		createdAt: now,
+		updatedAt: now,
+		dirty:     true,
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"collection": config.Name,
+		"dimension":  config.Dimension,
+		"metric":     collectionConfig.Metric,
+	}).Info("Created collection")
+
+	return nil
+}
+
+// DeleteCollection deletes a collection and its snapshot
+func (e *EmbeddedDB) DeleteCollection(ctx context.Context, name string) error {
+	_, span := e.tracer.Start(ctx, "embedded.delete_collection")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("collection.name", name))
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, err := e.collection(name); err != nil {
+		return err
+	}
+	delete(e.collections, name)
+
+	if e.directory != "" {
+		if err := os.Remove(e.snapshotPath(name)); err != nil && !os.IsNotExist(err) {
+			span.RecordError(err)
+			return fmt.Errorf("failed to delete snapshot: %w", err)
+		}
+	}
+
+	e.logger.WithField("collection", name).Info("Deleted collection")
+	return nil
+}
+
+// ListCollections returns all collections
+func (e *EmbeddedDB) ListCollections(ctx context.Context) ([]string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if err := e.checkConnected(); err != nil {
+		return nil, err
+	}
+	return e.collectionNames(), nil
+}
+
+// CollectionExists checks if a collection exists
+func (e *EmbeddedDB) CollectionExists(ctx context.Context, name string) (bool, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if err := e.checkConnected(); err != nil {
+		return false, err
+	}
+	_, exists := e.collections[name]
+	return exists, nil
+}
+
+// Insert inserts vectors into a collection, replacing vectors with the
+// same ID
+func (e *EmbeddedDB) Insert(ctx context.Context, collection string, vectors []*Vector) error {
+	_, span := e.tracer.Start(ctx, "embedded.insert")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", collection),
+		attribute.Int("vectors.count", len(vectors)),
+	)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	col, err := e.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	for _, vector := range vectors {
+		if vector.ID == "" {
+			return fmt.Errorf("vector ID is required")
+		}
+		if len(vector.Values) != col.config.Dimension {
+			return fmt.Errorf("vector %s has dimension %d, collection %s expects %d",
+				vector.ID, len(vector.Values), collection, col.config.Dimension)
+		}
+	}
+
+	for _, vector := range vectors {
+		col.index.add(vector.ID, col.prepare(vector.Values), copyMetadata(vector.Metadata))
+	}
+	col.touch()
+
+	e.logger.WithFields(logrus.Fields{
+		"collection": collection,
+		"count":      len(vectors),
+	}).Debug("Inserted vectors")
+
+	return nil
+}
+
+// Update updates existing vectors
+func (e *EmbeddedDB) Update(ctx context.Context, collection string, vectors []*Vector) error {
+	// Like Qdrant, updates are upserts
+	return e.Insert(ctx, collection, vectors)
+}
+
+// Delete deletes vectors by IDs
+func (e *EmbeddedDB) Delete(ctx context.Context, collection string, ids []string) error {
+	_, span := e.tracer.Start(ctx, "embedded.delete")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", collection),
+		attribute.Int("ids.count", len(ids)),
+	)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	col, err := e.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		if col.index.remove(id) {
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		col.touch()
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"collection": collection,
+		"count":      deleted,
+	}).Debug("Deleted vectors")
+
+	return nil
+}
+
+// Search performs similarity search
+func (e *EmbeddedDB) Search(ctx context.Context, request *SearchRequest) (*SearchResult, error) {
+	_, span := e.tracer.Start(ctx, "embedded.search")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", request.Collection),
+		attribute.Int("search.top_k", request.TopK),
+	)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	col, err := e.collection(request.Collection)
+	if err != nil {
+		return nil, err
+	}
+	return col.search(request)
+}
+
+// BatchSearch performs multiple searches
+func (e *EmbeddedDB) BatchSearch(ctx context.Context, requests []*SearchRequest) ([]*SearchResult, error) {
+	_, span := e.tracer.Start(ctx, "embedded.batch_search")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("search.count", len(requests)))
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	results := make([]*SearchResult, len(requests))
+	for i, request := range requests {
+		col, err := e.collection(request.Collection)
+		if err != nil {
+			return nil, fmt.Errorf("batch search failed at index %d: %w", i, err)
+		}
+		result, err := col.search(request)
+		if err != nil {
+			return nil, fmt.Errorf("batch search failed at index %d: %w", i, err)
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// GetVector retrieves a vector by ID
+func (e *EmbeddedDB) GetVector(ctx context.Context, collection string, id string) (*Vector, error) {
+	vectors, err := e.GetVectors(ctx, collection, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("vector not found: %s", id)
+	}
+	return vectors[0], nil
+}
+
+// GetVectors retrieves multiple vectors by IDs; unknown IDs are skipped
+func (e *EmbeddedDB) GetVectors(ctx context.Context, collection string, ids []string) ([]*Vector, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	col, err := e.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([]*Vector, 0, len(ids))
+	for _, id := range ids {
+		node := col.index.get(id)
+		if node == nil {
+			continue
+		}
+		vectors = append(vectors, &Vector{
+			ID:       node.id,
+			Values:   append([]float32(nil), node.values...),
+			Metadata: copyMetadata(node.metadata),
+		})
+	}
+	return vectors, nil
+}
+
+// ScanVectors calls fn with every vector of a collection. The vectors are
+// copied first, so fn may call back into the database.
+func (e *EmbeddedDB) ScanVectors(ctx context.Context, collection string, fn func(*Vector) error) error {
+	_, span := e.tracer.Start(ctx, "embedded.scan_vectors")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("collection.name", collection))
+
+	e.mu.RLock()
+	col, err := e.collection(collection)
+	if err != nil {
+		e.mu.RUnlock()
+		return err
+	}
+	vectors := make([]*Vector, 0, len(col.index.ids))
+	for _, node := range col.index.nodes {
+		if !node.deleted {
+			vectors = append(vectors, &Vector{ID: node.id, Metadata: copyMetadata(node.metadata)})
+		}
+	}
+	e.mu.RUnlock()
+
+	for _, vector := range vectors {
+		if err := fn(vector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Count returns the number of vectors in a collection
+func (e *EmbeddedDB) Count(ctx context.Context, collection string) (int64, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	col, err := e.collection(collection)
+	if err != nil {
+		return 0, err
+	}
+	return int64(col.index.Len()), nil
+}
+
+// CreateIndex configures the index of a collection. Type "hnsw" rebuilds
+// the graph with the parameters m, ef_construction and ef_search; type
+// "flat" drops the graph in favour of exact exhaustive search.
+func (e *EmbeddedDB) CreateIndex(ctx context.Context, collection string, config *IndexConfig) error {
+	_, span := e.tracer.Start(ctx, "embedded.create_index")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", collection),
+		attribute.String("index.type", config.Type),
+	)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	col, err := e.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(config.Type) {
+	case "", "hnsw":
+		params := HNSWParams{
+			M:              intParameter(config.Parameters, "m"),
+			EfConstruction: intParameter(config.Parameters, "ef_construction"),
+			EfSearch:       intParameter(config.Parameters, "ef_search"),
+		}
+		if params.M == 0 {
+			params.M = e.params.M
+		}
+		if params.EfConstruction == 0 {
+			params.EfConstruction = e.params.EfConstruction
+		}
+		if params.EfSearch == 0 {
+			params.EfSearch = e.params.EfSearch
+		}
+		col.index.rebuild(params, true)
+	case "flat":
+		col.index.rebuild(col.index.params, false)
+	default:
+		return fmt.Errorf("unsupported index type: %s", config.Type)
+	}
+	col.touch()
+
+	e.logger.WithFields(logrus.Fields{
+		"collection": collection,
+		"type":       config.Type,
+		"vectors":    col.index.Len(),
+	}).Info("Rebuilt collection index")
+
+	return nil
+}
+
+// DeleteIndex drops the HNSW graph of a collection, leaving exact search
+func (e *EmbeddedDB) DeleteIndex(ctx context.Context, collection string, indexName string) error {
+	return e.CreateIndex(ctx, collection, &IndexConfig{Name: indexName, Type: "flat"})
+}
+
+// GetCollectionInfo returns collection information
+func (e *EmbeddedDB) GetCollectionInfo(ctx context.Context, collection string) (*CollectionInfo, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	col, err := e.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	indexCount := 0
+	if col.index.graph {
+		indexCount = 1
+	}
+
+	return &CollectionInfo{
+		Name:        col.config.Name,
+		Dimension:   col.config.Dimension,
+		Metric:      col.config.Metric,
+		VectorCount: int64(col.index.Len()),
+		IndexCount:  indexCount,
+		CreatedAt:   col.createdAt,
+		UpdatedAt:   col.updatedAt,
+		Metadata: map[string]interface{}{
+			"hnsw_m":               col.index.params.M,
+			"hnsw_ef_construction": col.index.params.EfConstruction,
+			"hnsw_ef_search":       col.index.params.EfSearch,
+			"deleted_vectors":      col.index.deleted,
+		},
+	}, nil
+}
+
+// Health returns the health status
+func (e *EmbeddedDB) Health(ctx context.Context) (*HealthStatus, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if !e.connected {
+		return &HealthStatus{Status: "unhealthy"}, nil
+	}
+
+	var total int64
+	for _, collection := range e.collections {
+		total += int64(collection.index.Len())
+	}
+
+	return &HealthStatus{
+		Status:       "healthy",
+		Version:      "embedded",
+		Uptime:       time.Since(e.connectedAt),
+		Collections:  len(e.collections),
+		TotalVectors: total,
+		Metadata:     map[string]interface{}{"directory": e.directory},
+	}, nil
+}
+
+// Helper methods
+
+func (e *EmbeddedDB) checkConnected() error {
+	if !e.connected {
+		return fmt.Errorf("embedded vector database is not connected")
+	}
+	return nil
+}
+
+func (e *EmbeddedDB) collection(name string) (*embeddedCollection, error) {
+	if err := e.checkConnected(); err != nil {
+		return nil, err
+	}
+	collection, exists := e.collections[name]
+	if !exists {
+		return nil, fmt.Errorf("collection not found: %s", name)
+	}
+	return collection, nil
+}
+
+func (e *EmbeddedDB) collectionNames() []string {
+	names := make([]string, 0, len(e.collections))
+	for name := range e.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *EmbeddedDB) snapshotPath(name string) string {
+	return filepath.Join(e.directory, name+snapshotExtension)
+}
+
+// prepare normalizes vectors of cosine collections, as Qdrant does
+func (c *embeddedCollection) prepare(values []float32) []float32 {
+	if strings.EqualFold(c.config.Metric, "cosine") {
+		return normalize(values)
+	}
+	return append([]float32(nil), values...)
+}
+
+func (c *embeddedCollection) touch() {
+	c.updatedAt = time.Now()
+	c.dirty = true
+}
+
+func (c *embeddedCollection) search(request *SearchRequest) (*SearchResult, error) {
+	if len(request.Vector) == 0 {
+		return nil, fmt.Errorf("embedded vector database requires a query vector")
+	}
+	if len(request.Vector) != c.config.Dimension {
+		return nil, fmt.Errorf("query vector has dimension %d, collection %s expects %d",
+			len(request.Vector), c.config.Name, c.config.Dimension)
+	}
+
+	topK := request.TopK
+	if topK <= 0 {
+		topK = 10
+	}
+
+	filter, err := request.TypedFilter()
+	if err != nil {
+		return nil, err
+	}
+	var accept func(*hnswNode) bool
+	if filter != nil {
+		accept = func(node *hnswNode) bool {
+			return filter.Match(node.metadata)
+		}
+	}
+
+	items := c.index.search(c.prepare(request.Vector), topK, c.index.params.EfSearch, accept)
+
+	withValues := contains(request.Include, "values")
+	withMetadata := contains(request.Include, "metadata")
+	matches := make([]Match, len(items))
+	for i, item := range items {
+		node := c.index.nodes[item.id]
+		matches[i] = Match{ID: node.id, Score: c.toScore(item.dist)}
+		if withValues {
+			matches[i].Values = append([]float32(nil), node.values...)
+		}
+		if withMetadata {
+			matches[i].Metadata = copyMetadata(node.metadata)
+		}
+	}
+
+	return &SearchResult{
+		Matches: matches,
+		Total:   int64(len(matches)),
+	}, nil
+}
+
+func asList(value interface{}) ([]interface{}, bool) {
+	if list, ok := value.([]interface{}); ok {
+		return list, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// metadataEqual compares metadata values, treating all numeric types alike
+// since snapshots decode numbers as float64
+func metadataEqual(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toInt(value interface{}) (int, bool) {
+	f, ok := toFloat(value)
+	if !ok || f != float64(int(f)) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// intParameter reads an integer parameter, returning zero when it is absent
+func intParameter(parameters map[string]interface{}, key string) int {
+	value, _ := toInt(parameters[key])
+	return value
+}
+
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	return copied
+}
+
+func validateCollectionName(name string) error {
+	if name == "" {
+		return fmt.Errorf("collection name is required")
+	}
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid collection name: %s", name)
+	}
+	return nil
+}
+
+// newProviderLogger creates the logger of a provider instance, honouring
+// the log_level metadata key
+func newProviderLogger(config *VectorDBConfig) *logrus.Logger {
+	logger := logrus.New()
+	if config.Metadata != nil {
+		if logLevel, exists := config.Metadata["log_level"]; exists {
+			if level, ok := logLevel.(string); ok {
+				if parsedLevel, err := logrus.ParseLevel(level); err == nil {
+					logger.SetLevel(parsedLevel)
+				}
+			}
+		}
+	}
+	return logger
+}
+
+// Snapshots
+
+// collectionSnapshot is the on-disk form of a collection, graph included,
+// so loading does not rebuild the index. Metadata is stored as JSON since
+// gob cannot encode arbitrary interface values.
+type collectionSnapshot struct {
+	Config    []byte
+	Params    HNSWParams
+	Graph     bool
+	Entry     int32
+	MaxLevel  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Nodes     []nodeSnapshot
+}
+
+type nodeSnapshot struct {
+	ID        string
+	Values    []float32
+	Metadata  []byte
+	Neighbors [][]int32
+	Deleted   bool
+}
+
+func writeSnapshot(path string, collection *embeddedCollection) error {
+	config, err := json.Marshal(collection.config)
+	if err != nil {
+		return fmt.Errorf("failed to encode collection config: %w", err)
+	}
+
+	index := collection.index
+	snapshot := collectionSnapshot{
+		Config:    config,
+		Params:    index.params,
+		Graph:     index.graph,
+		Entry:     index.entry,
+		MaxLevel:  index.maxLevel,
+		CreatedAt: collection.createdAt,
+		UpdatedAt: collection.updatedAt,
+		Nodes:     make([]nodeSnapshot, len(index.nodes)),
+	}
+	for i, node := range index.nodes {
+		metadata, err := json.Marshal(node.metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata of %s: %w", node.id, err)
+		}
+		snapshot.Nodes[i] = nodeSnapshot{
+			ID:        node.id,
+			Values:    node.values,
+			Metadata:  metadata,
+			Neighbors: node.neighbors,
+			Deleted:   node.deleted,
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(&snapshot); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func readSnapshot(path string) (*embeddedCollection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var snapshot collectionSnapshot
+	if err := gob.NewDecoder(file).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	var config CollectionConfig
+	if err := json.Unmarshal(snapshot.Config, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode collection config: %w", err)
+	}
+	distance, toScore, err := metricDistance(config.Metric)
+	if err != nil {
+		return nil, err
+	}
+
+	index := newHNSWIndex(snapshot.Params, snapshot.Graph, distance)
+	index.entry = snapshot.Entry
+	index.maxLevel = snapshot.MaxLevel
+	index.nodes = make([]*hnswNode, len(snapshot.Nodes))
+	for i, stored := range snapshot.Nodes {
+		var metadata map[string]interface{}
+		if err := json.Unmarshal(stored.Metadata, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata of %s: %w", stored.ID, err)
+		}
+		index.nodes[i] = &hnswNode{
+			id:        stored.ID,
+			values:    stored.Values,
+			metadata:  metadata,
+			neighbors: stored.Neighbors,
+			deleted:   stored.Deleted,
+		}
+		if stored.Deleted {
+			index.deleted++
+		} else {
+			index.ids[stored.ID] = int32(i)
+		}
+	}
+
+	return &embeddedCollection{
+		config:    config,
+		index:     index,
+		toScore:   toScore,
+		createdAt: snapshot.CreatedAt,
+		updatedAt: snapshot.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,171 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEmbeddedDB(t *testing.T, directory string) VectorDB {
+	manager := NewVectorDBManager(logrus.New())
+	db, err := manager.CreateVectorDB(&VectorDBConfig{Provider: "embedded", Database: directory})
+	require.NoError(t, err)
+	require.NoError(t, db.Connect(context.Background()))
+	return db
+}
+
+func randomVectors(rng *rand.Rand, count, dimension int) []*Vector {
+	vectors := make([]*Vector, count)
+	for i := range vectors {
+		values := make([]float32, dimension)
+		for j := range values {
+			values[j] = rng.Float32()*2 - 1
+		}
+		vectors[i] = &Vector{
+			ID:       fmt.Sprintf("v%d", i),
+			Values:   values,
+			Metadata: map[string]interface{}{"shard": i % 4, "tags": []string{"all", fmt.Sprintf("t%d", i%10)}},
+		}
+	}
+	return vectors
+}
+
+func TestEmbeddedDBRecall(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(7))
+
+	for _, metric := range []string{"cosine", "dot_product", "euclidean"} {
+		t.Run(metric, func(t *testing.T) {
+			db := newEmbeddedDB(t, "")
+			require.NoError(t, db.CreateCollection(ctx, &CollectionConfig{Name: "docs", Dimension: 16, Metric: metric}))
+
+			vectors := randomVectors(rng, 1000, 16)
+			require.NoError(t, db.Insert(ctx, "docs", vectors))
+
+			// Compare against exact search on a flat index
+			exact := newEmbeddedDB(t, "")
+			require.NoError(t, exact.CreateCollection(ctx, &CollectionConfig{Name: "docs", Dimension: 16, Metric: metric}))
+			require.NoError(t, exact.CreateIndex(ctx, "docs", &IndexConfig{Type: "flat"}))
+			require.NoError(t, exact.Insert(ctx, "docs", vectors))
+
+			found, total := 0, 0
+			for _, query := range randomVectors(rng, 20, 16) {
+				request := &SearchRequest{Collection: "docs", Vector: query.Values, TopK: 10}
+				approximate, err := db.Search(ctx, request)
+				require.NoError(t, err)
+				expected, err := exact.Search(ctx, request)
+				require.NoError(t, err)
+				require.Len(t, expected.Matches, 10)
+
+				ids := make(map[string]bool)
+				for _, match := range approximate.Matches {
+					ids[match.ID] = true
+				}
+				for _, match := range expected.Matches {
+					if ids[match.ID] {
+						found++
+					}
+					total++
+				}
+
+				// Scores follow Qdrant: similarities descend, euclidean distances ascend
+				scores := make([]float64, len(expected.Matches))
+				for i, match := range expected.Matches {
+					scores[i] = float64(match.Score)
+				}
+				if metric == "euclidean" {
+					assert.True(t, sort.Float64sAreSorted(scores))
+				} else {
+					assert.True(t, sort.SliceIsSorted(scores, func(i, j int) bool { return scores[i] > scores[j] }))
+				}
+			}
+			assert.GreaterOrEqual(t, float64(found)/float64(total), 0.9)
+		})
+	}
+}
+
+func TestEmbeddedDBOperations(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(11))
+	dir := t.TempDir()
+
+	db := newEmbeddedDB(t, dir)
+	require.NoError(t, db.CreateCollection(ctx, &CollectionConfig{Name: "docs", Dimension: 8}))
+	vectors := randomVectors(rng, 300, 8)
+	require.NoError(t, db.Insert(ctx, "docs", vectors))
+
+	err := db.Insert(ctx, "docs", []*Vector{{ID: "short", Values: []float32{1}}})
+	assert.Error(t, err)
+
+	// Filters apply to scalars, lists of options and list fields
+	result, err := db.Search(ctx, &SearchRequest{
+		Collection: "docs",
+		Vector:     vectors[0].Values,
+		TopK:       50,
+		Filter:     map[string]interface{}{"shard": []interface{}{1, 2}, "tags": "t5"},
+		Include:    []string{"metadata"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.Matches, 15)
+	for _, match := range result.Matches {
+		assert.Contains(t, []interface{}{1, 2}, match.Metadata["shard"])
+	}
+
+	// Upserts replace and deletes remove, including past the rebuild threshold
+	require.NoError(t, db.Update(ctx, "docs", []*Vector{{ID: "v0", Values: vectors[1].Values, Metadata: map[string]interface{}{"shard": 9}}}))
+	ids := make([]string, 0, 200)
+	for i := 100; i < 300; i++ {
+		ids = append(ids, fmt.Sprintf("v%d", i))
+	}
+	require.NoError(t, db.Delete(ctx, "docs", ids))
+	count, err := db.Count(ctx, "docs")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), count)
+
+	result, err = db.Search(ctx, &SearchRequest{Collection: "docs", Vector: vectors[200].Values, TopK: 100})
+	require.NoError(t, err)
+	assert.Len(t, result.Matches, 100)
+
+	// Snapshots survive a restart, graph and metadata included
+	require.NoError(t, db.Disconnect(ctx))
+	db = newEmbeddedDB(t, dir)
+
+	vector, err := db.GetVector(ctx, "docs", "v0")
+	require.NoError(t, err)
+	assert.Equal(t, float64(9), vector.Metadata["shard"])
+	_, err = db.GetVector(ctx, "docs", "v150")
+	assert.Error(t, err)
+
+	result, err = db.Search(ctx, &SearchRequest{Collection: "docs", Vector: vectors[1].Values, TopK: 2, Filter: map[string]interface{}{"shard": 9}})
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, "v0", result.Matches[0].ID)
+	assert.InDelta(t, 1.0, result.Matches[0].Score, 1e-5)
+
+	scanned := make(map[string]interface{})
+	require.NoError(t, db.(VectorScanner).ScanVectors(ctx, "docs", func(vector *Vector) error {
+		assert.Empty(t, vector.Values)
+		scanned[vector.ID] = vector.Metadata["shard"]
+		return nil
+	}))
+	assert.Len(t, scanned, 100)
+	assert.Equal(t, float64(9), scanned["v0"])
+
+	info, err := db.GetCollectionInfo(ctx, "docs")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), info.VectorCount)
+	assert.Equal(t, "cosine", info.Metric)
+
+	require.NoError(t, db.DeleteCollection(ctx, "docs"))
+	require.NoError(t, db.Disconnect(ctx))
+	db = newEmbeddedDB(t, dir)
+	collections, err := db.ListCollections(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, collections)
+}
@@ -416,6 +416,7 @@ func NewEmbeddingManager(logger *logrus.Logger) *EmbeddingManager {
 	// Register default providers
 	manager.RegisterFactory("openai", NewOpenAIEmbeddingFactory())
 	manager.RegisterFactory("ollama", NewOllamaEmbeddingFactory())
+	manager.RegisterFactory("local", NewLocalEmbeddingFactory())
 
 	return manager
 }
@@ -0,0 +1,619 @@
+package vectordb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FilterOp is the operator of a filter node
+type FilterOp string
+
+// Filter operators
+const (
+	FilterEq     FilterOp = "eq"
+	FilterNe     FilterOp = "ne"
+	FilterIn     FilterOp = "in"
+	FilterNin    FilterOp = "nin"
+	FilterRange  FilterOp = "range"
+	FilterExists FilterOp = "exists"
+	FilterMatch  FilterOp = "match"
+	FilterAnd    FilterOp = "and"
+	FilterOr     FilterOp = "or"
+	FilterNot    FilterOp = "not"
+)
+
+// Filter is a typed metadata filter with the same semantics on every
+// backend. Field conditions read metadata fields, with dots reaching into
+// nested objects ("source.kind"). On list fields, eq, in, range and match
+// hold when any element satisfies them, as in Qdrant. Conditions on a
+// missing field are false, so ne and nin hold for it.
+//
+//	eq, ne    field equals (or not) Value; numbers compare by value
+//	in, nin   field equals one (or none) of Values
+//	range     field lies within Range; numbers or RFC 3339 timestamps
+//	exists    field is present, not null and not an empty list
+//	match     every word of the text in Value appears as a word of the
+//	          field, ignoring case (Qdrant needs a full-text index)
+//	and, or   all or any of Filters hold
+//	not       the single filter in Filters does not hold
+type Filter struct {
+	Op      FilterOp      `json:"op"`
+	Field   string        `json:"field,omitempty"`
+	Value   interface{}   `json:"value,omitempty"`
+	Values  []interface{} `json:"values,omitempty"`
+	Range   *RangeBounds  `json:"range,omitempty"`
+	Filters []*Filter     `json:"filters,omitempty"`
+}
+
+// RangeBounds bounds a range condition; nil bounds are open
+type RangeBounds struct {
+	Gt  interface{} `json:"gt,omitempty"`
+	Gte interface{} `json:"gte,omitempty"`
+	Lt  interface{} `json:"lt,omitempty"`
+	Lte interface{} `json:"lte,omitempty"`
+}
+
+// Builder API
+
+// FieldFilter builds conditions on one metadata field
+type FieldFilter struct {
+	name string
+}
+
+// Field starts a condition on a metadata field:
+//
+//	vectordb.And(vectordb.Field("lang").Eq("go"), vectordb.Field("stars").Gte(10))
+func Field(name string) FieldFilter {
+	return FieldFilter{name: name}
+}
+
+// Eq matches fields equal to value
+func (f FieldFilter) Eq(value interface{}) *Filter {
+	return &Filter{Op: FilterEq, Field: f.name, Value: value}
+}
+
+// Ne matches fields not equal to value
+func (f FieldFilter) Ne(value interface{}) *Filter {
+	return &Filter{Op: FilterNe, Field: f.name, Value: value}
+}
+
+// In matches fields equal to any of values
+func (f FieldFilter) In(values ...interface{}) *Filter {
+	return &Filter{Op: FilterIn, Field: f.name, Values: values}
+}
+
+// NotIn matches fields equal to none of values
+func (f FieldFilter) NotIn(values ...interface{}) *Filter {
+	return &Filter{Op: FilterNin, Field: f.name, Values: values}
+}
+
+// Gt matches fields greater than value
+func (f FieldFilter) Gt(value interface{}) *Filter {
+	return &Filter{Op: FilterRange, Field: f.name, Range: &RangeBounds{Gt: value}}
+}
+
+// Gte matches fields greater than or equal to value
+func (f FieldFilter) Gte(value interface{}) *Filter {
+	return &Filter{Op: FilterRange, Field: f.name, Range: &RangeBounds{Gte: value}}
+}
+
+// Lt matches fields less than value
+func (f FieldFilter) Lt(value interface{}) *Filter {
+	return &Filter{Op: FilterRange, Field: f.name, Range: &RangeBounds{Lt: value}}
+}
+
+// Lte matches fields less than or equal to value
+func (f FieldFilter) Lte(value interface{}) *Filter {
+	return &Filter{Op: FilterRange, Field: f.name, Range: &RangeBounds{Lte: value}}
+}
+
+// Between matches fields within [low, high]
+func (f FieldFilter) Between(low, high interface{}) *Filter {
+	return &Filter{Op: FilterRange, Field: f.name, Range: &RangeBounds{Gte: low, Lte: high}}
+}
+
+// Exists matches fields that are present, not null and not empty lists
+func (f FieldFilter) Exists() *Filter {
+	return &Filter{Op: FilterExists, Field: f.name}
+}
+
+// Match matches text fields containing every word of text
+func (f FieldFilter) Match(text string) *Filter {
+	return &Filter{Op: FilterMatch, Field: f.name, Value: text}
+}
+
+// And matches when all filters match
+func And(filters ...*Filter) *Filter {
+	return &Filter{Op: FilterAnd, Filters: filters}
+}
+
+// Or matches when any filter matches
+func Or(filters ...*Filter) *Filter {
+	return &Filter{Op: FilterOr, Filters: filters}
+}
+
+// Not matches when filter does not match
+func Not(filter *Filter) *Filter {
+	return &Filter{Op: FilterNot, Filters: []*Filter{filter}}
+}
+
+// Validation
+
+// Validate checks that the filter is well formed
+func (f *Filter) Validate() error {
+	return f.validate(0)
+}
+
+func (f *Filter) validate(depth int) error {
+	if f == nil {
+		return fmt.Errorf("filter cannot be nil")
+	}
+	if depth > maxFilterDepth {
+		return fmt.Errorf("filter nested deeper than %d levels", maxFilterDepth)
+	}
+
+	switch f.Op {
+	case FilterAnd, FilterOr:
+		if len(f.Filters) == 0 {
+			return fmt.Errorf("%s filter needs at least one operand", f.Op)
+		}
+	case FilterNot:
+		if len(f.Filters) != 1 {
+			return fmt.Errorf("not filter needs exactly one operand")
+		}
+	case FilterEq, FilterNe, FilterIn, FilterNin, FilterRange, FilterExists, FilterMatch:
+		if f.Field == "" {
+			return fmt.Errorf("%s filter needs a field", f.Op)
+		}
+		if strings.HasPrefix(f.Field, ".") || strings.HasSuffix(f.Field, ".") || strings.Contains(f.Field, "..") {
+			return fmt.Errorf("invalid field: %q", f.Field)
+		}
+	default:
+		return fmt.Errorf("unknown filter operator: %q", f.Op)
+	}
+
+	switch f.Op {
+	case FilterEq, FilterNe:
+		if !isScalar(f.Value) {
+			return fmt.Errorf("%s filter on %s needs a string, number or boolean value", f.Op, f.Field)
+		}
+	case FilterIn, FilterNin:
+		for _, value := range f.Values {
+			if !isScalar(value) {
+				return fmt.Errorf("%s filter on %s needs string, number or boolean values", f.Op, f.Field)
+			}
+		}
+	case FilterRange:
+		if _, err := f.Range.kind(); err != nil {
+			return fmt.Errorf("range filter on %s: %w", f.Field, err)
+		}
+	case FilterMatch:
+		text, ok := f.Value.(string)
+		if !ok || len(textTokens(text)) == 0 {
+			return fmt.Errorf("match filter on %s needs text with at least one word", f.Field)
+		}
+	case FilterAnd, FilterOr, FilterNot:
+		for _, operand := range f.Filters {
+			if err := operand.validate(depth + 1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// maxFilterDepth bounds the nesting of a filter
+const maxFilterDepth = 32
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case string, bool:
+		return true
+	}
+	_, ok := toFloat(value)
+	return ok
+}
+
+// rangeKind is the kind of values a range compares
+type rangeKind int
+
+const (
+	rangeNumber rangeKind = iota
+	rangeTime
+)
+
+// bounds returns the set bounds with their operators
+func (r *RangeBounds) bounds() []rangeBound {
+	var bounds []rangeBound
+	for _, bound := range []rangeBound{{"gt", r.Gt}, {"gte", r.Gte}, {"lt", r.Lt}, {"lte", r.Lte}} {
+		if bound.value != nil {
+			bounds = append(bounds, bound)
+		}
+	}
+	return bounds
+}
+
+type rangeBound struct {
+	op    string
+	value interface{}
+}
+
+// kind checks that the range has bounds of a single kind
+func (r *RangeBounds) kind() (rangeKind, error) {
+	if r == nil || len(r.bounds()) == 0 {
+		return 0, fmt.Errorf("at least one bound is required")
+	}
+
+	kinds := make(map[rangeKind]bool)
+	for _, bound := range r.bounds() {
+		if _, ok := toFloat(bound.value); ok {
+			kinds[rangeNumber] = true
+		} else if _, ok := toTime(bound.value); ok {
+			kinds[rangeTime] = true
+		} else {
+			return 0, fmt.Errorf("bound %v is neither a number nor an RFC 3339 timestamp", bound.value)
+		}
+	}
+	if len(kinds) > 1 {
+		return 0, fmt.Errorf("bounds mix numbers and timestamps")
+	}
+	if kinds[rangeTime] {
+		return rangeTime, nil
+	}
+	return rangeNumber, nil
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// In-memory evaluation
+
+// Match reports whether metadata satisfies the filter. It is the reference
+// implementation the backend translations follow.
+func (f *Filter) Match(metadata map[string]interface{}) bool {
+	switch f.Op {
+	case FilterAnd:
+		for _, operand := range f.Filters {
+			if !operand.Match(metadata) {
+				return false
+			}
+		}
+		return true
+	case FilterOr:
+		for _, operand := range f.Filters {
+			if operand.Match(metadata) {
+				return true
+			}
+		}
+		return false
+	case FilterNot:
+		return !f.Filters[0].Match(metadata)
+	case FilterNe:
+		return !Field(f.Field).Eq(f.Value).Match(metadata)
+	case FilterNin:
+		return !Field(f.Field).In(f.Values...).Match(metadata)
+	}
+
+	actual, exists := lookupField(metadata, f.Field)
+	if !exists || actual == nil {
+		return false
+	}
+	elements := []interface{}{actual}
+	if list, ok := asList(actual); ok {
+		if f.Op == FilterExists {
+			return len(list) > 0
+		}
+		elements = list
+	}
+
+	switch f.Op {
+	case FilterExists:
+		return true
+	case FilterEq:
+		return anyElement(elements, func(element interface{}) bool { return metadataEqual(element, f.Value) })
+	case FilterIn:
+		return anyElement(elements, func(element interface{}) bool {
+			for _, value := range f.Values {
+				if metadataEqual(element, value) {
+					return true
+				}
+			}
+			return false
+		})
+	case FilterRange:
+		kind, err := f.Range.kind()
+		if err != nil {
+			return false
+		}
+		return anyElement(elements, func(element interface{}) bool { return f.Range.contains(kind, element) })
+	case FilterMatch:
+		query, _ := f.Value.(string)
+		return anyElement(elements, func(element interface{}) bool {
+			text, ok := element.(string)
+			if !ok {
+				return false
+			}
+			words := make(map[string]bool)
+			for _, word := range textTokens(text) {
+				words[word] = true
+			}
+			for _, word := range textTokens(query) {
+				if !words[word] {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	return false
+}
+
+func anyElement(elements []interface{}, predicate func(interface{}) bool) bool {
+	for _, element := range elements {
+		if predicate(element) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RangeBounds) contains(kind rangeKind, value interface{}) bool {
+	var compare func(bound interface{}) (int, bool)
+	switch kind {
+	case rangeNumber:
+		x, ok := toFloat(value)
+		if !ok {
+			return false
+		}
+		compare = func(bound interface{}) (int, bool) {
+			y, _ := toFloat(bound)
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	case rangeTime:
+		x, ok := toTime(value)
+		if !ok {
+			return false
+		}
+		compare = func(bound interface{}) (int, bool) {
+			y, _ := toTime(bound)
+			return x.Compare(y), true
+		}
+	}
+
+	for _, bound := range r.bounds() {
+		c, _ := compare(bound.value)
+		switch bound.op {
+		case "gt":
+			if c <= 0 {
+				return false
+			}
+		case "gte":
+			if c < 0 {
+				return false
+			}
+		case "lt":
+			if c >= 0 {
+				return false
+			}
+		case "lte":
+			if c > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lookupField resolves a dotted field path in metadata
+func lookupField(metadata map[string]interface{}, field string) (interface{}, bool) {
+	var current interface{} = metadata
+	for _, part := range strings.Split(field, ".") {
+		fields, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = fields[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// textTokens splits text into lowercase words of letters, digits and
+// underscores, the word definition of PostgreSQL regular expressions
+func textTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// Rendering
+
+// String renders the filter in the syntax accepted by ParseFilter
+func (f *Filter) String() string {
+	switch f.Op {
+	case FilterAnd, FilterOr:
+		parts := make([]string, len(f.Filters))
+		for i, operand := range f.Filters {
+			parts[i] = operand.operandString()
+		}
+		return strings.Join(parts, " "+strings.ToUpper(string(f.Op))+" ")
+	case FilterNot:
+		return "NOT " + f.Filters[0].operandString()
+	case FilterEq:
+		return formatField(f.Field) + " = " + formatFilterValue(f.Value)
+	case FilterNe:
+		return formatField(f.Field) + " != " + formatFilterValue(f.Value)
+	case FilterIn, FilterNin:
+		values := make([]string, len(f.Values))
+		for i, value := range f.Values {
+			values[i] = formatFilterValue(value)
+		}
+		op := " IN ("
+		if f.Op == FilterNin {
+			op = " NOT IN ("
+		}
+		return formatField(f.Field) + op + strings.Join(values, ", ") + ")"
+	case FilterRange:
+		if f.Range == nil {
+			return formatField(f.Field) + " <invalid range>"
+		}
+		if r := f.Range; r.Gte != nil && r.Lte != nil && r.Gt == nil && r.Lt == nil {
+			return formatField(f.Field) + " BETWEEN " + formatFilterValue(r.Gte) + " AND " + formatFilterValue(r.Lte)
+		}
+		symbols := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+		var parts []string
+		for _, bound := range f.Range.bounds() {
+			parts = append(parts, formatField(f.Field)+" "+symbols[bound.op]+" "+formatFilterValue(bound.value))
+		}
+		return strings.Join(parts, " AND ")
+	case FilterExists:
+		return formatField(f.Field) + " EXISTS"
+	case FilterMatch:
+		return formatField(f.Field) + " MATCH " + formatFilterValue(f.Value)
+	}
+	return fmt.Sprintf("<unknown filter %q>", f.Op)
+}
+
+// operandString renders an operand of and, or and not, adding parentheses
+// where precedence requires them
+func (f *Filter) operandString() string {
+	switch {
+	case f.Op == FilterAnd || f.Op == FilterOr:
+		return "(" + f.String() + ")"
+	case f.Op == FilterRange && f.Range != nil && len(f.Range.bounds()) > 1 && !strings.Contains(f.String(), " BETWEEN "):
+		return "(" + f.String() + ")"
+	}
+	return f.String()
+}
+
+func formatField(field string) string {
+	for i, r := range field {
+		if !(unicode.IsLetter(r) || r == '_' || (i > 0 && (unicode.IsDigit(r) || r == '.'))) || isFilterKeyword(field) {
+			return "`" + strings.ReplaceAll(field, "`", "``") + "`"
+		}
+	}
+	return field
+}
+
+func formatFilterValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return strconv.Quote(v.Format(time.RFC3339Nano))
+	}
+	if number, ok := toFloat(value); ok {
+		return strconv.FormatFloat(number, 'g', -1, 64)
+	}
+	return strconv.Quote(fmt.Sprint(value))
+}
+
+// Legacy map filters
+
+// FilterFromMap converts a map filter to a typed filter. Each key is a
+// field: a list value matches any of its elements, a map of gt, gte, lt
+// and lte (optionally prefixed with $) is a range, and any other value must
+// be equal. Keys are combined with and.
+func FilterFromMap(filter map[string]interface{}) (*Filter, error) {
+	if len(filter) == 0 {
+		return nil, nil
+	}
+	if isQdrantFilter(filter) {
+		return nil, fmt.Errorf("native Qdrant filters are only supported by the qdrant provider")
+	}
+
+	fields := make([]string, 0, len(filter))
+	for field := range filter {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	conditions := make([]*Filter, 0, len(fields))
+	for _, field := range fields {
+		value := filter[field]
+		if list, ok := asList(value); ok {
+			conditions = append(conditions, Field(field).In(list...))
+			continue
+		}
+		if bounds, ok := value.(map[string]interface{}); ok {
+			r := &RangeBounds{}
+			for key, bound := range bounds {
+				switch strings.TrimPrefix(key, "$") {
+				case "gt":
+					r.Gt = bound
+				case "gte":
+					r.Gte = bound
+				case "lt":
+					r.Lt = bound
+				case "lte":
+					r.Lte = bound
+				default:
+					return nil, fmt.Errorf("unsupported operator %q for field %s", key, field)
+				}
+			}
+			conditions = append(conditions, &Filter{Op: FilterRange, Field: field, Range: r})
+			continue
+		}
+		conditions = append(conditions, Field(field).Eq(value))
+	}
+
+	result := conditions[0]
+	if len(conditions) > 1 {
+		result = And(conditions...)
+	}
+	if err := result.Validate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// isQdrantFilter reports whether a map filter uses Qdrant's native syntax
+func isQdrantFilter(filter map[string]interface{}) bool {
+	for _, key := range []string{"must", "should", "must_not", "min_should"} {
+		if _, exists := filter[key]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// TypedFilter returns the filter of a search request: Where when set,
+// otherwise Filter converted with FilterFromMap. It returns nil without a
+// filter.
+func (r *SearchRequest) TypedFilter() (*Filter, error) {
+	if r.Where != nil {
+		if err := r.Where.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+		return r.Where, nil
+	}
+	filter, err := FilterFromMap(r.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	return filter, nil
+}
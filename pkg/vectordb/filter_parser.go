@@ -0,0 +1,443 @@
+package vectordb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter syntax accepted by ParseFilter, for API callers:
+//
+//	lang = "go" AND stars >= 10
+//	(kind IN ("wiki", "runbook") OR pinned = true) AND NOT archived EXISTS
+//	source.updated BETWEEN "2024-01-01T00:00:00Z" AND "2025-01-01T00:00:00Z"
+//	title MATCH "connection pool" AND owner NOT IN ("bot")
+//
+// Fields are identifiers with dots selecting nested fields; fields that
+// are keywords or contain other characters are quoted with backticks.
+// Values are strings in single or double quotes, numbers and true/false.
+// Comparisons are = (or ==), !=, <, <=, >, >=, IN, NOT IN, BETWEEN,
+// EXISTS, NOT EXISTS and MATCH. Conditions combine with NOT, AND and OR
+// (or !, && and ||), in decreasing precedence, and parentheses. Keywords
+// are case-insensitive.
+
+const (
+	maxFilterLength = 4096
+)
+
+var filterKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true, "exists": true,
+	"between": true, "match": true, "true": true, "false": true,
+}
+
+func isFilterKeyword(text string) bool {
+	return filterKeywords[strings.ToLower(text)]
+}
+
+// ParseFilter parses a filter expression
+func ParseFilter(source string) (*Filter, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("filter is empty")
+	}
+	if len(source) > maxFilterLength {
+		return nil, fmt.Errorf("filter exceeds %d characters", maxFilterLength)
+	}
+
+	tokens, err := tokenizeFilter(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+
+	parser := &filterParser{tokens: tokens}
+	filter, err := parser.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	if token := parser.peek(); token.kind != filterTokenEOF {
+		return nil, fmt.Errorf("invalid filter: unexpected %s at position %d", token.text, token.pos)
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	return filter, nil
+}
+
+// Lexer
+
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	filterTokenIdent
+	filterTokenField
+	filterTokenString
+	filterTokenNumber
+	filterTokenOperator
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+var filterTwoCharOperators = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+func tokenizeFilter(source string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || ((r == '-' || r == '.') && i+1 < len(runes) && (unicode.IsDigit(runes[i+1]) || runes[i+1] == '.')):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			text := string(runes[start:i])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, start)
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenNumber, text: text, value: value, pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			// Escapes follow Go string literals, so String output parses back
+			for ; i < len(runes) && runes[i] != r; i++ {
+				switch {
+				case runes[i] == '\\' && i+1 < len(runes):
+					i++
+					if runes[i] != '\'' {
+						sb.WriteRune('\\')
+					}
+				case runes[i] == '"':
+					sb.WriteRune('\\')
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			value, err := strconv.Unquote(`"` + sb.String() + `"`)
+			if err != nil {
+				return nil, fmt.Errorf("invalid escape in string at position %d", start)
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenString, text: string(runes[start:i]), value: value, pos: start})
+
+		case r == '`':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes); i++ {
+				if runes[i] == '`' {
+					if i+1 < len(runes) && runes[i+1] == '`' {
+						sb.WriteRune('`')
+						i++
+						continue
+					}
+					break
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated field at position %d", start)
+			}
+			i++
+			tokens = append(tokens, filterToken{kind: filterTokenField, text: string(runes[start:i]), value: sb.String(), pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if strings.HasSuffix(text, ".") || strings.Contains(text, "..") {
+				return nil, fmt.Errorf("invalid field %q at position %d", text, start)
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenIdent, text: text, value: text, pos: start})
+
+		default:
+			matched := false
+			if i+1 < len(runes) {
+				pair := string(runes[i : i+2])
+				for _, op := range filterTwoCharOperators {
+					if pair == op {
+						tokens = append(tokens, filterToken{kind: filterTokenOperator, text: op, pos: i})
+						i += 2
+						matched = true
+						break
+					}
+				}
+			}
+			if matched {
+				continue
+			}
+			if strings.ContainsRune("<>=!(),", r) {
+				tokens = append(tokens, filterToken{kind: filterTokenOperator, text: string(r), pos: i})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+
+	return append(tokens, filterToken{kind: filterTokenEOF, text: "end of filter", pos: len(runes)}), nil
+}
+
+// Parser
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	depth  int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	token := p.tokens[p.pos]
+	if token.kind != filterTokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+// accept consumes the next token if it is one of the given operators or
+// keywords
+func (p *filterParser) accept(texts ...string) (string, bool) {
+	token := p.peek()
+	if token.kind != filterTokenOperator && token.kind != filterTokenIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if strings.EqualFold(token.text, text) {
+			p.next()
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *filterParser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		token := p.peek()
+		return fmt.Errorf("expected %s but found %s at position %d", text, token.text, token.pos)
+	}
+	return nil
+}
+
+func (p *filterParser) enter() error {
+	p.depth++
+	if p.depth > maxFilterDepth {
+		return fmt.Errorf("filter nested deeper than %d levels", maxFilterDepth)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (*Filter, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Filter{left}
+	for {
+		if _, ok := p.accept("OR", "||"); !ok {
+			break
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return Or(operands...), nil
+}
+
+func (p *filterParser) parseAnd() (*Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Filter{left}
+	for {
+		if _, ok := p.accept("AND", "&&"); !ok {
+			break
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return And(operands...), nil
+}
+
+func (p *filterParser) parseUnary() (*Filter, error) {
+	if _, ok := p.accept("NOT", "!"); ok {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(operand), nil
+	}
+
+	if _, ok := p.accept("("); ok {
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return filter, p.expect(")")
+	}
+	return p.parseCondition()
+}
+
+func (p *filterParser) parseCondition() (*Filter, error) {
+	token := p.next()
+	if token.kind != filterTokenField && (token.kind != filterTokenIdent || isFilterKeyword(token.text)) {
+		return nil, fmt.Errorf("expected a field but found %s at position %d", token.text, token.pos)
+	}
+	field := Field(token.value.(string))
+
+	if op, ok := p.accept("=", "==", "!=", "<", "<=", ">", ">="); ok {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "=", "==":
+			return field.Eq(value), nil
+		case "!=":
+			return field.Ne(value), nil
+		case "<":
+			return field.Lt(value), nil
+		case "<=":
+			return field.Lte(value), nil
+		case ">":
+			return field.Gt(value), nil
+		}
+		return field.Gte(value), nil
+	}
+
+	negated := false
+	if _, ok := p.accept("NOT"); ok {
+		negated = true
+	}
+
+	if _, ok := p.accept("IN"); ok {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if negated {
+			return field.NotIn(values...), nil
+		}
+		return field.In(values...), nil
+	}
+	if _, ok := p.accept("EXISTS"); ok {
+		if negated {
+			return Not(field.Exists()), nil
+		}
+		return field.Exists(), nil
+	}
+	if negated {
+		next := p.peek()
+		return nil, fmt.Errorf("expected IN or EXISTS but found %s at position %d", next.text, next.pos)
+	}
+
+	if _, ok := p.accept("BETWEEN"); ok {
+		low, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return field.Between(low, high), nil
+	}
+	if _, ok := p.accept("MATCH"); ok {
+		next := p.next()
+		if next.kind != filterTokenString {
+			return nil, fmt.Errorf("MATCH needs a string but found %s at position %d", next.text, next.pos)
+		}
+		return field.Match(next.value.(string)), nil
+	}
+
+	next := p.peek()
+	return nil, fmt.Errorf("expected a comparison after %s but found %s at position %d", token.text, next.text, next.pos)
+}
+
+func (p *filterParser) parseList() ([]interface{}, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if _, ok := p.accept(","); !ok {
+			break
+		}
+	}
+	return values, p.expect(")")
+}
+
+func (p *filterParser) parseValue() (interface{}, error) {
+	token := p.next()
+	switch token.kind {
+	case filterTokenString, filterTokenNumber:
+		return token.value, nil
+	case filterTokenIdent:
+		switch strings.ToLower(token.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a value but found %s at position %d", token.text, token.pos)
+}
@@ -0,0 +1,203 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	metadata := map[string]interface{}{
+		"lang":    "go",
+		"stars":   42,
+		"tags":    []interface{}{"db", "cli"},
+		"title":   "Connection pooling for Postgres",
+		"source":  map[string]interface{}{"kind": "wiki", "updated": "2024-06-01T12:00:00Z"},
+		"deleted": nil,
+		"empty":   []string{},
+	}
+
+	t.Run("Parse", func(t *testing.T) {
+		filter, err := ParseFilter(`lang = "go" AND stars >= 10`)
+		require.NoError(t, err)
+		assert.Equal(t, And(Field("lang").Eq("go"), Field("stars").Gte(float64(10))), filter)
+
+		filter, err = ParseFilter(`a = 1 or b != 'x' and not c in (1, -2.5, true)`)
+		require.NoError(t, err)
+		assert.Equal(t, Or(
+			Field("a").Eq(float64(1)),
+			And(Field("b").Ne("x"), Not(Field("c").In(float64(1), -2.5, true))),
+		), filter)
+
+		filter, err = ParseFilter("`not.x` NOT EXISTS && y NOT IN ('a') || z BETWEEN 1 AND 2")
+		require.NoError(t, err)
+		assert.Equal(t, Or(
+			And(Not(Field("not.x").Exists()), Field("y").NotIn("a")),
+			Field("z").Between(float64(1), float64(2)),
+		), filter)
+
+		for _, source := range []string{
+			"",
+			"lang",
+			`lang = `,
+			`lang = "go`,
+			`(lang = "go"`,
+			`lang = "go" stars`,
+			`and = 1`,
+			`title MATCH 3`,
+			`title MATCH "!!"`,
+			`stars > "soon"`,
+			`x IN ()`,
+			`a.b. = 1`,
+		} {
+			_, err := ParseFilter(source)
+			assert.Error(t, err, source)
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		for _, filter := range []*Filter{
+			And(Field("lang").Eq("go"), Field("stars").Gte(10)),
+			Or(Field("a").Eq(true), And(Field("b").Ne("say \"hi\"\n"), Not(Or(Field("c").Lt(-1.5), Field("d").Exists())))),
+			And(Field("tags").NotIn("x", 2), Field("n").Between(1, 2), &Filter{Op: FilterRange, Field: "m", Range: &RangeBounds{Gt: 1, Lt: 5}}),
+			Field("in").Match("pool"),
+			Field("weird key").Eq("it's"),
+		} {
+			parsed, err := ParseFilter(filter.String())
+			require.NoError(t, err, filter.String())
+			assert.Equal(t, filter.String(), parsed.String())
+			assert.Equal(t, filter.Match(metadata), parsed.Match(metadata))
+		}
+	})
+
+	t.Run("Match", func(t *testing.T) {
+		cases := map[string]bool{
+			`lang = "go"`:             true,
+			`stars = 42.0`:            true,
+			`lang != "go"`:            false,
+			`missing != "go"`:         true,
+			`tags = "cli"`:            true,
+			`tags IN ("x", "db")`:     true,
+			`tags NOT IN ("x", "db")`: false,
+			`missing NOT IN ("x")`:    true,
+			`stars > 42`:              false,
+			`stars BETWEEN 40 AND 50`: true,
+			`lang > 1`:                false,
+			`source.kind = "wiki"`:    true,
+			`source.updated >= "2024-01-01T00:00:00Z"`: true,
+			`source.updated < "2024-06-01T00:00:00Z"`:  false,
+			`source EXISTS`:                     true,
+			`deleted EXISTS`:                    false,
+			`empty EXISTS`:                      false,
+			`missing EXISTS`:                    false,
+			`title MATCH "postgres CONNECTION"`: true,
+			`title MATCH "postgres pool"`:       false,
+			`lang = "rust" OR NOT stars < 10`:   true,
+			`(lang = "rust" OR tags = "db") AND source.kind IN ("wiki")`: true,
+		}
+		for source, expected := range cases {
+			filter, err := ParseFilter(source)
+			require.NoError(t, err, source)
+			assert.Equal(t, expected, filter.Match(metadata), source)
+		}
+	})
+
+	t.Run("FromMap", func(t *testing.T) {
+		filter, err := FilterFromMap(map[string]interface{}{
+			"lang":  "go",
+			"stars": map[string]interface{}{"$gte": 10, "lt": 100},
+			"tags":  []string{"db", "web"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `lang = "go" AND (stars >= 10 AND stars < 100) AND tags IN ("db", "web")`, filter.String())
+		assert.True(t, filter.Match(metadata))
+
+		_, err = FilterFromMap(map[string]interface{}{"stars": map[string]interface{}{"$regex": "x"}})
+		assert.Error(t, err)
+		_, err = FilterFromMap(map[string]interface{}{"must": []interface{}{}})
+		assert.Error(t, err)
+
+		filter, err = FilterFromMap(nil)
+		require.NoError(t, err)
+		assert.Nil(t, filter)
+	})
+
+	t.Run("Qdrant", func(t *testing.T) {
+		filter, err := ParseFilter(`lang = "go" AND stars >= 10 AND score = 0.5 AND tags IN ("db", "cli") ` +
+			`AND NOT owner IN (true) AND source EXISTS AND title MATCH "pool" AND kind != "wiki"`)
+		require.NoError(t, err)
+
+		encoded, err := json.Marshal(qdrantFilter(filter))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"must": [
+			{"key": "lang", "match": {"value": "go"}},
+			{"key": "stars", "range": {"gte": 10}},
+			{"key": "score", "range": {"gte": 0.5, "lte": 0.5}},
+			{"key": "tags", "match": {"any": ["db", "cli"]}},
+			{"must_not": [{"should": [{"key": "owner", "match": {"value": true}}]}]},
+			{"must_not": [{"is_empty": {"key": "source"}}]},
+			{"key": "title", "match": {"text": "pool"}},
+			{"must_not": [{"key": "kind", "match": {"value": "wiki"}}]}
+		]}`, string(encoded))
+
+		// Time ranges use datetime_range, which takes RFC 3339 bounds
+		updated, err := ParseFilter(`updated >= "2024-01-01T00:00:00Z" AND updated < "2024-06-01T00:00:00+02:00"`)
+		require.NoError(t, err)
+		encoded, err = json.Marshal(qdrantFilter(updated))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"must": [
+			{"key": "updated", "datetime_range": {"gte": "2024-01-01T00:00:00Z"}},
+			{"key": "updated", "datetime_range": {"lt": "2024-06-01T00:00:00+02:00"}}
+		]}`, string(encoded))
+
+		between, err := json.Marshal(qdrantCondition(Field("updated").Between(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "2024-02-01T00:00:00Z")))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"key": "updated", "datetime_range": {"gte": "2024-01-01T00:00:00Z", "lte": "2024-02-01T00:00:00Z"}}`, string(between))
+
+		// Native filters pass through, map filters are converted
+		native := map[string]interface{}{"must": []interface{}{map[string]interface{}{"key": "a"}}}
+		translated, err := qdrantSearchFilter(&SearchRequest{Filter: native})
+		require.NoError(t, err)
+		assert.Equal(t, native, translated)
+
+		translated, err = qdrantSearchFilter(&SearchRequest{Filter: map[string]interface{}{"shard": 3}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"must": []interface{}{
+			map[string]interface{}{"key": "shard", "match": map[string]interface{}{"value": int64(3)}},
+		}}, translated)
+	})
+
+	t.Run("EmbeddedSearch", func(t *testing.T) {
+		ctx := context.Background()
+		db := newEmbeddedDB(t, "")
+		require.NoError(t, db.CreateCollection(ctx, &CollectionConfig{Name: "docs", Dimension: 8}))
+		vectors := randomVectors(rand.New(rand.NewSource(3)), 200, 8)
+		require.NoError(t, db.Insert(ctx, "docs", vectors))
+
+		where, err := ParseFilter(`shard IN (1, 2) AND tags = "t5" OR shard = 0 AND NOT tags IN ("t0", "t2", "t4", "t6")`)
+		require.NoError(t, err)
+		result, err := db.Search(ctx, &SearchRequest{
+			Collection: "docs",
+			Vector:     vectors[0].Values,
+			TopK:       100,
+			Filter:     map[string]interface{}{"shard": 3},
+			Where:      where,
+			Include:    []string{"metadata"},
+		})
+		require.NoError(t, err)
+		// 10 vectors have tag t5 and shard 1; shard 0 holds the even
+		// tags, of which t8 leaves another 10
+		assert.Len(t, result.Matches, 20)
+		for _, match := range result.Matches {
+			assert.True(t, where.Match(match.Metadata))
+		}
+
+		_, err = db.Search(ctx, &SearchRequest{Collection: "docs", Vector: vectors[0].Values, Where: And()})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,412 @@
+package vectordb
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default HNSW parameters, matching Qdrant's defaults
+const (
+	DefaultHNSWM              = 16
+	DefaultHNSWEfConstruction = 100
+	DefaultHNSWEfSearch       = 64
+)
+
+// HNSWParams tunes an HNSW graph. M is the number of links per node (twice
+// that on the bottom layer), EfConstruction the candidate list size while
+// inserting and EfSearch the candidate list size while searching.
+type HNSWParams struct {
+	M              int `json:"m"`
+	EfConstruction int `json:"ef_construction"`
+	EfSearch       int `json:"ef_search"`
+}
+
+func (p HNSWParams) withDefaults() HNSWParams {
+	if p.M <= 1 {
+		p.M = DefaultHNSWM
+	}
+	if p.EfConstruction <= 0 {
+		p.EfConstruction = DefaultHNSWEfConstruction
+	}
+	if p.EfSearch <= 0 {
+		p.EfSearch = DefaultHNSWEfSearch
+	}
+	return p
+}
+
+// distanceFunc returns the distance between two vectors; lower is closer
+type distanceFunc func(a, b []float32) float32
+
+// metricDistance returns the distance function of a metric together with
+// the conversion from distance to the score reported in matches. Scores
+// follow Qdrant: cosine similarity and dot product grow with similarity,
+// euclidean reports the distance itself.
+func metricDistance(metric string) (distanceFunc, func(float32) float32, error) {
+	switch strings.ToLower(metric) {
+	case "", "cosine":
+		// Vectors are normalized on insert, so cosine distance is 1 - dot
+		return func(a, b []float32) float32 { return 1 - dot(a, b) },
+			func(d float32) float32 { return 1 - d }, nil
+	case "dot_product", "dot":
+		return func(a, b []float32) float32 { return -dot(a, b) },
+			func(d float32) float32 { return -d }, nil
+	case "euclidean", "euclid":
+		return func(a, b []float32) float32 {
+				var sum float32
+				for i := range a {
+					diff := a[i] - b[i]
+					sum += diff * diff
+				}
+				return float32(math.Sqrt(float64(sum)))
+			},
+			func(d float32) float32 { return d }, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported metric: %s", metric)
+	}
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func normalize(values []float32) []float32 {
+	var norm float32
+	for _, v := range values {
+		norm += v * v
+	}
+	normalized := make([]float32, len(values))
+	if norm == 0 {
+		return normalized
+	}
+	scale := float32(1 / math.Sqrt(float64(norm)))
+	for i, v := range values {
+		normalized[i] = v * scale
+	}
+	return normalized
+}
+
+// hnswNode is a stored vector and its links on every layer it belongs to
+type hnswNode struct {
+	id        string
+	values    []float32
+	metadata  map[string]interface{}
+	neighbors [][]int32
+	deleted   bool
+}
+
+// hnswIndex is a hierarchical navigable small world graph (Malkov and
+// Yashunin, 2016). Deletes only mark nodes, which keep routing searches
+// until the graph is rebuilt. Without graph the index is a flat list
+// searched exhaustively.
+type hnswIndex struct {
+	params    HNSWParams
+	graph     bool
+	distance  distanceFunc
+	nodes     []*hnswNode
+	ids       map[string]int32
+	entry     int32
+	maxLevel  int
+	deleted   int
+	levelMult float64
+	rng       *rand.Rand
+}
+
+func newHNSWIndex(params HNSWParams, graph bool, distance distanceFunc) *hnswIndex {
+	params = params.withDefaults()
+	return &hnswIndex{
+		params:    params,
+		graph:     graph,
+		distance:  distance,
+		ids:       make(map[string]int32),
+		entry:     -1,
+		levelMult: 1 / math.Log(float64(params.M)),
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Len returns the number of live vectors
+func (h *hnswIndex) Len() int {
+	return len(h.ids)
+}
+
+func (h *hnswIndex) get(id string) *hnswNode {
+	index, exists := h.ids[id]
+	if !exists {
+		return nil
+	}
+	return h.nodes[index]
+}
+
+// add inserts a vector, replacing any vector with the same ID
+func (h *hnswIndex) add(id string, values []float32, metadata map[string]interface{}) {
+	h.remove(id)
+
+	index := int32(len(h.nodes))
+	node := &hnswNode{id: id, values: values, metadata: metadata}
+	h.nodes = append(h.nodes, node)
+	h.ids[id] = index
+
+	if !h.graph {
+		return
+	}
+
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+	node.neighbors = make([][]int32, level+1)
+
+	if h.entry < 0 {
+		h.entry = index
+		h.maxLevel = level
+		return
+	}
+
+	entries := []distItem{{id: h.entry, dist: h.distance(values, h.nodes[h.entry].values)}}
+	for layer := h.maxLevel; layer > level; layer-- {
+		entries = h.searchLayer(values, entries, 1, layer)[:1]
+	}
+
+	for layer := min(level, h.maxLevel); layer >= 0; layer-- {
+		candidates := h.searchLayer(values, entries, h.params.EfConstruction, layer)
+		selected := h.selectNeighbors(candidates, h.params.M)
+		node.neighbors[layer] = selected
+
+		for _, neighbor := range selected {
+			links := append(h.nodes[neighbor].neighbors[layer], index)
+			if maxLinks := h.maxLinks(layer); len(links) > maxLinks {
+				links = h.shrink(neighbor, links, maxLinks)
+			}
+			h.nodes[neighbor].neighbors[layer] = links
+		}
+		entries = candidates
+	}
+
+	if level > h.maxLevel {
+		h.maxLevel = level
+		h.entry = index
+	}
+}
+
+// remove marks a vector as deleted and rebuilds the graph once more than
+// half of the nodes are dead
+func (h *hnswIndex) remove(id string) bool {
+	index, exists := h.ids[id]
+	if !exists {
+		return false
+	}
+
+	delete(h.ids, id)
+	h.nodes[index].deleted = true
+	h.deleted++
+
+	if h.deleted > len(h.nodes)/2 {
+		h.rebuild(h.params, h.graph)
+	}
+	return true
+}
+
+// rebuild recreates the index from its live nodes
+func (h *hnswIndex) rebuild(params HNSWParams, graph bool) {
+	nodes := h.nodes
+	*h = *newHNSWIndex(params, graph, h.distance)
+	for _, node := range nodes {
+		if !node.deleted {
+			h.add(node.id, node.values, node.metadata)
+		}
+	}
+}
+
+func (h *hnswIndex) maxLinks(layer int) int {
+	if layer == 0 {
+		return 2 * h.params.M
+	}
+	return h.params.M
+}
+
+// shrink keeps the best maxLinks links of a node
+func (h *hnswIndex) shrink(index int32, links []int32, maxLinks int) []int32 {
+	candidates := make([]distItem, len(links))
+	for i, link := range links {
+		candidates[i] = distItem{id: link, dist: h.distance(h.nodes[index].values, h.nodes[link].values)}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+	return h.selectNeighbors(candidates, maxLinks)
+}
+
+// selectNeighbors applies the neighbor selection heuristic: a candidate is
+// skipped when it is closer to an already selected neighbor than to the
+// base, which keeps links spread out. Skipped candidates fill up the
+// remaining slots. Candidates must be sorted by distance.
+func (h *hnswIndex) selectNeighbors(candidates []distItem, m int) []int32 {
+	selected := make([]int32, 0, m)
+	var skipped []int32
+
+	for _, candidate := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		diverse := true
+		for _, other := range selected {
+			if h.distance(h.nodes[candidate.id].values, h.nodes[other].values) < candidate.dist {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, candidate.id)
+		} else {
+			skipped = append(skipped, candidate.id)
+		}
+	}
+	for _, candidate := range skipped {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, candidate)
+	}
+
+	return selected
+}
+
+// searchLayer is a best-first search of one layer, returning up to ef
+// nodes sorted by distance
+func (h *hnswIndex) searchLayer(query []float32, entries []distItem, ef int, layer int) []distItem {
+	visited := make(map[int32]bool, ef*4)
+	candidates := &minDistHeap{}
+	results := &maxDistHeap{}
+
+	for _, entry := range entries {
+		visited[entry.id] = true
+		heap.Push(candidates, entry)
+		heap.Push(results, entry)
+	}
+	for results.Len() > ef {
+		heap.Pop(results)
+	}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(distItem)
+		if results.Len() >= ef && current.dist > (*results)[0].dist {
+			break
+		}
+
+		for _, neighbor := range h.nodes[current.id].neighbors[layer] {
+			if visited[neighbor] {
+				continue
+			}
+			visited[neighbor] = true
+
+			dist := h.distance(query, h.nodes[neighbor].values)
+			if results.Len() < ef || dist < (*results)[0].dist {
+				heap.Push(candidates, distItem{id: neighbor, dist: dist})
+				heap.Push(results, distItem{id: neighbor, dist: dist})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := make([]distItem, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(distItem)
+	}
+	return sorted
+}
+
+// search returns up to k live nodes accepted by the filter, closest first.
+// The graph is searched with a candidate list of ef; filtered searches that
+// come back short fall back to an exhaustive scan, so a selective filter
+// never loses matches.
+func (h *hnswIndex) search(query []float32, k int, ef int, accept func(*hnswNode) bool) []distItem {
+	if len(h.ids) == 0 || k <= 0 {
+		return nil
+	}
+	if !h.graph {
+		return h.scan(query, k, accept)
+	}
+
+	ef = max(ef, k)
+	entries := []distItem{{id: h.entry, dist: h.distance(query, h.nodes[h.entry].values)}}
+	for layer := h.maxLevel; layer > 0; layer-- {
+		entries = h.searchLayer(query, entries, 1, layer)[:1]
+	}
+
+	results := make([]distItem, 0, k)
+	for _, candidate := range h.searchLayer(query, entries, ef, 0) {
+		node := h.nodes[candidate.id]
+		if node.deleted || (accept != nil && !accept(node)) {
+			continue
+		}
+		results = append(results, candidate)
+		if len(results) == k {
+			break
+		}
+	}
+
+	if len(results) < k && (accept != nil || h.deleted > 0) && len(h.ids) > len(results) {
+		return h.scan(query, k, accept)
+	}
+	return results
+}
+
+// scan is an exhaustive search over the live nodes
+func (h *hnswIndex) scan(query []float32, k int, accept func(*hnswNode) bool) []distItem {
+	results := &maxDistHeap{}
+	for index, node := range h.nodes {
+		if node.deleted || (accept != nil && !accept(node)) {
+			continue
+		}
+		dist := h.distance(query, node.values)
+		if results.Len() < k {
+			heap.Push(results, distItem{id: int32(index), dist: dist})
+		} else if dist < (*results)[0].dist {
+			(*results)[0] = distItem{id: int32(index), dist: dist}
+			heap.Fix(results, 0)
+		}
+	}
+
+	sorted := make([]distItem, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(distItem)
+	}
+	return sorted
+}
+
+type distItem struct {
+	id   int32
+	dist float32
+}
+
+type minDistHeap []distItem
+
+func (h minDistHeap) Len() int            { return len(h) }
+func (h minDistHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h minDistHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minDistHeap) Push(x interface{}) { *h = append(*h, x.(distItem)) }
+func (h *minDistHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+type maxDistHeap []distItem
+
+func (h maxDistHeap) Len() int            { return len(h) }
+func (h maxDistHeap) Less(i, j int) bool  { return h[i].dist > h[j].dist }
+func (h maxDistHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxDistHeap) Push(x interface{}) { *h = append(*h, x.(distItem)) }
+func (h *maxDistHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -65,6 +65,14 @@ type VectorDB interface {
 	Health(ctx context.Context) (*HealthStatus, error)
 }
 
+// VectorScanner is implemented by vector databases that can list the
+// vectors of a collection, for rebuilding indexes kept outside the database
+type VectorScanner interface {
+	// ScanVectors calls fn with every vector of a collection, metadata
+	// included but without values, and stops at the first error fn returns
+	ScanVectors(ctx context.Context, collection string, fn func(*Vector) error) error
+}
+
 // EmbeddingProvider defines the interface for generating embeddings
 type EmbeddingProvider interface {
 	// GenerateEmbedding generates an embedding for the given text
@@ -97,6 +105,7 @@ type SearchRequest struct {
 	Query      string                 `json:"query,omitempty"`
 	TopK       int                    `json:"top_k"`
 	Filter     map[string]interface{} `json:"filter,omitempty"`
+	Where      *Filter                `json:"where,omitempty"`   // takes precedence over Filter
 	Include    []string               `json:"include,omitempty"` // metadata, values, etc.
 }
 
@@ -216,7 +225,7 @@ type VectorDBConfig struct {
 
 // EmbeddingConfig represents configuration for embedding provider
 type EmbeddingConfig struct {
-	Provider   string                 `json:"provider"` // openai, ollama, huggingface, etc.
+	Provider   string                 `json:"provider"` // openai, ollama, local, huggingface, etc.
 	Model      string                 `json:"model"`
 	APIKey     string                 `json:"api_key,omitempty"`
 	BaseURL    string                 `json:"base_url,omitempty"`
@@ -0,0 +1,640 @@
+package vectordb
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Local embedding models
+const (
+	// LocalModelHashedNGram projects words, word bigrams and character
+	// n-grams into a fixed number of dimensions with signed feature
+	// hashing, weighted by TF-IDF when a vocabulary is configured
+	LocalModelHashedNGram = "hashed-ngram"
+
+	// LocalModelWordVectors averages static word vectors, such as GloVe or
+	// fastText text files, weighted by TF-IDF when a vocabulary is
+	// configured
+	LocalModelWordVectors = "word-vectors"
+)
+
+const (
+	defaultLocalDimensions = 384
+	defaultLocalNGramMin   = 3
+	defaultLocalNGramMax   = 5
+
+	// Weights of character n-grams and word bigrams relative to words
+	localCharWeight   = 0.5
+	localBigramWeight = 0.5
+)
+
+// LocalEmbeddingProvider implements EmbeddingProvider in process, without
+// a model server. Embeddings are deterministic: the same configuration,
+// vocabulary and text always give the same vector, on any machine. They
+// capture lexical rather than semantic similarity, which is enough for
+// tests, CI and air-gapped deployments.
+type LocalEmbeddingProvider struct {
+	config      *EmbeddingConfig
+	vocabulary  *Vocabulary
+	wordVectors *WordVectors
+	ngramMin    int
+	ngramMax    int
+	seed        uint64
+	logger      *logrus.Logger
+	tracer      trace.Tracer
+}
+
+// LocalEmbeddingFactory implements EmbeddingProviderFactory for local
+// embeddings. Metadata keys:
+//
+//	vocabulary    vocabulary file path (or *Vocabulary) for IDF weights
+//	word_vectors  word vector file path (or *WordVectors); selects the
+//	              word-vectors model
+//	ngram_min     shortest character n-gram, default 3
+//	ngram_max     longest character n-gram, default 5; 0 disables them
+//	seed          feature hashing seed, default 0
+type LocalEmbeddingFactory struct{}
+
+// NewLocalEmbeddingFactory creates a new local embedding factory
+func NewLocalEmbeddingFactory() *LocalEmbeddingFactory {
+	return &LocalEmbeddingFactory{}
+}
+
+// Create creates a new local embedding provider
+func (f *LocalEmbeddingFactory) Create(config *EmbeddingConfig) (EmbeddingProvider, error) {
+	if err := f.ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	logger := logrus.New()
+	if logLevel, ok := config.Metadata["log_level"].(string); ok {
+		if parsedLevel, err := logrus.ParseLevel(logLevel); err == nil {
+			logger.SetLevel(parsedLevel)
+		}
+	}
+
+	provider := &LocalEmbeddingProvider{
+		config:   config,
+		ngramMin: defaultLocalNGramMin,
+		ngramMax: defaultLocalNGramMax,
+		logger:   logger,
+		tracer:   otel.Tracer("vectordb.embeddings.local"),
+	}
+	if value, exists := config.Metadata["ngram_min"]; exists {
+		provider.ngramMin, _ = toInt(value)
+	}
+	if value, exists := config.Metadata["ngram_max"]; exists {
+		provider.ngramMax, _ = toInt(value)
+	}
+	if seed, ok := toInt(config.Metadata["seed"]); ok {
+		provider.seed = uint64(seed)
+	}
+
+	switch vocabulary := config.Metadata["vocabulary"].(type) {
+	case *Vocabulary:
+		provider.vocabulary = vocabulary
+	case string:
+		loaded, err := LoadVocabulary(vocabulary)
+		if err != nil {
+			return nil, err
+		}
+		provider.vocabulary = loaded
+	}
+
+	if config.Model == LocalModelWordVectors {
+		switch wordVectors := config.Metadata["word_vectors"].(type) {
+		case *WordVectors:
+			provider.wordVectors = wordVectors
+		case string:
+			loaded, err := LoadWordVectors(wordVectors)
+			if err != nil {
+				return nil, err
+			}
+			provider.wordVectors = loaded
+		}
+		if config.Dimensions == 0 {
+			config.Dimensions = provider.wordVectors.Dimension()
+		}
+		if config.Dimensions != provider.wordVectors.Dimension() {
+			return nil, fmt.Errorf("word vectors have dimension %d, config expects %d",
+				provider.wordVectors.Dimension(), config.Dimensions)
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"model":      config.Model,
+		"dimensions": config.Dimensions,
+		"vocabulary": provider.vocabulary.Len(),
+	}).Debug("Created local embedding provider")
+
+	return provider, nil
+}
+
+// GetProviderName returns the provider name
+func (f *LocalEmbeddingFactory) GetProviderName() string {
+	return "local"
+}
+
+// ValidateConfig validates the local embedding configuration
+func (f *LocalEmbeddingFactory) ValidateConfig(config *EmbeddingConfig) error {
+	if config.Metadata == nil {
+		config.Metadata = make(map[string]interface{})
+	}
+	_, hasWordVectors := config.Metadata["word_vectors"]
+	if config.Model == "" {
+		config.Model = LocalModelHashedNGram
+		if hasWordVectors {
+			config.Model = LocalModelWordVectors
+		}
+	}
+
+	switch config.Model {
+	case LocalModelHashedNGram:
+		if config.Dimensions <= 0 {
+			config.Dimensions = defaultLocalDimensions
+		}
+	case LocalModelWordVectors:
+		switch config.Metadata["word_vectors"].(type) {
+		case string, *WordVectors:
+		default:
+			return fmt.Errorf("word_vectors is required for the %s model", LocalModelWordVectors)
+		}
+	default:
+		return fmt.Errorf("unknown local embedding model: %s", config.Model)
+	}
+
+	switch config.Metadata["vocabulary"].(type) {
+	case nil, string, *Vocabulary:
+	default:
+		return fmt.Errorf("vocabulary must be a file path")
+	}
+
+	ngramMin, ngramMax := defaultLocalNGramMin, defaultLocalNGramMax
+	for key, target := range map[string]*int{"ngram_min": &ngramMin, "ngram_max": &ngramMax} {
+		if value, exists := config.Metadata[key]; exists {
+			parsed, ok := toInt(value)
+			if !ok || parsed < 0 {
+				return fmt.Errorf("%s must be a non-negative integer", key)
+			}
+			*target = parsed
+		}
+	}
+	if ngramMax > 0 && (ngramMin < 1 || ngramMin > ngramMax) {
+		return fmt.Errorf("ngram_min must be between 1 and ngram_max")
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = 8192
+	}
+
+	return nil
+}
+
+// GenerateEmbedding generates an embedding for a single text
+func (p *LocalEmbeddingProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := p.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings generates embeddings for multiple texts. Texts with
+// no known words embed to the zero vector.
+func (p *LocalEmbeddingProvider) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	ctx, span := p.tracer.Start(ctx, "local_embedding.generate_embeddings")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("embedding.model", p.config.Model),
+		attribute.Int("embedding.text_count", len(texts)),
+	)
+
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if p.wordVectors != nil {
+			embeddings[i] = p.embedWordVectors(text)
+		} else {
+			embeddings[i] = p.embedHashed(text)
+		}
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"model":      p.config.Model,
+		"text_count": len(texts),
+	}).Debug("Generated embeddings")
+
+	return embeddings, nil
+}
+
+// GetDimensions returns the embedding dimensions
+func (p *LocalEmbeddingProvider) GetDimensions() int {
+	return p.config.Dimensions
+}
+
+// GetModel returns the model name
+func (p *LocalEmbeddingProvider) GetModel() string {
+	return p.config.Model
+}
+
+// GetMaxTokens returns the maximum tokens
+func (p *LocalEmbeddingProvider) GetMaxTokens() int {
+	return p.config.MaxTokens
+}
+
+// termWeights returns the TF-IDF weight of each word in text, with
+// sublinear term frequencies, and the words in text order
+func (p *LocalEmbeddingProvider) termWeights(text string) ([]string, map[string]float64) {
+	words := textTokens(text)
+	counts := make(map[string]int, len(words))
+	for _, word := range words {
+		counts[word]++
+	}
+	weights := make(map[string]float64, len(counts))
+	for word, count := range counts {
+		weights[word] = (1 + math.Log(float64(count))) * p.vocabulary.IDF(word)
+	}
+	return words, weights
+}
+
+func (p *LocalEmbeddingProvider) embedHashed(text string) []float32 {
+	words, weights := p.termWeights(text)
+	values := make([]float64, p.config.Dimensions)
+
+	// Features are added in sorted order so rounding is reproducible
+	for _, word := range sortedKeys(weights) {
+		weight := weights[word]
+		p.addFeature(values, "w\x00"+word, weight)
+
+		grams := charNGrams(word, p.ngramMin, p.ngramMax)
+		for _, gram := range grams {
+			p.addFeature(values, "c\x00"+gram, weight*localCharWeight/math.Sqrt(float64(len(grams))))
+		}
+	}
+
+	bigrams := make(map[string]float64)
+	for i := 1; i < len(words); i++ {
+		bigrams[words[i-1]+" "+words[i]] += 1
+	}
+	for _, bigram := range sortedKeys(bigrams) {
+		first, second, _ := strings.Cut(bigram, " ")
+		idf := math.Sqrt(p.vocabulary.IDF(first) * p.vocabulary.IDF(second))
+		p.addFeature(values, "b\x00"+bigram, (1+math.Log(bigrams[bigram]))*idf*localBigramWeight)
+	}
+
+	return normalizeFloat64(values)
+}
+
+// addFeature adds a hashed feature: the hash picks the dimension and the
+// sign, so colliding features cancel out on average
+func (p *LocalEmbeddingProvider) addFeature(values []float64, feature string, weight float64) {
+	hash := fnv.New64a()
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], p.seed)
+	hash.Write(seed[:])
+	hash.Write([]byte(feature))
+	sum := hash.Sum64()
+
+	index := (sum & math.MaxInt64) % uint64(len(values))
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	values[index] += weight
+}
+
+func (p *LocalEmbeddingProvider) embedWordVectors(text string) []float32 {
+	_, weights := p.termWeights(text)
+	values := make([]float64, p.config.Dimensions)
+	for _, word := range sortedKeys(weights) {
+		vector, ok := p.wordVectors.Lookup(word)
+		if !ok {
+			continue
+		}
+		for i, v := range vector {
+			values[i] += weights[word] * float64(v)
+		}
+	}
+	return normalizeFloat64(values)
+}
+
+// charNGrams returns the character n-grams of a word framed by < and >,
+// or the framed word itself when it is shorter than minN
+func charNGrams(word string, minN, maxN int) []string {
+	if maxN <= 0 {
+		return nil
+	}
+	runes := []rune("<" + word + ">")
+	if len(runes) < minN {
+		return []string{string(runes)}
+	}
+	var grams []string
+	for n := minN; n <= maxN && n <= len(runes); n++ {
+		for i := 0; i+n <= len(runes); i++ {
+			grams = append(grams, string(runes[i:i+n]))
+		}
+	}
+	return grams
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func normalizeFloat64(values []float64) []float32 {
+	var norm float64
+	for _, v := range values {
+		norm += v * v
+	}
+	result := make([]float32, len(values))
+	if norm == 0 {
+		return result
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range values {
+		result[i] = float32(v / norm)
+	}
+	return result
+}
+
+// Vocabulary
+
+// vocabularyHeader starts every vocabulary file
+const vocabularyHeader = "# aios embedding vocabulary v1"
+
+// Vocabulary holds the document frequencies of words in a corpus, which
+// give the IDF weights of local embeddings. Its file form is
+// deterministic: a header, the document count, then one tab-separated
+// word and frequency per line in word order.
+type Vocabulary struct {
+	Documents   int
+	Frequencies map[string]int
+}
+
+// BuildVocabulary counts the document frequencies of words in texts,
+// keeping at most maxTerms of the most frequent words (all when maxTerms
+// is zero). Dropped words are weighted as rare words.
+func BuildVocabulary(texts []string, maxTerms int) *Vocabulary {
+	vocabulary := &Vocabulary{Documents: len(texts), Frequencies: make(map[string]int)}
+	for _, text := range texts {
+		seen := make(map[string]bool)
+		for _, word := range textTokens(text) {
+			if !seen[word] {
+				seen[word] = true
+				vocabulary.Frequencies[word]++
+			}
+		}
+	}
+
+	if maxTerms > 0 && len(vocabulary.Frequencies) > maxTerms {
+		words := make([]string, 0, len(vocabulary.Frequencies))
+		for word := range vocabulary.Frequencies {
+			words = append(words, word)
+		}
+		sort.Slice(words, func(i, j int) bool {
+			fi, fj := vocabulary.Frequencies[words[i]], vocabulary.Frequencies[words[j]]
+			if fi != fj {
+				return fi > fj
+			}
+			return words[i] < words[j]
+		})
+		for _, word := range words[maxTerms:] {
+			delete(vocabulary.Frequencies, word)
+		}
+	}
+
+	return vocabulary
+}
+
+// Len returns the number of words in the vocabulary
+func (v *Vocabulary) Len() int {
+	if v == nil {
+		return 0
+	}
+	return len(v.Frequencies)
+}
+
+// IDF returns the smoothed inverse document frequency of a word. Words
+// missing from the vocabulary weigh as words seen in no document, and
+// every word weighs 1 without a vocabulary.
+func (v *Vocabulary) IDF(word string) float64 {
+	if v == nil || v.Documents == 0 {
+		return 1
+	}
+	return math.Log(float64(1+v.Documents)/float64(1+v.Frequencies[word])) + 1
+}
+
+// WriteTo writes the vocabulary in its file form
+func (v *Vocabulary) WriteTo(w io.Writer) (int64, error) {
+	words := make([]string, 0, len(v.Frequencies))
+	for word := range v.Frequencies {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\ndocuments\t%d\n", vocabularyHeader, v.Documents)
+	for _, word := range words {
+		fmt.Fprintf(&sb, "%s\t%d\n", word, v.Frequencies[word])
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// Save writes the vocabulary to a file, replacing it atomically
+func (v *Vocabulary) Save(path string) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save vocabulary: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := v.WriteTo(temp); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to save vocabulary: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to save vocabulary: %w", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save vocabulary: %w", err)
+	}
+	return nil
+}
+
+// LoadVocabulary reads a vocabulary file
+func LoadVocabulary(path string) (*Vocabulary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary: %w", err)
+	}
+	defer file.Close()
+
+	vocabulary, err := ReadVocabulary(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary %s: %w", path, err)
+	}
+	return vocabulary, nil
+}
+
+// ReadVocabulary reads a vocabulary in its file form
+func ReadVocabulary(r io.Reader) (*Vocabulary, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || scanner.Text() != vocabularyHeader {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("missing vocabulary header")
+	}
+
+	vocabulary := &Vocabulary{Documents: -1, Frequencies: make(map[string]int)}
+	for line := 2; scanner.Scan(); line++ {
+		word, count, ok := strings.Cut(scanner.Text(), "\t")
+		frequency, err := strconv.Atoi(count)
+		if !ok || err != nil || frequency < 0 {
+			return nil, fmt.Errorf("invalid entry on line %d", line)
+		}
+		if line == 2 {
+			if word != "documents" {
+				return nil, fmt.Errorf("missing document count")
+			}
+			vocabulary.Documents = frequency
+			continue
+		}
+		vocabulary.Frequencies[word] = frequency
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if vocabulary.Documents < 0 {
+		return nil, fmt.Errorf("missing document count")
+	}
+	return vocabulary, nil
+}
+
+// Word vectors
+
+// WordVectors holds static word embeddings
+type WordVectors struct {
+	dimension int
+	vectors   map[string][]float32
+}
+
+// Dimension returns the dimensionality of the word vectors
+func (w *WordVectors) Dimension() int {
+	return w.dimension
+}
+
+// Len returns the number of words
+func (w *WordVectors) Len() int {
+	return len(w.vectors)
+}
+
+// Lookup returns the vector of a lowercase word
+func (w *WordVectors) Lookup(word string) ([]float32, bool) {
+	vector, ok := w.vectors[word]
+	return vector, ok
+}
+
+// LoadWordVectors reads a word vector file
+func LoadWordVectors(path string) (*WordVectors, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word vectors: %w", err)
+	}
+	defer file.Close()
+
+	wordVectors, err := ReadWordVectors(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read word vectors %s: %w", path, err)
+	}
+	return wordVectors, nil
+}
+
+// ReadWordVectors reads word vectors in the text format of word2vec, GloVe
+// and fastText: a word and its space-separated values per line, optionally
+// after a "count dimension" header. Words are lowercased; when several
+// words fold to the same one, the first wins, which with frequency-ordered
+// files is the most common spelling.
+func ReadWordVectors(r io.Reader) (*WordVectors, error) {
+	wordVectors := &WordVectors{vectors: make(map[string][]float32)}
+	reader := bufio.NewReader(r)
+
+	for line := 1; ; line++ {
+		text, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		fields := strings.Fields(text)
+
+		if line == 1 && len(fields) == 2 {
+			if _, countErr := strconv.Atoi(fields[0]); countErr == nil {
+				if _, dimErr := strconv.Atoi(fields[1]); dimErr == nil {
+					continue
+				}
+			}
+		}
+
+		if len(fields) > 1 {
+			if wordVectors.dimension == 0 {
+				wordVectors.dimension = len(fields) - 1
+			}
+			if len(fields)-1 != wordVectors.dimension {
+				return nil, fmt.Errorf("line %d has %d values, expected %d", line, len(fields)-1, wordVectors.dimension)
+			}
+
+			vector := make([]float32, wordVectors.dimension)
+			for i, field := range fields[1:] {
+				value, parseErr := strconv.ParseFloat(field, 32)
+				if parseErr != nil {
+					return nil, fmt.Errorf("invalid value %q on line %d", field, line)
+				}
+				vector[i] = float32(value)
+			}
+			word := strings.ToLower(fields[0])
+			if _, exists := wordVectors.vectors[word]; !exists {
+				wordVectors.vectors[word] = vector
+			}
+		} else if len(fields) == 1 {
+			return nil, fmt.Errorf("line %d has no values", line)
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if len(wordVectors.vectors) == 0 {
+		return nil, fmt.Errorf("no word vectors found")
+	}
+	return wordVectors, nil
+}
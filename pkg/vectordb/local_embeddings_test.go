@@ -0,0 +1,149 @@
+package vectordb
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var localCorpus = []string{
+	"Configure the PostgreSQL connection pool size for the API server",
+	"The Kubernetes deployment restarts pods when the liveness probe fails",
+	"Rotate TLS certificates before they expire to avoid outages",
+	"Connection pooling reduces latency for database heavy workloads",
+	"Scale the deployment horizontally by adding more pod replicas",
+}
+
+func newLocalEmbeddings(t *testing.T, metadata map[string]interface{}) EmbeddingProvider {
+	config := &EmbeddingConfig{Provider: "local", Metadata: metadata}
+	provider, err := NewEmbeddingManager(logrus.New()).CreateProvider(config)
+	require.NoError(t, err)
+	return provider
+}
+
+func TestLocalEmbeddings(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("HashedNGram", func(t *testing.T) {
+		provider := newLocalEmbeddings(t, nil)
+		assert.Equal(t, LocalModelHashedNGram, provider.GetModel())
+		assert.Equal(t, 384, provider.GetDimensions())
+
+		embeddings, err := provider.GenerateEmbeddings(ctx, localCorpus)
+		require.NoError(t, err)
+		query, err := provider.GenerateEmbedding(ctx, "database connection pool")
+		require.NoError(t, err)
+		require.Len(t, query, 384)
+		assert.InDelta(t, 1.0, dot(query, query), 1e-5)
+
+		// Deterministic across providers, and sensitive to the seed
+		again, err := newLocalEmbeddings(t, nil).GenerateEmbedding(ctx, "database connection pool")
+		require.NoError(t, err)
+		assert.Equal(t, query, again)
+		seeded, err := newLocalEmbeddings(t, map[string]interface{}{"seed": 7}).GenerateEmbedding(ctx, "database connection pool")
+		require.NoError(t, err)
+		assert.NotEqual(t, query, seeded)
+
+		// Pooling documents rank first; character n-grams relate pool and pooling
+		scores := make([]float32, len(embeddings))
+		for i, embedding := range embeddings {
+			scores[i] = dot(query, embedding)
+		}
+		for _, other := range []int{1, 2, 4} {
+			assert.Greater(t, scores[0], scores[other])
+			assert.Greater(t, scores[3], scores[other])
+		}
+
+		empty, err := provider.GenerateEmbedding(ctx, "?!")
+		require.NoError(t, err)
+		assert.Equal(t, make([]float32, 384), empty)
+	})
+
+	t.Run("Vocabulary", func(t *testing.T) {
+		vocabulary := BuildVocabulary(localCorpus, 0)
+		assert.Equal(t, 5, vocabulary.Documents)
+		assert.Equal(t, 3, vocabulary.Frequencies["the"])
+		assert.Greater(t, vocabulary.IDF("kubernetes"), vocabulary.IDF("the"))
+		assert.Greater(t, vocabulary.IDF("unseen"), vocabulary.IDF("kubernetes"))
+
+		path := filepath.Join(t.TempDir(), "vocabulary.tsv")
+		require.NoError(t, vocabulary.Save(path))
+		loaded, err := LoadVocabulary(path)
+		require.NoError(t, err)
+		assert.Equal(t, vocabulary, loaded)
+
+		// The file form is deterministic
+		var first, second bytes.Buffer
+		_, err = vocabulary.WriteTo(&first)
+		require.NoError(t, err)
+		_, err = BuildVocabulary(localCorpus, 0).WriteTo(&second)
+		require.NoError(t, err)
+		assert.Equal(t, first.String(), second.String())
+		assert.True(t, strings.HasPrefix(first.String(), vocabularyHeader+"\ndocuments\t5\nadding\t1\n"))
+
+		pruned := BuildVocabulary(localCorpus, 3)
+		assert.Equal(t, map[string]int{"the": 3, "connection": 2, "deployment": 2}, pruned.Frequencies)
+
+		_, err = ReadVocabulary(strings.NewReader("documents\t3\n"))
+		assert.Error(t, err)
+
+		// IDF weights stop common words from dominating
+		plain := newLocalEmbeddings(t, nil)
+		weighted := newLocalEmbeddings(t, map[string]interface{}{"vocabulary": path})
+		similarity := func(provider EmbeddingProvider) float32 {
+			embeddings, err := provider.GenerateEmbeddings(ctx, []string{"the the the kubernetes", "the the the certificates"})
+			require.NoError(t, err)
+			return dot(embeddings[0], embeddings[1])
+		}
+		assert.Less(t, similarity(weighted), similarity(plain))
+	})
+
+	t.Run("WordVectors", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "vectors.txt")
+		require.NoError(t, os.WriteFile(path, []byte("4 3\n"+
+			"Database 1 0 0\n"+
+			"postgres 0.9 0.1 0\n"+
+			"database 0 0 1\n"+
+			"kubernetes 0 1 0\n"), 0o644))
+
+		provider := newLocalEmbeddings(t, map[string]interface{}{"word_vectors": path})
+		assert.Equal(t, LocalModelWordVectors, provider.GetModel())
+		assert.Equal(t, 3, provider.GetDimensions())
+
+		embeddings, err := provider.GenerateEmbeddings(ctx, []string{"the DATABASE", "Postgres!", "kubernetes", "unknown words"})
+		require.NoError(t, err)
+		assert.Equal(t, []float32{1, 0, 0}, embeddings[0])
+		assert.Greater(t, dot(embeddings[0], embeddings[1]), float32(0.9))
+		assert.Equal(t, float32(0), dot(embeddings[0], embeddings[2]))
+		assert.Equal(t, []float32{0, 0, 0}, embeddings[3])
+
+		_, err = NewLocalEmbeddingFactory().Create(&EmbeddingConfig{
+			Dimensions: 8,
+			Metadata:   map[string]interface{}{"word_vectors": path},
+		})
+		assert.Error(t, err)
+		_, err = ReadWordVectors(strings.NewReader("a 1 2\nb 1\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Offline", func(t *testing.T) {
+		db := newEmbeddedDB(t, "")
+		provider := newLocalEmbeddings(t, map[string]interface{}{"vocabulary": BuildVocabulary(localCorpus, 0)})
+		require.NoError(t, db.CreateCollection(ctx, &CollectionConfig{Name: "docs", Dimension: provider.GetDimensions()}))
+
+		store := NewVectorStore(db, provider, &VectorStoreConfig{}, logrus.New())
+		require.NoError(t, store.AddTexts(ctx, "docs", localCorpus, nil))
+
+		documents, err := store.SimilaritySearch(ctx, "docs", "how do I renew expiring TLS certificates", 1, nil)
+		require.NoError(t, err)
+		require.Len(t, documents, 1)
+		assert.Equal(t, localCorpus[2], documents[0].Content)
+	})
+}
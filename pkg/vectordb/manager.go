@@ -30,10 +30,13 @@ func NewVectorDBManager(logger *logrus.Logger) VectorDBManager {
 
 	// Register default providers
 	manager.RegisterProvider("qdrant", NewQdrantFactory())
+	manager.RegisterProvider("embedded", NewEmbeddedFactory())
+	manager.RegisterProvider("pgvector", NewPgVectorFactory())
 
 	// Register default embedding providers
 	manager.registerEmbeddingFactory("openai", NewOpenAIEmbeddingFactory())
 	manager.registerEmbeddingFactory("ollama", NewOllamaEmbeddingFactory())
+	manager.registerEmbeddingFactory("local", NewLocalEmbeddingFactory())
 
 	return manager
 }
@@ -0,0 +1,1159 @@
+package vectordb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Defaults of the pgvector backend
+const (
+	DefaultPgVectorSchema = "vectordb"
+	pgVectorTablePrefix   = "vectors_"
+	pgVectorInsertBatch   = 500
+)
+
+// Collection names keep derived table and index names within the 63 byte
+// identifier limit of PostgreSQL
+var pgCollectionNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,42}$`)
+
+// PgVectorDB implements VectorDB on PostgreSQL with the pgvector extension.
+// Each collection is a table in the configured schema holding an ID, the
+// embedding and JSONB metadata; a registry table records the dimension and
+// metric of every collection.
+type PgVectorDB struct {
+	config      *VectorDBConfig
+	db          *sqlx.DB
+	ownsDB      bool
+	schema      string
+	collections map[string]*pgCollection
+	connected   bool
+	logger      *logrus.Logger
+	tracer      trace.Tracer
+	mu          sync.RWMutex
+}
+
+// pgCollection caches the registry row of a collection
+type pgCollection struct {
+	Name        string    `db:"name"`
+	Dimension   int       `db:"dimension"`
+	Metric      string    `db:"metric"`
+	Description string    `db:"description"`
+	Metadata    []byte    `db:"metadata"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// PgVectorFactory implements VectorDBFactory for pgvector
+type PgVectorFactory struct{}
+
+// NewPgVectorFactory creates a new pgvector factory
+func NewPgVectorFactory() *PgVectorFactory {
+	return &PgVectorFactory{}
+}
+
+// Create creates a new pgvector database instance. Host, Port and Database
+// address the server; the metadata keys user, password, sslmode and schema
+// complete the connection, or dsn replaces all of them.
+func (f *PgVectorFactory) Create(config *VectorDBConfig) (VectorDB, error) {
+	if err := f.ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return &PgVectorDB{
+		config:      config,
+		ownsDB:      true,
+		schema:      stringParameter(config.Metadata, "schema", DefaultPgVectorSchema),
+		collections: make(map[string]*pgCollection),
+		logger:      newProviderLogger(config),
+		tracer:      otel.Tracer("vectordb.pgvector"),
+	}, nil
+}
+
+// GetProviderName returns the provider name
+func (f *PgVectorFactory) GetProviderName() string {
+	return "pgvector"
+}
+
+// ValidateConfig validates the pgvector configuration
+func (f *PgVectorFactory) ValidateConfig(config *VectorDBConfig) error {
+	if stringParameter(config.Metadata, "dsn", "") == "" {
+		if config.Host == "" {
+			return fmt.Errorf("host is required")
+		}
+		if config.Database == "" {
+			return fmt.Errorf("database is required")
+		}
+		if config.Port <= 0 {
+			config.Port = 5432
+		}
+	}
+	if schema := stringParameter(config.Metadata, "schema", DefaultPgVectorSchema); !pgCollectionNamePattern.MatchString(schema) {
+		return fmt.Errorf("invalid schema name: %s", schema)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return nil
+}
+
+// NewPgVectorDB creates a pgvector database on an existing connection
+// pool, such as the one of the knowledge repository. Disconnect leaves the
+// pool open.
+func NewPgVectorDB(db *sqlx.DB, schema string, logger *logrus.Logger) (*PgVectorDB, error) {
+	if schema == "" {
+		schema = DefaultPgVectorSchema
+	}
+	if !pgCollectionNamePattern.MatchString(schema) {
+		return nil, fmt.Errorf("invalid schema name: %s", schema)
+	}
+
+	return &PgVectorDB{
+		config:      &VectorDBConfig{Provider: "pgvector"},
+		db:          db,
+		schema:      schema,
+		collections: make(map[string]*pgCollection),
+		logger:      logger,
+		tracer:      otel.Tracer("vectordb.pgvector"),
+	}, nil
+}
+
+// Connect opens the connection and creates the extension, schema and
+// registry table when missing
+func (p *PgVectorDB) Connect(ctx context.Context) error {
+	ctx, span := p.tracer.Start(ctx, "pgvector.connect")
+	defer span.End()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.connected {
+		return nil
+	}
+
+	if p.db == nil {
+		connectCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+		defer cancel()
+
+		db, err := sqlx.ConnectContext(connectCtx, "postgres", p.dsn())
+		if err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+		}
+		p.db = db
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s`, pq.QuoteIdentifier(p.schema)),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			name TEXT PRIMARY KEY,
+			dimension INTEGER NOT NULL,
+			metric TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			metadata JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`, p.registryTable()),
+	}
+	for _, statement := range statements {
+		if _, err := p.db.ExecContext(ctx, statement); err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to prepare pgvector schema: %w", err)
+		}
+	}
+
+	p.connected = true
+	p.logger.WithField("schema", p.schema).Info("Connected to pgvector")
+
+	return nil
+}
+
+// Disconnect closes the connection pool when the database opened it
+func (p *PgVectorDB) Disconnect(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ownsDB && p.db != nil {
+		if err := p.db.Close(); err != nil {
+			return fmt.Errorf("failed to close connection: %w", err)
+		}
+		p.db = nil
+	}
+	p.connected = false
+	p.collections = make(map[string]*pgCollection)
+	p.logger.Info("Disconnected from pgvector")
+	return nil
+}
+
+// IsConnected returns connection status
+func (p *PgVectorDB) IsConnected() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.connected
+}
+
+// CreateCollection creates the table of a collection and registers it
+func (p *PgVectorDB) CreateCollection(ctx context.Context, config *CollectionConfig) error {
+	ctx, span := p.tracer.Start(ctx, "pgvector.create_collection")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", config.Name),
+		attribute.Int("collection.dimension", config.Dimension),
+		attribute.String("collection.metric", config.Metric),
+	)
+
+	if !pgCollectionNamePattern.MatchString(config.Name) {
+		return fmt.Errorf("invalid collection name: %s", config.Name)
+	}
+	if config.Dimension <= 0 {
+		return fmt.Errorf("dimension must be positive")
+	}
+	metric, err := pgMetric(config.Metric)
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(config.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode collection metadata: %w", err)
+	}
+	if config.Metadata == nil {
+		metadata = []byte("{}")
+	}
+
+	if err := p.checkConnected(); err != nil {
+		return err
+	}
+
+	table := p.collectionTable(config.Name)
+	err = p.inTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			INSERT INTO %s (name, dimension, metric, description, metadata)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (name) DO NOTHING
+		`, p.registryTable()), config.Name, config.Dimension, metric.name, config.Description, string(metadata))
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return fmt.Errorf("collection already exists: %s", config.Name)
+		}
+
+		statements := []string{
+			fmt.Sprintf(`CREATE TABLE %s (
+				id TEXT PRIMARY KEY,
+				embedding vector(%d) NOT NULL,
+				metadata JSONB NOT NULL DEFAULT '{}',
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			)`, table, config.Dimension),
+			fmt.Sprintf(`CREATE INDEX %s ON %s USING gin (metadata jsonb_path_ops)`,
+				pq.QuoteIdentifier(pgVectorTablePrefix+config.Name+"_metadata_idx"), table),
+		}
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"collection": config.Name,
+		"dimension":  config.Dimension,
+		"metric":     metric.name,
+	}).Info("Created collection")
+
+	return nil
+}
+
+// DeleteCollection drops the table of a collection and unregisters it
+func (p *PgVectorDB) DeleteCollection(ctx context.Context, name string) error {
+	ctx, span := p.tracer.Start(ctx, "pgvector.delete_collection")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("collection.name", name))
+
+	if _, err := p.collection(ctx, name); err != nil {
+		return err
+	}
+
+	err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s`, p.collectionTable(name))); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE name = $1`, p.registryTable()), name)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+
+	p.mu.Lock()
+	delete(p.collections, name)
+	p.mu.Unlock()
+
+	p.logger.WithField("collection", name).Info("Deleted collection")
+	return nil
+}
+
+// ListCollections returns all collections
+func (p *PgVectorDB) ListCollections(ctx context.Context) ([]string, error) {
+	ctx, span := p.tracer.Start(ctx, "pgvector.list_collections")
+	defer span.End()
+
+	if err := p.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	query := fmt.Sprintf(`SELECT name FROM %s ORDER BY name`, p.registryTable())
+	if err := p.db.SelectContext(ctx, &names, query); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	return names, nil
+}
+
+// CollectionExists checks if a collection exists
+func (p *PgVectorDB) CollectionExists(ctx context.Context, name string) (bool, error) {
+	if err := p.checkConnected(); err != nil {
+		return false, err
+	}
+
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE name = $1)`, p.registryTable())
+	if err := p.db.GetContext(ctx, &exists, query, name); err != nil {
+		return false, fmt.Errorf("failed to check collection: %w", err)
+	}
+	return exists, nil
+}
+
+// Insert upserts vectors into a collection in batches
+func (p *PgVectorDB) Insert(ctx context.Context, collection string, vectors []*Vector) error {
+	ctx, span := p.tracer.Start(ctx, "pgvector.insert")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", collection),
+		attribute.Int("vectors.count", len(vectors)),
+	)
+
+	col, err := p.collection(ctx, collection)
+	if err != nil {
+		return err
+	}
+	for _, vector := range vectors {
+		if vector.ID == "" {
+			return fmt.Errorf("vector ID is required")
+		}
+		if len(vector.Values) != col.Dimension {
+			return fmt.Errorf("vector %s has dimension %d, collection %s expects %d",
+				vector.ID, len(vector.Values), collection, col.Dimension)
+		}
+	}
+
+	table := p.collectionTable(collection)
+	err = p.inTx(ctx, func(tx *sqlx.Tx) error {
+		for start := 0; start < len(vectors); start += pgVectorInsertBatch {
+			batch := vectors[start:min(start+pgVectorInsertBatch, len(vectors))]
+
+			rows := make([]string, len(batch))
+			args := make([]interface{}, 0, len(batch)*3)
+			for i, vector := range batch {
+				metadata, err := json.Marshal(vector.Metadata)
+				if err != nil {
+					return fmt.Errorf("failed to encode metadata of %s: %w", vector.ID, err)
+				}
+				if vector.Metadata == nil {
+					metadata = []byte("{}")
+				}
+				rows[i] = fmt.Sprintf("($%d, $%d::vector, $%d::jsonb)", i*3+1, i*3+2, i*3+3)
+				args = append(args, vector.ID, formatVector(vector.Values), string(metadata))
+			}
+
+			query := fmt.Sprintf(`
+				INSERT INTO %s (id, embedding, metadata) VALUES %s
+				ON CONFLICT (id) DO UPDATE SET
+					embedding = EXCLUDED.embedding,
+					metadata = EXCLUDED.metadata,
+					updated_at = NOW()
+			`, table, strings.Join(rows, ", "))
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to insert vectors: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"collection": collection,
+		"count":      len(vectors),
+	}).Debug("Inserted vectors")
+
+	return nil
+}
+
+// Update updates existing vectors
+func (p *PgVectorDB) Update(ctx context.Context, collection string, vectors []*Vector) error {
+	// Like Qdrant, updates are upserts
+	return p.Insert(ctx, collection, vectors)
+}
+
+// Delete deletes vectors by IDs
+func (p *PgVectorDB) Delete(ctx context.Context, collection string, ids []string) error {
+	ctx, span := p.tracer.Start(ctx, "pgvector.delete")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", collection),
+		attribute.Int("ids.count", len(ids)),
+	)
+
+	if _, err := p.collection(ctx, collection); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1)`, p.collectionTable(collection))
+	if _, err := p.db.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete vectors: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"collection": collection,
+		"count":      len(ids),
+	}).Debug("Deleted vectors")
+
+	return nil
+}
+
+// Search performs similarity search
+func (p *PgVectorDB) Search(ctx context.Context, request *SearchRequest) (*SearchResult, error) {
+	results, err := p.BatchSearch(ctx, []*SearchRequest{request})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// BatchSearch performs multiple searches. Requests sharing a collection and
+// filter run as one query that joins the query vectors laterally against
+// the collection, so a batch costs one round trip per group.
+func (p *PgVectorDB) BatchSearch(ctx context.Context, requests []*SearchRequest) ([]*SearchResult, error) {
+	ctx, span := p.tracer.Start(ctx, "pgvector.batch_search")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("search.count", len(requests)))
+
+	type searchGroup struct {
+		collection *pgCollection
+		filter     *Filter
+		indexes    []int
+	}
+	groups := make(map[string]*searchGroup)
+	var order []string
+
+	for i, request := range requests {
+		col, err := p.collection(ctx, request.Collection)
+		if err != nil {
+			return nil, fmt.Errorf("batch search failed at index %d: %w", i, err)
+		}
+		if len(request.Vector) != col.Dimension {
+			return nil, fmt.Errorf("batch search failed at index %d: query vector has dimension %d, collection %s expects %d",
+				i, len(request.Vector), request.Collection, col.Dimension)
+		}
+
+		filter, err := request.TypedFilter()
+		if err != nil {
+			return nil, fmt.Errorf("batch search failed at index %d: %w", i, err)
+		}
+		key := request.Collection
+		if filter != nil {
+			key += "\x00" + filter.String()
+		}
+		group, exists := groups[key]
+		if !exists {
+			group = &searchGroup{collection: col, filter: filter}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.indexes = append(group.indexes, i)
+	}
+
+	results := make([]*SearchResult, len(requests))
+	for _, key := range order {
+		group := groups[key]
+		groupRequests := make([]*SearchRequest, len(group.indexes))
+		for i, index := range group.indexes {
+			groupRequests[i] = requests[index]
+		}
+
+		groupResults, err := p.searchGroup(ctx, group.collection, group.filter, groupRequests)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to search: %w", err)
+		}
+		for i, index := range group.indexes {
+			results[index] = groupResults[i]
+		}
+	}
+
+	return results, nil
+}
+
+func (p *PgVectorDB) searchGroup(ctx context.Context, col *pgCollection, filter *Filter, requests []*SearchRequest) ([]*SearchResult, error) {
+	metric, err := pgMetric(col.Metric)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([]string, len(requests))
+	limits := make([]int64, len(requests))
+	for i, request := range requests {
+		vectors[i] = formatVector(request.Vector)
+		limits[i] = int64(request.TopK)
+		if limits[i] <= 0 {
+			limits[i] = 10
+		}
+	}
+
+	args := []interface{}{pq.Array(vectors), pq.Array(limits)}
+	where, args, err := pgFilterSQL(filter, args)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT q.idx, m.id, m.distance, m.embedding, m.metadata
+		FROM unnest($1::text[], $2::int[]) WITH ORDINALITY AS q(vec, k, idx)
+		CROSS JOIN LATERAL (
+			SELECT id, embedding %[1]s q.vec::vector AS distance, embedding::text AS embedding, metadata
+			FROM %[2]s
+			WHERE %[3]s
+			ORDER BY embedding %[1]s q.vec::vector
+			LIMIT q.k
+		) m
+		ORDER BY q.idx, m.distance
+	`, metric.operator, p.collectionTable(col.Name), where)
+
+	rows, err := p.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]*SearchResult, len(requests))
+	for i := range results {
+		results[i] = &SearchResult{Matches: []Match{}}
+	}
+
+	for rows.Next() {
+		var (
+			index     int
+			id        string
+			distance  float64
+			embedding string
+			metadata  []byte
+		)
+		if err := rows.Scan(&index, &id, &distance, &embedding, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan match: %w", err)
+		}
+
+		request := requests[index-1]
+		match := Match{ID: id, Score: float32(metric.score(distance))}
+		if contains(request.Include, "values") {
+			if match.Values, err = parseVector(embedding); err != nil {
+				return nil, err
+			}
+		}
+		if contains(request.Include, "metadata") {
+			if err := json.Unmarshal(metadata, &match.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode metadata of %s: %w", id, err)
+			}
+		}
+
+		result := results[index-1]
+		result.Matches = append(result.Matches, match)
+		result.Total++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetVector retrieves a vector by ID
+func (p *PgVectorDB) GetVector(ctx context.Context, collection string, id string) (*Vector, error) {
+	vectors, err := p.GetVectors(ctx, collection, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("vector not found: %s", id)
+	}
+	return vectors[0], nil
+}
+
+// GetVectors retrieves multiple vectors by IDs; unknown IDs are skipped
+func (p *PgVectorDB) GetVectors(ctx context.Context, collection string, ids []string) ([]*Vector, error) {
+	ctx, span := p.tracer.Start(ctx, "pgvector.get_vectors")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", collection),
+		attribute.Int("ids.count", len(ids)),
+	)
+
+	if _, err := p.collection(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ID        string `db:"id"`
+		Embedding string `db:"embedding"`
+		Metadata  []byte `db:"metadata"`
+	}
+	query := fmt.Sprintf(`
+		SELECT t.id, t.embedding::text AS embedding, t.metadata
+		FROM unnest($1::text[]) WITH ORDINALITY AS q(id, idx)
+		JOIN %s t ON t.id = q.id
+		ORDER BY q.idx
+	`, p.collectionTable(collection))
+	if err := p.db.SelectContext(ctx, &rows, query, pq.Array(ids)); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get vectors: %w", err)
+	}
+
+	vectors := make([]*Vector, len(rows))
+	for i, row := range rows {
+		values, err := parseVector(row.Embedding)
+		if err != nil {
+			return nil, err
+		}
+		vector := &Vector{ID: row.ID, Values: values}
+		if err := json.Unmarshal(row.Metadata, &vector.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata of %s: %w", row.ID, err)
+		}
+		vectors[i] = vector
+	}
+
+	return vectors, nil
+}
+
+// ScanVectors calls fn with every vector of a collection in ID order
+func (p *PgVectorDB) ScanVectors(ctx context.Context, collection string, fn func(*Vector) error) error {
+	ctx, span := p.tracer.Start(ctx, "pgvector.scan_vectors")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("collection.name", collection))
+
+	if _, err := p.collection(ctx, collection); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`SELECT id, metadata FROM %s ORDER BY id`, p.collectionTable(collection))
+	rows, err := p.db.QueryxContext(ctx, query)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to scan vectors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var metadata []byte
+		if err := rows.Scan(&id, &metadata); err != nil {
+			return fmt.Errorf("failed to scan vectors: %w", err)
+		}
+		vector := &Vector{ID: id}
+		if err := json.Unmarshal(metadata, &vector.Metadata); err != nil {
+			return fmt.Errorf("failed to decode metadata of %s: %w", id, err)
+		}
+		if err := fn(vector); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to scan vectors: %w", err)
+	}
+	return nil
+}
+
+// Count returns the number of vectors in a collection
+func (p *PgVectorDB) Count(ctx context.Context, collection string) (int64, error) {
+	if _, err := p.collection(ctx, collection); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, p.collectionTable(collection))
+	if err := p.db.GetContext(ctx, &count, query); err != nil {
+		return 0, fmt.Errorf("failed to count vectors: %w", err)
+	}
+	return count, nil
+}
+
+// CreateIndex creates an approximate nearest neighbour index. Type "hnsw"
+// accepts the parameters m and ef_construction, type "ivfflat" accepts
+// lists. The index name defaults to one derived from the collection.
+func (p *PgVectorDB) CreateIndex(ctx context.Context, collection string, config *IndexConfig) error {
+	ctx, span := p.tracer.Start(ctx, "pgvector.create_index")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("collection.name", collection),
+		attribute.String("index.type", config.Type),
+	)
+
+	col, err := p.collection(ctx, collection)
+	if err != nil {
+		return err
+	}
+	metric, err := pgMetric(col.Metric)
+	if err != nil {
+		return err
+	}
+
+	indexType := strings.ToLower(config.Type)
+	if indexType == "" {
+		indexType = "hnsw"
+	}
+
+	var options []string
+	switch indexType {
+	case "hnsw":
+		if m := intParameter(config.Parameters, "m"); m > 0 {
+			options = append(options, fmt.Sprintf("m = %d", m))
+		}
+		if ef := intParameter(config.Parameters, "ef_construction"); ef > 0 {
+			options = append(options, fmt.Sprintf("ef_construction = %d", ef))
+		}
+	case "ivfflat":
+		lists := intParameter(config.Parameters, "lists")
+		if lists <= 0 {
+			lists = 100
+		}
+		options = append(options, fmt.Sprintf("lists = %d", lists))
+	default:
+		return fmt.Errorf("unsupported index type: %s", config.Type)
+	}
+
+	name := config.Name
+	if name == "" {
+		name = pgVectorTablePrefix + collection + "_" + indexType + "_idx"
+	}
+
+	query := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING %s (embedding %s)`,
+		pq.QuoteIdentifier(name), p.collectionTable(collection), indexType, metric.opclass)
+	if len(options) > 0 {
+		query += " WITH (" + strings.Join(options, ", ") + ")"
+	}
+
+	if _, err := p.db.ExecContext(ctx, query); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"collection": collection,
+		"index":      name,
+		"type":       indexType,
+	}).Info("Created vector index")
+
+	return nil
+}
+
+// DeleteIndex drops an index of a collection
+func (p *PgVectorDB) DeleteIndex(ctx context.Context, collection string, indexName string) error {
+	ctx, span := p.tracer.Start(ctx, "pgvector.delete_index")
+	defer span.End()
+
+	if _, err := p.collection(ctx, collection); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`DROP INDEX IF EXISTS %s.%s`, pq.QuoteIdentifier(p.schema), pq.QuoteIdentifier(indexName))
+	if _, err := p.db.ExecContext(ctx, query); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete index: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"collection": collection,
+		"index":      indexName,
+	}).Info("Deleted vector index")
+
+	return nil
+}
+
+// GetCollectionInfo returns collection information
+func (p *PgVectorDB) GetCollectionInfo(ctx context.Context, collection string) (*CollectionInfo, error) {
+	col, err := p.collection(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := p.Count(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexCount int
+	query := `
+		SELECT COUNT(*) FROM pg_indexes
+		WHERE schemaname = $1 AND tablename = $2
+			AND (indexdef ILIKE '%USING hnsw%' OR indexdef ILIKE '%USING ivfflat%')
+	`
+	if err := p.db.GetContext(ctx, &indexCount, query, p.schema, pgVectorTablePrefix+collection); err != nil {
+		return nil, fmt.Errorf("failed to count indexes: %w", err)
+	}
+
+	var metadata map[string]interface{}
+	if len(col.Metadata) > 0 {
+		if err := json.Unmarshal(col.Metadata, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode collection metadata: %w", err)
+		}
+	}
+
+	return &CollectionInfo{
+		Name:        col.Name,
+		Dimension:   col.Dimension,
+		Metric:      col.Metric,
+		VectorCount: count,
+		IndexCount:  indexCount,
+		CreatedAt:   col.CreatedAt,
+		UpdatedAt:   col.UpdatedAt,
+		Metadata:    metadata,
+	}, nil
+}
+
+// Health returns the health status
+func (p *PgVectorDB) Health(ctx context.Context) (*HealthStatus, error) {
+	ctx, span := p.tracer.Start(ctx, "pgvector.health")
+	defer span.End()
+
+	if err := p.checkConnected(); err != nil {
+		return &HealthStatus{Status: "unhealthy"}, nil
+	}
+
+	var version string
+	if err := p.db.GetContext(ctx, &version, `SELECT extversion FROM pg_extension WHERE extname = 'vector'`); err != nil {
+		span.RecordError(err)
+		return &HealthStatus{Status: "unhealthy"}, nil
+	}
+
+	collections, err := p.ListCollections(ctx)
+	if err != nil {
+		return &HealthStatus{Status: "degraded", Version: version}, nil
+	}
+
+	return &HealthStatus{
+		Status:      "healthy",
+		Version:     version,
+		Collections: len(collections),
+		Metadata:    map[string]interface{}{"schema": p.schema},
+	}, nil
+}
+
+// Helper methods
+
+func (p *PgVectorDB) checkConnected() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.connected {
+		return fmt.Errorf("pgvector database is not connected")
+	}
+	return nil
+}
+
+// collection returns the registry row of a collection, cached after the
+// first lookup
+func (p *PgVectorDB) collection(ctx context.Context, name string) (*pgCollection, error) {
+	if err := p.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	col, exists := p.collections[name]
+	p.mu.RUnlock()
+	if exists {
+		return col, nil
+	}
+
+	col = &pgCollection{}
+	query := fmt.Sprintf(`
+		SELECT name, dimension, metric, description, metadata, created_at, updated_at
+		FROM %s WHERE name = $1
+	`, p.registryTable())
+	if err := p.db.GetContext(ctx, col, query, name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("collection not found: %s", name)
+		}
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	p.mu.Lock()
+	p.collections[name] = col
+	p.mu.Unlock()
+
+	return col, nil
+}
+
+func (p *PgVectorDB) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *PgVectorDB) dsn() string {
+	if dsn := stringParameter(p.config.Metadata, "dsn", ""); dsn != "" {
+		return dsn
+	}
+
+	parts := []string{
+		"host=" + quoteDSNValue(p.config.Host),
+		fmt.Sprintf("port=%d", p.config.Port),
+		"dbname=" + quoteDSNValue(p.config.Database),
+		"sslmode=" + quoteDSNValue(stringParameter(p.config.Metadata, "sslmode", "disable")),
+		fmt.Sprintf("connect_timeout=%d", max(int(p.config.Timeout.Seconds()), 1)),
+	}
+	if user := stringParameter(p.config.Metadata, "user", ""); user != "" {
+		parts = append(parts, "user="+quoteDSNValue(user))
+	}
+	if password := stringParameter(p.config.Metadata, "password", ""); password != "" {
+		parts = append(parts, "password="+quoteDSNValue(password))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p *PgVectorDB) registryTable() string {
+	return pq.QuoteIdentifier(p.schema) + ".collections"
+}
+
+func (p *PgVectorDB) collectionTable(name string) string {
+	return pq.QuoteIdentifier(p.schema) + "." + pq.QuoteIdentifier(pgVectorTablePrefix+name)
+}
+
+// pgMetricSpec maps a metric to its pgvector distance operator and
+// operator class. Scores follow Qdrant: cosine similarity and dot product
+// grow with similarity, euclidean reports the distance itself.
+type pgMetricSpec struct {
+	name     string
+	operator string
+	opclass  string
+	score    func(distance float64) float64
+}
+
+func pgMetric(metric string) (*pgMetricSpec, error) {
+	switch strings.ToLower(metric) {
+	case "", "cosine":
+		return &pgMetricSpec{"cosine", "<=>", "vector_cosine_ops", func(d float64) float64 { return 1 - d }}, nil
+	case "dot_product", "dot":
+		// <#> is the negative inner product
+		return &pgMetricSpec{"dot_product", "<#>", "vector_ip_ops", func(d float64) float64 { return -d }}, nil
+	case "euclidean", "euclid":
+		return &pgMetricSpec{"euclidean", "<->", "vector_l2_ops", func(d float64) float64 { return d }}, nil
+	default:
+		return nil, fmt.Errorf("unsupported metric: %s", metric)
+	}
+}
+
+// pgFilterSQL translates a filter to a predicate on the metadata column
+// with the semantics of Filter.Match, appending its parameters to args.
+// Equality uses containment so the GIN index on metadata applies.
+func pgFilterSQL(filter *Filter, args []interface{}) (string, []interface{}, error) {
+	if filter == nil {
+		return "TRUE", args, nil
+	}
+
+	switch filter.Op {
+	case FilterAnd, FilterOr:
+		conditions := make([]string, len(filter.Filters))
+		for i, operand := range filter.Filters {
+			var err error
+			conditions[i], args, err = pgFilterSQL(operand, args)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		return "(" + strings.Join(conditions, " "+strings.ToUpper(string(filter.Op))+" ") + ")", args, nil
+	case FilterNot, FilterNe, FilterNin:
+		operand := Field(filter.Field).Eq(filter.Value)
+		switch filter.Op {
+		case FilterNot:
+			operand = filter.Filters[0]
+		case FilterNin:
+			operand = Field(filter.Field).In(filter.Values...)
+		}
+		condition, args, err := pgFilterSQL(operand, args)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT " + condition, args, nil
+	case FilterEq, FilterIn:
+		values := filter.Values
+		if filter.Op == FilterEq {
+			values = []interface{}{filter.Value}
+		}
+		var alternatives []string
+		for _, value := range values {
+			// A list field matches when it contains the value
+			for _, document := range []interface{}{value, []interface{}{value}} {
+				encoded, err := json.Marshal(nestField(filter.Field, document))
+				if err != nil {
+					return "", nil, fmt.Errorf("invalid filter value for %s: %w", filter.Field, err)
+				}
+				args = append(args, string(encoded))
+				alternatives = append(alternatives, fmt.Sprintf("metadata @> $%d::jsonb", len(args)))
+			}
+		}
+		if len(alternatives) == 0 {
+			return "FALSE", args, nil
+		}
+		return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+	}
+
+	args = append(args, pq.Array(strings.Split(filter.Field, ".")))
+	value := fmt.Sprintf("metadata #> $%d::text[]", len(args))
+	// Elements of list fields, or the field itself
+	elements := fmt.Sprintf("jsonb_array_elements(CASE jsonb_typeof(%[1]s) WHEN 'array' THEN %[1]s ELSE jsonb_build_array(%[1]s) END)", value)
+
+	switch filter.Op {
+	case FilterExists:
+		return fmt.Sprintf("COALESCE(%s, 'null') NOT IN ('null'::jsonb, '[]'::jsonb)", value), args, nil
+	case FilterRange:
+		kind, err := filter.Range.kind()
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid range filter for %s: %w", filter.Field, err)
+		}
+		// CASE guards the casts, which fail on other JSON types
+		element, cast := `CASE WHEN jsonb_typeof(e) = 'number' THEN (e #>> '{}')::numeric END`, "numeric"
+		if kind == rangeTime {
+			element = `CASE WHEN jsonb_typeof(e) = 'string' AND e #>> '{}' ~ '^\d{4}-\d\d-\d\d[T ]\d\d:\d\d' THEN (e #>> '{}')::timestamptz END`
+			cast = "timestamptz"
+		}
+		symbols := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+		var comparisons []string
+		for _, bound := range filter.Range.bounds() {
+			args = append(args, formatRangeBound(bound.value))
+			comparisons = append(comparisons, fmt.Sprintf("%s %s $%d::%s", element, symbols[bound.op], len(args), cast))
+		}
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM %s e WHERE %s)", elements, strings.Join(comparisons, " AND ")), args, nil
+	case FilterMatch:
+		text, _ := filter.Value.(string)
+		comparisons := []string{"jsonb_typeof(e) = 'string'"}
+		for _, word := range textTokens(text) {
+			args = append(args, `\m`+word+`\M`)
+			comparisons = append(comparisons, fmt.Sprintf("e #>> '{}' ~* $%d", len(args)))
+		}
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM %s e WHERE %s)", elements, strings.Join(comparisons, " AND ")), args, nil
+	}
+
+	return "", nil, fmt.Errorf("unsupported filter operator: %q", filter.Op)
+}
+
+// formatRangeBound renders a range bound as a query parameter
+func formatRangeBound(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	if number, ok := toFloat(value); ok {
+		return number
+	}
+	return value
+}
+
+// nestField builds the JSON document {"a": {"b": value}} for the key "a.b"
+func nestField(key string, value interface{}) map[string]interface{} {
+	parts := strings.Split(key, ".")
+	document := map[string]interface{}{parts[len(parts)-1]: value}
+	for i := len(parts) - 2; i >= 0; i-- {
+		document = map[string]interface{}{parts[i]: document}
+	}
+	return document
+}
+
+// formatVector renders a vector in pgvector's text format
+func formatVector(values []float32) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, value := range values {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(value), 'g', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// parseVector parses pgvector's text format
+func parseVector(text string) ([]float32, error) {
+	text = strings.TrimSpace(text)
+	if len(text) < 2 || text[0] != '[' || text[len(text)-1] != ']' {
+		return nil, fmt.Errorf("invalid vector: %q", text)
+	}
+	text = text[1 : len(text)-1]
+	if text == "" {
+		return []float32{}, nil
+	}
+
+	parts := strings.Split(text, ",")
+	values := make([]float32, len(parts))
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector component %q: %w", part, err)
+		}
+		values[i] = float32(value)
+	}
+	return values, nil
+}
+
+func stringParameter(parameters map[string]interface{}, key, defaultValue string) string {
+	if value, ok := parameters[key].(string); ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
@@ -0,0 +1,148 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPgVectorHelpers(t *testing.T) {
+	t.Run("VectorFormat", func(t *testing.T) {
+		text := formatVector([]float32{0.5, -1, 3.25e-7})
+		assert.Equal(t, "[0.5,-1,3.25e-07]", text)
+
+		values, err := parseVector(text)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0.5, -1, 3.25e-7}, values)
+
+		_, err = parseVector("0.5,1")
+		assert.Error(t, err)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		filter, err := FilterFromMap(map[string]interface{}{
+			"lang":        "go",
+			"source.kind": []interface{}{"wiki", "runbook"},
+		})
+		require.NoError(t, err)
+		where, args, err := pgFilterSQL(filter, []interface{}{"vectors", "limits"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "((metadata @> $3::jsonb OR metadata @> $4::jsonb) AND "+
+			"(metadata @> $5::jsonb OR metadata @> $6::jsonb OR metadata @> $7::jsonb OR metadata @> $8::jsonb))", where)
+		assert.Equal(t, []interface{}{
+			"vectors", "limits",
+			`{"lang":"go"}`, `{"lang":["go"]}`,
+			`{"source":{"kind":"wiki"}}`, `{"source":{"kind":["wiki"]}}`,
+			`{"source":{"kind":"runbook"}}`, `{"source":{"kind":["runbook"]}}`,
+		}, args)
+
+		filter, err = ParseFilter(`stars >= 10 OR NOT (title MATCH "Pool" AND owner EXISTS)`)
+		require.NoError(t, err)
+		where, args, err = pgFilterSQL(filter, nil)
+		require.NoError(t, err)
+		elements := func(n int) string {
+			return fmt.Sprintf("jsonb_array_elements(CASE jsonb_typeof(metadata #> $%[1]d::text[]) WHEN 'array' "+
+				"THEN metadata #> $%[1]d::text[] ELSE jsonb_build_array(metadata #> $%[1]d::text[]) END)", n)
+		}
+		assert.Equal(t, "(EXISTS (SELECT 1 FROM "+elements(1)+" e WHERE "+
+			"CASE WHEN jsonb_typeof(e) = 'number' THEN (e #>> '{}')::numeric END >= $2::numeric) OR "+
+			"NOT (EXISTS (SELECT 1 FROM "+elements(3)+" e WHERE jsonb_typeof(e) = 'string' AND e #>> '{}' ~* $4) AND "+
+			"COALESCE(metadata #> $5::text[], 'null') NOT IN ('null'::jsonb, '[]'::jsonb)))", where)
+		assert.Len(t, args, 5)
+		assert.Equal(t, float64(10), args[1])
+		assert.Equal(t, `\mpool\M`, args[3])
+
+		where, _, err = pgFilterSQL(nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "TRUE", where)
+	})
+
+	t.Run("Config", func(t *testing.T) {
+		factory := NewPgVectorFactory()
+		assert.Error(t, factory.ValidateConfig(&VectorDBConfig{Host: "localhost"}))
+
+		db, err := factory.Create(&VectorDBConfig{
+			Provider: "pgvector",
+			Host:     "db.internal",
+			Database: "aios",
+			Metadata: map[string]interface{}{"user": "aios", "password": "it's secret"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `host=db.internal port=5432 dbname=aios sslmode=disable connect_timeout=30 user=aios password='it\'s secret'`,
+			db.(*PgVectorDB).dsn())
+
+		_, err = NewPgVectorDB(nil, "bad-schema", logrus.New())
+		assert.Error(t, err)
+	})
+}
+
+// TestPgVectorDB runs against a live PostgreSQL with pgvector, given as a
+// connection string in AIOS_PGVECTOR_DSN
+func TestPgVectorDB(t *testing.T) {
+	dsn := os.Getenv("AIOS_PGVECTOR_DSN")
+	if dsn == "" {
+		t.Skip("AIOS_PGVECTOR_DSN not set")
+	}
+
+	ctx := context.Background()
+	manager := NewVectorDBManager(logrus.New())
+	db, err := manager.CreateVectorDB(&VectorDBConfig{
+		Provider: "pgvector",
+		Metadata: map[string]interface{}{"dsn": dsn, "schema": "vectordb_test"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Connect(ctx))
+	defer db.Disconnect(ctx)
+
+	_ = db.DeleteCollection(ctx, "docs")
+	require.NoError(t, db.CreateCollection(ctx, &CollectionConfig{Name: "docs", Dimension: 3, Metric: "cosine"}))
+	defer db.DeleteCollection(ctx, "docs")
+
+	require.NoError(t, db.Insert(ctx, "docs", []*Vector{
+		{ID: "a", Values: []float32{1, 0, 0}, Metadata: map[string]interface{}{"lang": "go", "tags": []string{"db"}}},
+		{ID: "b", Values: []float32{0.9, 0.1, 0}, Metadata: map[string]interface{}{"lang": "rust"}},
+		{ID: "c", Values: []float32{0, 1, 0}, Metadata: map[string]interface{}{"lang": "go"}},
+	}))
+	require.NoError(t, db.CreateIndex(ctx, "docs", &IndexConfig{Type: "hnsw", Parameters: map[string]interface{}{"m": 8}}))
+
+	results, err := db.BatchSearch(ctx, []*SearchRequest{
+		{Collection: "docs", Vector: []float32{1, 0, 0}, TopK: 2},
+		{Collection: "docs", Vector: []float32{1, 0, 0}, TopK: 2, Filter: map[string]interface{}{"lang": "go"}, Include: []string{"metadata"}},
+		{Collection: "docs", Vector: []float32{0, 1, 0}, TopK: 1},
+		{Collection: "docs", Vector: []float32{1, 0, 0}, TopK: 5, Filter: map[string]interface{}{"tags": "db"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, matchIDs(results[0]))
+	assert.Equal(t, []string{"a", "c"}, matchIDs(results[1]))
+	assert.Equal(t, "go", results[1].Matches[1].Metadata["lang"])
+	assert.Equal(t, []string{"c"}, matchIDs(results[2]))
+	assert.Equal(t, []string{"a"}, matchIDs(results[3]))
+	assert.InDelta(t, 1.0, results[0].Matches[0].Score, 1e-6)
+
+	require.NoError(t, db.Delete(ctx, "docs", []string{"a"}))
+	var scanned []string
+	require.NoError(t, db.(VectorScanner).ScanVectors(ctx, "docs", func(vector *Vector) error {
+		scanned = append(scanned, vector.ID+":"+vector.Metadata["lang"].(string))
+		return nil
+	}))
+	assert.Equal(t, []string{"b:rust", "c:go"}, scanned)
+
+	info, err := db.GetCollectionInfo(ctx, "docs")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), info.VectorCount)
+	assert.Equal(t, 1, info.IndexCount)
+}
+
+func matchIDs(result *SearchResult) []string {
+	ids := make([]string, len(result.Matches))
+	for i, match := range result.Matches {
+		ids[i] = match.ID
+	}
+	return ids
+}
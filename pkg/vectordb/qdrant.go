@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strings"
 	"time"
@@ -40,17 +41,6 @@ func (f *QdrantFactory) Create(config *VectorDBConfig) (VectorDB, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	logger := logrus.New()
-	if config.Metadata != nil {
-		if logLevel, exists := config.Metadata["log_level"]; exists {
-			if level, ok := logLevel.(string); ok {
-				if parsedLevel, err := logrus.ParseLevel(level); err == nil {
-					logger.SetLevel(parsedLevel)
-				}
-			}
-		}
-	}
-
 	baseURL := fmt.Sprintf("http://%s:%d", config.Host, config.Port)
 	if config.TLS {
 		baseURL = fmt.Sprintf("https://%s:%d", config.Host, config.Port)
@@ -65,7 +55,7 @@ func (f *QdrantFactory) Create(config *VectorDBConfig) (VectorDB, error) {
 		httpClient: httpClient,
 		baseURL:    baseURL,
 		connected:  false,
-		logger:     logger,
+		logger:     newProviderLogger(config),
 		tracer:     otel.Tracer("vectordb.qdrant"),
 	}, nil
 }
@@ -326,8 +316,13 @@ func (q *QdrantDB) Search(ctx context.Context, request *SearchRequest) (*SearchR
 		"with_payload": contains(request.Include, "metadata"),
 	}
 
-	if request.Filter != nil {
-		payload["filter"] = request.Filter
+	filter, err := qdrantSearchFilter(request)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if filter != nil {
+		payload["filter"] = filter
 	}
 
 	path := fmt.Sprintf("/collections/%s/points/search", request.Collection)
@@ -440,6 +435,57 @@ func (q *QdrantDB) GetVectors(ctx context.Context, collection string, ids []stri
 	return vectors, nil
 }
 
+// ScanVectors calls fn with every vector of a collection, scrolling
+// through the points a page at a time
+func (q *QdrantDB) ScanVectors(ctx context.Context, collection string, fn func(*Vector) error) error {
+	ctx, span := q.tracer.Start(ctx, "qdrant.scan_vectors")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("collection.name", collection))
+
+	path := fmt.Sprintf("/collections/%s/points/scroll", collection)
+	var offset interface{}
+	for {
+		payload := map[string]interface{}{
+			"limit":        256,
+			"with_vector":  false,
+			"with_payload": true,
+		}
+		if offset != nil {
+			payload["offset"] = offset
+		}
+
+		response, err := q.makeRequest(ctx, "POST", path, payload)
+		if err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to scroll vectors: %w", err)
+		}
+
+		var result struct {
+			Result struct {
+				Points []struct {
+					ID      interface{}            `json:"id"`
+					Payload map[string]interface{} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(response, &result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		for _, point := range result.Result.Points {
+			if err := fn(&Vector{ID: fmt.Sprintf("%v", point.ID), Metadata: point.Payload}); err != nil {
+				return err
+			}
+		}
+		if result.Result.NextPageOffset == nil {
+			return nil
+		}
+		offset = result.Result.NextPageOffset
+	}
+}
+
 // Count returns the number of vectors in a collection
 func (q *QdrantDB) Count(ctx context.Context, collection string) (int64, error) {
 	ctx, span := q.tracer.Start(ctx, "qdrant.count")
@@ -613,6 +659,105 @@ func (q *QdrantDB) makeRequest(ctx context.Context, method, path string, payload
 	return responseBody, nil
 }
 
+// qdrantSearchFilter returns the Qdrant filter of a search request. Map
+// filters already in Qdrant's syntax pass through unchanged.
+func qdrantSearchFilter(request *SearchRequest) (map[string]interface{}, error) {
+	if request.Where == nil && isQdrantFilter(request.Filter) {
+		return request.Filter, nil
+	}
+	filter, err := request.TypedFilter()
+	if err != nil || filter == nil {
+		return nil, err
+	}
+	return qdrantFilter(filter), nil
+}
+
+// qdrantFilter translates a typed filter to Qdrant's filter syntax
+func qdrantFilter(filter *Filter) map[string]interface{} {
+	switch filter.Op {
+	case FilterAnd, FilterOr:
+		conditions := make([]interface{}, len(filter.Filters))
+		for i, operand := range filter.Filters {
+			conditions[i] = qdrantCondition(operand)
+		}
+		if filter.Op == FilterAnd {
+			return map[string]interface{}{"must": conditions}
+		}
+		return map[string]interface{}{"should": conditions}
+	case FilterNot:
+		return map[string]interface{}{"must_not": []interface{}{qdrantCondition(filter.Filters[0])}}
+	case FilterNe:
+		return map[string]interface{}{"must_not": []interface{}{qdrantCondition(Field(filter.Field).Eq(filter.Value))}}
+	case FilterNin:
+		return map[string]interface{}{"must_not": []interface{}{qdrantCondition(Field(filter.Field).In(filter.Values...))}}
+	}
+	return map[string]interface{}{"must": []interface{}{qdrantCondition(filter)}}
+}
+
+// qdrantCondition translates a filter to a single Qdrant condition, which
+// is either a field condition or a nested filter
+func qdrantCondition(filter *Filter) map[string]interface{} {
+	switch filter.Op {
+	case FilterEq:
+		if number, ok := toFloat(filter.Value); ok && number != math.Trunc(number) {
+			// Qdrant matches integers only; a closed range matches a float
+			return map[string]interface{}{"key": filter.Field, "range": map[string]interface{}{"gte": number, "lte": number}}
+		}
+		return map[string]interface{}{"key": filter.Field, "match": map[string]interface{}{"value": qdrantValue(filter.Value)}}
+	case FilterIn:
+		values := make([]interface{}, len(filter.Values))
+		for i, value := range filter.Values {
+			values[i] = qdrantValue(value)
+			if !isQdrantKeyword(values[i]) {
+				// match any takes keywords or integers only
+				options := make([]*Filter, len(filter.Values))
+				for j, option := range filter.Values {
+					options[j] = Field(filter.Field).Eq(option)
+				}
+				return qdrantFilter(Or(options...))
+			}
+		}
+		return map[string]interface{}{"key": filter.Field, "match": map[string]interface{}{"any": values}}
+	case FilterRange:
+		// Qdrant's range condition takes numbers only; timestamps need a
+		// datetime_range condition
+		condition := "range"
+		if kind, err := filter.Range.kind(); err == nil && kind == rangeTime {
+			condition = "datetime_range"
+		}
+		bounds := make(map[string]interface{})
+		for _, bound := range filter.Range.bounds() {
+			bounds[bound.op] = bound.value
+			if t, ok := toTime(bound.value); ok && condition == "datetime_range" {
+				bounds[bound.op] = t.Format(time.RFC3339Nano)
+			}
+		}
+		return map[string]interface{}{"key": filter.Field, condition: bounds}
+	case FilterExists:
+		return map[string]interface{}{"must_not": []interface{}{map[string]interface{}{"is_empty": map[string]interface{}{"key": filter.Field}}}}
+	case FilterMatch:
+		return map[string]interface{}{"key": filter.Field, "match": map[string]interface{}{"text": filter.Value}}
+	}
+	return qdrantFilter(filter)
+}
+
+// qdrantValue converts integral numbers to int64 so they match integer
+// payloads
+func qdrantValue(value interface{}) interface{} {
+	if number, ok := toFloat(value); ok && number == math.Trunc(number) {
+		return int64(number)
+	}
+	return value
+}
+
+func isQdrantKeyword(value interface{}) bool {
+	switch value.(type) {
+	case string, int64:
+		return true
+	}
+	return false
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -0,0 +1,11 @@
+-- AIOS LangGraph Checkpoint Schema Rollback
+-- This migration removes the graph execution state and checkpoint tables
+
+-- Drop indexes
+DROP INDEX IF EXISTS aios.idx_graph_checkpoints_execution_step;
+DROP INDEX IF EXISTS aios.idx_graph_checkpoints_status;
+DROP INDEX IF EXISTS aios.idx_graph_execution_states_updated_at;
+
+-- Drop tables
+DROP TABLE IF EXISTS aios.graph_checkpoints;
+DROP TABLE IF EXISTS aios.graph_execution_states;
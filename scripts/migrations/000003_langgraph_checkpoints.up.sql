@@ -0,0 +1,31 @@
+-- AIOS LangGraph Checkpoint Schema
+-- This migration creates the tables used by langgraph.PostgresStateManager
+-- to persist graph execution state and per-node checkpoints
+
+-- Current state of each graph execution
+CREATE TABLE IF NOT EXISTS aios.graph_execution_states (
+    execution_id VARCHAR(255) PRIMARY KEY,
+    state JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Checkpoints taken after every completed node
+CREATE TABLE IF NOT EXISTS aios.graph_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    execution_id VARCHAR(255) NOT NULL,
+    checkpoint_id VARCHAR(255) NOT NULL,
+    node_id VARCHAR(255) NOT NULL DEFAULT '',
+    step INTEGER NOT NULL DEFAULT 0,
+    executed_path JSONB DEFAULT '[]',
+    state JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(50) NOT NULL DEFAULT 'running',
+    metadata JSONB DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(execution_id, checkpoint_id)
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_graph_checkpoints_execution_step ON aios.graph_checkpoints(execution_id, step DESC);
+CREATE INDEX IF NOT EXISTS idx_graph_checkpoints_status ON aios.graph_checkpoints(status);
+CREATE INDEX IF NOT EXISTS idx_graph_execution_states_updated_at ON aios.graph_execution_states(updated_at);
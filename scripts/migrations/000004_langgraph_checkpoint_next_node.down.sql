@@ -0,0 +1,3 @@
+-- AIOS LangGraph Checkpoint Next Node Rollback
+
+ALTER TABLE aios.graph_checkpoints DROP COLUMN IF EXISTS next_node;
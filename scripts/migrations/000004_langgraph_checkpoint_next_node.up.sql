@@ -0,0 +1,5 @@
+-- AIOS LangGraph Checkpoint Next Node
+-- Checkpoints taken after parallel branches join record the node execution
+-- continues at, so a resumed run does not execute the branches again
+
+ALTER TABLE aios.graph_checkpoints ADD COLUMN IF NOT EXISTS next_node VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+-- AIOS LangChain Memory Schema Rollback
+-- This migration removes the conversation memory table
+
+-- Drop indexes
+DROP INDEX IF EXISTS aios.idx_langchain_memory_entries_user_updated;
+DROP INDEX IF EXISTS aios.idx_langchain_memory_entries_expires_at;
+
+-- Drop tables
+DROP TABLE IF EXISTS aios.langchain_memory_entries;
@@ -0,0 +1,19 @@
+-- AIOS LangChain Memory Schema
+-- This migration creates the table used by memory.PostgresSessionStore
+-- to persist conversation memory per user session
+
+-- One row per key of a session
+CREATE TABLE IF NOT EXISTS aios.langchain_memory_entries (
+    user_id VARCHAR(255) NOT NULL,
+    session_id VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (user_id, session_id, key)
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_langchain_memory_entries_user_updated ON aios.langchain_memory_entries(user_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_langchain_memory_entries_expires_at ON aios.langchain_memory_entries(expires_at) WHERE expires_at IS NOT NULL;